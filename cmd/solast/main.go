@@ -4,11 +4,22 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"runtime/debug"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/th13vn/solast-go/pkg/ast"
+	"github.com/th13vn/solast-go/pkg/callgraph"
+	"github.com/th13vn/solast-go/pkg/cfg"
+	"github.com/th13vn/solast-go/pkg/compat"
+	"github.com/th13vn/solast-go/pkg/lsp"
 	"github.com/th13vn/solast-go/pkg/parser"
+	"github.com/th13vn/solast-go/pkg/query"
+	"github.com/th13vn/solast-go/pkg/solc"
+	"github.com/th13vn/solast-go/pkg/summary"
 	"github.com/th13vn/solast-go/pkg/version"
 )
 
@@ -47,6 +58,39 @@ var (
 	withRange   bool
 	tolerant    bool
 	prettyPrint bool
+	units       string
+	literalValues bool
+	parseFormat string
+)
+
+// CFG command flags
+var (
+	cfgFunction string
+	cfgAssembly bool
+)
+
+// Callgraph command flags
+var (
+	graphFormat string
+)
+
+// Summary command flags
+var (
+	summaryFormat   string
+	summaryContract string
+)
+
+// Compat-diff command flags
+var (
+	goldenDir       string
+	ignorePositions bool
+	ignoreFields    []string
+	summaryOnly     bool
+)
+
+// Query command flags
+var (
+	queryFormat string
 )
 
 func main() {
@@ -74,6 +118,9 @@ If no file is specified or '-' is given, reads from stdin.`,
 	parseCmd.Flags().BoolVar(&withRange, "range", false, "Include character range information")
 	parseCmd.Flags().BoolVar(&tolerant, "tolerant", false, "Tolerant mode (collect errors)")
 	parseCmd.Flags().BoolVarP(&prettyPrint, "pretty", "p", true, "Pretty print JSON output")
+	parseCmd.Flags().StringVar(&units, "units", "byte", "Position units for --loc and --range: byte, rune or utf16")
+	parseCmd.Flags().BoolVar(&literalValues, "literal-values", false, "Include decoded literal values (number value, string and hex bytes)")
+	parseCmd.Flags().StringVar(&parseFormat, "format", "json", "Output format: json, or solc for solc's compact JSON AST (implies --range)")
 
 	// Validate command
 	validateCmd := &cobra.Command{
@@ -94,9 +141,111 @@ Returns exit code 0 if valid, 1 if there are syntax errors.`,
 		RunE:  runVersionDetect,
 	}
 
+	// CFG command
+	cfgCmd := &cobra.Command{
+		Use:   "cfg [file]",
+		Short: "Print control-flow graphs in DOT format",
+		Long: `Build the control-flow graph of every function and modifier body and
+print it in Graphviz DOT format. Modifiers are inlined into the functions
+that invoke them.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runCFG,
+	}
+
+	cfgCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file (default: stdout)")
+	cfgCmd.Flags().StringVarP(&cfgFunction, "function", "f", "", "Only print the graph named Contract.function")
+	cfgCmd.Flags().BoolVar(&cfgAssembly, "assembly", false, "Also print Yul graphs of inline assembly (named Contract.function#asmN)")
+
+	// Callgraph command
+	callgraphCmd := &cobra.Command{
+		Use:   "callgraph [files...]",
+		Short: "Print the call graph of one or more files",
+		Long: `Build a call graph across the given files and the files they import
+through relative paths. Edges are labelled internal, super, modifier,
+library, external or new. If no file is specified, reads from stdin.`,
+		RunE: runCallgraph,
+	}
+
+	callgraphCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file (default: stdout)")
+	callgraphCmd.Flags().StringVar(&graphFormat, "format", "json", "Output format: json or dot")
+	callgraphCmd.Flags().BoolVarP(&prettyPrint, "pretty", "p", true, "Pretty print JSON output")
+
+	// Summary command
+	summaryCmd := &cobra.Command{
+		Use:   "summary [files...]",
+		Short: "Print the state variables each function reads and writes",
+		Long: `Summarize, for every function of every contract, the modifiers it
+invokes, the state variables it reads and writes (including through its
+modifiers and internal callees) and its conditions on msg.sender. Files are
+loaded as for callgraph. If no file is specified, reads from stdin.`,
+		RunE: runSummary,
+	}
+
+	summaryCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file (default: stdout)")
+	summaryCmd.Flags().StringVar(&summaryFormat, "format", "table", "Output format: table or json")
+	summaryCmd.Flags().StringVarP(&summaryContract, "contract", "c", "", "Only summarize the named contract")
+	summaryCmd.Flags().BoolVarP(&prettyPrint, "pretty", "p", true, "Pretty print JSON output")
+
+	// Compat-diff command
+	compatDiffCmd := &cobra.Command{
+		Use:   "compat-diff [files or directories...]",
+		Short: "Compare the JSON AST with the TypeScript parser's golden files",
+		Long: `Compare the JSON AST of each .sol file, given or found under a given
+directory, with the output of the TypeScript @solidity-parser/parser for it,
+read from the golden directory: the file's path relative to the directory
+argument (its base name for a file argument), with a .json extension.
+Golden files are written by scripts/conformance.sh. Mismatches are reported
+field by field; exits with status 1 if there are any. Defaults to
+testdata/contracts against testdata/conformance.`,
+		RunE: runCompatDiff,
+	}
+
+	compatDiffCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file (default: stdout)")
+	compatDiffCmd.Flags().StringVarP(&goldenDir, "golden", "g", "testdata/conformance", "Directory of golden JSON files")
+	compatDiffCmd.Flags().BoolVar(&ignorePositions, "ignore-positions", false, "Skip the loc and range fields")
+	compatDiffCmd.Flags().StringSliceVar(&ignoreFields, "ignore", nil, "Field names to skip (comma-separated)")
+	compatDiffCmd.Flags().BoolVar(&summaryOnly, "summary", false, "Only print mismatch counts by node type and field")
+
+	// Query command
+	queryCmd := &cobra.Command{
+		Use:   "query <selector> [files or directories...]",
+		Short: "Find AST nodes matching a structural selector",
+		Long: `Search .sol files, given or found under a given directory, for AST
+nodes matching a CSS-like selector over node types and fields, such as
+
+  FunctionDefinition[visibility=external]:not(:has(ModifierInvocation[name=onlyOwner])) FunctionCall > MemberAccess[memberName=delegatecall]
+
+Each match is printed as file:line:column with the first line of its
+source. See package query for the selector syntax. If no file is
+specified, reads from stdin.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runQuery,
+	}
+
+	queryCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file (default: stdout)")
+	queryCmd.Flags().StringVar(&queryFormat, "format", "text", "Output format: text or json")
+	queryCmd.Flags().BoolVarP(&prettyPrint, "pretty", "p", true, "Pretty print JSON output")
+
+	// LSP command
+	lspCmd := &cobra.Command{
+		Use:   "lsp",
+		Short: "Run a Solidity language server over stdio",
+		Long: `Run a Language Server Protocol server on stdin/stdout, for editors.
+It provides diagnostics, document symbols, folding ranges, go-to-definition,
+references, hover with signatures and NatSpec, and semantic tokens.`,
+		Args: cobra.NoArgs,
+		RunE: runLSP,
+	}
+
 	rootCmd.AddCommand(parseCmd)
 	rootCmd.AddCommand(validateCmd)
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(cfgCmd)
+	rootCmd.AddCommand(callgraphCmd)
+	rootCmd.AddCommand(summaryCmd)
+	rootCmd.AddCommand(compatDiffCmd)
+	rootCmd.AddCommand(queryCmd)
+	rootCmd.AddCommand(lspCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -109,10 +258,28 @@ func runParse(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	unit, err := positionUnit(units)
+	if err != nil {
+		return err
+	}
+
 	opts := &parser.Options{
-		Tolerant: tolerant,
-		Loc:      withLoc,
-		Range:    withRange,
+		Tolerant:     tolerant,
+		Loc:          withLoc,
+		Range:        withRange,
+		PositionUnit: unit,
+		LiteralValues: literalValues,
+	}
+	switch parseFormat {
+	case "json":
+	case "solc":
+		// solc's src offsets are bytes
+		if unit != parser.UnitByte {
+			return fmt.Errorf("--units %s cannot be used with --format solc", units)
+		}
+		opts.Range = true
+	default:
+		return fmt.Errorf("unknown format %q (want json or solc)", parseFormat)
 	}
 
 	ast, err := parser.Parse(input, opts)
@@ -120,11 +287,16 @@ func runParse(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("parse error: %w", err)
 	}
 
+	var result any = ast
+	if parseFormat == "solc" {
+		result = solc.Export(ast, &solc.Options{AbsolutePath: inputPath(args), Source: input})
+	}
+
 	var output []byte
 	if prettyPrint {
-		output, err = json.MarshalIndent(ast, "", "  ")
+		output, err = json.MarshalIndent(result, "", "  ")
 	} else {
-		output, err = json.Marshal(ast)
+		output, err = json.Marshal(result)
 	}
 	if err != nil {
 		return fmt.Errorf("JSON encoding error: %w", err)
@@ -133,6 +305,16 @@ func runParse(cmd *cobra.Command, args []string) error {
 	return writeOutput(output)
 }
 
+// positionUnit maps a --units value to a parser.PositionUnit
+func positionUnit(name string) (parser.PositionUnit, error) {
+	for _, u := range []parser.PositionUnit{parser.UnitByte, parser.UnitRune, parser.UnitUTF16} {
+		if u.String() == name {
+			return u, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown units %q (want byte, rune or utf16)", name)
+}
+
 func runValidate(cmd *cobra.Command, args []string) error {
 	input, err := readInput(args)
 	if err != nil {
@@ -143,16 +325,20 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		Tolerant: true,
 	}
 
-	_, err = parser.Parse(input, opts)
+	_, errs, err := parser.ParseWithErrors(input, opts)
 	if err != nil {
 		if parserErr, ok := err.(*parser.ParserError); ok {
-			fmt.Fprintf(os.Stderr, "Syntax errors found:\n")
-			for _, e := range parserErr.Errors {
-				fmt.Fprintf(os.Stderr, "  line %d:%d: %s\n", e.Line, e.Column, e.Message)
-			}
-			os.Exit(1)
+			errs = parserErr.Errors
+		} else {
+			return fmt.Errorf("parse error: %w", err)
 		}
-		return fmt.Errorf("parse error: %w", err)
+	}
+	if len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "Syntax errors found:\n")
+		for _, e := range errs {
+			fmt.Fprintf(os.Stderr, "  line %d:%d: %s [%s]\n", e.Line, e.Column, e.Message, e.Code)
+		}
+		os.Exit(1)
 	}
 
 	fmt.Println("Syntax OK")
@@ -179,6 +365,337 @@ func runVersionDetect(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runCFG(cmd *cobra.Command, args []string) error {
+	input, err := readInput(args)
+	if err != nil {
+		return err
+	}
+
+	unit, err := parser.Parse(input, &parser.Options{Tolerant: true, Loc: true})
+	if err != nil {
+		return fmt.Errorf("parse error: %w", err)
+	}
+
+	graphs := cfg.BuildAll(unit)
+	if cfgAssembly {
+		graphs = append(graphs, cfg.BuildAllAssembly(unit)...)
+	}
+
+	var sb strings.Builder
+	for _, g := range graphs {
+		if cfgFunction != "" && g.Name != cfgFunction {
+			continue
+		}
+		if err := g.WriteDOT(&sb); err != nil {
+			return err
+		}
+	}
+	if sb.Len() == 0 && cfgFunction != "" {
+		return fmt.Errorf("no function or modifier named %q", cfgFunction)
+	}
+
+	return writeOutput([]byte(strings.TrimSuffix(sb.String(), "\n")))
+}
+
+func runCallgraph(cmd *cobra.Command, args []string) error {
+	files, err := loadProject(args)
+	if err != nil {
+		return err
+	}
+	graph := callgraph.Build(files...)
+
+	var output []byte
+	switch graphFormat {
+	case "json":
+		if prettyPrint {
+			output, err = json.MarshalIndent(graph, "", "  ")
+		} else {
+			output, err = json.Marshal(graph)
+		}
+		if err != nil {
+			return fmt.Errorf("JSON encoding error: %w", err)
+		}
+	case "dot":
+		output = []byte(strings.TrimSuffix(graph.DOT(), "\n"))
+	default:
+		return fmt.Errorf("unknown format %q (want json or dot)", graphFormat)
+	}
+
+	return writeOutput(output)
+}
+
+func runSummary(cmd *cobra.Command, args []string) error {
+	files, err := loadProject(args)
+	if err != nil {
+		return err
+	}
+	sum := summary.Build(files...)
+	if summaryContract != "" {
+		c := sum.Contract(summaryContract)
+		if c == nil {
+			return fmt.Errorf("no contract named %q", summaryContract)
+		}
+		sum.Contracts = []*summary.Contract{c}
+	}
+
+	var output []byte
+	switch summaryFormat {
+	case "table":
+		var sb strings.Builder
+		if err := sum.WriteTable(&sb); err != nil {
+			return err
+		}
+		output = []byte(strings.TrimSuffix(sb.String(), "\n"))
+	case "json":
+		if prettyPrint {
+			output, err = json.MarshalIndent(sum, "", "  ")
+		} else {
+			output, err = json.Marshal(sum)
+		}
+		if err != nil {
+			return fmt.Errorf("JSON encoding error: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown format %q (want table or json)", summaryFormat)
+	}
+
+	return writeOutput(output)
+}
+
+func runCompatDiff(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		args = []string{"testdata/contracts"}
+	}
+	// Each source with the name of its golden file, relative to goldenDir
+	type source struct{ path, golden string }
+	var sources []source
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return fmt.Errorf("cannot read file: %w", err)
+		}
+		if !info.IsDir() {
+			sources = append(sources, source{arg, strings.TrimSuffix(filepath.Base(arg), ".sol") + ".json"})
+			continue
+		}
+		err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || filepath.Ext(path) != ".sol" {
+				return err
+			}
+			rel, err := filepath.Rel(arg, path)
+			if err != nil {
+				return err
+			}
+			sources = append(sources, source{path, strings.TrimSuffix(rel, ".sol") + ".json"})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	opts := &compat.Options{IgnorePositions: ignorePositions, IgnoreFields: ignoreFields}
+	var all []compat.Mismatch
+	var sb strings.Builder
+	checked, failed := 0, 0
+	for _, s := range sources {
+		golden, err := os.ReadFile(filepath.Join(goldenDir, s.golden))
+		if os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "%s: no golden file %s\n", s.path, filepath.Join(goldenDir, s.golden))
+			continue
+		} else if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(s.path)
+		if err != nil {
+			return fmt.Errorf("cannot read file: %w", err)
+		}
+		checked++
+		ms, err := compat.Check(string(content), golden, opts)
+		if err != nil {
+			failed++
+			fmt.Fprintf(&sb, "%s: %v\n", s.path, err)
+			continue
+		}
+		if len(ms) > 0 {
+			failed++
+		}
+		if !summaryOnly {
+			for _, m := range ms {
+				fmt.Fprintf(&sb, "%s: %s\n", s.path, m)
+			}
+		}
+		all = append(all, ms...)
+	}
+	if checked == 0 {
+		return fmt.Errorf("no golden files found in %s; generate them with scripts/conformance.sh", goldenDir)
+	}
+
+	fmt.Fprintf(&sb, "%d mismatches in %d of %d files\n", len(all), failed, checked)
+	for _, g := range compat.Summarize(all) {
+		fmt.Fprintf(&sb, "%6d  %s\n", g.Count, g)
+	}
+	if err := writeOutput([]byte(strings.TrimSuffix(sb.String(), "\n"))); err != nil {
+		return err
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+	return nil
+}
+
+func runQuery(cmd *cobra.Command, args []string) error {
+	selector, err := query.Compile(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid selector: %w", err)
+	}
+	if queryFormat != "text" && queryFormat != "json" {
+		return fmt.Errorf("unknown format %q (want text or json)", queryFormat)
+	}
+
+	// Each source: a file, a .sol file under a directory, or stdin
+	var paths []string
+	for _, arg := range args[1:] {
+		info, err := os.Stat(arg)
+		if err != nil || !info.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+		err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && filepath.Ext(path) == ".sol" {
+				paths = append(paths, path)
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	type match struct {
+		File    string `json:"file"`
+		Line    int    `json:"line"`
+		Column  int    `json:"column"`
+		Type    string `json:"type"`
+		Path    string `json:"path"`
+		Snippet string `json:"snippet"`
+	}
+	matches := []match{}
+	for _, path := range paths {
+		src, err := readInput([]string{path})
+		if err != nil {
+			return err
+		}
+		unit, err := parser.Parse(src, &parser.Options{Tolerant: true, Loc: true, Range: true})
+		if err != nil {
+			return fmt.Errorf("parse error in %s: %w", inputPath([]string{path}), err)
+		}
+		ix := ast.NewIndex(unit)
+		for _, n := range selector.All(unit) {
+			m := match{File: inputPath([]string{path}), Type: string(n.GetType()), Path: ix.Path(n), Snippet: snippet(src, n)}
+			if loc := n.GetLocation(); loc != nil {
+				m.Line, m.Column = loc.Start.Line, loc.Start.Column+1
+			}
+			matches = append(matches, m)
+		}
+	}
+
+	if queryFormat == "json" {
+		var output []byte
+		if prettyPrint {
+			output, err = json.MarshalIndent(matches, "", "  ")
+		} else {
+			output, err = json.Marshal(matches)
+		}
+		if err != nil {
+			return fmt.Errorf("JSON encoding error: %w", err)
+		}
+		return writeOutput(output)
+	}
+	var sb strings.Builder
+	for _, m := range matches {
+		fmt.Fprintf(&sb, "%s:%d:%d: %s\n", m.File, m.Line, m.Column, m.Snippet)
+	}
+	return writeOutput([]byte(strings.TrimSuffix(sb.String(), "\n")))
+}
+
+// snippet is the first line of n's source, shortened to fit a line
+func snippet(src string, n ast.Node) string {
+	r := n.GetRange()
+	if r == nil {
+		return string(n.GetType())
+	}
+	text, _, cut := strings.Cut(src[r[0]:r[1]], "\n")
+	text = strings.TrimSpace(text)
+	if len(text) > 80 {
+		text, cut = text[:77], true
+	}
+	if cut {
+		text += " ..."
+	}
+	return text
+}
+
+func runLSP(cmd *cobra.Command, args []string) error {
+	server := lsp.NewServer()
+	server.Version = Version
+	return server.Serve(os.Stdin, os.Stdout)
+}
+
+// loadProject parses the given files (or stdin) and, transitively, every file
+// they import through a relative path that exists on disk
+func loadProject(args []string) ([]callgraph.File, error) {
+	opts := &parser.Options{Tolerant: true, Loc: true}
+	if len(args) == 0 || (len(args) == 1 && args[0] == "-") {
+		input, err := readInput(nil)
+		if err != nil {
+			return nil, err
+		}
+		unit, err := parser.Parse(input, opts)
+		if err != nil {
+			return nil, fmt.Errorf("parse error: %w", err)
+		}
+		return []callgraph.File{{Path: "<stdin>", Unit: unit}}, nil
+	}
+
+	var files []callgraph.File
+	seen := make(map[string]bool)
+	queue := append([]string(nil), args...)
+	for len(queue) > 0 {
+		path := filepath.Clean(queue[0])
+		queue = queue[1:]
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read file: %w", err)
+		}
+		unit, err := parser.Parse(string(content), opts)
+		if err != nil {
+			return nil, fmt.Errorf("parse error in %s: %w", path, err)
+		}
+		files = append(files, callgraph.File{Path: path, Unit: unit})
+
+		for _, child := range unit.Children {
+			imp, ok := child.(*ast.ImportDirective)
+			if !ok || !(strings.HasPrefix(imp.Path, "./") || strings.HasPrefix(imp.Path, "../")) {
+				continue
+			}
+			dep := filepath.Join(filepath.Dir(path), imp.Path)
+			if _, err := os.Stat(dep); err == nil {
+				queue = append(queue, dep)
+			}
+		}
+	}
+	return files, nil
+}
+
 func readInput(args []string) (string, error) {
 	var reader io.Reader
 
@@ -201,6 +718,14 @@ func readInput(args []string) (string, error) {
 	return string(content), nil
 }
 
+// inputPath names the input readInput reads
+func inputPath(args []string) string {
+	if len(args) == 0 || args[0] == "-" {
+		return "<stdin>"
+	}
+	return args[0]
+}
+
 func writeOutput(data []byte) error {
 	var writer io.Writer
 
@@ -9,8 +9,13 @@ import (
 	"github.com/th13vn/solast-go/pkg/ast"
 )
 
+// CodeSyntax is the code of the errors the builder raises; lexical errors
+// carry the lexer's codes
+const CodeSyntax = "syntax"
+
 // Error represents a parsing error
 type Error struct {
+	Code    string
 	Message string
 	Line    int
 	Column  int
@@ -22,10 +27,11 @@ func (e *Error) Error() string {
 
 // Builder builds an AST from Solidity source code
 type Builder struct {
-	tokens   []lexer.Token
-	pos      int
-	errors   []*Error
-	options  *Options
+	tokens    []lexer.Token
+	pos       int
+	errors    []*Error
+	lexErrors []*Error
+	options   *Options
 }
 
 // Options configures the parser behavior
@@ -33,6 +39,7 @@ type Options struct {
 	Tolerant bool // Collect errors instead of stopping
 	Loc      bool // Add location information
 	Range    bool // Add range information
+	LiteralValues bool // Fill the decoded values of literals
 }
 
 // New creates a new Builder
@@ -44,11 +51,17 @@ func New(input string, opts *Options) *Builder {
 		opts = &Options{}
 	}
 	
+	var lexErrors []*Error
+	for _, e := range lex.Errors() {
+		lexErrors = append(lexErrors, &Error{Code: e.Code, Message: e.Message, Line: e.Line, Column: e.Column})
+	}
+	
 	return &Builder{
-		tokens:  tokens,
-		pos:     0,
-		errors:  make([]*Error, 0),
-		options: opts,
+		tokens:    tokens,
+		pos:       0,
+		errors:    make([]*Error, 0),
+		lexErrors: lexErrors,
+		options:   opts,
 	}
 }
 
@@ -69,17 +82,18 @@ func (b *Builder) Build() (*ast.SourceUnit, error) {
 		}
 	}
 
-	if b.options.Loc {
-		if len(sourceUnit.Children) > 0 {
-			first := sourceUnit.Children[0]
-			last := sourceUnit.Children[len(sourceUnit.Children)-1]
-			if first.GetLocation() != nil && last.GetLocation() != nil {
-				sourceUnit.Loc = &ast.Location{
-					Start: first.GetLocation().Start,
-					End:   last.GetLocation().End,
-				}
+	if len(sourceUnit.Children) > 0 {
+		first := sourceUnit.Children[0]
+		last := sourceUnit.Children[len(sourceUnit.Children)-1]
+		if b.options.Loc && first.GetLocation() != nil && last.GetLocation() != nil {
+			sourceUnit.Loc = &ast.Location{
+				Start: first.GetLocation().Start,
+				End:   last.GetLocation().End,
 			}
 		}
+		if b.options.Range && first.GetRange() != nil && last.GetRange() != nil {
+			sourceUnit.Range = &ast.Range{first.GetRange()[0], last.GetRange()[1]}
+		}
 	}
 
 	return sourceUnit, nil
@@ -90,6 +104,92 @@ func (b *Builder) Errors() []*Error {
 	return b.errors
 }
 
+// LexErrors returns the lexical errors of the input. They never stop Build;
+// the offending tokens are parsed as well as they can be.
+func (b *Builder) LexErrors() []*Error {
+	return b.lexErrors
+}
+
+// NewRegion creates a Builder over input[start:end] alone. Token positions
+// are those in the whole input: line and column are the position of start.
+func NewRegion(input string, start, end, line, column int, opts *Options) *Builder {
+	b := New(input[start:end], opts)
+	for i := range b.tokens {
+		tok := &b.tokens[i]
+		if tok.Line == 1 {
+			tok.Column += column
+		}
+		tok.Line += line - 1
+		tok.Start += start
+		tok.End += start
+	}
+	for _, e := range b.lexErrors {
+		if e.Line == 1 {
+			e.Column += column
+		}
+		e.Line += line - 1
+	}
+	return b
+}
+
+// Closed reports whether the input ends exactly at a ';' or '}' token, so
+// that the text after it cannot have extended or swallowed that token
+func (b *Builder) Closed() bool {
+	n := len(b.tokens)
+	if n < 2 {
+		return false
+	}
+	last := b.tokens[n-2]
+	return (last.Type == lexer.SEMICOLON || last.Type == lexer.RBRACE) && last.End == b.tokens[n-1].Start
+}
+
+// BuildSourceUnitElement parses exactly one source unit element from the
+// whole token stream
+func (b *Builder) BuildSourceUnitElement() (ast.Node, error) {
+	return b.buildElement(b.parseSourceUnitElement)
+}
+
+// BuildContractBodyElement parses exactly one contract body element from the
+// whole token stream
+func (b *Builder) BuildContractBodyElement() (ast.Node, error) {
+	return b.buildElement(b.parseContractBodyElement)
+}
+
+// BuildExpression parses exactly one expression from the whole token stream
+func (b *Builder) BuildExpression() (ast.Node, error) {
+	return b.buildElement(b.parseExpression)
+}
+
+// BuildStatement parses exactly one statement from the whole token stream
+func (b *Builder) BuildStatement() (ast.Node, error) {
+	return b.buildElement(b.parseStatement)
+}
+
+// BuildTypeName parses exactly one type name from the whole token stream
+func (b *Builder) BuildTypeName() (ast.Node, error) {
+	return b.buildElement(b.parseTypeName)
+}
+
+// BuildVariableDeclaration parses exactly one variable declaration, such as
+// a parameter, from the whole token stream
+func (b *Builder) BuildVariableDeclaration() (ast.Node, error) {
+	return b.buildElement(func() ast.Node { return b.parseVariableDeclaration() })
+}
+
+func (b *Builder) buildElement(parse func() ast.Node) (ast.Node, error) {
+	if len(b.lexErrors) > 0 {
+		return nil, b.lexErrors[0]
+	}
+	node := parse()
+	if len(b.errors) == 0 && (node == nil || !b.isAtEnd()) {
+		b.addError(fmt.Sprintf("unexpected token: %s", b.peek().Value))
+	}
+	if len(b.errors) > 0 {
+		return nil, b.errors[0]
+	}
+	return node, nil
+}
+
 func (b *Builder) parseSourceUnitElement() ast.Node {
 	tok := b.peek()
 	
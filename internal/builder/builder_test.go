@@ -0,0 +1,53 @@
+package builder
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// TestAssemblyJSON pins the JSON of Yul forms whose tokens the lexer shares
+// with Solidity: `:=` (COLON ASSIGN), `->`, break/continue, and builtins
+// named like keywords
+func TestAssemblyJSON(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{
+			"assembly { let y := add(x, 1) }",
+			`{"type":"InlineAssembly","body":{"type":"AssemblyBlock","operations":[{"type":"AssemblyLocalDefinition","names":[{"type":"Identifier","name":"y"}],"expression":{"type":"AssemblyCall","functionName":"add","arguments":[{"type":"AssemblyIdentifier","name":"x"},{"type":"AssemblyLiteral","kind":"number","value":"1"}]}}]}}`,
+		},
+		{
+			"assembly { r := y }",
+			`{"type":"InlineAssembly","body":{"type":"AssemblyBlock","operations":[{"type":"AssemblyAssignment","names":[{"type":"Identifier","name":"r"}],"expression":{"type":"AssemblyIdentifier","name":"y"}}]}}`,
+		},
+		{
+			"assembly { function inc(v) -> out { out := v } }",
+			`{"type":"InlineAssembly","body":{"type":"AssemblyBlock","operations":[{"type":"AssemblyFunctionDefinition","name":"inc","arguments":[{"type":"Identifier","name":"v"}],"returnArguments":[{"type":"Identifier","name":"out"}],"body":{"type":"AssemblyBlock","operations":[{"type":"AssemblyAssignment","names":[{"type":"Identifier","name":"out"}],"expression":{"type":"AssemblyIdentifier","name":"v"}}]}}]}}`,
+		},
+		{
+			"assembly { for { } 1 { } { break continue } }",
+			`{"type":"InlineAssembly","body":{"type":"AssemblyBlock","operations":[{"type":"AssemblyFor","pre":{"type":"AssemblyBlock","operations":[]},"condition":{"type":"AssemblyLiteral","kind":"number","value":"1"},"post":{"type":"AssemblyBlock","operations":[]},"body":{"type":"AssemblyBlock","operations":[{"type":"BreakStatement"},{"type":"ContinueStatement"}]}}]}}`,
+		},
+		{
+			"assembly { return(0, 32) revert(0, 0) }",
+			`{"type":"InlineAssembly","body":{"type":"AssemblyBlock","operations":[{"type":"AssemblyCall","functionName":"return","arguments":[{"type":"AssemblyLiteral","kind":"number","value":"0"},{"type":"AssemblyLiteral","kind":"number","value":"32"}]},{"type":"AssemblyCall","functionName":"revert","arguments":[{"type":"AssemblyLiteral","kind":"number","value":"0"},{"type":"AssemblyLiteral","kind":"number","value":"0"}]}]}}`,
+		},
+	}
+	for _, tt := range tests {
+		unit, err := New("function f() { "+tt.src+" }", nil).Build()
+		if err != nil {
+			t.Errorf("%s: %v", tt.src, err)
+			continue
+		}
+		got, err := json.Marshal(unit.Children[0].(*ast.FunctionDefinition).Body.Statements[0])
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.src, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package builder
 
 import (
+	"encoding/hex"
+
 	"github.com/th13vn/solast-go/internal/lexer"
 	"github.com/th13vn/solast-go/pkg/ast"
 )
@@ -29,24 +31,28 @@ func (b *Builder) parseExpression() ast.Node {
 }
 
 func (b *Builder) parseAssignment() ast.Node {
+	startTok := b.peek()
 	left := b.parseTernary()
 	
 	if b.isAssignmentOperator() {
 		op := b.advance().Value
 		right := b.parseAssignment()
 		
-		return &ast.BinaryOperation{
+		node := &ast.BinaryOperation{
 			BaseNode: ast.BaseNode{Type: ast.NodeBinaryOperation},
 			Operator: op,
 			Left:     left,
 			Right:    right,
 		}
+		b.setLocation(node, startTok, b.previous())
+		return node
 	}
 	
 	return left
 }
 
 func (b *Builder) parseTernary() ast.Node {
+	startTok := b.peek()
 	condition := b.parseLogicalOr()
 	
 	if b.check(lexer.QUESTION) {
@@ -55,18 +61,21 @@ func (b *Builder) parseTernary() ast.Node {
 		b.expect(lexer.COLON)
 		falseExpr := b.parseTernary()
 		
-		return &ast.Conditional{
+		node := &ast.Conditional{
 			BaseNode:        ast.BaseNode{Type: ast.NodeConditional},
 			Condition:       condition,
 			TrueExpression:  trueExpr,
 			FalseExpression: falseExpr,
 		}
+		b.setLocation(node, startTok, b.previous())
+		return node
 	}
 	
 	return condition
 }
 
 func (b *Builder) parseLogicalOr() ast.Node {
+	startTok := b.peek()
 	left := b.parseLogicalAnd()
 	
 	for b.check(lexer.OR) {
@@ -78,12 +87,14 @@ func (b *Builder) parseLogicalOr() ast.Node {
 			Left:     left,
 			Right:    right,
 		}
+		b.setLocation(left, startTok, b.previous())
 	}
 	
 	return left
 }
 
 func (b *Builder) parseLogicalAnd() ast.Node {
+	startTok := b.peek()
 	left := b.parseEquality()
 	
 	for b.check(lexer.AND) {
@@ -95,12 +106,14 @@ func (b *Builder) parseLogicalAnd() ast.Node {
 			Left:     left,
 			Right:    right,
 		}
+		b.setLocation(left, startTok, b.previous())
 	}
 	
 	return left
 }
 
 func (b *Builder) parseEquality() ast.Node {
+	startTok := b.peek()
 	left := b.parseRelational()
 	
 	for b.check(lexer.EQ) || b.check(lexer.NEQ) {
@@ -112,12 +125,14 @@ func (b *Builder) parseEquality() ast.Node {
 			Left:     left,
 			Right:    right,
 		}
+		b.setLocation(left, startTok, b.previous())
 	}
 	
 	return left
 }
 
 func (b *Builder) parseRelational() ast.Node {
+	startTok := b.peek()
 	left := b.parseBitwiseOr()
 	
 	for b.check(lexer.LT) || b.check(lexer.GT) || b.check(lexer.LTE) || b.check(lexer.GTE) {
@@ -129,12 +144,14 @@ func (b *Builder) parseRelational() ast.Node {
 			Left:     left,
 			Right:    right,
 		}
+		b.setLocation(left, startTok, b.previous())
 	}
 	
 	return left
 }
 
 func (b *Builder) parseBitwiseOr() ast.Node {
+	startTok := b.peek()
 	left := b.parseBitwiseXor()
 	
 	for b.check(lexer.BIT_OR) {
@@ -146,12 +163,14 @@ func (b *Builder) parseBitwiseOr() ast.Node {
 			Left:     left,
 			Right:    right,
 		}
+		b.setLocation(left, startTok, b.previous())
 	}
 	
 	return left
 }
 
 func (b *Builder) parseBitwiseXor() ast.Node {
+	startTok := b.peek()
 	left := b.parseBitwiseAnd()
 	
 	for b.check(lexer.BIT_XOR) {
@@ -163,12 +182,14 @@ func (b *Builder) parseBitwiseXor() ast.Node {
 			Left:     left,
 			Right:    right,
 		}
+		b.setLocation(left, startTok, b.previous())
 	}
 	
 	return left
 }
 
 func (b *Builder) parseBitwiseAnd() ast.Node {
+	startTok := b.peek()
 	left := b.parseShift()
 	
 	for b.check(lexer.BIT_AND) {
@@ -180,12 +201,14 @@ func (b *Builder) parseBitwiseAnd() ast.Node {
 			Left:     left,
 			Right:    right,
 		}
+		b.setLocation(left, startTok, b.previous())
 	}
 	
 	return left
 }
 
 func (b *Builder) parseShift() ast.Node {
+	startTok := b.peek()
 	left := b.parseAdditive()
 	
 	for b.check(lexer.SHL) || b.check(lexer.SHR) || b.check(lexer.SAR) {
@@ -197,12 +220,14 @@ func (b *Builder) parseShift() ast.Node {
 			Left:     left,
 			Right:    right,
 		}
+		b.setLocation(left, startTok, b.previous())
 	}
 	
 	return left
 }
 
 func (b *Builder) parseAdditive() ast.Node {
+	startTok := b.peek()
 	left := b.parseMultiplicative()
 	
 	for b.check(lexer.ADD) || b.check(lexer.SUB) {
@@ -214,12 +239,14 @@ func (b *Builder) parseAdditive() ast.Node {
 			Left:     left,
 			Right:    right,
 		}
+		b.setLocation(left, startTok, b.previous())
 	}
 	
 	return left
 }
 
 func (b *Builder) parseMultiplicative() ast.Node {
+	startTok := b.peek()
 	left := b.parseExponentiation()
 	
 	for b.check(lexer.MUL) || b.check(lexer.DIV) || b.check(lexer.MOD) {
@@ -231,12 +258,14 @@ func (b *Builder) parseMultiplicative() ast.Node {
 			Left:     left,
 			Right:    right,
 		}
+		b.setLocation(left, startTok, b.previous())
 	}
 	
 	return left
 }
 
 func (b *Builder) parseExponentiation() ast.Node {
+	startTok := b.peek()
 	left := b.parseUnary()
 	
 	if b.check(lexer.EXP) {
@@ -248,6 +277,7 @@ func (b *Builder) parseExponentiation() ast.Node {
 			Left:     left,
 			Right:    right,
 		}
+		b.setLocation(left, startTok, b.previous())
 	}
 	
 	return left
@@ -256,21 +286,24 @@ func (b *Builder) parseExponentiation() ast.Node {
 func (b *Builder) parseUnary() ast.Node {
 	if b.check(lexer.NOT) || b.check(lexer.BIT_NOT) || b.check(lexer.SUB) || 
 	   b.check(lexer.ADD) || b.check(lexer.INC) || b.check(lexer.DEC) || b.check(lexer.DELETE) {
-		op := b.advance().Value
+		opTok := b.advance()
 		expr := b.parseUnary()
 		
-		return &ast.UnaryOperation{
+		node := &ast.UnaryOperation{
 			BaseNode:      ast.BaseNode{Type: ast.NodeUnaryOperation},
-			Operator:      op,
+			Operator:      opTok.Value,
 			SubExpression: expr,
 			IsPrefix:      true,
 		}
+		b.setLocation(node, opTok, b.previous())
+		return node
 	}
 	
 	return b.parsePostfix()
 }
 
 func (b *Builder) parsePostfix() ast.Node {
+	startTok := b.peek()
 	expr := b.parseCallMemberIndex()
 	
 	for b.check(lexer.INC) || b.check(lexer.DEC) {
@@ -281,12 +314,14 @@ func (b *Builder) parsePostfix() ast.Node {
 			SubExpression: expr,
 			IsPrefix:      false,
 		}
+		b.setLocation(expr, startTok, b.previous())
 	}
 	
 	return expr
 }
 
 func (b *Builder) parseCallMemberIndex() ast.Node {
+	startTok := b.peek()
 	expr := b.parsePrimary()
 	
 	for {
@@ -298,6 +333,7 @@ func (b *Builder) parseCallMemberIndex() ast.Node {
 				Expression: expr,
 				MemberName: memberTok.Value,
 			}
+			b.setLocation(expr, startTok, memberTok)
 		} else if b.check(lexer.LBRACK) {
 			b.advance() // [
 			
@@ -333,11 +369,12 @@ func (b *Builder) parseCallMemberIndex() ast.Node {
 					Index:    indexStart,
 				}
 			}
+			b.setLocation(expr, startTok, b.previous())
 		} else if b.check(lexer.LPAREN) {
-			expr = b.parseFunctionCall(expr)
+			expr = b.parseFunctionCall(expr, startTok)
 		} else if b.check(lexer.LBRACE) {
 			// Named arguments for function call options
-			expr = b.parseFunctionCallOptions(expr)
+			expr = b.parseFunctionCallOptions(expr, startTok)
 		} else {
 			break
 		}
@@ -346,7 +383,9 @@ func (b *Builder) parseCallMemberIndex() ast.Node {
 	return expr
 }
 
-func (b *Builder) parseFunctionCall(callee ast.Node) *ast.FunctionCall {
+// parseFunctionCall parses the argument list of a call; startTok is the first
+// token of the callee
+func (b *Builder) parseFunctionCall(callee ast.Node, startTok lexer.Token) *ast.FunctionCall {
 	b.expect(lexer.LPAREN)
 	
 	node := &ast.FunctionCall{
@@ -376,10 +415,13 @@ func (b *Builder) parseFunctionCall(callee ast.Node) *ast.FunctionCall {
 	}
 	
 	b.expect(lexer.RPAREN)
+	b.setLocation(node, startTok, b.previous())
 	return node
 }
 
-func (b *Builder) parseFunctionCallOptions(expr ast.Node) *ast.FunctionCallOptions {
+// parseFunctionCallOptions parses `{value: v, gas: g}` after a callee;
+// startTok is the first token of the callee
+func (b *Builder) parseFunctionCallOptions(expr ast.Node, startTok lexer.Token) *ast.FunctionCallOptions {
 	b.expect(lexer.LBRACE)
 	
 	node := &ast.FunctionCallOptions{
@@ -403,6 +445,7 @@ func (b *Builder) parseFunctionCallOptions(expr ast.Node) *ast.FunctionCallOptio
 	}
 	
 	b.expect(lexer.RBRACE)
+	b.setLocation(node, startTok, b.previous())
 	return node
 }
 
@@ -412,18 +455,12 @@ func (b *Builder) parsePrimary() ast.Node {
 	switch tok.Type {
 	case lexer.IDENTIFIER:
 		b.advance()
-		return &ast.Identifier{
-			BaseNode: ast.BaseNode{Type: ast.NodeIdentifier},
-			Name:     tok.Value,
-		}
+		return b.identifierAt(tok)
 	
 	// Contextual keywords can also be used as identifiers in expressions
 	case lexer.FROM, lexer.ERROR, lexer.REVERT, lexer.GLOBAL, lexer.TRANSIENT, lexer.LAYOUT, lexer.AT:
 		b.advance()
-		return &ast.Identifier{
-			BaseNode: ast.BaseNode{Type: ast.NodeIdentifier},
-			Name:     tok.Value,
-		}
+		return b.identifierAt(tok)
 	
 	case lexer.NUMBER:
 		b.advance()
@@ -431,27 +468,25 @@ func (b *Builder) parsePrimary() ast.Node {
 	
 	case lexer.HEX_NUMBER:
 		b.advance()
-		return &ast.NumberLiteral{
+		node := &ast.NumberLiteral{
 			BaseNode: ast.BaseNode{Type: ast.NodeNumberLiteral},
 			Number:   tok.Value,
 		}
+		b.setNumberValue(node)
+		b.setLocation(node, tok, tok)
+		return node
 	
 	case lexer.STRING, lexer.HEX_STRING, lexer.UNICODE_STRING:
 		return b.parseStringLiteral()
 	
-	case lexer.TRUE:
+	case lexer.TRUE, lexer.FALSE:
 		b.advance()
-		return &ast.BooleanLiteral{
+		node := &ast.BooleanLiteral{
 			BaseNode: ast.BaseNode{Type: ast.NodeBooleanLiteral},
-			Value:    true,
-		}
-	
-	case lexer.FALSE:
-		b.advance()
-		return &ast.BooleanLiteral{
-			BaseNode: ast.BaseNode{Type: ast.NodeBooleanLiteral},
-			Value:    false,
+			Value:    tok.Type == lexer.TRUE,
 		}
+		b.setLocation(node, tok, tok)
+		return node
 	
 	case lexer.LPAREN:
 		return b.parseTupleOrParenthesized()
@@ -477,10 +512,12 @@ func (b *Builder) parsePrimary() ast.Node {
 	default:
 		b.addError("expected expression")
 		b.advance()
-		return &ast.Identifier{
+		node := &ast.Identifier{
 			BaseNode: ast.BaseNode{Type: ast.NodeIdentifier},
 			Name:     "",
 		}
+		b.setLocation(node, tok, tok)
+		return node
 	}
 }
 
@@ -494,11 +531,24 @@ func (b *Builder) parseNumberLiteral(tok lexer.Token) *ast.NumberLiteral {
 	if b.checkNumberUnit() {
 		node.SubDenomination = b.advance().Value
 	}
+	b.setNumberValue(node)
 	
+	b.setLocation(node, tok, b.previous())
 	return node
 }
 
+// setNumberValue fills node.Value when literal values are enabled
+func (b *Builder) setNumberValue(node *ast.NumberLiteral) {
+	if !b.options.LiteralValues {
+		return
+	}
+	if value, ok := node.Rat(); ok {
+		node.Value = value.RatString()
+	}
+}
+
 func (b *Builder) parseStringLiteral() ast.Node {
+	startTok := b.peek()
 	var parts []string
 	var isUnicode bool
 	var isHex bool
@@ -515,19 +565,31 @@ func (b *Builder) parseStringLiteral() ast.Node {
 	}
 	
 	if isHex {
-		return &ast.HexLiteral{
+		node := &ast.HexLiteral{
 			BaseNode: ast.BaseNode{Type: ast.NodeHexLiteral},
 			Value:    parts[0],
 			Parts:    parts,
 		}
+		if b.options.LiteralValues {
+			if value, ok := node.Bytes(); ok {
+				node.HexValue = hex.EncodeToString(value)
+			}
+		}
+		b.setLocation(node, startTok, b.previous())
+		return node
 	}
 	
-	return &ast.StringLiteral{
+	node := &ast.StringLiteral{
 		BaseNode:  ast.BaseNode{Type: ast.NodeStringLiteral},
 		Value:     parts[0],
 		Parts:     parts,
 		IsUnicode: isUnicode,
 	}
+	if b.options.LiteralValues {
+		node.HexValue = hex.EncodeToString(node.Bytes())
+	}
+	b.setLocation(node, startTok, b.previous())
+	return node
 }
 
 func (b *Builder) parseTupleOrParenthesized() ast.Node {
@@ -536,11 +598,13 @@ func (b *Builder) parseTupleOrParenthesized() ast.Node {
 	// Empty tuple
 	if b.check(lexer.RPAREN) {
 		b.advance()
-		return &ast.TupleExpression{
+		node := &ast.TupleExpression{
 			BaseNode:   ast.BaseNode{Type: ast.NodeTupleExpression},
 			Components: make([]ast.Node, 0),
 			IsArray:    false,
 		}
+		b.setLocation(node, startTok, b.previous())
+		return node
 	}
 	
 	// Parse first expression
@@ -613,20 +677,19 @@ func (b *Builder) parseNewExpression() *ast.NewExpression {
 }
 
 func (b *Builder) parseTypeExpression() ast.Node {
-	b.advance() // type
+	startTok := b.advance() // type
 	b.expect(lexer.LPAREN)
 	typeName := b.parseTypeName()
 	b.expect(lexer.RPAREN)
 	
 	// Return the type name as a member access: type(T)
-	return &ast.FunctionCall{
-		BaseNode: ast.BaseNode{Type: ast.NodeFunctionCall},
-		Expression: &ast.Identifier{
-			BaseNode: ast.BaseNode{Type: ast.NodeIdentifier},
-			Name:     "type",
-		},
-		Arguments: []ast.Node{typeName},
+	node := &ast.FunctionCall{
+		BaseNode:   ast.BaseNode{Type: ast.NodeFunctionCall},
+		Expression: b.identifierAt(startTok),
+		Arguments:  []ast.Node{typeName},
 	}
+	b.setLocation(node, startTok, b.previous())
+	return node
 }
 
 func (b *Builder) parsePayableConversion() ast.Node {
@@ -635,14 +698,16 @@ func (b *Builder) parsePayableConversion() ast.Node {
 	expr := b.parseExpression()
 	b.expect(lexer.RPAREN)
 	
+	callee := &ast.ElementaryTypeName{
+		BaseNode:        ast.BaseNode{Type: ast.NodeElementaryTypeName},
+		Name:            "address",
+		StateMutability: "payable",
+	}
+	b.setLocation(callee, startTok, startTok)
 	node := &ast.FunctionCall{
-		BaseNode: ast.BaseNode{Type: ast.NodeFunctionCall},
-		Expression: &ast.ElementaryTypeName{
-			BaseNode:        ast.BaseNode{Type: ast.NodeElementaryTypeName},
-			Name:            "address",
-			StateMutability: "payable",
-		},
-		Arguments: []ast.Node{expr},
+		BaseNode:   ast.BaseNode{Type: ast.NodeFunctionCall},
+		Expression: callee,
+		Arguments:  []ast.Node{expr},
 	}
 	
 	b.setLocation(node, startTok, b.previous())
@@ -70,6 +70,7 @@ func (b *Builder) expectKeyword(keyword string) lexer.Token {
 func (b *Builder) addError(message string) {
 	tok := b.peek()
 	b.errors = append(b.errors, &Error{
+		Code:    CodeSyntax,
 		Message: message,
 		Line:    tok.Line,
 		Column:  tok.Column,
@@ -140,6 +141,43 @@ func (b *Builder) expectMemberName() lexer.Token {
 	return b.expect(lexer.IDENTIFIER)
 }
 
+// matchAssemblyAssign consumes Yul's `:=`. The lexer has no such token, so it
+// arrives as a COLON immediately followed by an ASSIGN.
+func (b *Builder) matchAssemblyAssign() bool {
+	if !b.check(lexer.COLON) || b.pos+1 >= len(b.tokens) {
+		return false
+	}
+	next := b.tokens[b.pos+1]
+	if next.Type != lexer.ASSIGN || next.Start != b.peek().End {
+		return false
+	}
+	b.advance() // :
+	b.advance() // =
+	return true
+}
+
+// isAssemblyCallee reports whether the current token starts a Yul function
+// call. Several EVM builtins (return, revert, byte, address, ...) lex as
+// Solidity keywords, so any word followed by `(` is accepted as a callee.
+func (b *Builder) isAssemblyCallee() bool {
+	tok := b.peek()
+	if tok.Value == "" || b.pos+1 >= len(b.tokens) || b.tokens[b.pos+1].Type != lexer.LPAREN {
+		return false
+	}
+	ch := tok.Value[0]
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch == '_' || ch == '$'
+}
+
+// identifierAt returns an Identifier named by tok, positioned at tok
+func (b *Builder) identifierAt(tok lexer.Token) *ast.Identifier {
+	node := &ast.Identifier{
+		BaseNode: ast.BaseNode{Type: ast.NodeIdentifier},
+		Name:     tok.Value,
+	}
+	b.setLocation(node, tok, tok)
+	return node
+}
+
 // locationSetter is an interface for nodes that can have location set
 type locationSetter interface {
 	setLoc(*ast.Location)
@@ -158,7 +196,9 @@ func (b *Builder) setLocation(node ast.Node, startTok, endTok lexer.Token) {
 	if b.options.Loc {
 		loc = &ast.Location{
 			Start: ast.Position{Line: startTok.Line, Column: startTok.Column},
-			End:   ast.Position{Line: endTok.Line, Column: endTok.Column + len(endTok.Value)},
+			// Token values of string literals are unquoted and unescaped, so
+			// the width comes from the source offsets
+			End: ast.Position{Line: endTok.Line, Column: endTok.Column + endTok.End - endTok.Start},
 		}
 	}
 	
@@ -252,8 +292,12 @@ func (b *Builder) setLocation(node ast.Node, startTok, endTok lexer.Token) {
 		n.Loc, n.Range = loc, rng
 	case *ast.AssemblyIdentifier:
 		n.Loc, n.Range = loc, rng
+	case *ast.AssemblyMemberAccess:
+		n.Loc, n.Range = loc, rng
 	case *ast.AssemblyLiteral:
 		n.Loc, n.Range = loc, rng
+	case *ast.AssemblyCase:
+		n.Loc, n.Range = loc, rng
 	case *ast.AssemblyIf:
 		n.Loc, n.Range = loc, rng
 	case *ast.AssemblySwitch:
@@ -292,5 +336,11 @@ func (b *Builder) setLocation(node ast.Node, startTok, endTok lexer.Token) {
 		n.Loc, n.Range = loc, rng
 	case *ast.HexLiteral:
 		n.Loc, n.Range = loc, rng
+	case *ast.EnumValue:
+		n.Loc, n.Range = loc, rng
+	case *ast.NameValueExpression:
+		n.Loc, n.Range = loc, rng
+	case *ast.NameValueList:
+		n.Loc, n.Range = loc, rng
 	}
 }
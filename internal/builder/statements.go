@@ -337,6 +337,7 @@ func (b *Builder) parseTryExpression() ast.Node {
 }
 
 func (b *Builder) parseTryCallMemberIndex() ast.Node {
+	startTok := b.peek()
 	expr := b.parsePrimary()
 	
 	for {
@@ -348,6 +349,7 @@ func (b *Builder) parseTryCallMemberIndex() ast.Node {
 				Expression: expr,
 				MemberName: memberTok.Value,
 			}
+			b.setLocation(expr, startTok, memberTok)
 		} else if b.check(lexer.LBRACK) {
 			b.advance() // [
 			
@@ -383,8 +385,9 @@ func (b *Builder) parseTryCallMemberIndex() ast.Node {
 					Index:    indexStart,
 				}
 			}
+			b.setLocation(expr, startTok, b.previous())
 		} else if b.check(lexer.LPAREN) {
-			expr = b.parseFunctionCall(expr)
+			expr = b.parseFunctionCall(expr, startTok)
 		} else {
 			// Don't parse { as FunctionCallOptions in try context
 			break
@@ -476,10 +479,29 @@ func (b *Builder) parseAssemblyStatement_() ast.Node {
 		return b.parseAssemblyFunctionDefinition()
 	case lexer.IDENTIFIER:
 		return b.parseAssemblyExpressionOrAssignment()
+	case lexer.BREAK:
+		b.advance() // break
+		node := &ast.BreakStatement{
+			BaseNode: ast.BaseNode{Type: ast.NodeBreakStatement},
+		}
+		b.setLocation(node, tok, tok)
+		return node
+	case lexer.CONTINUE:
+		b.advance() // continue
+		node := &ast.ContinueStatement{
+			BaseNode: ast.BaseNode{Type: ast.NodeContinueStatement},
+		}
+		b.setLocation(node, tok, tok)
+		return node
 	default:
 		if tok.Type == lexer.RBRACE {
 			return nil
 		}
+		// Builtins named like Solidity keywords: return(...), revert(...), byte(...)
+		if b.isAssemblyCallee() {
+			startTok := b.advance()
+			return b.parseAssemblyCall(startTok.Value, startTok)
+		}
 		b.advance()
 		return nil
 	}
@@ -496,18 +518,14 @@ func (b *Builder) parseAssemblyLocalDefinition() *ast.AssemblyLocalDefinition {
 	// Parse identifier list
 	for {
 		nameTok := b.expect(lexer.IDENTIFIER)
-		node.Names = append(node.Names, &ast.Identifier{
-			BaseNode: ast.BaseNode{Type: ast.NodeIdentifier},
-			Name:     nameTok.Value,
-		})
+		node.Names = append(node.Names, b.identifierAt(nameTok))
 		if !b.check(lexer.COMMA) {
 			break
 		}
 		b.advance() // ,
 	}
 	
-	if b.check(lexer.ASSIGN) {
-		b.advance() // :=
+	if b.matchAssemblyAssign() {
 		node.Expression = b.parseAssemblyExpression()
 	}
 	
@@ -564,7 +582,7 @@ func (b *Builder) parseAssemblySwitch() *ast.AssemblySwitch {
 	
 	for b.check(lexer.CASE) || b.check(lexer.DEFAULT) {
 		isDefault := b.check(lexer.DEFAULT)
-		b.advance() // case/default
+		caseTok := b.advance() // case/default
 		
 		caseNode := &ast.AssemblyCase{
 			BaseNode: ast.BaseNode{Type: ast.NodeAssemblyCase},
@@ -576,6 +594,7 @@ func (b *Builder) parseAssemblySwitch() *ast.AssemblySwitch {
 		}
 		
 		caseNode.Body = b.parseAssemblyBlock()
+		b.setLocation(caseNode, caseTok, b.previous())
 		node.Cases = append(node.Cases, caseNode)
 	}
 	
@@ -597,10 +616,7 @@ func (b *Builder) parseAssemblyFunctionDefinition() *ast.AssemblyFunctionDefinit
 	// Arguments
 	for !b.check(lexer.RPAREN) && !b.isAtEnd() {
 		argTok := b.expect(lexer.IDENTIFIER)
-		node.Arguments = append(node.Arguments, &ast.Identifier{
-			BaseNode: ast.BaseNode{Type: ast.NodeIdentifier},
-			Name:     argTok.Value,
-		})
+		node.Arguments = append(node.Arguments, b.identifierAt(argTok))
 		if !b.check(lexer.RPAREN) {
 			b.expect(lexer.COMMA)
 		}
@@ -608,14 +624,11 @@ func (b *Builder) parseAssemblyFunctionDefinition() *ast.AssemblyFunctionDefinit
 	b.expect(lexer.RPAREN)
 	
 	// Return values
-	if b.check(lexer.ARROW) {
+	if b.check(lexer.RIGHT_ARROW) {
 		b.advance() // ->
 		for {
 			retTok := b.expect(lexer.IDENTIFIER)
-			node.ReturnArguments = append(node.ReturnArguments, &ast.Identifier{
-				BaseNode: ast.BaseNode{Type: ast.NodeIdentifier},
-				Name:     retTok.Value,
-			})
+			node.ReturnArguments = append(node.ReturnArguments, b.identifierAt(retTok))
 			if !b.check(lexer.COMMA) {
 				break
 			}
@@ -634,12 +647,23 @@ func (b *Builder) parseAssemblyExpressionOrAssignment() ast.Node {
 	
 	// Parse identifier(s)
 	var names []*ast.Identifier
+	var targets []ast.Node
 	for {
 		nameTok := b.expect(lexer.IDENTIFIER)
-		names = append(names, &ast.Identifier{
+		name := nameTok.Value
+		// Yul path target such as `s.slot := p`: the name keeps the joined
+		// path, the target holds its parts
+		if b.check(lexer.PERIOD) {
+			path := b.parseAssemblyMemberAccess(nameTok)
+			name += "." + path.MemberName.Name
+			targets = append(targets, path)
+		}
+		id := &ast.Identifier{
 			BaseNode: ast.BaseNode{Type: ast.NodeIdentifier},
-			Name:     nameTok.Value,
-		})
+			Name:     name,
+		}
+		b.setLocation(id, nameTok, b.previous())
+		names = append(names, id)
 		if !b.check(lexer.COMMA) {
 			break
 		}
@@ -647,13 +671,13 @@ func (b *Builder) parseAssemblyExpressionOrAssignment() ast.Node {
 	}
 	
 	// Check for assignment
-	if b.check(lexer.ASSIGN) {
-		b.advance() // :=
+	if b.matchAssemblyAssign() {
 		expr := b.parseAssemblyExpression()
 		
 		node := &ast.AssemblyAssignment{
 			BaseNode:   ast.BaseNode{Type: ast.NodeAssemblyAssignment},
 			Names:      names,
+			Targets:    targets,
 			Expression: expr,
 		}
 		b.setLocation(node, startTok, b.previous())
@@ -702,6 +726,9 @@ func (b *Builder) parseAssemblyExpression() ast.Node {
 		if b.check(lexer.LPAREN) {
 			return b.parseAssemblyCall(startTok.Value, startTok)
 		}
+		if b.check(lexer.PERIOD) {
+			return b.parseAssemblyMemberAccess(startTok)
+		}
 		node := &ast.AssemblyIdentifier{
 			BaseNode: ast.BaseNode{Type: ast.NodeAssemblyIdentifier},
 			Name:     startTok.Value,
@@ -710,9 +737,29 @@ func (b *Builder) parseAssemblyExpression() ast.Node {
 		return node
 	}
 	
+	if b.isAssemblyCallee() {
+		startTok := b.advance()
+		return b.parseAssemblyCall(startTok.Value, startTok)
+	}
+	
 	return b.parseAssemblyLiteral()
 }
 
+// parseAssemblyMemberAccess parses a Yul path such as x.slot after its first
+// identifier has been consumed
+func (b *Builder) parseAssemblyMemberAccess(startTok lexer.Token) *ast.AssemblyMemberAccess {
+	b.advance() // .
+	memberTok := b.expect(lexer.IDENTIFIER)
+	
+	node := &ast.AssemblyMemberAccess{
+		BaseNode:   ast.BaseNode{Type: ast.NodeAssemblyMemberAccess},
+		Expression: b.identifierAt(startTok),
+		MemberName: b.identifierAt(memberTok),
+	}
+	b.setLocation(node, startTok, memberTok)
+	return node
+}
+
 func (b *Builder) parseAssemblyLiteral() ast.Node {
 	tok := b.advance()
 	
@@ -726,6 +773,8 @@ func (b *Builder) parseAssemblyLiteral() ast.Node {
 		node.Kind = "number"
 	case lexer.STRING:
 		node.Kind = "string"
+	case lexer.HEX_STRING:
+		node.Kind = "hex"
 	case lexer.TRUE, lexer.FALSE:
 		node.Kind = "boolean"
 	default:
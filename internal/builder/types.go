@@ -100,10 +100,7 @@ func (b *Builder) parseMappingType() *ast.Mapping {
 	var keyName *ast.Identifier
 	if b.check(lexer.IDENTIFIER) {
 		keyNameTok := b.advance()
-		keyName = &ast.Identifier{
-			BaseNode: ast.BaseNode{Type: ast.NodeIdentifier},
-			Name:     keyNameTok.Value,
-		}
+		keyName = b.identifierAt(keyNameTok)
 	}
 	
 	b.expect(lexer.ARROW)
@@ -115,10 +112,7 @@ func (b *Builder) parseMappingType() *ast.Mapping {
 	var valueName *ast.Identifier
 	if b.check(lexer.IDENTIFIER) {
 		valueNameTok := b.advance()
-		valueName = &ast.Identifier{
-			BaseNode: ast.BaseNode{Type: ast.NodeIdentifier},
-			Name:     valueNameTok.Value,
-		}
+		valueName = b.identifierAt(valueNameTok)
 	}
 	
 	b.expect(lexer.RPAREN)
@@ -215,10 +209,7 @@ func (b *Builder) parseParameter() *ast.VariableDeclaration {
 	if b.check(lexer.IDENTIFIER) || b.isContextualKeyword() {
 		nameTok := b.advance()
 		node.Name = nameTok.Value
-		node.Identifier = &ast.Identifier{
-			BaseNode: ast.BaseNode{Type: ast.NodeIdentifier},
-			Name:     nameTok.Value,
-		}
+		node.Identifier = b.identifierAt(nameTok)
 	}
 	
 	b.setLocation(node, startTok, b.previous())
@@ -244,10 +235,7 @@ func (b *Builder) parseVariableDeclaration() *ast.VariableDeclaration {
 	if b.check(lexer.IDENTIFIER) || b.isContextualKeyword() {
 		nameTok := b.advance()
 		node.Name = nameTok.Value
-		node.Identifier = &ast.Identifier{
-			BaseNode: ast.BaseNode{Type: ast.NodeIdentifier},
-			Name:     nameTok.Value,
-		}
+		node.Identifier = b.identifierAt(nameTok)
 	}
 	
 	b.setLocation(node, startTok, b.previous())
@@ -307,10 +295,7 @@ func (b *Builder) parseStateVariableDeclaration() *ast.StateVariableDeclaration
 	// Name
 	nameTok := b.expect(lexer.IDENTIFIER)
 	varDecl.Name = nameTok.Value
-	varDecl.Identifier = &ast.Identifier{
-		BaseNode: ast.BaseNode{Type: ast.NodeIdentifier},
-		Name:     nameTok.Value,
-	}
+	varDecl.Identifier = b.identifierAt(nameTok)
 	
 	node := &ast.StateVariableDeclaration{
 		BaseNode:  ast.BaseNode{Type: ast.NodeStateVariableDeclaration},
@@ -346,6 +331,7 @@ func (b *Builder) parseStructDefinition() *ast.StructDefinition {
 	b.expect(lexer.LBRACE)
 	
 	for !b.check(lexer.RBRACE) && !b.isAtEnd() {
+		memberTok := b.peek()
 		typeName := b.parseTypeName()
 		// Member name may be a contextual keyword (from, error, …) used as an
 		// identifier. A bare expect(IDENTIFIER) here desyncs the parser on a
@@ -357,11 +343,9 @@ func (b *Builder) parseStructDefinition() *ast.StructDefinition {
 			BaseNode: ast.BaseNode{Type: ast.NodeVariableDeclaration},
 			TypeName: typeName,
 			Name:     memberNameTok.Value,
-			Identifier: &ast.Identifier{
-				BaseNode: ast.BaseNode{Type: ast.NodeIdentifier},
-				Name:     memberNameTok.Value,
-			},
+			Identifier: b.identifierAt(memberNameTok),
 		}
+		b.setLocation(member, memberTok, memberNameTok)
 		node.Members = append(node.Members, member)
 	}
 	
@@ -390,6 +374,7 @@ func (b *Builder) parseEnumDefinition() *ast.EnumDefinition {
 			BaseNode: ast.BaseNode{Type: ast.NodeEnumValue},
 			Name:     valueTok.Value,
 		}
+		b.setLocation(member, valueTok, valueTok)
 		node.Members = append(node.Members, member)
 		
 		if !b.check(lexer.RBRACE) {
@@ -415,6 +400,7 @@ func (b *Builder) parseEventDefinition() *ast.EventDefinition {
 	b.expect(lexer.LPAREN)
 	
 	for !b.check(lexer.RPAREN) && !b.isAtEnd() {
+		paramTok := b.peek()
 		typeName := b.parseTypeName()
 		
 		param := &ast.VariableDeclaration{
@@ -432,12 +418,10 @@ func (b *Builder) parseEventDefinition() *ast.EventDefinition {
 		if b.check(lexer.IDENTIFIER) || b.isContextualKeyword() {
 			paramNameTok := b.advance()
 			param.Name = paramNameTok.Value
-			param.Identifier = &ast.Identifier{
-				BaseNode: ast.BaseNode{Type: ast.NodeIdentifier},
-				Name:     paramNameTok.Value,
-			}
+			param.Identifier = b.identifierAt(paramNameTok)
 		}
 		
+		b.setLocation(param, paramTok, b.previous())
 		node.Parameters = append(node.Parameters, param)
 		
 		if b.check(lexer.COMMA) {
@@ -474,6 +458,7 @@ func (b *Builder) parseErrorDefinition() *ast.ErrorDefinition {
 	b.expect(lexer.LPAREN)
 	
 	for !b.check(lexer.RPAREN) && !b.isAtEnd() {
+		paramTok := b.peek()
 		typeName := b.parseTypeName()
 		
 		param := &ast.VariableDeclaration{
@@ -485,12 +470,10 @@ func (b *Builder) parseErrorDefinition() *ast.ErrorDefinition {
 		if b.check(lexer.IDENTIFIER) {
 			paramNameTok := b.advance()
 			param.Name = paramNameTok.Value
-			param.Identifier = &ast.Identifier{
-				BaseNode: ast.BaseNode{Type: ast.NodeIdentifier},
-				Name:     paramNameTok.Value,
-			}
+			param.Identifier = b.identifierAt(paramNameTok)
 		}
 		
+		b.setLocation(param, paramTok, b.previous())
 		node.Parameters = append(node.Parameters, param)
 		
 		if !b.check(lexer.RPAREN) {
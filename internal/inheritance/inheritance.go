@@ -0,0 +1,112 @@
+// Package inheritance computes the C3 linearization Solidity uses to order a
+// contract and its bases, for the analyses that resolve names through it.
+package inheritance
+
+import "github.com/th13vn/solast-go/pkg/ast"
+
+// Linearizer computes and caches the linearizations of contracts. Bases are
+// found by lookup from the name path of their inheritance specifier.
+type Linearizer struct {
+	lookup   func(name string) *ast.ContractDefinition
+	lin      map[*ast.ContractDefinition][]*ast.ContractDefinition
+	visiting map[*ast.ContractDefinition]bool
+}
+
+// New creates a Linearizer resolving base names with lookup, which returns
+// nil for bases that are not known
+func New(lookup func(name string) *ast.ContractDefinition) *Linearizer {
+	return &Linearizer{
+		lookup:   lookup,
+		lin:      make(map[*ast.ContractDefinition][]*ast.ContractDefinition),
+		visiting: make(map[*ast.ContractDefinition]bool),
+	}
+}
+
+// Linearize returns the C3 linearization of c, most derived (c itself)
+// first. Solidity lists bases from most base to most derived, so they are
+// merged right to left. Unknown bases are skipped; an inconsistent hierarchy
+// appends whatever the merge could not place. The result is shared and must
+// not be modified.
+func (l *Linearizer) Linearize(c *ast.ContractDefinition) []*ast.ContractDefinition {
+	if lin, ok := l.lin[c]; ok {
+		return lin
+	}
+	if l.visiting[c] {
+		// Cyclic inheritance
+		return []*ast.ContractDefinition{c}
+	}
+	l.visiting[c] = true
+	defer delete(l.visiting, c)
+
+	var bases []*ast.ContractDefinition
+	for i := len(c.BaseContracts) - 1; i >= 0; i-- {
+		spec := c.BaseContracts[i]
+		if spec == nil || spec.BaseName == nil {
+			continue
+		}
+		if base := l.lookup(spec.BaseName.NamePath); base != nil && base != c {
+			bases = append(bases, base)
+		}
+	}
+	var seqs [][]*ast.ContractDefinition
+	for _, base := range bases {
+		var seq []*ast.ContractDefinition
+		for _, other := range l.Linearize(base) {
+			// Cyclic inheritance leads back to c
+			if other != c {
+				seq = append(seq, other)
+			}
+		}
+		seqs = append(seqs, seq)
+	}
+	seqs = append(seqs, append([]*ast.ContractDefinition(nil), bases...))
+
+	lin := []*ast.ContractDefinition{c}
+	for {
+		var next *ast.ContractDefinition
+		for _, seq := range seqs {
+			if len(seq) > 0 && !inTail(seqs, seq[0]) {
+				next = seq[0]
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		lin = append(lin, next)
+		for i, seq := range seqs {
+			if len(seq) > 0 && seq[0] == next {
+				seqs[i] = seq[1:]
+			}
+		}
+	}
+	// Inconsistent hierarchy: append whatever merge could not place
+	for _, seq := range seqs {
+		for _, base := range seq {
+			if !contains(lin, base) {
+				lin = append(lin, base)
+			}
+		}
+	}
+	l.lin[c] = lin
+	return lin
+}
+
+// inTail reports whether c appears after the head of any sequence
+func inTail(seqs [][]*ast.ContractDefinition, c *ast.ContractDefinition) bool {
+	for _, seq := range seqs {
+		if len(seq) > 1 && contains(seq[1:], c) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []*ast.ContractDefinition, c *ast.ContractDefinition) bool {
+	for _, other := range list {
+		if other == c {
+			return true
+		}
+	}
+	return false
+}
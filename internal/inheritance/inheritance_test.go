@@ -0,0 +1,46 @@
+package inheritance
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+	"github.com/th13vn/solast-go/pkg/parser"
+)
+
+func TestLinearize(t *testing.T) {
+	unit, err := parser.Parse(`
+contract A {}
+contract B is A {}
+contract C is A {}
+contract D is B, C {}
+contract E is D, Missing {}
+contract X is Y {}
+contract Y is X {}`, nil)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	contracts := make(map[string]*ast.ContractDefinition)
+	for _, child := range unit.Children {
+		c := child.(*ast.ContractDefinition)
+		contracts[c.Name] = c
+	}
+	l := New(func(name string) *ast.ContractDefinition { return contracts[name] })
+	names := func(c string) string {
+		var out []string
+		for _, def := range l.Linearize(contracts[c]) {
+			out = append(out, def.Name)
+		}
+		return strings.Join(out, ",")
+	}
+	for c, want := range map[string]string{
+		"A": "A",
+		"D": "D,C,B,A",
+		"E": "E,D,C,B,A",
+		"X": "X,Y",
+	} {
+		if got := names(c); got != want {
+			t.Errorf("Linearize(%s) = %s, want %s", c, got, want)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 // TokenType represents the type of a token
@@ -16,6 +17,7 @@ const (
 	EOF TokenType = iota
 	ILLEGAL
 	COMMENT
+	WHITESPACE
 
 	// Literals
 	IDENTIFIER
@@ -173,6 +175,7 @@ var tokenNames = map[TokenType]string{
 	EOF:       "EOF",
 	ILLEGAL:   "ILLEGAL",
 	COMMENT:   "COMMENT",
+	WHITESPACE: "WHITESPACE",
 	IDENTIFIER: "IDENTIFIER",
 	NUMBER:    "NUMBER",
 	HEX_NUMBER: "HEX_NUMBER",
@@ -398,6 +401,30 @@ type Token struct {
 	End     int
 }
 
+// Lexical error codes
+const (
+	CodeUnterminatedString  = "unterminated-string"
+	CodeUnterminatedComment = "unterminated-comment"
+	CodeMalformedNumber     = "malformed-number"
+	CodeMalformedHexString  = "malformed-hex-string"
+	CodeInvalidEscape       = "invalid-escape"
+	CodeInvalidCharacter    = "invalid-character"
+)
+
+// Error is a lexical error. The offending token is still returned, so
+// parsing can go on.
+type Error struct {
+	Code    string
+	Message string
+	Line    int
+	Column  int
+	Offset  int
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d:%d: %s", e.Line, e.Column, e.Message)
+}
+
 // Position represents a position in the source
 type Position struct {
 	Line   int
@@ -412,6 +439,8 @@ type Lexer struct {
 	line    int
 	column  int
 	start   int
+	trivia  bool
+	errors  []*Error
 }
 
 // New creates a new Lexer
@@ -424,9 +453,39 @@ func New(input string) *Lexer {
 	}
 }
 
+// NewTrivia creates a Lexer that also returns comments as COMMENT tokens and
+// runs of whitespace as WHITESPACE tokens
+func NewTrivia(input string) *Lexer {
+	l := New(input)
+	l.trivia = true
+	return l
+}
+
+// Errors returns the lexical errors of the tokens read so far
+func (l *Lexer) Errors() []*Error {
+	return l.errors
+}
+
+// errorAt records an error at offset, which lies on line at column
+func (l *Lexer) errorAt(code string, offset, line, column int, format string, args ...interface{}) {
+	l.errors = append(l.errors, &Error{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+		Line:    line,
+		Column:  column,
+		Offset:  offset,
+	})
+}
+
 // NextToken returns the next token from the input
 func (l *Lexer) NextToken() Token {
-	l.skipWhitespaceAndComments()
+	if l.trivia {
+		if tok, ok := l.readTrivia(); ok {
+			return tok
+		}
+	} else {
+		l.skipWhitespaceAndComments()
+	}
 
 	if l.pos >= len(l.input) {
 		return Token{Type: EOF, Line: l.line, Column: l.column, Start: l.pos, End: l.pos}
@@ -497,38 +556,57 @@ func (l *Lexer) skipWhitespaceAndComments() {
 		ch := l.peek()
 
 		// Whitespace
-		if ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' {
+		if isWhitespace(ch) {
 			l.advance()
 			continue
 		}
 
-		// Line comment
-		if ch == '/' && l.peekAt(1) == '/' {
-			l.advance() // /
-			l.advance() // /
-			for l.pos < len(l.input) && l.peek() != '\n' {
-				l.advance()
-			}
+		if ch == '/' && (l.peekAt(1) == '/' || l.peekAt(1) == '*') {
+			l.readComment()
 			continue
 		}
 
-		// Block comment
-		if ch == '/' && l.peekAt(1) == '*' {
-			l.advance() // /
+		break
+	}
+}
+
+// readComment reads a '//' or '/* */' comment
+func (l *Lexer) readComment() {
+	start, line, column := l.pos, l.line, l.column
+	l.advance() // /
+	if l.advance() == '/' {
+		for l.pos < len(l.input) && l.peek() != '\n' {
+			l.advance()
+		}
+		return
+	}
+	for l.pos < len(l.input) {
+		if l.peek() == '*' && l.peekAt(1) == '/' {
 			l.advance() // *
-			for l.pos < len(l.input) {
-				if l.peek() == '*' && l.peekAt(1) == '/' {
-					l.advance() // *
-					l.advance() // /
-					break
-				}
-				l.advance()
-			}
-			continue
+			l.advance() // /
+			return
 		}
+		l.advance()
+	}
+	l.errorAt(CodeUnterminatedComment, start, line, column, "unterminated block comment")
+}
 
-		break
+// readTrivia reads one whitespace run or comment, if the input is at one
+func (l *Lexer) readTrivia() (Token, bool) {
+	start, line, column := l.pos, l.line, l.column
+	tokenType := WHITESPACE
+	switch {
+	case isWhitespace(l.peek()):
+		for l.pos < len(l.input) && isWhitespace(l.peek()) {
+			l.advance()
+		}
+	case l.peek() == '/' && (l.peekAt(1) == '/' || l.peekAt(1) == '*'):
+		tokenType = COMMENT
+		l.readComment()
+	default:
+		return Token{}, false
 	}
+	return Token{Type: tokenType, Value: l.input[start:l.pos], Line: line, Column: column, Start: start, End: l.pos}, true
 }
 
 func (l *Lexer) readIdentifier(line, column int) Token {
@@ -538,6 +616,18 @@ func (l *Lexer) readIdentifier(line, column int) Token {
 	}
 	value := l.input[start:l.pos]
 
+	// hex"..." and unicode"..." literals: the prefix is part of the token
+	if (value == "hex" || value == "unicode") && (l.peek() == '"' || l.peek() == '\'') {
+		tok := l.readString(line, column)
+		tok.Start = start
+		tok.Type = UNICODE_STRING
+		if value == "hex" {
+			tok.Type = HEX_STRING
+			l.checkHexString(tok)
+		}
+		return tok
+	}
+
 	// Check for typed keywords (int, uint, bytes with size suffix)
 	tokenType := IDENTIFIER
 	if kw, ok := keywords[value]; ok {
@@ -675,8 +765,9 @@ func (l *Lexer) readNumber(line, column int) Token {
 		}
 	}
 
-	// Exponent
-	if l.peek() == 'e' || l.peek() == 'E' {
+	// Exponent; a letter after the e means a unit such as ether that lacks
+	// its separating space
+	if (l.peek() == 'e' || l.peek() == 'E') && !isLetter(l.peekAt(1)) {
 		l.advance() // e/E
 		if l.peek() == '+' || l.peek() == '-' {
 			l.advance()
@@ -687,6 +778,7 @@ func (l *Lexer) readNumber(line, column int) Token {
 	}
 
 	value := l.input[start:l.pos]
+	l.checkNumber(value, start, line, column)
 	// Remove underscores for the actual value
 	value = strings.ReplaceAll(value, "_", "")
 
@@ -710,6 +802,7 @@ func (l *Lexer) readHexNumber(line, column int) Token {
 	}
 
 	value := l.input[start:l.pos]
+	l.checkNumber(value, start, line, column)
 
 	return Token{
 		Type:   HEX_NUMBER,
@@ -726,13 +819,16 @@ func (l *Lexer) readString(line, column int) Token {
 	quote := l.advance() // opening quote
 	
 	var sb strings.Builder
+	closed := false
 	for l.pos < len(l.input) {
 		ch := l.peek()
 		if ch == quote {
 			l.advance() // closing quote
+			closed = true
 			break
 		}
 		if ch == '\\' && l.pos+1 < len(l.input) {
+			l.checkEscape()
 			l.advance() // backslash
 			escaped := l.advance()
 			switch escaped {
@@ -742,12 +838,36 @@ func (l *Lexer) readString(line, column int) Token {
 				sb.WriteByte('\r')
 			case 't':
 				sb.WriteByte('\t')
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'v':
+				sb.WriteByte('\v')
 			case '\\':
 				sb.WriteByte('\\')
 			case '\'':
 				sb.WriteByte('\'')
 			case '"':
 				sb.WriteByte('"')
+			case '\n':
+				// Line continuation
+			case '\r':
+				if l.peek() == '\n' {
+					l.advance()
+				}
+			case 'x':
+				if v, ok := l.readHexEscape(2); ok {
+					sb.WriteByte(byte(v))
+				} else {
+					sb.WriteByte(escaped)
+				}
+			case 'u':
+				if v, ok := l.readHexEscape(4); ok {
+					sb.WriteRune(rune(v))
+				} else {
+					sb.WriteByte(escaped)
+				}
 			default:
 				sb.WriteByte(escaped)
 			}
@@ -758,6 +878,9 @@ func (l *Lexer) readString(line, column int) Token {
 		}
 		sb.WriteByte(l.advance())
 	}
+	if !closed {
+		l.errorAt(CodeUnterminatedString, start, line, column, "unterminated string literal")
+	}
 
 	return Token{
 		Type:   STRING,
@@ -769,6 +892,23 @@ func (l *Lexer) readString(line, column int) Token {
 	}
 }
 
+// readHexEscape consumes the n hex digits of a \x or \u escape, reporting
+// false without consuming anything if they are not all there
+func (l *Lexer) readHexEscape(n int) (int, bool) {
+	v := 0
+	for i := 0; i < n; i++ {
+		ch := l.peekAt(i)
+		if !isHexDigit(ch) {
+			return 0, false
+		}
+		v = v<<4 | hexValue(ch)
+	}
+	for i := 0; i < n; i++ {
+		l.advance()
+	}
+	return v, true
+}
+
 func (l *Lexer) readOperator(line, column int) Token {
 	start := l.pos
 	ch := l.advance()
@@ -918,7 +1058,126 @@ func (l *Lexer) readOperator(line, column int) Token {
 		return Token{Type: MOD, Value: "%", Line: line, Column: column, Start: start, End: l.pos}
 	}
 
-	return Token{Type: ILLEGAL, Value: string(ch), Line: line, Column: column, Start: start, End: l.pos}
+	if ch < utf8.RuneSelf {
+		l.errorAt(CodeInvalidCharacter, start, line, column, "invalid character %q", ch)
+	} else if r, size := utf8.DecodeRuneInString(l.input[start:]); r == utf8.RuneError && size == 1 {
+		l.errorAt(CodeInvalidCharacter, start, line, column, "invalid byte 0x%02X", ch)
+	} else {
+		for l.pos < start+size {
+			l.advance()
+		}
+		l.errorAt(CodeInvalidCharacter, start, line, column, "invalid character %q (%U)", r, r)
+	}
+	return Token{Type: ILLEGAL, Value: l.input[start:l.pos], Line: line, Column: column, Start: start, End: l.pos}
+}
+
+// checkNumber reports the first problem of the number literal text read
+// from start: missing digits, leading zeros, misplaced underscores or a
+// letter right after it
+func (l *Lexer) checkNumber(text string, start, line, column int) {
+	report := func(i int, format string, args ...interface{}) {
+		l.errorAt(CodeMalformedNumber, start+i, line, column+i, format, args...)
+	}
+	hex := len(text) > 1 && (text[1] == 'x' || text[1] == 'X')
+	digit := isDigit
+	if hex {
+		digit = isHexDigit
+		if text[1] == 'X' {
+			report(1, "hex number %q must start with 0x", text)
+			return
+		}
+		if len(text) == 2 {
+			report(0, "hex number %q has no digits", text)
+			return
+		}
+	} else {
+		integer := text
+		if i := strings.IndexAny(text, ".eE"); i >= 0 {
+			integer = text[:i]
+		}
+		if len(integer) > 1 && integer[0] == '0' {
+			report(0, "leading zeros are not allowed in %q", text)
+			return
+		}
+		if i := strings.IndexByte(text, '.'); i >= 0 && (i+1 == len(text) || !isDigit(text[i+1])) {
+			report(i, "number %q has no digits after the decimal point", text)
+			return
+		}
+		if i := strings.IndexAny(text, "eE"); i >= 0 && strings.TrimLeft(text[i+1:], "+-") == "" {
+			report(i, "number %q has no exponent digits", text)
+			return
+		}
+		if i := strings.IndexByte(text, '+'); i >= 0 {
+			report(i, "exponent of %q cannot have a plus sign", text)
+			return
+		}
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '_' && (i == 0 || !digit(text[i-1]) || i+1 == len(text) || !digit(text[i+1])) {
+			report(i, "underscores in %q must separate digits", text)
+			return
+		}
+	}
+	if next := l.peek(); isIdentifierPart(next) {
+		report(len(text), "unexpected %q right after number %q", next, text)
+	}
+}
+
+// checkEscape reports an invalid escape sequence starting at the backslash
+// under the cursor
+func (l *Lexer) checkEscape() {
+	switch l.peekAt(1) {
+	case '\\', '\'', '"', 'n', 'r', 't', 'b', 'f', 'v', '\n', '\r':
+		return
+	case 'x':
+		if isHexDigit(l.peekAt(2)) && isHexDigit(l.peekAt(3)) {
+			return
+		}
+	case 'u':
+		if isHexDigit(l.peekAt(2)) && isHexDigit(l.peekAt(3)) && isHexDigit(l.peekAt(4)) && isHexDigit(l.peekAt(5)) {
+			return
+		}
+	}
+	end := l.pos + 2
+	switch l.peekAt(1) {
+	case 'x':
+		end = l.pos + 4
+	case 'u':
+		end = l.pos + 6
+	}
+	if l.peekAt(1) >= utf8.RuneSelf {
+		_, size := utf8.DecodeRuneInString(l.input[l.pos+1:])
+		end = l.pos + 1 + size
+	}
+	if end > len(l.input) {
+		end = len(l.input)
+	}
+	if i := strings.IndexAny(l.input[l.pos+1:end], "\"'\n"); i >= 0 {
+		end = l.pos + 1 + i
+	}
+	l.errorAt(CodeInvalidEscape, l.pos, l.line, l.column, "invalid escape sequence %s", l.input[l.pos:end])
+}
+
+// checkHexString reports hex string contents that are not whole bytes with
+// single underscores between them
+func (l *Lexer) checkHexString(tok Token) {
+	digits := 0
+	for i := 0; i < len(tok.Value); i++ {
+		ch := tok.Value[i]
+		if isHexDigit(ch) {
+			digits++
+			continue
+		}
+		if ch != '_' || digits%2 != 0 || i == 0 || i+1 == len(tok.Value) || tok.Value[i+1] == '_' {
+			// Hex strings hold no escapes, so the content follows hex and the quote
+			offset := tok.Start + len("hex") + 1 + i
+			l.errorAt(CodeMalformedHexString, offset, tok.Line, tok.Column+offset-tok.Start, "invalid hex string %q", tok.Value)
+			return
+		}
+	}
+	if digits%2 != 0 {
+		l.errorAt(CodeMalformedHexString, tok.Start, tok.Line, tok.Column, "hex string %q has an odd number of digits", tok.Value)
+	}
 }
 
 // Tokenize returns all tokens from the input
@@ -942,6 +1201,14 @@ func isIdentifierPart(ch byte) bool {
 	return isIdentifierStart(ch) || isDigit(ch)
 }
 
+func isWhitespace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
+
+func isLetter(ch byte) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
 func isDigit(ch byte) bool {
 	return ch >= '0' && ch <= '9'
 }
@@ -950,6 +1217,17 @@ func isHexDigit(ch byte) bool {
 	return isDigit(ch) || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
 }
 
+func hexValue(ch byte) int {
+	switch {
+	case isDigit(ch):
+		return int(ch - '0')
+	case ch >= 'a' && ch <= 'f':
+		return int(ch-'a') + 10
+	default:
+		return int(ch-'A') + 10
+	}
+}
+
 // IsKeyword checks if a token type is a keyword
 func IsKeyword(t TokenType) bool {
 	return t >= ABSTRACT && t <= WHILE
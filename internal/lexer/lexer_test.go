@@ -96,3 +96,104 @@ func TestBasicTypes(t *testing.T) {
 	}
 }
 
+
+func TestPrefixedStrings(t *testing.T) {
+	tokens := New(`hex"00ff" unicode'é' hex (x)`).Tokenize()
+	expected := []TokenType{HEX_STRING, UNICODE_STRING, HEX, LPAREN, IDENTIFIER, RPAREN, EOF}
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %d tokens, got %d", len(expected), len(tokens))
+	}
+	for i, exp := range expected {
+		if tokens[i].Type != exp {
+			t.Errorf("Token %d: expected %s, got %s (value: %q)", i, exp, tokens[i].Type, tokens[i].Value)
+		}
+	}
+	if tokens[0].Value != "00ff" || tokens[0].Start != 0 || tokens[0].End != 9 {
+		t.Errorf("hex string token = %+v", tokens[0])
+	}
+}
+
+func TestStringEscapes(t *testing.T) {
+	tests := []struct {
+		input string
+		value string
+	}{
+		{`"a\nb\t\\"`, "a\nb\t\\"},
+		{`'it\'s'`, "it's"},
+		{`"\x41\xff"`, "A\xff"},
+		{`"\u00e9\u20AC"`, "é€"},
+		{`"\b\f\v"`, "\b\f\v"},
+		{"\"a\\\nb\\\r\nc\"", "abc"},
+	}
+	for _, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+		if tok.Type != STRING || tok.Value != tt.value {
+			t.Errorf("%s: got %s %q, want %q", tt.input, tok.Type, tok.Value, tt.value)
+		}
+		if len(l.Errors()) != 0 {
+			t.Errorf("%s: unexpected errors %v", tt.input, l.Errors())
+		}
+	}
+}
+
+func TestTrivia(t *testing.T) {
+	tokens := NewTrivia("a /* b */\n// c\nd").Tokenize()
+	expected := []TokenType{IDENTIFIER, WHITESPACE, COMMENT, WHITESPACE, COMMENT, WHITESPACE, IDENTIFIER, EOF}
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %d tokens, got %d", len(expected), len(tokens))
+	}
+	for i, exp := range expected {
+		if tokens[i].Type != exp {
+			t.Errorf("Token %d: expected %s, got %s (value: %q)", i, exp, tokens[i].Type, tokens[i].Value)
+		}
+	}
+	if tokens[4].Value != "// c" || tokens[4].Line != 2 {
+		t.Errorf("line comment token = %+v", tokens[4])
+	}
+}
+
+func TestLexicalErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		code   string
+		line   int
+		column int
+	}{
+		{`x = 0x;`, CodeMalformedNumber, 1, 4},
+		{`x = 1e;`, CodeMalformedNumber, 1, 5},
+		{`x = 1__0;`, CodeMalformedNumber, 1, 5},
+		{`x = 1.;`, CodeMalformedNumber, 1, 5},
+		{`x = 1e+5;`, CodeMalformedNumber, 1, 6},
+		{`x = 0X1f;`, CodeMalformedNumber, 1, 5},
+		{`x = 012;`, CodeMalformedNumber, 1, 4},
+		{`x = 1ether;`, CodeMalformedNumber, 1, 5},
+		{`x = hex"abc";`, CodeMalformedHexString, 1, 4},
+		{"x = \"abc;\ny;", CodeUnterminatedString, 1, 4},
+		{"x;\n  /* open", CodeUnterminatedComment, 2, 2},
+		{`x = "a\qb";`, CodeInvalidEscape, 1, 6},
+		{`x = "\x4";`, CodeInvalidEscape, 1, 5},
+		{"x = 1;\n é", CodeInvalidCharacter, 2, 1},
+		{"x # y", CodeInvalidCharacter, 1, 2},
+	}
+	for _, tt := range tests {
+		lex := New(tt.input)
+		lex.Tokenize()
+		errs := lex.Errors()
+		if len(errs) != 1 {
+			t.Errorf("%q: expected 1 error, got %d", tt.input, len(errs))
+			continue
+		}
+		if e := errs[0]; e.Code != tt.code || e.Line != tt.line || e.Column != tt.column {
+			t.Errorf("%q: got %s at %d:%d (%s), want %s at %d:%d", tt.input, e.Code, e.Line, e.Column, e.Message, tt.code, tt.line, tt.column)
+		}
+	}
+
+	for _, input := range []string{`x = 1_000 + 0xff_ff + 2.5e-3 + .5 + 1 ether;`, `s = "\n\t\\\'\x41é"; h = hex"00_ff";`, "// é\n/* é */"} {
+		lex := New(input)
+		lex.Tokenize()
+		if errs := lex.Errors(); len(errs) != 0 {
+			t.Errorf("%q: unexpected error %s", input, errs[0].Message)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ApplyFunc is called by Apply for each node, with the cursor at it
+type ApplyFunc func(c *Cursor) bool
+
+// Apply walks the tree below root depth-first in field order, as Traverse
+// does, calling pre before and post after each node's children, and returns
+// the root, which pre or post may have replaced. Either func may be nil.
+//
+// If pre returns false, the node's children and post are skipped; if post
+// returns false, Apply stops.
+//
+// The cursor's Replace, Delete, InsertBefore and InsertAfter change the tree
+// as the walk goes. When pre replaces a node, the replacement's children are
+// walked; when it deletes one, its children and post are skipped. Inserted
+// nodes are not walked.
+func Apply(root Node, pre, post ApplyFunc) Node {
+	if root == nil {
+		return nil
+	}
+	a := &applier{pre: pre, post: post}
+	a.apply(&Cursor{node: root, index: -1, slot: reflect.ValueOf(&root).Elem(), step: 1})
+	return root
+}
+
+type applier struct {
+	pre, post ApplyFunc
+	stopped   bool
+}
+
+func (a *applier) apply(c *Cursor) {
+	if a.pre != nil && !a.pre(c) {
+		return
+	}
+	if c.deleted || c.node == nil {
+		return
+	}
+	if v := reflect.ValueOf(c.node); v.Kind() == reflect.Ptr && !v.IsNil() {
+		for _, field := range fields(v.Elem().Type(), false) {
+			a.value(c, field.name, -1, v.Elem().Field(field.index), reflect.Value{})
+			if a.stopped {
+				return
+			}
+		}
+	}
+	if a.post != nil && !a.post(c) {
+		a.stopped = true
+	}
+}
+
+// value walks the nodes in f, held by parent's field at index (-1 outside a
+// slice). list is the slice whose element f is, if any. It returns how far
+// the enclosing slice moves on, which deletions and insertions change.
+func (a *applier) value(parent *Cursor, field string, index int, f, list reflect.Value) int {
+	switch f.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if f.IsNil() {
+			return 1
+		}
+		if child, ok := f.Interface().(Node); ok {
+			if v := reflect.ValueOf(child); v.Kind() == reflect.Ptr && v.IsNil() {
+				return 1
+			}
+			c := &Cursor{node: child, parent: parent, field: field, index: index, depth: parent.depth + 1, slot: f, list: list, step: 1}
+			a.apply(c)
+			return c.index - index + c.step
+		}
+		if f.Kind() == reflect.Ptr && f.Elem().Kind() == reflect.Struct {
+			a.value(parent, field, index, f.Elem(), reflect.Value{})
+		}
+	case reflect.Slice:
+		for i := 0; i < f.Len() && !a.stopped; {
+			i += a.value(parent, field, i, f.Index(i), f)
+		}
+	case reflect.Struct:
+		// Plain structs holding nodes, such as ImportSymbolIdentifiers
+		for _, sf := range fields(f.Type(), true) {
+			if a.stopped {
+				break
+			}
+			a.value(parent, field, index, f.Field(sf.index), reflect.Value{})
+		}
+	}
+	return 1
+}
+
+// Replace puts n in place of the current node. A nil n clears the field the
+// node is held in; use Delete to remove it from a slice. It panics if n does
+// not fit the field, such as a non-Block in FunctionDefinition.Body.
+func (c *Cursor) Replace(n Node) {
+	c.check("Replace", false)
+	if n == nil {
+		c.slot.Set(reflect.Zero(c.slot.Type()))
+	} else {
+		c.slot.Set(c.fit("Replace", n, c.slot.Type()))
+	}
+	c.node = n
+}
+
+// Delete removes the current node from the slice holding it
+func (c *Cursor) Delete() {
+	c.check("Delete", true)
+	n := c.list.Len()
+	c.list.Set(reflect.AppendSlice(c.list.Slice(0, c.index), c.list.Slice(c.index+1, n)))
+	// Drop the stale reference left past the new end
+	c.list.Slice(0, n).Index(n - 1).Set(reflect.Zero(c.list.Type().Elem()))
+	c.deleted = true
+	c.step = 0
+}
+
+// InsertBefore inserts n before the current node in the slice holding it.
+// n is not walked.
+func (c *Cursor) InsertBefore(n Node) {
+	c.check("InsertBefore", true)
+	c.insert("InsertBefore", c.index, n)
+	c.index++
+	c.slot = c.list.Index(c.index)
+}
+
+// InsertAfter inserts n after the current node, and after nodes inserted
+// after it before, in the slice holding it. n is not walked.
+func (c *Cursor) InsertAfter(n Node) {
+	c.check("InsertAfter", true)
+	c.insert("InsertAfter", c.index+c.step, n)
+	c.step++
+	c.slot = c.list.Index(c.index)
+}
+
+func (c *Cursor) insert(op string, i int, n Node) {
+	v := c.fit(op, n, c.list.Type().Elem())
+	s := reflect.Append(c.list, v)
+	reflect.Copy(s.Slice(i+1, s.Len()), s.Slice(i, s.Len()-1))
+	s.Index(i).Set(v)
+	c.list.Set(s)
+}
+
+// check panics unless the cursor, passed by Apply, can still change the tree
+// and, with inList, is at a slice element
+func (c *Cursor) check(op string, inList bool) {
+	switch {
+	case !c.slot.IsValid():
+		panic("ast: " + op + " called outside Apply")
+	case c.deleted:
+		panic("ast: " + op + " called after Delete")
+	case inList && !c.list.IsValid():
+		panic(fmt.Sprintf("ast: %s: %s is not a slice", op, c.fieldName()))
+	}
+}
+
+// fit returns n as a value for a field of type t, or panics
+func (c *Cursor) fit(op string, n Node, t reflect.Type) reflect.Value {
+	if n == nil {
+		panic("ast: " + op + " of a nil node")
+	}
+	v := reflect.ValueOf(n)
+	if !v.Type().AssignableTo(t) {
+		panic(fmt.Sprintf("ast: %s: %T does not fit %s of type %s", op, n, c.fieldName(), t))
+	}
+	return v
+}
+
+func (c *Cursor) fieldName() string {
+	if c.parent == nil {
+		return "the root"
+	}
+	return fmt.Sprintf("%T.%s", c.parent.node, c.field)
+}
@@ -0,0 +1,483 @@
+package ast_test
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+	"github.com/th13vn/solast-go/pkg/parser"
+)
+
+func parse(t *testing.T, src string) *ast.SourceUnit {
+	t.Helper()
+	unit, err := parser.Parse(src, &parser.Options{Loc: true, Range: true})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	return unit
+}
+
+const tokenSrc = `pragma solidity ^0.8.0;
+
+contract Token {
+    mapping(address => uint) balances;
+
+    constructor() { balances[msg.sender] = 1; }
+
+    function transfer(address to, uint amount) external {
+        require(balances[msg.sender] >= amount);
+        balances[msg.sender] -= amount;
+        balances[to] += amount;
+    }
+
+    function transfer(address to) external {
+        transfer(to, 1);
+    }
+}
+`
+
+// find returns the first node of type T for which match holds
+func find[T ast.Node](root ast.Node, match func(T) bool) T {
+	var found T
+	var done bool
+	var walk func(n ast.Node)
+	walk = func(n ast.Node) {
+		if done {
+			return
+		}
+		if m, ok := n.(T); ok && match(m) {
+			found, done = m, true
+			return
+		}
+		for _, child := range ast.Children(n) {
+			walk(child)
+		}
+	}
+	walk(root)
+	return found
+}
+
+func TestIndex(t *testing.T) {
+	unit := parse(t, tokenSrc)
+	ix := ast.NewIndex(unit)
+
+	// balances[to] in the third statement of transfer
+	to := find(unit, func(id *ast.Identifier) bool { return id.Name == "to" && id.Loc.Start.Line == 11 })
+	if to == nil {
+		t.Fatal("identifier not found")
+	}
+	if got := ix.Path(to); got != "Token/transfer/body/2/expression/left/index" {
+		t.Errorf("Path = %q", got)
+	}
+	if f := ix.EnclosingFunction(to); f == nil || f.Name != "transfer" || len(f.Parameters) != 2 {
+		t.Errorf("EnclosingFunction = %+v", f)
+	}
+	if c := ix.EnclosingContract(to); c == nil || c.Name != "Token" {
+		t.Errorf("EnclosingContract = %+v", c)
+	}
+	if _, ok := ix.Parent(to).(*ast.IndexAccess); !ok {
+		t.Errorf("Parent = %T", ix.Parent(to))
+	}
+	ancestors := ix.Ancestors(to)
+	if len(ancestors) == 0 || ancestors[len(ancestors)-1] != unit {
+		t.Errorf("Ancestors do not end at the root")
+	}
+
+	// Overloads, constructors and unnamed elements
+	call := find(unit, func(c *ast.FunctionCall) bool { return c.Loc.Start.Line == 15 })
+	if got := ix.Path(call); got != "Token/transfer#1/body/0/expression" {
+		t.Errorf("overload Path = %q", got)
+	}
+	sender := find(unit, func(m *ast.MemberAccess) bool { return m.Loc.Start.Line == 6 })
+	if got := ix.Path(sender); got != "Token/constructor/body/0/expression/left/index" {
+		t.Errorf("constructor Path = %q", got)
+	}
+	if got := ix.Path(unit.Children[0]); got != "0" {
+		t.Errorf("pragma Path = %q", got)
+	}
+	if ix.Path(unit) != "" || ix.Parent(unit) != nil || ix.EnclosingFunction(unit.Children[1]) != nil {
+		t.Errorf("root or top level has an enclosing node")
+	}
+
+	// Paths are stable across parses of the same source
+	again := parse(t, tokenSrc)
+	to2 := find(again, func(id *ast.Identifier) bool { return id.Name == "to" && id.Loc.Start.Line == 11 })
+	if ast.NewIndex(again).Path(to2) != ix.Path(to) {
+		t.Errorf("Path differs between parses")
+	}
+}
+
+func TestNodeAt(t *testing.T) {
+	unit := parse(t, tokenSrc)
+	offset := strings.Index(tokenSrc, "+= amount") + len("+= ")
+
+	chain := ast.NodeAt(unit, offset)
+	if len(chain) == 0 {
+		t.Fatal("no node at offset")
+	}
+	if id, ok := chain[0].(*ast.Identifier); !ok || id.Name != "amount" {
+		t.Errorf("innermost = %#v", chain[0])
+	}
+	if _, ok := chain[1].(*ast.BinaryOperation); !ok || chain[len(chain)-1] != unit {
+		t.Errorf("chain = %T ... %T", chain[1], chain[len(chain)-1])
+	}
+	ix := ast.NewIndex(unit)
+	for i, n := range chain[1:] {
+		if ix.Parent(chain[i]) != n {
+			t.Errorf("chain[%d] is not the parent of chain[%d]", i+1, i)
+		}
+	}
+
+	// The same node by line and column
+	loc := chain[0].GetLocation()
+	if at := ast.NodeAtPosition(unit, loc.Start); len(at) == 0 || at[0] != chain[0] {
+		t.Errorf("NodeAtPosition(%v) = %v", loc.Start, at)
+	}
+	if at := ast.NodeAtPosition(unit, loc.End); len(at) > 0 && at[0] == chain[0] {
+		t.Errorf("NodeAtPosition includes the end position")
+	}
+
+	// The smallest node covering a range
+	start := strings.Index(tokenSrc, "balances[to]")
+	end := strings.Index(tokenSrc, "+= amount") + len("+= amount")
+	if in := ast.NodesInRange(unit, start, end); len(in) == 0 || in[0] != chain[1] {
+		t.Errorf("NodesInRange = %v", in)
+	}
+
+	// Between members only the contract covers the offset
+	if at := ast.NodeAt(unit, strings.Index(tokenSrc, "\n\n    function")); len(at) != 2 || at[0] != unit.Children[1] {
+		t.Errorf("between members = %v", at)
+	}
+	if at := ast.NodeAt(unit, len(tokenSrc)+10); at != nil {
+		t.Errorf("past the end = %v", at)
+	}
+}
+
+func TestTraverse(t *testing.T) {
+	unit := parse(t, tokenSrc)
+
+	// Enter and Leave pair up, so the current function can be tracked
+	var fn *ast.FunctionDefinition
+	calls := map[string]int{}
+	depth := 0
+	ast.Traverse(unit, ast.WalkerFuncs{
+		EnterFn: func(n ast.Node, c *ast.Cursor) ast.Action {
+			if c.Depth() != depth {
+				t.Errorf("%T: depth %d, want %d", n, c.Depth(), depth)
+			}
+			depth++
+			switch n := n.(type) {
+			case *ast.FunctionDefinition:
+				fn = n
+			case *ast.FunctionCall:
+				if fn == nil {
+					t.Errorf("call outside a function")
+				} else {
+					calls[fn.Name]++
+				}
+			}
+			return ast.Continue
+		},
+		LeaveFn: func(n ast.Node, c *ast.Cursor) ast.Action {
+			depth--
+			if n == fn {
+				fn = nil
+			}
+			return ast.Continue
+		},
+	})
+	if depth != 0 || calls["transfer"] != 2 {
+		t.Errorf("depth = %d, calls = %v", depth, calls)
+	}
+
+	// The cursor tells how the parent holds a node: 1 in transfer(to, 1)
+	seen := false
+	ast.Traverse(unit, ast.WalkerFuncs{EnterFn: func(n ast.Node, c *ast.Cursor) ast.Action {
+		if lit, ok := n.(*ast.NumberLiteral); ok && lit.Number == "1" {
+			if _, ok := c.Parent().(*ast.FunctionCall); ok {
+				seen = true
+				if c.Field() != "arguments" || c.Index() != 1 {
+					t.Errorf("1 held at %s[%d]", c.Field(), c.Index())
+				}
+			}
+		}
+		if _, ok := c.Parent().(*ast.BinaryOperation); ok && c.Index() != -1 {
+			t.Errorf("%s is not a slice but index = %d", c.Field(), c.Index())
+		}
+		if n == unit && (c.Parent() != nil || c.Field() != "" || c.Index() != -1) {
+			t.Errorf("root cursor = %v %q %d", c.Parent(), c.Field(), c.Index())
+		}
+		return ast.Continue
+	}})
+	if !seen {
+		t.Errorf("call argument not visited")
+	}
+
+	// SkipChildren still leaves the node; nothing below it is entered
+	var entered, left []string
+	ast.Traverse(unit, ast.WalkerFuncs{
+		EnterFn: func(n ast.Node, c *ast.Cursor) ast.Action {
+			if _, ok := c.Parent().(*ast.FunctionDefinition); ok {
+				t.Errorf("entered %T below a skipped function", n)
+			}
+			if f, ok := n.(*ast.FunctionDefinition); ok {
+				entered = append(entered, f.Name)
+				return ast.SkipChildren
+			}
+			return ast.Continue
+		},
+		LeaveFn: func(n ast.Node, c *ast.Cursor) ast.Action {
+			if f, ok := n.(*ast.FunctionDefinition); ok {
+				left = append(left, f.Name)
+			}
+			return ast.Continue
+		},
+	})
+	if len(entered) != 3 || strings.Join(entered, ",") != strings.Join(left, ",") {
+		t.Errorf("entered %v, left %v", entered, left)
+	}
+
+	// Stop ends the walk at once, without further Leave calls
+	var after int
+	stopped := false
+	ast.Traverse(unit, ast.WalkerFuncs{
+		EnterFn: func(n ast.Node, c *ast.Cursor) ast.Action {
+			if stopped {
+				after++
+			}
+			if _, ok := n.(*ast.FunctionCall); ok {
+				stopped = true
+				return ast.Stop
+			}
+			return ast.Continue
+		},
+		LeaveFn: func(n ast.Node, c *ast.Cursor) ast.Action {
+			if stopped {
+				after++
+			}
+			return ast.Continue
+		},
+	})
+	if !stopped || after != 0 {
+		t.Errorf("stopped = %v, %d calls after Stop", stopped, after)
+	}
+}
+
+// emit builds `emit Covered(k);`
+func emit(k int) *ast.EmitStatement {
+	return &ast.EmitStatement{
+		BaseNode: ast.BaseNode{Type: ast.NodeEmitStatement},
+		EventCall: &ast.FunctionCall{
+			BaseNode:   ast.BaseNode{Type: ast.NodeFunctionCall},
+			Expression: &ast.Identifier{BaseNode: ast.BaseNode{Type: ast.NodeIdentifier}, Name: "Covered"},
+			Arguments:  []ast.Node{&ast.NumberLiteral{BaseNode: ast.BaseNode{Type: ast.NodeNumberLiteral}, Number: strconv.Itoa(k)}},
+		},
+	}
+}
+
+func TestApply(t *testing.T) {
+	unit := parse(t, tokenSrc)
+
+	// Coverage instrumentation: an event before every statement, none of
+	// which is walked itself
+	k := 0
+	ast.Apply(unit, func(c *ast.Cursor) bool {
+		if _, ok := c.Node().(*ast.EmitStatement); ok {
+			t.Errorf("inserted node walked")
+		}
+		if _, ok := c.Parent().(*ast.Block); ok && c.Field() == "statements" {
+			c.InsertBefore(emit(k))
+			k++
+		}
+		return true
+	}, nil)
+	transfer := find(unit, func(f *ast.FunctionDefinition) bool { return f.Name == "transfer" })
+	stmts := transfer.Body.Statements
+	if k != 5 || len(stmts) != 6 {
+		t.Fatalf("inserted %d, transfer has %d statements", k, len(stmts))
+	}
+	for i, s := range stmts {
+		if _, ok := s.(*ast.EmitStatement); ok != (i%2 == 0) {
+			t.Errorf("statement %d is %T", i, s)
+		}
+	}
+
+	// InsertAfter keeps call order; then delete the events again and the
+	// require, and replace a literal
+	unit = parse(t, tokenSrc)
+	ast.Apply(unit, func(c *ast.Cursor) bool {
+		if _, ok := c.Parent().(*ast.Block); ok {
+			c.InsertAfter(emit(3))
+			c.InsertAfter(emit(4))
+		}
+		return true
+	}, nil)
+	var order []string
+	ast.Apply(unit, nil, func(c *ast.Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.EmitStatement:
+			order = append(order, n.EventCall.(*ast.FunctionCall).Arguments[0].(*ast.NumberLiteral).Number)
+			c.Delete()
+		case *ast.ExpressionStatement:
+			if call, ok := n.Expression.(*ast.FunctionCall); ok {
+				if id, ok := call.Expression.(*ast.Identifier); ok && id.Name == "require" {
+					c.Delete()
+				}
+			}
+		case *ast.NumberLiteral:
+			if n.Number == "1" {
+				c.Replace(&ast.NumberLiteral{BaseNode: n.BaseNode, Number: "2"})
+			}
+		}
+		return true
+	})
+	transfer = find(unit, func(f *ast.FunctionDefinition) bool { return f.Name == "transfer" })
+	if len(transfer.Body.Statements) != 2 || strings.Join(order, "") != "3434343434" {
+		t.Errorf("statements = %d, events in order %v", len(transfer.Body.Statements), order)
+	}
+	call := find(unit, func(c *ast.FunctionCall) bool { return len(c.Arguments) == 2 })
+	if lit, ok := call.Arguments[1].(*ast.NumberLiteral); !ok || lit.Number != "2" {
+		t.Errorf("argument = %#v", call.Arguments[1])
+	}
+
+	// post returning false stops; the root can be replaced
+	visited := 0
+	other := &ast.SourceUnit{BaseNode: ast.BaseNode{Type: ast.NodeSourceUnit}}
+	root := ast.Apply(unit, nil, func(c *ast.Cursor) bool {
+		visited++
+		if c.Depth() == 0 {
+			c.Replace(other)
+		}
+		return visited < 3
+	})
+	if visited != 3 || root != unit {
+		t.Errorf("visited %d after stopping", visited)
+	}
+	if root := ast.Apply(unit, func(c *ast.Cursor) bool { c.Replace(other); return true }, nil); root != other {
+		t.Errorf("root not replaced")
+	}
+
+	// Misuse panics
+	for name, pre := range map[string]ast.ApplyFunc{
+		"delete field": func(c *ast.Cursor) bool {
+			if _, ok := c.Node().(*ast.Block); ok {
+				c.Delete()
+			}
+			return true
+		},
+		"wrong type": func(c *ast.Cursor) bool {
+			if _, ok := c.Node().(*ast.Block); ok {
+				c.Replace(emit(0))
+			}
+			return true
+		},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: no panic", name)
+				}
+			}()
+			ast.Apply(parse(t, tokenSrc), pre, nil)
+		}()
+	}
+}
+
+func TestCloneEqual(t *testing.T) {
+	unit := parse(t, tokenSrc)
+	clone := ast.Clone(unit)
+	if clone == unit || !ast.Equal(unit, clone, nil) {
+		t.Fatal("clone differs from the original")
+	}
+	if clone.Children[1] == unit.Children[1] || clone.Loc == unit.Loc {
+		t.Error("clone shares nodes or positions with the original")
+	}
+
+	// Mutate the clone; the original keeps its literal
+	ast.Apply(clone, func(c *ast.Cursor) bool {
+		if lit, ok := c.Node().(*ast.NumberLiteral); ok {
+			c.Replace(&ast.NumberLiteral{BaseNode: lit.BaseNode, Number: "2"})
+		}
+		return true
+	}, nil)
+	if ast.Equal(unit, clone, nil) {
+		t.Error("mutated clone equals the original")
+	}
+	if lit := find(unit, func(*ast.NumberLiteral) bool { return true }); lit.Number != "1" {
+		t.Errorf("original literal = %s", lit.Number)
+	}
+
+	// Positions are compared unless ignored
+	bare, err := parser.Parse(tokenSrc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ast.Equal(unit, bare, nil) || !ast.Equal(unit, bare, &ast.EqualOptions{IgnorePositions: true}) {
+		t.Error("IgnorePositions not honoured")
+	}
+
+	// nil and empty slices are equal; node types and names are not
+	a := &ast.Block{BaseNode: ast.BaseNode{Type: ast.NodeBlock}}
+	b := &ast.Block{BaseNode: ast.BaseNode{Type: ast.NodeBlock}, Statements: []ast.Node{}}
+	if !ast.Equal(a, b, nil) {
+		t.Error("nil and empty statements differ")
+	}
+	b.Statements = append(b.Statements, emit(0))
+	if ast.Equal(a, b, nil) || ast.Equal(emit(0), emit(1), nil) || !ast.Equal(emit(0), emit(0), nil) {
+		t.Error("different trees compare equal")
+	}
+	if ast.Equal(a, emit(0), nil) || !ast.Equal(nil, nil, nil) {
+		t.Error("different node types")
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	opts := &parser.Options{Loc: true, Range: true, LiteralValues: true, Tolerant: true}
+	err := filepath.WalkDir("../../testdata", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".sol" {
+			return err
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		data, err := parser.ParseToJSON(string(src), opts)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			return nil
+		}
+		var unit ast.SourceUnit
+		if err := json.Unmarshal(data, &unit); err != nil {
+			t.Errorf("%s: %v", path, err)
+			return nil
+		}
+		want, _ := parser.Parse(string(src), opts)
+		if !ast.Equal(want, &unit, nil) {
+			t.Errorf("%s: round trip differs", path)
+		}
+		again, _ := json.MarshalIndent(&unit, "", "  ")
+		if string(again) != string(data) {
+			t.Errorf("%s: re-encoded JSON differs", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Any node, with null and unknown types
+	n, err := ast.UnmarshalNode([]byte(`{"type":"TupleExpression","components":[null,{"type":"Identifier","name":"a"}],"isArray":false}`))
+	tuple, ok := n.(*ast.TupleExpression)
+	if err != nil || !ok || tuple.Components[0] != nil || tuple.Components[1].(*ast.Identifier).Name != "a" {
+		t.Errorf("UnmarshalNode = %#v, %v", n, err)
+	}
+	_, err = ast.UnmarshalNode([]byte(`{"type":"Block","statements":[{"type":"Nonsense"}]}`))
+	if err == nil || !strings.Contains(err.Error(), "statements/0") {
+		t.Errorf("unknown type error = %v", err)
+	}
+}
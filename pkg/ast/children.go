@@ -0,0 +1,110 @@
+package ast
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Children returns the nodes held directly by n's fields, in field order.
+// Nil children are skipped. Reflection keeps it complete as node types grow
+// fields; use Walk when a per-type traversal is wanted.
+func Children(n Node) []Node {
+	var out []Node
+	eachChild(n, false, func(_ string, child Node) bool {
+		out = append(out, child)
+		return true
+	})
+	return out
+}
+
+// eachChild calls fn for every node held directly by n's fields, in field
+// order. With paths set it also passes where the child is held: the field's
+// JSON name, then slice indices and the fields of plain structs, such as
+// "arguments/1" or "symbolAliasesIdentifiers/0/symbol". It stops when fn
+// returns false.
+func eachChild(n Node, paths bool, fn func(path string, child Node) bool) {
+	v := reflect.ValueOf(n)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return
+	}
+	v = v.Elem()
+	stopped := false
+	var collect func(f reflect.Value, path string)
+	collect = func(f reflect.Value, path string) {
+		if stopped {
+			return
+		}
+		switch f.Kind() {
+		case reflect.Interface, reflect.Ptr:
+			if f.IsNil() {
+				return
+			}
+			if child, ok := f.Interface().(Node); ok {
+				if c := reflect.ValueOf(child); c.Kind() == reflect.Ptr && c.IsNil() {
+					return
+				}
+				stopped = !fn(path, child)
+				return
+			}
+			if f.Kind() == reflect.Ptr && f.Elem().Kind() == reflect.Struct {
+				collect(f.Elem(), path)
+			}
+		case reflect.Slice:
+			for i := 0; i < f.Len(); i++ {
+				var p string
+				if paths {
+					p = path + "/" + strconv.Itoa(i)
+				}
+				collect(f.Index(i), p)
+			}
+		case reflect.Struct:
+			// Plain structs holding nodes, such as ImportSymbolIdentifiers
+			for _, field := range fields(f.Type(), true) {
+				var p string
+				if paths {
+					p = path + "/" + field.name
+				}
+				collect(f.Field(field.index), p)
+			}
+		}
+	}
+	for _, field := range fields(v.Type(), false) {
+		collect(v.Field(field.index), field.name)
+	}
+}
+
+// fieldCache maps a struct type and whether embedded fields count to its
+// exported fields
+var fieldCache sync.Map
+
+type fieldKey struct {
+	t        reflect.Type
+	embedded bool
+}
+
+type structField struct {
+	index int
+	name  string // JSON name
+}
+
+func fields(t reflect.Type, embedded bool) []structField {
+	key := fieldKey{t, embedded}
+	if cached, ok := fieldCache.Load(key); ok {
+		return cached.([]structField)
+	}
+	var out []structField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.IsExported() && (embedded || !field.Anonymous) {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" {
+				name = field.Name
+			}
+			out = append(out, structField{i, name})
+		}
+	}
+	fieldCache.Store(key, out)
+	return out
+}
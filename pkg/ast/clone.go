@@ -0,0 +1,67 @@
+package ast
+
+import "reflect"
+
+// Clone returns a deep copy of the tree below n, positions included, which
+// can be changed without affecting n. A node held in several places in n is
+// copied once and held in the same places in the copy.
+func Clone[T Node](n T) T {
+	c := cloner{seen: make(map[any]reflect.Value)}
+	return c.value(reflect.ValueOf(&n).Elem()).Interface().(T)
+}
+
+type cloner struct {
+	seen map[any]reflect.Value // original pointer → its copy
+}
+
+func (c *cloner) value(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		if done, ok := c.seen[v.Interface()]; ok {
+			return done
+		}
+		out := reflect.New(v.Type().Elem())
+		c.seen[v.Interface()] = out
+		out.Elem().Set(c.value(v.Elem()))
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(c.value(v.Elem()))
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(c.value(v.Index(i)))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for it := v.MapRange(); it.Next(); {
+			out.SetMapIndex(it.Key(), c.value(it.Value()))
+		}
+		return out
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if out.Field(i).CanSet() {
+				out.Field(i).Set(c.value(v.Field(i)))
+			}
+		}
+		return out
+	}
+	// Scalars, and arrays of them such as Range
+	return v
+}
@@ -0,0 +1,92 @@
+package ast
+
+import "reflect"
+
+// EqualOptions configures Equal
+type EqualOptions struct {
+	// IgnorePositions: do not compare Loc and Range, so that trees parsed
+	// with different options, or built by hand, can be compared
+	IgnorePositions bool
+}
+
+var (
+	locationType = reflect.TypeOf((*Location)(nil))
+	rangeType    = reflect.TypeOf((*Range)(nil))
+)
+
+// Equal reports whether the trees below a and b are structurally equal:
+// the same node types with equal fields, compared recursively through
+// Node fields and slices. A nil slice equals an empty one, and a nil node
+// a nil interface, as JSON round trips do not keep the difference. opts
+// may be nil.
+func Equal(a, b Node, opts *EqualOptions) bool {
+	if opts == nil {
+		opts = &EqualOptions{}
+	}
+	return equal(reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem(), opts)
+}
+
+func equal(a, b reflect.Value, opts *EqualOptions) bool {
+	if a.Type() != b.Type() {
+		return false
+	}
+	switch a.Kind() {
+	case reflect.Interface:
+		if isNil(a) || isNil(b) {
+			return isNil(a) && isNil(b)
+		}
+		return equal(a.Elem(), b.Elem(), opts)
+	case reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() && b.IsNil()
+		}
+		return a.Pointer() == b.Pointer() || equal(a.Elem(), b.Elem(), opts)
+	case reflect.Slice, reflect.Array:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equal(a.Index(i), b.Index(i), opts) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for it := a.MapRange(); it.Next(); {
+			bv := b.MapIndex(it.Key())
+			if !bv.IsValid() || !equal(it.Value(), bv, opts) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if t := a.Type().Field(i).Type; opts.IgnorePositions && (t == locationType || t == rangeType) {
+				continue
+			}
+			if !equal(a.Field(i), b.Field(i), opts) {
+				return false
+			}
+		}
+		return true
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float()
+	case reflect.String:
+		return a.String() == b.String()
+	}
+	return false
+}
+
+// isNil reports whether an interface value is nil or holds a nil pointer
+func isNil(v reflect.Value) bool {
+	return v.IsNil() || v.Elem().Kind() == reflect.Ptr && v.Elem().IsNil()
+}
@@ -0,0 +1,156 @@
+package ast
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Index records the parent of every node below a root, so that the
+// ancestors of a node can be found without walking the tree again. Build it
+// once per SourceUnit; it does not see later changes to the tree.
+type Index struct {
+	parents  map[Node]Node
+	segments map[Node]string
+}
+
+// NewIndex indexes the nodes below root. A node reachable through several
+// fields is indexed under the first.
+func NewIndex(root Node) *Index {
+	ix := &Index{
+		parents:  make(map[Node]Node),
+		segments: make(map[Node]string),
+	}
+	ix.add(root)
+	return ix
+}
+
+func (ix *Index) add(parent Node) {
+	names := make(map[string]int)
+	eachChild(parent, true, func(path string, child Node) bool {
+		if _, ok := ix.parents[child]; !ok {
+			ix.parents[child] = parent
+			ix.segments[child] = segment(path, child, names)
+			ix.add(child)
+		}
+		return true
+	})
+}
+
+// listFields hold the top-level elements, contract members and statements,
+// which are addressed by index alone, or by name for definitions
+var listFields = map[string]bool{
+	"children":   true,
+	"subNodes":   true,
+	"statements": true,
+	"operations": true,
+}
+
+// segment is the path step from a parent to child, held at path within the
+// parent. names counts the definition names already used among siblings, so
+// that overloads get distinct steps: transfer, transfer#1, ...
+func segment(path string, child Node, names map[string]int) string {
+	field, index, _ := strings.Cut(path, "/")
+	if !listFields[field] {
+		return path
+	}
+	name := definitionName(child)
+	if name == "" {
+		return index
+	}
+	k := names[name]
+	names[name]++
+	if k > 0 {
+		return name + "#" + strconv.Itoa(k)
+	}
+	return name
+}
+
+// definitionName names a contract member or top-level definition
+func definitionName(n Node) string {
+	switch n := n.(type) {
+	case *ContractDefinition:
+		return n.Name
+	case *FunctionDefinition:
+		switch {
+		case n.IsConstructor:
+			return "constructor"
+		case n.IsFallback:
+			return "fallback"
+		case n.IsReceiveEther:
+			return "receive"
+		}
+		return n.Name
+	case *ModifierDefinition:
+		return n.Name
+	case *StructDefinition:
+		return n.Name
+	case *EnumDefinition:
+		return n.Name
+	case *EventDefinition:
+		return n.Name
+	case *ErrorDefinition:
+		return n.Name
+	case *UserDefinedValueTypeDefinition:
+		return n.Name
+	case *StateVariableDeclaration:
+		if len(n.Variables) > 0 && n.Variables[0] != nil {
+			return n.Variables[0].Name
+		}
+	}
+	return ""
+}
+
+// Parent returns the node holding n, or nil for the root and nodes not in
+// the index
+func (ix *Index) Parent(n Node) Node {
+	return ix.parents[n]
+}
+
+// Ancestors returns the nodes enclosing n, nearest first, ending with the
+// root
+func (ix *Index) Ancestors(n Node) []Node {
+	var out []Node
+	for p := ix.parents[n]; p != nil; p = ix.parents[p] {
+		out = append(out, p)
+	}
+	return out
+}
+
+// EnclosingFunction returns the nearest function strictly enclosing n, or
+// nil
+func (ix *Index) EnclosingFunction(n Node) *FunctionDefinition {
+	for p := ix.parents[n]; p != nil; p = ix.parents[p] {
+		if f, ok := p.(*FunctionDefinition); ok {
+			return f
+		}
+	}
+	return nil
+}
+
+// EnclosingContract returns the contract strictly enclosing n, or nil
+func (ix *Index) EnclosingContract(n Node) *ContractDefinition {
+	for p := ix.parents[n]; p != nil; p = ix.parents[p] {
+		if c, ok := p.(*ContractDefinition); ok {
+			return c
+		}
+	}
+	return nil
+}
+
+// Path returns the location of n below the root, such as
+// "Token/transfer/body/2/expression": definitions by name (overloads as
+// name#1, name#2, ...), other elements of the top level, contract bodies
+// and blocks by index, and everything else by JSON field name. It depends
+// only on the tree, so it is stable across parses of the same source and
+// usable as a finding identifier. The root's path is empty, as is that of a
+// node not in the index.
+func (ix *Index) Path(n Node) string {
+	var segments []string
+	for ; ix.parents[n] != nil; n = ix.parents[n] {
+		segments = append(segments, ix.segments[n])
+	}
+	for i, j := 0, len(segments)-1; i < j; i, j = i+1, j-1 {
+		segments[i], segments[j] = segments[j], segments[i]
+	}
+	return strings.Join(segments, "/")
+}
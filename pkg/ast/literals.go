@@ -0,0 +1,113 @@
+package ast
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// denominations maps a number unit to its multiplier. szabo and finney were
+// removed in Solidity 0.7.0 but still appear in older sources.
+var denominations = map[string]int64{
+	"wei":     1,
+	"gwei":    1e9,
+	"szabo":   1e12,
+	"finney":  1e15,
+	"ether":   1e18,
+	"seconds": 1,
+	"minutes": 60,
+	"hours":   60 * 60,
+	"days":    24 * 60 * 60,
+	"weeks":   7 * 24 * 60 * 60,
+	"years":   365 * 24 * 60 * 60,
+}
+
+// maxExponent bounds the decimal exponent Rat accepts, so that a literal
+// such as 1e999999999 cannot exhaust memory
+const maxExponent = 4096
+
+// Rat returns the exact value of the literal with its subdenomination
+// applied. It reports false if Number or SubDenomination is malformed.
+func (n *NumberLiteral) Rat() (*big.Rat, bool) {
+	text := strings.ReplaceAll(n.Number, "_", "")
+	var r *big.Rat
+	if strings.HasPrefix(text, "0x") || strings.HasPrefix(text, "0X") {
+		digits := text[2:]
+		if digits == "" || strings.Trim(digits, "0123456789abcdefABCDEF") != "" {
+			return nil, false
+		}
+		i, _ := new(big.Int).SetString(digits, 16)
+		r = new(big.Rat).SetInt(i)
+	} else {
+		var ok bool
+		if r, ok = parseDecimal(text); !ok {
+			return nil, false
+		}
+	}
+	if n.SubDenomination != "" {
+		unit, ok := denominations[n.SubDenomination]
+		if !ok {
+			return nil, false
+		}
+		r.Mul(r, new(big.Rat).SetInt64(unit))
+	}
+	return r, true
+}
+
+// parseDecimal parses digits with an optional fraction and exponent, such as
+// 12, 1.5, .5 or 2.5e-3
+func parseDecimal(text string) (*big.Rat, bool) {
+	mantissa, exponent, hasExponent := strings.Cut(strings.ToLower(text), "e")
+	whole, frac, hasFrac := strings.Cut(mantissa, ".")
+	digits := whole + frac
+	if digits == "" || (hasFrac && frac == "") || strings.Trim(digits, "0123456789") != "" {
+		return nil, false
+	}
+	scale := -len(frac)
+	if hasExponent {
+		exp, err := strconv.Atoi(exponent)
+		if err != nil || exp > maxExponent || exp < -maxExponent {
+			return nil, false
+		}
+		scale += exp
+	}
+
+	i, _ := new(big.Int).SetString(digits, 10)
+	r := new(big.Rat).SetInt(i)
+	pow := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(scale))), nil))
+	if scale >= 0 {
+		return r.Mul(r, pow), true
+	}
+	return r.Quo(r, pow), true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Bytes returns the contents of the literal, its parts concatenated, with
+// escape sequences decoded
+func (n *StringLiteral) Bytes() []byte {
+	if len(n.Parts) == 0 {
+		return []byte(n.Value)
+	}
+	return []byte(strings.Join(n.Parts, ""))
+}
+
+// Bytes returns the bytes the literal's parts spell. It reports false if
+// they are not whole hex bytes.
+func (n *HexLiteral) Bytes() ([]byte, bool) {
+	text := n.Value
+	if len(n.Parts) > 0 {
+		text = strings.Join(n.Parts, "")
+	}
+	b, err := hex.DecodeString(strings.ReplaceAll(text, "_", ""))
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
@@ -0,0 +1,68 @@
+package ast
+
+// NodeAt returns the innermost node whose Range contains offset, followed
+// by its enclosing nodes, nearest first, ending with root. Ranges are
+// half-open, so an offset just past a node is not in it. It returns nil if
+// root does not contain offset. Offsets are in the units the tree was
+// parsed with; nodes without a Range are looked through.
+func NodeAt(root Node, offset int) []Node {
+	return NodesInRange(root, offset, offset+1)
+}
+
+// NodesInRange returns the innermost node whose Range covers [start, end),
+// followed by its enclosing nodes as NodeAt does. An empty range is covered
+// by the nodes containing start or ending at it.
+func NodesInRange(root Node, start, end int) []Node {
+	return covering(root, func(n Node) (bool, bool) {
+		rng := n.GetRange()
+		if rng == nil {
+			return false, false
+		}
+		return true, rng[0] <= start && end <= rng[1]
+	})
+}
+
+// NodeAtPosition is NodeAt for a line and column, using the Loc fields
+func NodeAtPosition(root Node, pos Position) []Node {
+	return covering(root, func(n Node) (bool, bool) {
+		loc := n.GetLocation()
+		if loc == nil {
+			return false, false
+		}
+		return true, !before(pos, loc.Start) && before(pos, loc.End)
+	})
+}
+
+func before(a, b Position) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+}
+
+// covering descends from root through the first child that covers the
+// target, by contains, and returns the covering nodes innermost first.
+// Nodes without positions are not returned but their children are searched.
+func covering(root Node, contains func(Node) (positioned, covers bool)) []Node {
+	var chain []Node
+	var visit func(n Node) bool
+	visit = func(n Node) bool {
+		positioned, covers := contains(n)
+		if positioned && !covers {
+			return false
+		}
+		found := false
+		for _, child := range Children(n) {
+			if visit(child) {
+				found = true
+				break
+			}
+		}
+		if found || positioned {
+			if positioned {
+				chain = append(chain, n)
+			}
+			return true
+		}
+		return false
+	}
+	visit(root)
+	return chain
+}
@@ -3,7 +3,10 @@
 // solidity-parser (https://github.com/solidity-parser/parser).
 package ast
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"reflect"
+)
 
 // NodeType represents the type of an AST node
 type NodeType string
@@ -82,6 +85,7 @@ const (
 	NodeAssemblyLocalDefinition NodeType = "AssemblyLocalDefinition"
 	NodeAssemblyAssignment    NodeType = "AssemblyAssignment"
 	NodeAssemblyIdentifier    NodeType = "AssemblyIdentifier"
+	NodeAssemblyMemberAccess  NodeType = "AssemblyMemberAccess"
 	NodeAssemblyLiteral       NodeType = "AssemblyLiteral"
 	NodeAssemblyIf            NodeType = "AssemblyIf"
 	NodeAssemblySwitch        NodeType = "AssemblySwitch"
@@ -534,6 +538,9 @@ type NumberLiteral struct {
 	BaseNode
 	Number      string `json:"number"`
 	SubDenomination string `json:"subdenomination,omitempty"`
+	// Value: the exact value as an integer or a fraction "a/b", with the
+	// subdenomination applied; set only with parser.Options.LiteralValues
+	Value       string `json:"value,omitempty"`
 }
 
 // BooleanLiteral represents a boolean literal
@@ -548,6 +555,8 @@ type StringLiteral struct {
 	Value    string   `json:"value"`
 	Parts    []string `json:"parts,omitempty"`
 	IsUnicode bool    `json:"isUnicode"`
+	// HexValue: the decoded bytes in hex; set only with parser.Options.LiteralValues
+	HexValue string   `json:"hexValue,omitempty"`
 }
 
 // HexLiteral represents a hex literal
@@ -555,6 +564,8 @@ type HexLiteral struct {
 	BaseNode
 	Value string   `json:"value"`
 	Parts []string `json:"parts,omitempty"`
+	// HexValue: the decoded bytes in hex; set only with parser.Options.LiteralValues
+	HexValue string `json:"hexValue,omitempty"`
 }
 
 // InlineAssembly represents inline assembly
@@ -587,8 +598,11 @@ type AssemblyLocalDefinition struct {
 // AssemblyAssignment represents an assignment in assembly
 type AssemblyAssignment struct {
 	BaseNode
-	Names      []*Identifier `json:"names"`
-	Expression Node          `json:"expression"`
+	Names []*Identifier `json:"names"`
+	// Targets holds an AssemblyMemberAccess for each path target such as
+	// x.slot, whose name in Names is the joined path
+	Targets    []Node `json:"targets,omitempty"`
+	Expression Node   `json:"expression"`
 }
 
 // AssemblyIdentifier represents an identifier in assembly
@@ -597,10 +611,17 @@ type AssemblyIdentifier struct {
 	Name string `json:"name"`
 }
 
+// AssemblyMemberAccess represents a Yul path such as x.slot or x.offset
+type AssemblyMemberAccess struct {
+	BaseNode
+	Expression *Identifier `json:"expression"`
+	MemberName *Identifier `json:"memberName"`
+}
+
 // AssemblyLiteral represents a literal in assembly
 type AssemblyLiteral struct {
 	BaseNode
-	Kind  string `json:"kind"` // "number", "string", "boolean"
+	Kind  string `json:"kind"` // "number", "string", "hex", "boolean"
 	Value string `json:"value"`
 }
 
@@ -654,14 +675,10 @@ func (s *SourceUnit) MarshalJSON() ([]byte, error) {
 	})
 }
 
-// UnmarshalJSON implements custom JSON unmarshaling for SourceUnit
+// UnmarshalJSON implements custom JSON unmarshaling for SourceUnit: every
+// node below it is decoded into the Go type named by its "type" field
 func (s *SourceUnit) UnmarshalJSON(data []byte) error {
 	type Alias SourceUnit
-	aux := &struct {
-		*Alias
-	}{
-		Alias: (*Alias)(s),
-	}
-	return json.Unmarshal(data, aux)
+	return decode(data, reflect.ValueOf((*Alias)(s)).Elem(), "")
 }
 
@@ -0,0 +1,138 @@
+package ast
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Action tells Traverse how to go on after a Walker callback
+type Action int
+
+const (
+	// Continue visits the node's children, then its following siblings
+	Continue Action = iota
+	// SkipChildren skips the node's children; Leave is still called for it.
+	// Returned from Leave it is the same as Continue.
+	SkipChildren
+	// Stop ends the walk; no further Enter or Leave calls are made
+	Stop
+)
+
+// Walker is the enter/leave counterpart of Visitor: Enter is called before a
+// node's children, Leave after them, which suits analyses that track scopes
+// such as functions, blocks and unchecked regions.
+type Walker interface {
+	Enter(node Node, cursor *Cursor) Action
+	Leave(node Node, cursor *Cursor) Action
+}
+
+// BaseWalker continues everywhere; embed it to implement only one callback
+type BaseWalker struct{}
+
+func (BaseWalker) Enter(node Node, cursor *Cursor) Action { return Continue }
+func (BaseWalker) Leave(node Node, cursor *Cursor) Action { return Continue }
+
+// WalkerFuncs is a Walker calling EnterFn and LeaveFn; a nil func continues
+type WalkerFuncs struct {
+	EnterFn func(node Node, cursor *Cursor) Action
+	LeaveFn func(node Node, cursor *Cursor) Action
+}
+
+func (w WalkerFuncs) Enter(node Node, cursor *Cursor) Action {
+	if w.EnterFn == nil {
+		return Continue
+	}
+	return w.EnterFn(node, cursor)
+}
+
+func (w WalkerFuncs) Leave(node Node, cursor *Cursor) Action {
+	if w.LeaveFn == nil {
+		return Continue
+	}
+	return w.LeaveFn(node, cursor)
+}
+
+// Cursor describes where Traverse or Apply is: the current node and how its
+// parent holds it. It is only valid during the callback it is passed to.
+// Cursors passed by Apply can also change the tree, through Replace, Delete,
+// InsertBefore and InsertAfter.
+type Cursor struct {
+	node   Node
+	parent *Cursor
+	field  string
+	index  int
+	depth  int
+
+	// Set by Apply only
+	slot    reflect.Value // settable value holding node
+	list    reflect.Value // settable slice holding node, if slot is its element
+	step    int           // how far the enclosing list moves on after node
+	deleted bool
+}
+
+// Node returns the current node
+func (c *Cursor) Node() Node { return c.node }
+
+// Parent returns the node holding the current one, or nil at the root
+func (c *Cursor) Parent() Node {
+	if c.parent == nil {
+		return nil
+	}
+	return c.parent.node
+}
+
+// Field returns the JSON name of the parent field holding the current node,
+// such as "arguments"; empty at the root
+func (c *Cursor) Field() string { return c.field }
+
+// Index returns the position of the current node in the parent field's
+// slice, or -1 if the field is not a slice
+func (c *Cursor) Index() int { return c.index }
+
+// Depth returns the number of ancestors of the current node; 0 at the root
+func (c *Cursor) Depth() int { return c.depth }
+
+// Traverse walks the tree below root depth-first in field order, as
+// Children does, calling w.Enter before and w.Leave after each node's
+// children
+func Traverse(root Node, w Walker) {
+	if root == nil {
+		return
+	}
+	traverse(&Cursor{node: root, index: -1}, w)
+}
+
+// traverse reports false once the walk is stopped
+func traverse(c *Cursor, w Walker) bool {
+	switch w.Enter(c.node, c) {
+	case Stop:
+		return false
+	case SkipChildren:
+	default:
+		ok := true
+		eachChild(c.node, true, func(path string, child Node) bool {
+			field, index := splitPath(path)
+			ok = traverse(&Cursor{node: child, parent: c, field: field, index: index, depth: c.depth + 1}, w)
+			return ok
+		})
+		if !ok {
+			return false
+		}
+	}
+	return w.Leave(c.node, c) != Stop
+}
+
+// splitPath returns the field of an eachChild path and the slice index
+// following it, or -1
+func splitPath(path string) (string, int) {
+	field, rest, _ := strings.Cut(path, "/")
+	index := -1
+	if rest != "" {
+		step, _, _ := strings.Cut(rest, "/")
+		if i, err := strconv.Atoi(step); err == nil {
+			index = i
+		}
+	}
+	return field, index
+}
@@ -0,0 +1,196 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// nodeTypes makes an empty node for each value of the JSON "type" field
+var nodeTypes = map[NodeType]func() Node{
+	NodeSourceUnit:                     func() Node { return &SourceUnit{} },
+	NodePragmaDirective:                func() Node { return &PragmaDirective{} },
+	NodeImportDirective:                func() Node { return &ImportDirective{} },
+	NodeContractDefinition:             func() Node { return &ContractDefinition{} },
+	NodeInheritanceSpecifier:           func() Node { return &InheritanceSpecifier{} },
+	NodeFunctionDefinition:             func() Node { return &FunctionDefinition{} },
+	NodeModifierDefinition:             func() Node { return &ModifierDefinition{} },
+	NodeModifierInvocation:             func() Node { return &ModifierInvocation{} },
+	NodeStructDefinition:               func() Node { return &StructDefinition{} },
+	NodeEnumDefinition:                 func() Node { return &EnumDefinition{} },
+	NodeEnumValue:                      func() Node { return &EnumValue{} },
+	NodeEventDefinition:                func() Node { return &EventDefinition{} },
+	NodeErrorDefinition:                func() Node { return &ErrorDefinition{} },
+	NodeUserDefinedValueTypeDefinition: func() Node { return &UserDefinedValueTypeDefinition{} },
+	NodeUsingForDeclaration:            func() Node { return &UsingForDeclaration{} },
+	NodeStateVariableDeclaration:       func() Node { return &StateVariableDeclaration{} },
+	NodeVariableDeclaration:            func() Node { return &VariableDeclaration{} },
+	NodeVariableDeclarationStatement:   func() Node { return &VariableDeclarationStatement{} },
+	NodeElementaryTypeName:             func() Node { return &ElementaryTypeName{} },
+	NodeUserDefinedTypeName:            func() Node { return &UserDefinedTypeName{} },
+	NodeMapping:                        func() Node { return &Mapping{} },
+	NodeArrayTypeName:                  func() Node { return &ArrayTypeName{} },
+	NodeFunctionTypeName:               func() Node { return &FunctionTypeName{} },
+	NodeBlock:                          func() Node { return &Block{} },
+	NodeUncheckedBlock:                 func() Node { return &UncheckedBlock{} },
+	NodeExpressionStatement:            func() Node { return &ExpressionStatement{} },
+	NodeIfStatement:                    func() Node { return &IfStatement{} },
+	NodeWhileStatement:                 func() Node { return &WhileStatement{} },
+	NodeDoWhileStatement:               func() Node { return &DoWhileStatement{} },
+	NodeForStatement:                   func() Node { return &ForStatement{} },
+	NodeContinueStatement:              func() Node { return &ContinueStatement{} },
+	NodeBreakStatement:                 func() Node { return &BreakStatement{} },
+	NodeReturnStatement:                func() Node { return &ReturnStatement{} },
+	NodeEmitStatement:                  func() Node { return &EmitStatement{} },
+	NodeRevertStatement:                func() Node { return &RevertStatement{} },
+	NodeTryStatement:                   func() Node { return &TryStatement{} },
+	NodeCatchClause:                    func() Node { return &CatchClause{} },
+	NodeBinaryOperation:                func() Node { return &BinaryOperation{} },
+	NodeUnaryOperation:                 func() Node { return &UnaryOperation{} },
+	NodeConditional:                    func() Node { return &Conditional{} },
+	NodeFunctionCall:                   func() Node { return &FunctionCall{} },
+	NodeFunctionCallOptions:            func() Node { return &FunctionCallOptions{} },
+	NodeMemberAccess:                   func() Node { return &MemberAccess{} },
+	NodeIndexAccess:                    func() Node { return &IndexAccess{} },
+	NodeIndexRangeAccess:               func() Node { return &IndexRangeAccess{} },
+	NodeNewExpression:                  func() Node { return &NewExpression{} },
+	NodeTupleExpression:                func() Node { return &TupleExpression{} },
+	NodeNameValueExpression:            func() Node { return &NameValueExpression{} },
+	NodeIdentifier:                     func() Node { return &Identifier{} },
+	NodeNumberLiteral:                  func() Node { return &NumberLiteral{} },
+	NodeBooleanLiteral:                 func() Node { return &BooleanLiteral{} },
+	NodeStringLiteral:                  func() Node { return &StringLiteral{} },
+	NodeHexLiteral:                     func() Node { return &HexLiteral{} },
+	NodeInlineAssembly:                 func() Node { return &InlineAssembly{} },
+	NodeAssemblyBlock:                  func() Node { return &AssemblyBlock{} },
+	NodeAssemblyCall:                   func() Node { return &AssemblyCall{} },
+	NodeAssemblyLocalDefinition:        func() Node { return &AssemblyLocalDefinition{} },
+	NodeAssemblyAssignment:             func() Node { return &AssemblyAssignment{} },
+	NodeAssemblyIdentifier:             func() Node { return &AssemblyIdentifier{} },
+	NodeAssemblyMemberAccess:           func() Node { return &AssemblyMemberAccess{} },
+	NodeAssemblyLiteral:                func() Node { return &AssemblyLiteral{} },
+	NodeAssemblyIf:                     func() Node { return &AssemblyIf{} },
+	NodeAssemblySwitch:                 func() Node { return &AssemblySwitch{} },
+	NodeAssemblyCase:                   func() Node { return &AssemblyCase{} },
+	NodeAssemblyFor:                    func() Node { return &AssemblyFor{} },
+	NodeAssemblyFunctionDefinition:     func() Node { return &AssemblyFunctionDefinition{} },
+}
+
+// NewNode returns an empty node of type t, or nil if t names no node type
+func NewNode(t NodeType) Node {
+	if newNode, ok := nodeTypes[t]; ok {
+		return newNode()
+	}
+	return nil
+}
+
+var nodeInterface = reflect.TypeOf((*Node)(nil)).Elem()
+
+// UnmarshalNode decodes a node of any type from the JSON that encoding/json
+// produces for it, such as the output of parser.ParseToJSON, choosing the
+// Go type of every node, including those in Node fields and slices, by its
+// "type" field. It returns nil for JSON null.
+func UnmarshalNode(data []byte) (Node, error) {
+	var n Node
+	if err := decode(data, reflect.ValueOf(&n).Elem(), ""); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// decode decodes data into v; path locates data in the document for errors
+func decode(data json.RawMessage, v reflect.Value, path string) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	switch {
+	case v.Type() == nodeInterface:
+		var head struct {
+			Type NodeType `json:"type"`
+		}
+		if err := json.Unmarshal(data, &head); err != nil {
+			return fmt.Errorf("%s: %w", at(path), err)
+		}
+		newNode, ok := nodeTypes[head.Type]
+		if !ok {
+			return fmt.Errorf("%s: unknown node type %q", at(path), head.Type)
+		}
+		n := reflect.ValueOf(newNode())
+		if err := decode(data, n.Elem(), path); err != nil {
+			return err
+		}
+		v.Set(n)
+	case v.Kind() == reflect.Ptr:
+		p := reflect.New(v.Type().Elem())
+		if err := decode(data, p.Elem(), path); err != nil {
+			return err
+		}
+		v.Set(p)
+	case v.Kind() == reflect.Slice:
+		var elems []json.RawMessage
+		if err := json.Unmarshal(data, &elems); err != nil {
+			return fmt.Errorf("%s: %w", at(path), err)
+		}
+		s := reflect.MakeSlice(v.Type(), len(elems), len(elems))
+		for i, elem := range elems {
+			if err := decode(elem, s.Index(i), fmt.Sprintf("%s/%d", path, i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case v.Kind() == reflect.Struct:
+		var members map[string]json.RawMessage
+		if err := json.Unmarshal(data, &members); err != nil {
+			return fmt.Errorf("%s: %w", at(path), err)
+		}
+		return decodeFields(members, v, path)
+	default:
+		if err := json.Unmarshal(data, v.Addr().Interface()); err != nil {
+			return fmt.Errorf("%s: %w", at(path), err)
+		}
+	}
+	return nil
+}
+
+// decodeFields decodes the members of a JSON object into the fields of the
+// struct v, including those of embedded structs such as BaseNode
+func decodeFields(members map[string]json.RawMessage, v reflect.Value, path string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := decodeFields(members, v.Field(i), path); err != nil {
+				return err
+			}
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if data, ok := members[name]; ok {
+			if err := decode(data, v.Field(i), path+"/"+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// at prefixes decoding errors with the location in the document, such as
+// children/1/subNodes/0
+func at(path string) string {
+	if path == "" {
+		return "ast: decoding"
+	}
+	return "ast: decoding " + path[1:]
+}
@@ -70,6 +70,14 @@ type Visitor interface {
 	VisitAssemblyFunctionDefinition(node *AssemblyFunctionDefinition) bool
 }
 
+// AssemblyMemberAccessVisitor is implemented by visitors that handle Yul
+// paths such as x.slot. It is not part of Visitor, so that implementations
+// written before the node existed still compile; Walk calls it when present
+// and otherwise descends into the path.
+type AssemblyMemberAccessVisitor interface {
+	VisitAssemblyMemberAccess(node *AssemblyMemberAccess) bool
+}
+
 // BaseVisitor provides default implementations for all visitor methods
 type BaseVisitor struct{}
 
@@ -132,6 +140,7 @@ func (v *BaseVisitor) VisitAssemblyCall(node *AssemblyCall) bool
 func (v *BaseVisitor) VisitAssemblyLocalDefinition(node *AssemblyLocalDefinition) bool { return true }
 func (v *BaseVisitor) VisitAssemblyAssignment(node *AssemblyAssignment) bool       { return true }
 func (v *BaseVisitor) VisitAssemblyIdentifier(node *AssemblyIdentifier) bool       { return true }
+func (v *BaseVisitor) VisitAssemblyMemberAccess(node *AssemblyMemberAccess) bool   { return true }
 func (v *BaseVisitor) VisitAssemblyLiteral(node *AssemblyLiteral) bool             { return true }
 func (v *BaseVisitor) VisitAssemblyIf(node *AssemblyIf) bool                       { return true }
 func (v *BaseVisitor) VisitAssemblySwitch(node *AssemblySwitch) bool               { return true }
@@ -201,6 +210,7 @@ type SimpleVisitor struct {
 	AssemblyLocalDefinitionFn          func(*AssemblyLocalDefinition)
 	AssemblyAssignmentFn               func(*AssemblyAssignment)
 	AssemblyIdentifierFn               func(*AssemblyIdentifier)
+	AssemblyMemberAccessFn             func(*AssemblyMemberAccess)
 	AssemblyLiteralFn                  func(*AssemblyLiteral)
 	AssemblyIfFn                       func(*AssemblyIf)
 	AssemblySwitchFn                   func(*AssemblySwitch)
@@ -271,7 +281,9 @@ func Walk(node Node, visitor Visitor) {
 	case *StateVariableDeclaration:
 		if visitor.VisitStateVariableDeclaration(n) {
 			for _, v := range n.Variables {
-				Walk(v, visitor)
+				if v != nil { // empty tuple slot: (, uint b) = ...
+					Walk(v, visitor)
+				}
 			}
 			Walk(n.InitialValue, visitor)
 		}
@@ -506,6 +518,14 @@ func Walk(node Node, visitor Visitor) {
 		}
 	case *AssemblyIdentifier:
 		visitor.VisitAssemblyIdentifier(n)
+	case *AssemblyMemberAccess:
+		descend := true
+		if v, ok := visitor.(AssemblyMemberAccessVisitor); ok {
+			descend = v.VisitAssemblyMemberAccess(n)
+		}
+		if descend && n.Expression != nil {
+			Walk(n.Expression, visitor)
+		}
 	case *AssemblyLiteral:
 		visitor.VisitAssemblyLiteral(n)
 	case *AssemblyIf:
@@ -627,7 +647,9 @@ func WalkSimple(node Node, visitor *SimpleVisitor) {
 			visitor.VariableDeclarationStatementFn(n)
 		}
 		for _, v := range n.Variables {
-			WalkSimple(v, visitor)
+			if v != nil { // empty tuple slot: (, uint b) = ...
+				WalkSimple(v, visitor)
+			}
 		}
 		WalkSimple(n.InitialValue, visitor)
 	case *StructDefinition:
@@ -908,6 +930,13 @@ func WalkSimple(node Node, visitor *SimpleVisitor) {
 		if visitor.AssemblyIdentifierFn != nil {
 			visitor.AssemblyIdentifierFn(n)
 		}
+	case *AssemblyMemberAccess:
+		if visitor.AssemblyMemberAccessFn != nil {
+			visitor.AssemblyMemberAccessFn(n)
+		}
+		if n.Expression != nil {
+			WalkSimple(n.Expression, visitor)
+		}
 	case *AssemblyLiteral:
 		if visitor.AssemblyLiteralFn != nil {
 			visitor.AssemblyLiteralFn(n)
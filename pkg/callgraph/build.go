@@ -0,0 +1,602 @@
+package callgraph
+
+import (
+	"strings"
+
+	"github.com/th13vn/solast-go/internal/inheritance"
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// contractInfo is the resolution table of one contract
+type contractInfo struct {
+	def  *ast.ContractDefinition
+	file string
+	// lin is the C3 linearization, most derived (this contract) first
+	lin       []*contractInfo
+	funcs     map[string][]*Node
+	modifiers map[string]*Node
+	ctor      *Node
+	using     []usingFor
+	stateVars map[string]ast.Node
+}
+
+// usingFor is one `using L for T` / `using {f, g} for T` attachment
+type usingFor struct {
+	library string
+	funcs   []string // attached free or library functions ("f", "L.f")
+	typ     string   // attached type, "*" for all
+	global  bool
+	file    string // declaring file, for file-level attachments
+}
+
+type builder struct {
+	g         *Graph
+	contracts map[string]*contractInfo
+	order     []*contractInfo
+	free      map[string][]*Node
+	// fileUsing are the file-level attachments, in input order
+	fileUsing []usingFor
+}
+
+// scope is the context of the body being resolved
+type scope struct {
+	from     *Node
+	contract *contractInfo // nil for free functions
+	file     string
+	// vars holds the types of the parameters and locals in scope, by block,
+	// innermost last
+	vars []map[string]ast.Node
+	// line is the line of the statement being resolved
+	line int
+}
+
+// Build builds the call graph of files. Contracts are matched by name across
+// files; when a name is declared twice the first declaration wins.
+func Build(files ...File) *Graph {
+	b := &builder{
+		g:         newGraph(),
+		contracts: make(map[string]*contractInfo),
+		free:      make(map[string][]*Node),
+	}
+	for _, f := range files {
+		b.declare(f)
+	}
+	b.linearize()
+	for _, f := range files {
+		b.resolveFile(f)
+	}
+	return b.g
+}
+
+// declare records the contracts, functions and modifiers of a file
+func (b *builder) declare(f File) {
+	if f.Unit == nil {
+		return
+	}
+	for _, child := range f.Unit.Children {
+		switch n := child.(type) {
+		case *ast.ContractDefinition:
+			if _, dup := b.contracts[n.Name]; dup {
+				continue
+			}
+			ci := &contractInfo{
+				def:       n,
+				file:      f.Path,
+				funcs:     make(map[string][]*Node),
+				modifiers: make(map[string]*Node),
+				stateVars: make(map[string]ast.Node),
+			}
+			b.contracts[n.Name] = ci
+			b.order = append(b.order, ci)
+			for _, sub := range n.SubNodes {
+				switch def := sub.(type) {
+				case *ast.FunctionDefinition:
+					node := b.g.addNode(functionNode(def, n, f.Path))
+					if def.IsConstructor {
+						ci.ctor = node
+					} else {
+						ci.funcs[def.Name] = append(ci.funcs[def.Name], node)
+					}
+				case *ast.ModifierDefinition:
+					ci.modifiers[def.Name] = b.g.addNode(&Node{
+						ID:           n.Name + "." + def.Name + signature(def.Parameters),
+						Contract:     n.Name,
+						ContractKind: n.Kind,
+						Name:         def.Name,
+						Kind:         KindModifier,
+						File:         f.Path,
+						Line:         line(def),
+						Definition:   def,
+					})
+				case *ast.StateVariableDeclaration:
+					for _, v := range def.Variables {
+						if v != nil {
+							ci.stateVars[v.Name] = v.TypeName
+						}
+					}
+				case *ast.UsingForDeclaration:
+					ci.using = append(ci.using, attachment(def))
+				}
+			}
+		case *ast.FunctionDefinition:
+			node := b.g.addNode(functionNode(n, nil, f.Path))
+			b.free[n.Name] = append(b.free[n.Name], node)
+		case *ast.UsingForDeclaration:
+			u := attachment(n)
+			u.file = f.Path
+			b.fileUsing = append(b.fileUsing, u)
+		}
+	}
+}
+
+func functionNode(fn *ast.FunctionDefinition, c *ast.ContractDefinition, file string) *Node {
+	n := &Node{
+		Name:       fn.Name,
+		Kind:       KindFunction,
+		Visibility: fn.Visibility,
+		File:       file,
+		Line:       line(fn),
+		Definition: fn,
+	}
+	switch {
+	case fn.IsConstructor:
+		n.Name, n.Kind = "constructor", KindConstructor
+	case fn.IsReceiveEther:
+		n.Name, n.Kind = "receive", KindReceive
+	case fn.IsFallback:
+		n.Name, n.Kind = "fallback", KindFallback
+	}
+	n.ID = n.Name + signature(fn.Parameters)
+	switch {
+	case c != nil:
+		n.Contract, n.ContractKind = c.Name, c.Kind
+		n.ID = c.Name + "." + n.ID
+	case file != "":
+		// Free functions of one signature may be declared in several files
+		n.ID = file + ":" + n.ID
+	}
+	return n
+}
+
+func attachment(u *ast.UsingForDeclaration) usingFor {
+	typ := "*"
+	if u.TypeName != nil {
+		typ = typeString(u.TypeName)
+	}
+	return usingFor{library: u.LibraryName, funcs: u.Functions, typ: typ, global: u.IsGlobal}
+}
+
+// linearize computes the C3 linearization of every contract. Bases are
+// matched by the last segment of their name path; bases not declared in the
+// project are skipped.
+func (b *builder) linearize() {
+	lin := inheritance.New(func(name string) *ast.ContractDefinition {
+		if ci, ok := b.contracts[lastSegment(name)]; ok {
+			return ci.def
+		}
+		return nil
+	})
+	for _, ci := range b.order {
+		for _, def := range lin.Linearize(ci.def) {
+			ci.lin = append(ci.lin, b.contracts[def.Name])
+			b.g.lin[ci.def.Name] = append(b.g.lin[ci.def.Name], def.Name)
+		}
+	}
+}
+
+// resolveFile adds the edges of every body in f
+func (b *builder) resolveFile(f File) {
+	if f.Unit == nil {
+		return
+	}
+	for _, child := range f.Unit.Children {
+		switch n := child.(type) {
+		case *ast.ContractDefinition:
+			ci := b.contracts[n.Name]
+			if ci == nil || ci.def != n {
+				continue
+			}
+			for _, sub := range n.SubNodes {
+				switch def := sub.(type) {
+				case *ast.FunctionDefinition:
+					from := b.g.Node(functionNode(def, n, f.Path).ID)
+					b.resolveBody(&scope{from: from, contract: ci, file: f.Path}, def.Parameters, def.ReturnParameters, def.Body)
+					b.modifierInvocations(from, ci, def)
+				case *ast.ModifierDefinition:
+					from := ci.modifiers[def.Name]
+					b.resolveBody(&scope{from: from, contract: ci, file: f.Path}, def.Parameters, nil, def.Body)
+				}
+			}
+		case *ast.FunctionDefinition:
+			from := b.g.Node(functionNode(n, nil, f.Path).ID)
+			b.resolveBody(&scope{from: from, file: f.Path}, n.Parameters, n.ReturnParameters, n.Body)
+		}
+	}
+}
+
+// modifierInvocations adds modifier edges, and internal edges to base
+// constructors invoked from a constructor header (`constructor() Base(1)`)
+func (b *builder) modifierInvocations(from *Node, ci *contractInfo, fn *ast.FunctionDefinition) {
+	for _, inv := range fn.Modifiers {
+		if inv == nil {
+			continue
+		}
+		for _, c := range ci.lin {
+			if mod, ok := c.modifiers[inv.Name]; ok {
+				b.g.addEdge(from, mod, EdgeModifier, inv, 0)
+				break
+			}
+		}
+		if base, ok := b.contracts[inv.Name]; ok && fn.IsConstructor {
+			b.g.addEdge(from, b.constructor(base), EdgeInternal, inv, 0)
+		}
+	}
+}
+
+func (b *builder) resolveBody(s *scope, params, returns []*ast.VariableDeclaration, body *ast.Block) {
+	if s.from == nil || body == nil {
+		return
+	}
+	s.vars = []map[string]ast.Node{{}}
+	for _, list := range [][]*ast.VariableDeclaration{params, returns} {
+		for _, p := range list {
+			s.declare(p)
+		}
+	}
+	// A local is in scope from its declaration to the end of its block (or
+	// for statement, or catch clause); the try's return parameters are in
+	// scope in its body. Statements are visited before the calls inside them.
+	ast.Traverse(body, ast.WalkerFuncs{
+		EnterFn: func(n ast.Node, c *ast.Cursor) ast.Action {
+			switch n := n.(type) {
+			case *ast.Block, *ast.CatchClause:
+				s.vars = append(s.vars, make(map[string]ast.Node))
+				if try, ok := c.Parent().(*ast.TryStatement); ok && c.Field() == "body" {
+					for _, p := range try.ReturnParameters {
+						s.declare(p)
+					}
+				}
+			case *ast.ForStatement:
+				s.vars = append(s.vars, make(map[string]ast.Node))
+				s.at(n)
+			case *ast.VariableDeclaration:
+				if c.Field() != "returnParameters" {
+					s.declare(n)
+				}
+			case *ast.FunctionTypeName:
+				return ast.SkipChildren // its parameters are not locals
+			case *ast.ExpressionStatement, *ast.VariableDeclarationStatement, *ast.ReturnStatement,
+				*ast.EmitStatement, *ast.RevertStatement, *ast.IfStatement, *ast.WhileStatement,
+				*ast.DoWhileStatement, *ast.TryStatement:
+				s.at(n)
+			case *ast.FunctionCall:
+				b.call(s, n)
+			}
+			return ast.Continue
+		},
+		LeaveFn: func(n ast.Node, c *ast.Cursor) ast.Action {
+			switch n.(type) {
+			case *ast.Block, *ast.ForStatement, *ast.CatchClause:
+				s.vars = s.vars[:len(s.vars)-1]
+			}
+			return ast.Continue
+		},
+	})
+}
+
+// at moves the call-site line to statement n
+func (s *scope) at(n ast.Node) {
+	if l := line(n); l != 0 {
+		s.line = l
+	}
+}
+
+// declare brings parameter or local v into the innermost scope
+func (s *scope) declare(v *ast.VariableDeclaration) {
+	if v != nil && v.Name != "" {
+		s.vars[len(s.vars)-1][v.Name] = v.TypeName
+	}
+}
+
+// variable returns the type of the parameter or local name in scope
+func (s *scope) variable(name string) (ast.Node, bool) {
+	for i := len(s.vars) - 1; i >= 0; i-- {
+		if t, ok := s.vars[i][name]; ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// call resolves one call site
+func (b *builder) call(s *scope, call *ast.FunctionCall) {
+	callee := call.Expression
+	for {
+		switch c := callee.(type) {
+		case *ast.FunctionCallOptions:
+			callee = c.Expression
+			continue
+		case *ast.NameValueExpression:
+			callee = c.Expression
+			continue
+		}
+		break
+	}
+	argc := len(call.Arguments)
+
+	switch c := callee.(type) {
+	case *ast.NewExpression:
+		if t, ok := c.TypeName.(*ast.UserDefinedTypeName); ok {
+			if target, ok := b.contracts[lastSegment(t.NamePath)]; ok {
+				b.g.addEdge(s.from, b.constructor(target), EdgeCreation, call, s.line)
+			}
+		}
+	case *ast.Identifier:
+		if _, isVar := s.variable(c.Name); isVar {
+			return // call through a function-typed variable
+		}
+		if s.contract != nil {
+			if fn := lookup(s.contract.lin, c.Name, argc); fn != nil {
+				b.g.addEdge(s.from, fn, EdgeInternal, call, s.line)
+				return
+			}
+		}
+		if fn := b.freeFunction(c.Name, s.file, argc); fn != nil {
+			b.g.addEdge(s.from, fn, EdgeInternal, call, s.line)
+		}
+	case *ast.MemberAccess:
+		b.memberCall(s, call, c, argc)
+	}
+}
+
+func (b *builder) memberCall(s *scope, call *ast.FunctionCall, ma *ast.MemberAccess, argc int) {
+	name := ma.MemberName
+	if id, ok := ma.Expression.(*ast.Identifier); ok {
+		_, isVar := s.variable(id.Name)
+		if !isVar && s.contract != nil {
+			_, isVar = b.stateVar(s.contract, id.Name)
+		}
+		switch {
+		case id.Name == "super" && s.contract != nil:
+			if fn := lookup(s.contract.lin[1:], name, argc); fn != nil {
+				b.g.addEdge(s.from, fn, EdgeSuper, call, s.line)
+			}
+			return
+		case id.Name == "this" && s.contract != nil:
+			if fn := lookup(s.contract.lin, name, argc); fn != nil {
+				b.g.addEdge(s.from, fn, EdgeExternal, call, s.line)
+			}
+			return
+		case !isVar:
+			if target, ok := b.contracts[id.Name]; ok {
+				// L.f() on a library, or Base.f() on a base contract
+				if fn := lookup(target.lin, name, argc); fn != nil {
+					kind := EdgeInternal
+					if target.def.Kind == "library" {
+						kind = EdgeLibrary
+					}
+					b.g.addEdge(s.from, fn, kind, call, s.line)
+				}
+				return
+			}
+		}
+	}
+
+	recvType := b.typeOf(s, ma.Expression)
+	if target, ok := b.contracts[lastSegment(typeString(recvType))]; ok && recvType != nil && target.def.Kind != "library" {
+		if fn := lookup(target.lin, name, argc); fn != nil {
+			b.g.addEdge(s.from, fn, EdgeExternal, call, s.line)
+		}
+		return
+	}
+	// using-for attached function: the receiver is the first argument
+	if fn := b.attached(s, typeString(recvType), name, argc+1); fn != nil {
+		b.g.addEdge(s.from, fn, EdgeLibrary, call, s.line)
+	}
+}
+
+// attached finds a library or free function attached with `using for` that
+// matches typ ("" when the receiver type is unknown)
+func (b *builder) attached(s *scope, typ, name string, argc int) *Node {
+	var using []usingFor
+	if s.contract != nil {
+		for _, c := range s.contract.lin {
+			using = append(using, c.using...)
+		}
+	}
+	for _, u := range b.fileUsing {
+		// `using ... for T global` reaches every file
+		if u.file == s.file || u.global {
+			using = append(using, u)
+		}
+	}
+	for _, u := range using {
+		if u.typ != "*" && typ != "" && u.typ != typ {
+			continue
+		}
+		if u.library != "" {
+			if lib, ok := b.contracts[u.library]; ok {
+				if fn := pick(lib.funcs[name], argc); fn != nil {
+					return fn
+				}
+			}
+			continue
+		}
+		for _, f := range u.funcs {
+			lib, fname := "", f
+			if i := strings.LastIndex(f, "."); i >= 0 {
+				lib, fname = f[:i], f[i+1:]
+			}
+			if fname != name {
+				continue
+			}
+			if lib == "" {
+				if fn := b.freeFunction(fname, s.file, argc); fn != nil {
+					return fn
+				}
+			} else if c, ok := b.contracts[lib]; ok {
+				if fn := pick(c.funcs[fname], argc); fn != nil {
+					return fn
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// typeOf returns the declared type of a receiver expression, or nil
+func (b *builder) typeOf(s *scope, expr ast.Node) ast.Node {
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		if t, ok := s.variable(e.Name); ok {
+			return t
+		}
+		if s.contract != nil {
+			if t, ok := b.stateVar(s.contract, e.Name); ok {
+				return t
+			}
+		}
+	case *ast.IndexAccess:
+		switch t := b.typeOf(s, e.Base).(type) {
+		case *ast.Mapping:
+			return t.ValueType
+		case *ast.ArrayTypeName:
+			return t.BaseTypeName
+		}
+	case *ast.FunctionCall:
+		// Type conversion such as IERC20(token)
+		if id, ok := e.Expression.(*ast.Identifier); ok && len(e.Arguments) == 1 {
+			if _, ok := b.contracts[id.Name]; ok {
+				return &ast.UserDefinedTypeName{NamePath: id.Name}
+			}
+		}
+		if et, ok := e.Expression.(*ast.ElementaryTypeName); ok {
+			return et
+		}
+	}
+	return nil
+}
+
+func (b *builder) stateVar(ci *contractInfo, name string) (ast.Node, bool) {
+	for _, c := range ci.lin {
+		if t, ok := c.stateVars[name]; ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// constructor returns the constructor node of c, adding an implicit one when
+// the contract declares none
+func (b *builder) constructor(c *contractInfo) *Node {
+	if c.ctor == nil {
+		c.ctor = b.g.addNode(&Node{
+			ID:           c.def.Name + ".constructor()",
+			Contract:     c.def.Name,
+			ContractKind: c.def.Kind,
+			Name:         "constructor",
+			Kind:         KindConstructor,
+			Visibility:   "public",
+			File:         c.file,
+			Line:         line(c.def),
+			Implicit:     true,
+		})
+	}
+	return c.ctor
+}
+
+// freeFunction picks the free function name called from file, preferring
+// one declared in that file
+func (b *builder) freeFunction(name, file string, argc int) *Node {
+	var local []*Node
+	for _, n := range b.free[name] {
+		if n.File == file {
+			local = append(local, n)
+		}
+	}
+	if fn := arity(local, argc); fn != nil {
+		return fn
+	}
+	return pick(b.free[name], argc)
+}
+
+// lookup finds the function named name along a linearization: the first
+// taking argc arguments, else the first of that name
+func lookup(lin []*contractInfo, name string, argc int) *Node {
+	for _, c := range lin {
+		if fn := arity(c.funcs[name], argc); fn != nil {
+			return fn
+		}
+	}
+	for _, c := range lin {
+		if fns := c.funcs[name]; len(fns) > 0 {
+			return fns[0]
+		}
+	}
+	return nil
+}
+
+// pick chooses the overload taking argc arguments, or the first candidate
+func pick(candidates []*Node, argc int) *Node {
+	if fn := arity(candidates, argc); fn != nil {
+		return fn
+	}
+	if len(candidates) > 0 {
+		return candidates[0]
+	}
+	return nil
+}
+
+// arity returns the first candidate taking argc arguments, or nil
+func arity(candidates []*Node, argc int) *Node {
+	for _, n := range candidates {
+		if fn, ok := n.Definition.(*ast.FunctionDefinition); ok && len(fn.Parameters) == argc {
+			return n
+		}
+	}
+	return nil
+}
+
+// signature renders "(type,...)" for a parameter list
+func signature(params []*ast.VariableDeclaration) string {
+	types := make([]string, 0, len(params))
+	for _, p := range params {
+		if p != nil {
+			types = append(types, typeString(p.TypeName))
+		}
+	}
+	return "(" + strings.Join(types, ",") + ")"
+}
+
+// typeString renders a type name canonically ("uint" becomes "uint256",
+// "address payable" becomes "address")
+func typeString(t ast.Node) string {
+	switch v := t.(type) {
+	case *ast.ElementaryTypeName:
+		switch v.Name {
+		case "uint":
+			return "uint256"
+		case "int":
+			return "int256"
+		case "address payable":
+			return "address"
+		}
+		return v.Name
+	case *ast.UserDefinedTypeName:
+		return v.NamePath
+	case *ast.ArrayTypeName:
+		return typeString(v.BaseTypeName) + "[]"
+	case *ast.Mapping:
+		return "mapping(" + typeString(v.KeyType) + "=>" + typeString(v.ValueType) + ")"
+	case *ast.FunctionTypeName:
+		return "function"
+	}
+	return ""
+}
+
+func lastSegment(path string) string {
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
@@ -0,0 +1,266 @@
+// Package callgraph builds a whole-project call graph over one or more parsed
+// source units.
+//
+// Nodes are functions, modifiers and constructors; edges are labelled with
+// how the call is made (internal, super, modifier, library, external, new), so
+// auditors can scope reentrancy paths and privileged entry points. Resolution
+// is syntactic: names are looked up through the C3 linearization of the
+// calling contract, declared variable types and `using for` attachments.
+package callgraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// EdgeKind labels how a call is made
+type EdgeKind string
+
+// Edge kinds
+const (
+	// EdgeInternal is a call to a function of the same contract, a base
+	// contract, or a free function
+	EdgeInternal EdgeKind = "internal"
+	// EdgeSuper is a `super.f()` call
+	EdgeSuper EdgeKind = "super"
+	// EdgeModifier is a modifier invocation
+	EdgeModifier EdgeKind = "modifier"
+	// EdgeLibrary is a call into a library, directly or through `using for`
+	EdgeLibrary EdgeKind = "library"
+	// EdgeExternal is a message call through a contract- or interface-typed
+	// value (including `this.f()`)
+	EdgeExternal EdgeKind = "external"
+	// EdgeCreation is a `new C(...)` contract creation
+	EdgeCreation EdgeKind = "new"
+)
+
+// Node kinds
+const (
+	KindFunction    = "function"
+	KindModifier    = "modifier"
+	KindConstructor = "constructor"
+	KindFallback    = "fallback"
+	KindReceive     = "receive"
+)
+
+// File is a parsed source unit and the path it was read from
+type File struct {
+	Path string
+	Unit *ast.SourceUnit
+}
+
+// Node is a callable: a function, modifier or constructor
+type Node struct {
+	// ID is "Contract.name(types)", or "file:name(types)" for free functions
+	ID       string `json:"id"`
+	Contract string `json:"contract,omitempty"`
+	// ContractKind is the declaring contract's kind (contract, interface,
+	// library, abstract); empty for free functions
+	ContractKind string `json:"contractKind,omitempty"`
+	Name         string `json:"name"`
+	Kind         string `json:"kind"`
+	Visibility   string `json:"visibility,omitempty"`
+	File         string `json:"file,omitempty"`
+	Line         int    `json:"line,omitempty"`
+	// Implicit marks a default constructor that is not declared in source
+	Implicit bool `json:"implicit,omitempty"`
+	// Definition is the FunctionDefinition or ModifierDefinition; nil for
+	// implicit constructors
+	Definition ast.Node `json:"-"`
+}
+
+// Edge is a call from one node to another. Repeated calls of the same kind
+// between two nodes share one edge, located at the first call site.
+type Edge struct {
+	From *Node
+	To   *Node
+	Kind EdgeKind
+	// Call is the first call site (FunctionCall or ModifierInvocation)
+	Call ast.Node
+	// Line is the source line of Call, or of its statement when the call
+	// itself carries no position
+	Line int
+}
+
+// Graph is a call graph
+type Graph struct {
+	Nodes []*Node
+	Edges []*Edge
+	byID  map[string]*Node
+	seen  map[edgeKey]bool
+	lin   map[string][]string
+}
+
+type edgeKey struct {
+	from, to *Node
+	kind     EdgeKind
+}
+
+func newGraph() *Graph {
+	return &Graph{byID: make(map[string]*Node), seen: make(map[edgeKey]bool), lin: make(map[string][]string)}
+}
+
+// Node returns the node with the given ID, or nil
+func (g *Graph) Node(id string) *Node {
+	return g.byID[id]
+}
+
+// Linearization returns the C3 linearization of a contract by name, most
+// derived first, or nil if the contract is not in the graph
+func (g *Graph) Linearization(contract string) []string {
+	return g.lin[contract]
+}
+
+// Callees returns the edges leaving n
+func (g *Graph) Callees(n *Node) []*Edge {
+	var out []*Edge
+	for _, e := range g.Edges {
+		if e.From == n {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Callers returns the edges entering n
+func (g *Graph) Callers(n *Node) []*Edge {
+	var out []*Edge
+	for _, e := range g.Edges {
+		if e.To == n {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func (g *Graph) addNode(n *Node) *Node {
+	if existing, ok := g.byID[n.ID]; ok {
+		return existing
+	}
+	g.byID[n.ID] = n
+	g.Nodes = append(g.Nodes, n)
+	return n
+}
+
+func (g *Graph) addEdge(from, to *Node, kind EdgeKind, call ast.Node, fallback int) {
+	key := edgeKey{from, to, kind}
+	if from == nil || to == nil || g.seen[key] {
+		return
+	}
+	g.seen[key] = true
+	l := line(call)
+	if l == 0 {
+		l = fallback
+	}
+	g.Edges = append(g.Edges, &Edge{From: from, To: to, Kind: kind, Call: call, Line: l})
+}
+
+type jsonEdge struct {
+	From string   `json:"from"`
+	To   string   `json:"to"`
+	Kind EdgeKind `json:"kind"`
+	Line int      `json:"line,omitempty"`
+}
+
+// MarshalJSON encodes the graph as {"nodes": [...], "edges": [...]} with edges
+// referring to node IDs
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	edges := make([]jsonEdge, 0, len(g.Edges))
+	for _, e := range g.Edges {
+		edges = append(edges, jsonEdge{From: e.From.ID, To: e.To.ID, Kind: e.Kind, Line: e.Line})
+	}
+	nodes := g.Nodes
+	if nodes == nil {
+		nodes = []*Node{}
+	}
+	return json.Marshal(struct {
+		Nodes []*Node    `json:"nodes"`
+		Edges []jsonEdge `json:"edges"`
+	}{nodes, edges})
+}
+
+// DOT renders the graph in Graphviz DOT format
+func (g *Graph) DOT() string {
+	var sb strings.Builder
+	g.WriteDOT(&sb)
+	return sb.String()
+}
+
+// WriteDOT writes the graph in Graphviz DOT format to w, one cluster per
+// contract
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("digraph \"callgraph\" {\n")
+	sb.WriteString("  node [shape=box, fontname=\"monospace\"];\n")
+
+	groups := make(map[string][]*Node)
+	var contracts []string
+	for _, n := range g.Nodes {
+		if _, ok := groups[n.Contract]; !ok {
+			contracts = append(contracts, n.Contract)
+		}
+		groups[n.Contract] = append(groups[n.Contract], n)
+	}
+	sort.Strings(contracts)
+	for i, contract := range contracts {
+		indent := "  "
+		if contract != "" {
+			fmt.Fprintf(&sb, "  subgraph cluster_%d {\n", i)
+			fmt.Fprintf(&sb, "    label=%q;\n", contract+" ("+groups[contract][0].ContractKind+")")
+			indent = "    "
+		}
+		for _, n := range groups[contract] {
+			label := strings.TrimPrefix(n.ID, contract+".")
+			if contract == "" {
+				label = strings.TrimPrefix(n.ID, n.File+":")
+			}
+			attrs := []string{fmt.Sprintf("label=%q", label)}
+			switch {
+			case n.Kind == KindModifier:
+				attrs = append(attrs, "shape=hexagon")
+			case n.Implicit || n.ContractKind == "interface":
+				attrs = append(attrs, "style=dashed")
+			case n.Visibility == "external" || n.Visibility == "public" || n.Kind == KindFallback || n.Kind == KindReceive:
+				attrs = append(attrs, "style=bold")
+			}
+			fmt.Fprintf(&sb, "%s%q [%s];\n", indent, n.ID, strings.Join(attrs, ", "))
+		}
+		if contract != "" {
+			sb.WriteString("  }\n")
+		}
+	}
+	for _, e := range g.Edges {
+		attrs := []string{fmt.Sprintf("label=%q", string(e.Kind))}
+		switch e.Kind {
+		case EdgeExternal:
+			attrs = append(attrs, "color=red")
+		case EdgeSuper:
+			attrs = append(attrs, "style=dashed")
+		case EdgeModifier:
+			attrs = append(attrs, "style=dotted")
+		case EdgeLibrary:
+			attrs = append(attrs, "color=blue")
+		case EdgeCreation:
+			attrs = append(attrs, "style=bold")
+		}
+		fmt.Fprintf(&sb, "  %q -> %q [%s];\n", e.From.ID, e.To.ID, strings.Join(attrs, ", "))
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func line(n ast.Node) int {
+	if n == nil {
+		return 0
+	}
+	if loc := n.GetLocation(); loc != nil {
+		return loc.Start.Line
+	}
+	return 0
+}
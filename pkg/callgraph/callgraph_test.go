@@ -0,0 +1,286 @@
+package callgraph
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/th13vn/solast-go/pkg/parser"
+)
+
+func build(t *testing.T, sources map[string]string, order ...string) *Graph {
+	t.Helper()
+	var files []File
+	for _, path := range order {
+		unit, err := parser.Parse(sources[path], &parser.Options{Loc: true})
+		if err != nil {
+			t.Fatalf("Parse %s failed: %v", path, err)
+		}
+		files = append(files, File{Path: path, Unit: unit})
+	}
+	return Build(files...)
+}
+
+// hasEdge reports whether an edge from -> to of the given kind exists
+func hasEdge(g *Graph, from, to string, kind EdgeKind) bool {
+	for _, e := range g.Edges {
+		if e.From.ID == from && e.To.ID == to && e.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+const tokenSrc = `
+interface IERC20 {
+	function transfer(address to, uint amount) external returns (bool);
+}
+
+library SafeMath {
+	function add(uint a, uint b) internal pure returns (uint) { return a + b; }
+}
+
+library Address {
+	function isContract(address a) internal view returns (bool) { return a.code.length > 0; }
+}
+`
+
+const vaultSrc = `
+import "./Token.sol";
+
+contract Ownable {
+	address owner;
+	modifier onlyOwner() { require(msg.sender == owner); _; }
+	function _check() internal view {}
+	function hook() internal virtual {}
+}
+
+contract Child {
+	constructor(uint x) {}
+}
+
+contract Vault is Ownable {
+	using SafeMath for uint;
+	using Address for address;
+	IERC20 token;
+	mapping(address => IERC20) tokens;
+
+	constructor() Ownable() {}
+
+	function hook() internal override {
+		super.hook();
+	}
+
+	function withdraw(uint amount) external onlyOwner {
+		_check();
+		hook();
+		uint total = amount.add(1);
+		token.transfer(msg.sender, total);
+		tokens[msg.sender].transfer(msg.sender, 1);
+		IERC20(address(0)).transfer(msg.sender, SafeMath.add(1, 2));
+		msg.sender.isContract();
+		this.ping();
+		new Child(total);
+	}
+
+	function ping() external {}
+}
+`
+
+func TestEdgeKinds(t *testing.T) {
+	g := build(t, map[string]string{"Token.sol": tokenSrc, "Vault.sol": vaultSrc}, "Token.sol", "Vault.sol")
+
+	from := "Vault.withdraw(uint256)"
+	cases := []struct {
+		to   string
+		kind EdgeKind
+	}{
+		{"Ownable.onlyOwner()", EdgeModifier},
+		{"Ownable._check()", EdgeInternal},
+		{"Vault.hook()", EdgeInternal},
+		{"SafeMath.add(uint256,uint256)", EdgeLibrary},
+		{"IERC20.transfer(address,uint256)", EdgeExternal},
+		{"Address.isContract(address)", EdgeLibrary},
+		{"Vault.ping()", EdgeExternal},
+		{"Child.constructor(uint256)", EdgeCreation},
+	}
+	for _, tc := range cases {
+		if !hasEdge(g, from, tc.to, tc.kind) {
+			t.Errorf("missing %s edge %s -> %s", tc.kind, from, tc.to)
+		}
+	}
+	if !hasEdge(g, "Vault.hook()", "Ownable.hook()", EdgeSuper) {
+		t.Error("missing super edge Vault.hook() -> Ownable.hook()")
+	}
+	if !hasEdge(g, "Vault.constructor()", "Ownable.constructor()", EdgeInternal) {
+		t.Error("missing base constructor edge from Vault constructor")
+	}
+	if n := g.Node("Ownable.constructor()"); n == nil || !n.Implicit {
+		t.Error("expected an implicit constructor node for Ownable")
+	}
+	// three transfer calls share one external edge
+	n := 0
+	for _, e := range g.Callees(g.Node(from)) {
+		if e.To.ID == "IERC20.transfer(address,uint256)" {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("expected repeated calls to share one edge, got %d", n)
+	}
+	if len(g.Callers(g.Node("Ownable.onlyOwner()"))) != 1 {
+		t.Error("expected one caller of onlyOwner")
+	}
+}
+
+func TestLinearization(t *testing.T) {
+	g := build(t, map[string]string{"a.sol": `
+contract A { function f() public virtual {} }
+contract B is A { function f() public virtual override { super.f(); } }
+contract C is A { function f() public virtual override { super.f(); } }
+contract D is B, C {
+	function f() public override(B, C) { super.f(); }
+	function g() public { f(); }
+}`}, "a.sol")
+	// D's linearization is D, C, B, A: super in D is C, super in C is A
+	if !hasEdge(g, "D.f()", "C.f()", EdgeSuper) {
+		t.Error("super.f() in D should resolve to C.f()")
+	}
+	if !hasEdge(g, "C.f()", "A.f()", EdgeSuper) {
+		t.Error("super.f() in C should resolve to A.f()")
+	}
+	if !hasEdge(g, "D.g()", "D.f()", EdgeInternal) {
+		t.Error("f() in D should resolve to D.f()")
+	}
+}
+
+func TestOverloadsAndFreeFunctions(t *testing.T) {
+	g := build(t, map[string]string{
+		"a.sol": `
+function helper(uint x) pure returns (uint) { return x; }
+contract Base { function f(uint a, uint b) internal {} }
+contract Derived is Base {
+	function f(uint a) internal {}
+	function g() public { f(1, 2); helper(1); }
+}`,
+		"b.sol": `
+function helper(uint x) pure returns (uint) { return x + 1; }
+function useIt() pure returns (uint) { return helper(2); }`,
+	}, "a.sol", "b.sol")
+	// A derived overload of the wrong arity does not shadow the base one
+	if !hasEdge(g, "Derived.g()", "Base.f(uint256,uint256)", EdgeInternal) {
+		t.Error("f(1, 2) in Derived should resolve to Base.f(uint256,uint256)")
+	}
+	if hasEdge(g, "Derived.g()", "Derived.f(uint256)", EdgeInternal) {
+		t.Error("f(1, 2) in Derived resolved to Derived.f(uint256)")
+	}
+	// Free functions of one signature in two files are two nodes
+	a, b := g.Node("a.sol:helper(uint256)"), g.Node("b.sol:helper(uint256)")
+	if a == nil || b == nil || a == b {
+		t.Fatalf("free functions: a.sol %v, b.sol %v", a, b)
+	}
+	if !hasEdge(g, "b.sol:useIt()", "b.sol:helper(uint256)", EdgeInternal) {
+		t.Error("helper(2) in b.sol should resolve to b.sol's helper")
+	}
+	if !hasEdge(g, "Derived.g()", "a.sol:helper(uint256)", EdgeInternal) {
+		t.Error("helper(1) in a.sol should resolve to a.sol's helper")
+	}
+}
+
+func TestUsingForOrder(t *testing.T) {
+	sources := map[string]string{
+		"a.sol": `
+type Amount is uint;
+library A { function inc(Amount x) internal pure returns (Amount) { return x; } }
+using A for Amount global;`,
+		"b.sol": `
+library B { function inc(Amount x) internal pure returns (Amount) { return x; } }
+using B for Amount global;
+contract C { function f(Amount x) public { x.inc(); } }`,
+	}
+	// Two global attachments match: the first file's wins, on every build
+	for i := 0; i < 20; i++ {
+		g := build(t, sources, "a.sol", "b.sol")
+		if !hasEdge(g, "C.f(Amount)", "A.inc(Amount)", EdgeLibrary) || hasEdge(g, "C.f(Amount)", "B.inc(Amount)", EdgeLibrary) {
+			t.Fatalf("build %d: x.inc() should resolve to A.inc only", i)
+		}
+	}
+}
+
+func TestLocalScopes(t *testing.T) {
+	g := build(t, map[string]string{"a.sol": `
+contract T { function ping() external {} }
+contract U { function ping() external {} }
+contract C {
+	T t;
+	function helper() internal {}
+	function f(bool b) public {
+		if (b) {
+			U t = U(address(0));
+			t.ping();
+		}
+		t.ping();
+		{ uint helper = 1; helper; }
+		helper();
+	}
+	function h() public {
+		try this.k() returns (uint helper) { helper; } catch {}
+		helper();
+	}
+	function k() external returns (uint) {}
+}`}, "a.sol")
+	// A local is in scope to the end of its block only
+	for _, tc := range []struct {
+		from, to string
+		kind     EdgeKind
+	}{
+		{"C.f(bool)", "U.ping()", EdgeExternal},
+		{"C.f(bool)", "T.ping()", EdgeExternal},
+		{"C.f(bool)", "C.helper()", EdgeInternal},
+		{"C.h()", "C.helper()", EdgeInternal},
+		{"C.h()", "C.k()", EdgeExternal},
+	} {
+		if !hasEdge(g, tc.from, tc.to, tc.kind) {
+			t.Errorf("missing %s edge %s -> %s", tc.kind, tc.from, tc.to)
+		}
+	}
+}
+
+func TestOutput(t *testing.T) {
+	g := build(t, map[string]string{"Token.sol": tokenSrc, "Vault.sol": vaultSrc}, "Token.sol", "Vault.sol")
+
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded struct {
+		Nodes []struct {
+			ID   string `json:"id"`
+			File string `json:"file"`
+		} `json:"nodes"`
+		Edges []struct {
+			From, To string
+			Kind     string
+			Line     int
+		} `json:"edges"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if len(decoded.Nodes) != len(g.Nodes) || len(decoded.Edges) != len(g.Edges) {
+		t.Errorf("JSON lost nodes or edges: %d/%d nodes, %d/%d edges",
+			len(decoded.Nodes), len(g.Nodes), len(decoded.Edges), len(g.Edges))
+	}
+	for _, e := range decoded.Edges {
+		if e.Line == 0 {
+			t.Errorf("edge %s -> %s has no call-site line", e.From, e.To)
+		}
+	}
+
+	dot := g.DOT()
+	for _, want := range []string{`digraph "callgraph"`, "subgraph cluster_", `label="Vault (contract)"`, `label="external", color=red`} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output missing %q", want)
+		}
+	}
+}
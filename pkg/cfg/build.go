@@ -0,0 +1,442 @@
+package cfg
+
+import (
+	"github.com/th13vn/solast-go/internal/inheritance"
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// ModifierResolver looks up the definition of an invoked modifier by name.
+// It returns nil for names that are not modifiers (e.g. base constructor
+// calls on a constructor) or cannot be resolved.
+type ModifierResolver func(name string) *ast.ModifierDefinition
+
+// loopTargets records where break/continue jump inside the innermost loop
+type loopTargets struct {
+	brk  *BasicBlock
+	cont *BasicBlock
+}
+
+// layer is one level of modifier inlining: the body of a modifier (or of the
+// function itself, innermost) together with where its `return` lands
+type layer struct {
+	modifier string
+	ret      *BasicBlock
+	loops    []loopTargets
+	// inner builds the next layer at a `_` placeholder; nil when the body is
+	// not a modifier or there is nothing left to inline
+	inner func()
+}
+
+type builder struct {
+	g         *Graph
+	cur       *BasicBlock
+	unchecked int
+	layer     *layer
+}
+
+// BuildAll builds a graph for every function and modifier with a body in the
+// source unit. Modifiers invoked by a function are resolved through its
+// contract's C3 linearization over the contracts declared in the same unit.
+func BuildAll(unit *ast.SourceUnit) []*Graph {
+	contracts := make(map[string]*ast.ContractDefinition)
+	for _, child := range unit.Children {
+		if c, ok := child.(*ast.ContractDefinition); ok {
+			contracts[c.Name] = c
+		}
+	}
+	lin := inheritance.New(func(name string) *ast.ContractDefinition { return contracts[name] })
+
+	var graphs []*Graph
+	for _, child := range unit.Children {
+		switch n := child.(type) {
+		case *ast.FunctionDefinition:
+			if n.Body != nil {
+				graphs = append(graphs, Build(n, "", nil))
+			}
+		case *ast.ContractDefinition:
+			resolve := LinearizedModifiers(lin.Linearize(n))
+			for _, sub := range n.SubNodes {
+				switch def := sub.(type) {
+				case *ast.FunctionDefinition:
+					if def.Body != nil {
+						graphs = append(graphs, Build(def, n.Name, resolve))
+					}
+				case *ast.ModifierDefinition:
+					if def.Body != nil {
+						graphs = append(graphs, BuildModifier(def, n.Name))
+					}
+				}
+			}
+		}
+	}
+	return graphs
+}
+
+// ContractModifiers returns a resolver over the modifiers declared directly in
+// contract
+func ContractModifiers(contract *ast.ContractDefinition) ModifierResolver {
+	return LinearizedModifiers([]*ast.ContractDefinition{contract})
+}
+
+// LinearizedModifiers returns a resolver searching the contracts of a C3
+// linearization in order, most derived first, so that the override Solidity
+// would run wins
+func LinearizedModifiers(lin []*ast.ContractDefinition) ModifierResolver {
+	return func(name string) *ast.ModifierDefinition {
+		for _, c := range lin {
+			for _, sub := range c.SubNodes {
+				if mod, ok := sub.(*ast.ModifierDefinition); ok && mod.Name == name && mod.Body != nil {
+					return mod
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// Build builds the graph of a function body. Modifier invocations resolved by
+// resolve are inlined around the body, each `_` placeholder expanding to the
+// next modifier (or the body itself); a nil resolver leaves invocations as
+// plain nodes in the entry path. contract may be empty for free functions.
+func Build(fn *ast.FunctionDefinition, contract string, resolve ModifierResolver) *Graph {
+	g := newGraph(qualifiedName(contract, functionName(fn)), fn)
+	b := &builder{g: g}
+	b.cur = b.newBlock()
+	g.connect(g.Entry, b.cur, EdgeNormal)
+	b.inline(fn, resolve, 0, g.Exit)
+	g.prune()
+	return g
+}
+
+// inline builds modifier i of fn (or the body once modifiers run out) so that
+// falling off its end or returning from it continues at ret
+func (b *builder) inline(fn *ast.FunctionDefinition, resolve ModifierResolver, i int, ret *BasicBlock) {
+	for ; i < len(fn.Modifiers); i++ {
+		inv := fn.Modifiers[i]
+		b.add(inv)
+		var def *ast.ModifierDefinition
+		if resolve != nil {
+			def = resolve(inv.Name)
+		}
+		if def == nil {
+			continue
+		}
+		next := i + 1
+		b.enter(&layer{modifier: inv.Name, ret: ret, inner: func() {
+			after := b.newBlock()
+			b.inline(fn, resolve, next, after)
+			b.cur = after
+		}}, def.Body)
+		return
+	}
+	b.enter(&layer{ret: ret}, fn.Body)
+}
+
+// BuildModifier builds the graph of a modifier body on its own. Each `_`
+// becomes a placeholder block standing for the modified function.
+func BuildModifier(mod *ast.ModifierDefinition, contract string) *Graph {
+	g := newGraph(qualifiedName(contract, mod.Name), mod)
+	b := &builder{g: g}
+	b.cur = b.newBlock()
+	g.connect(g.Entry, b.cur, EdgeNormal)
+	b.enter(&layer{ret: g.Exit, inner: func() {
+		placeholder := b.g.newBlock(BlockPlaceholder)
+		b.jump(placeholder, EdgeNormal)
+		b.cur = placeholder
+		b.split()
+	}}, mod.Body)
+	g.prune()
+	return g
+}
+
+// enter builds body as a new layer; falling off its end continues at l.ret
+func (b *builder) enter(l *layer, body *ast.Block) {
+	saved := b.layer
+	b.layer = l
+	if b.cur != nil && len(b.cur.Nodes) == 0 {
+		b.cur.Modifier = l.modifier
+	} else {
+		b.split()
+	}
+	b.block(body)
+	b.jump(l.ret, EdgeNormal)
+	b.layer = saved
+}
+
+func qualifiedName(contract, name string) string {
+	if contract == "" {
+		return name
+	}
+	return contract + "." + name
+}
+
+func functionName(fn *ast.FunctionDefinition) string {
+	switch {
+	case fn.IsConstructor:
+		return "constructor"
+	case fn.Name != "":
+		return fn.Name
+	case fn.IsReceiveEther:
+		return "receive"
+	case fn.IsFallback:
+		return "fallback"
+	}
+	return ""
+}
+
+// newBlock creates a block carrying the current unchecked/modifier context
+func (b *builder) newBlock() *BasicBlock {
+	blk := b.g.newBlock(BlockBasic)
+	blk.Unchecked = b.unchecked > 0
+	if b.layer != nil {
+		blk.Modifier = b.layer.modifier
+	}
+	return blk
+}
+
+// add appends n to the current block, opening a fresh (unreachable) block if
+// control cannot reach this point
+func (b *builder) add(n ast.Node) {
+	if b.cur == nil {
+		b.cur = b.newBlock()
+	}
+	b.cur.Nodes = append(b.cur.Nodes, n)
+}
+
+// split ends the current block and continues in a new one
+func (b *builder) split() {
+	next := b.newBlock()
+	b.g.connect(b.cur, next, EdgeNormal)
+	b.cur = next
+}
+
+// jump ends the current block with an edge to target; code after it is
+// unreachable until the next join point
+func (b *builder) jump(target *BasicBlock, kind EdgeKind) {
+	b.g.connect(b.cur, target, kind)
+	b.cur = nil
+}
+
+func (b *builder) loops() []loopTargets {
+	return b.layer.loops
+}
+
+func (b *builder) block(blk *ast.Block) {
+	if blk == nil {
+		return
+	}
+	for _, stmt := range blk.Statements {
+		b.statement(stmt)
+	}
+}
+
+func (b *builder) statement(n ast.Node) {
+	switch s := n.(type) {
+	case nil:
+	case *ast.Block:
+		b.block(s)
+	case *ast.UncheckedBlock:
+		b.unchecked++
+		b.split()
+		b.block(s.Body)
+		b.unchecked--
+		if b.cur != nil {
+			b.split()
+		}
+	case *ast.IfStatement:
+		b.ifStatement(s)
+	case *ast.WhileStatement:
+		b.whileStatement(s)
+	case *ast.DoWhileStatement:
+		b.doWhileStatement(s)
+	case *ast.ForStatement:
+		b.forStatement(s)
+	case *ast.BreakStatement:
+		b.add(s)
+		if loops := b.loops(); len(loops) > 0 {
+			b.jump(loops[len(loops)-1].brk, EdgeBreak)
+		}
+	case *ast.ContinueStatement:
+		b.add(s)
+		if loops := b.loops(); len(loops) > 0 {
+			b.jump(loops[len(loops)-1].cont, EdgeContinue)
+		}
+	case *ast.ReturnStatement:
+		b.add(s)
+		b.jump(b.layer.ret, EdgeReturn)
+	case *ast.RevertStatement:
+		b.add(s)
+		b.jump(b.g.Revert, EdgeRevert)
+	case *ast.TryStatement:
+		b.tryStatement(s)
+	case *ast.ExpressionStatement:
+		b.expressionStatement(s)
+	default:
+		b.add(n)
+	}
+}
+
+// expressionStatement handles the `_` placeholder and the terminating
+// builtins `revert(...)`, `require(...)` and `assert(...)`
+func (b *builder) expressionStatement(s *ast.ExpressionStatement) {
+	if id, ok := s.Expression.(*ast.Identifier); ok && id.Name == "_" && b.layer.inner != nil {
+		b.add(s)
+		b.layer.inner()
+		return
+	}
+
+	b.add(s)
+	switch builtinCall(s.Expression) {
+	case "revert":
+		b.jump(b.g.Revert, EdgeRevert)
+	case "require", "assert":
+		cond := b.cur
+		cond.Kind = BlockCondition
+		b.g.connect(cond, b.g.Revert, EdgeRevert)
+		b.cur = b.newBlock()
+		b.g.connect(cond, b.cur, EdgeTrue)
+	}
+}
+
+// builtinCall returns the callee name of a call to a bare identifier such as
+// `require(x)`, or "" otherwise
+func builtinCall(expr ast.Node) string {
+	if call, ok := expr.(*ast.FunctionCall); ok {
+		if id, ok := call.Expression.(*ast.Identifier); ok {
+			return id.Name
+		}
+	}
+	return ""
+}
+
+// condition ends the current block on a branch condition and returns it
+func (b *builder) condition(expr ast.Node) *BasicBlock {
+	if b.cur == nil || len(b.cur.Nodes) > 0 {
+		next := b.newBlock()
+		b.g.connect(b.cur, next, EdgeNormal)
+		b.cur = next
+	}
+	b.cur.Kind = BlockCondition
+	if expr != nil {
+		b.cur.Nodes = append(b.cur.Nodes, expr)
+	}
+	return b.cur
+}
+
+func (b *builder) ifStatement(s *ast.IfStatement) {
+	cond := b.condition(s.Condition)
+	join := b.newBlock()
+
+	b.cur = b.newBlock()
+	b.g.connect(cond, b.cur, EdgeTrue)
+	b.statement(s.TrueBody)
+	b.jump(join, EdgeNormal)
+
+	if s.FalseBody != nil {
+		b.cur = b.newBlock()
+		b.g.connect(cond, b.cur, EdgeFalse)
+		b.statement(s.FalseBody)
+		b.jump(join, EdgeNormal)
+	} else {
+		b.g.connect(cond, join, EdgeFalse)
+	}
+	b.cur = join
+}
+
+func (b *builder) whileStatement(s *ast.WhileStatement) {
+	header := b.condition(s.Condition)
+	after := b.newBlock()
+	b.g.connect(header, after, EdgeFalse)
+
+	b.cur = b.newBlock()
+	b.g.connect(header, b.cur, EdgeTrue)
+	b.loopBody(s.Body, after, header)
+	b.jump(header, EdgeLoopBack)
+	b.cur = after
+}
+
+func (b *builder) doWhileStatement(s *ast.DoWhileStatement) {
+	body := b.newBlock()
+	b.g.connect(b.cur, body, EdgeNormal)
+	cond := b.newBlock()
+	cond.Kind = BlockCondition
+	cond.Nodes = append(cond.Nodes, s.Condition)
+	after := b.newBlock()
+
+	b.cur = body
+	b.loopBody(s.Body, after, cond)
+	b.jump(cond, EdgeNormal)
+	b.g.connect(cond, body, EdgeTrue)
+	b.g.connect(cond, after, EdgeFalse)
+	b.cur = after
+}
+
+func (b *builder) forStatement(s *ast.ForStatement) {
+	if s.InitExpression != nil {
+		b.add(s.InitExpression)
+	}
+	header := b.condition(s.ConditionExpression)
+	after := b.newBlock()
+	latch := b.newBlock()
+	if s.LoopExpression != nil {
+		latch.Nodes = append(latch.Nodes, s.LoopExpression)
+	}
+
+	b.cur = b.newBlock()
+	if s.ConditionExpression != nil {
+		b.g.connect(header, b.cur, EdgeTrue)
+		b.g.connect(header, after, EdgeFalse)
+	} else {
+		header.Kind = BlockBasic
+		b.g.connect(header, b.cur, EdgeNormal)
+	}
+	b.loopBody(s.Body, after, latch)
+	b.jump(latch, EdgeNormal)
+	b.g.connect(latch, header, EdgeLoopBack)
+	b.cur = after
+}
+
+func (b *builder) loopBody(body ast.Node, brk, cont *BasicBlock) {
+	b.layer.loops = append(b.layer.loops, loopTargets{brk: brk, cont: cont})
+	b.statement(body)
+	b.layer.loops = b.layer.loops[:len(b.layer.loops)-1]
+}
+
+// tryStatement models the external call as a branch: the success edge runs
+// the try body, and a catch edge leads to each catch clause
+func (b *builder) tryStatement(s *ast.TryStatement) {
+	b.add(s.Expression)
+	call := b.cur
+	call.Kind = BlockCondition
+	join := b.newBlock()
+
+	b.cur = b.newBlock()
+	b.g.connect(call, b.cur, EdgeTrySuccess)
+	b.block(s.Body)
+	b.jump(join, EdgeNormal)
+
+	for _, clause := range s.CatchClauses {
+		b.cur = b.newBlock()
+		b.g.connect(call, b.cur, EdgeTryCatch)
+		b.add(clause)
+		b.block(clause.Body)
+		b.jump(join, EdgeNormal)
+	}
+	if !catchesAll(s.CatchClauses) {
+		// A failure no clause catches bubbles the revert up: always without
+		// clauses, and with only typed `catch Error(...)`/`catch Panic(...)`
+		b.g.connect(call, b.g.Revert, EdgeTryCatch)
+	}
+	b.cur = join
+}
+
+// catchesAll reports whether a clause catches every failure: `catch { }` or
+// `catch (bytes memory)`, which carry no Error/Panic kind
+func catchesAll(clauses []*ast.CatchClause) bool {
+	for _, clause := range clauses {
+		if clause.Kind == "" {
+			return true
+		}
+	}
+	return false
+}
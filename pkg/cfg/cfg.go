@@ -0,0 +1,316 @@
+// Package cfg builds control-flow graphs over the Solidity AST.
+//
+// A Graph is built per FunctionDefinition or ModifierDefinition body. Blocks
+// hold the statements (and loop/branch conditions) that execute in order, and
+// edges are labelled with the reason control moves between them, so dataflow
+// detectors can share one graph instead of each re-deriving control flow from
+// the tree.
+package cfg
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// BlockKind classifies a basic block
+type BlockKind string
+
+// Block kinds
+const (
+	BlockEntry       BlockKind = "entry"
+	BlockExit        BlockKind = "exit"
+	BlockRevert      BlockKind = "revert"
+	BlockBasic       BlockKind = "basic"
+	BlockCondition   BlockKind = "condition"
+	BlockPlaceholder BlockKind = "placeholder" // modifier `_` with nothing to inline
+	BlockHalt        BlockKind = "halt"        // Yul return/stop/selfdestruct
+)
+
+// EdgeKind labels why control flows along an edge
+type EdgeKind string
+
+// Edge kinds
+const (
+	EdgeNormal     EdgeKind = "normal"
+	EdgeTrue       EdgeKind = "true"
+	EdgeFalse      EdgeKind = "false"
+	EdgeLoopBack   EdgeKind = "loop"
+	EdgeBreak      EdgeKind = "break"
+	EdgeContinue   EdgeKind = "continue"
+	EdgeReturn     EdgeKind = "return"
+	EdgeRevert     EdgeKind = "revert"
+	EdgeTrySuccess EdgeKind = "try"
+	EdgeTryCatch   EdgeKind = "catch"
+
+	// Yul (inline assembly) edges
+	EdgeSwitchCase    EdgeKind = "case"
+	EdgeSwitchDefault EdgeKind = "default"
+	EdgeLeave         EdgeKind = "leave"
+	EdgeHalt          EdgeKind = "halt"
+)
+
+// BasicBlock is a straight-line sequence of nodes with a single entry point
+type BasicBlock struct {
+	ID    int
+	Kind  BlockKind
+	Nodes []ast.Node
+	// Unchecked is set for blocks inside an `unchecked { ... }` region
+	Unchecked bool
+	// Modifier names the modifier whose body produced this block; empty for
+	// blocks of the function body itself
+	Modifier string
+	Succs    []*Edge
+	Preds    []*Edge
+}
+
+// Edge is a directed control-flow edge between two blocks
+type Edge struct {
+	From *BasicBlock
+	To   *BasicBlock
+	Kind EdgeKind
+}
+
+// Graph is the control-flow graph of a single body
+type Graph struct {
+	// Name is "Contract.function" (or just the function name for free functions)
+	Name string
+	// Node is the FunctionDefinition or ModifierDefinition the graph was built
+	// from
+	Node   ast.Node
+	Entry  *BasicBlock
+	Exit   *BasicBlock
+	Revert *BasicBlock
+	// Halt is the successful-termination sink of Yul graphs (return, stop,
+	// selfdestruct); nil for Solidity bodies
+	Halt   *BasicBlock
+	Blocks []*BasicBlock
+	// Functions holds the graphs of Yul functions defined in an assembly body,
+	// and Calls the call sites that invoke them
+	Functions []*Graph
+	Calls     []*CallSite
+}
+
+func newGraph(name string, node ast.Node) *Graph {
+	g := &Graph{Name: name, Node: node}
+	g.Entry = g.newBlock(BlockEntry)
+	g.Exit = g.newBlock(BlockExit)
+	g.Revert = g.newBlock(BlockRevert)
+	return g
+}
+
+func (g *Graph) newBlock(kind BlockKind) *BasicBlock {
+	blk := &BasicBlock{ID: len(g.Blocks), Kind: kind}
+	g.Blocks = append(g.Blocks, blk)
+	return blk
+}
+
+// connect adds an edge from -> to. A nil source (unreachable code) is ignored.
+func (g *Graph) connect(from, to *BasicBlock, kind EdgeKind) {
+	if from == nil || to == nil {
+		return
+	}
+	e := &Edge{From: from, To: to, Kind: kind}
+	from.Succs = append(from.Succs, e)
+	to.Preds = append(to.Preds, e)
+}
+
+// prune drops empty blocks that nothing flows into (join points of branches
+// that all terminated) and renumbers the rest. Non-empty unreachable blocks
+// are kept so dead code stays visible.
+func (g *Graph) prune() {
+	for {
+		removed := false
+		kept := g.Blocks[:0]
+		for _, blk := range g.Blocks {
+			special := blk == g.Entry || blk == g.Exit || blk == g.Revert || blk == g.Halt
+			if !special && len(blk.Preds) == 0 && len(blk.Nodes) == 0 {
+				for _, e := range blk.Succs {
+					e.To.Preds = removeEdge(e.To.Preds, e)
+				}
+				removed = true
+				continue
+			}
+			kept = append(kept, blk)
+		}
+		g.Blocks = kept
+		if !removed {
+			break
+		}
+	}
+	for i, blk := range g.Blocks {
+		blk.ID = i
+	}
+}
+
+func removeEdge(edges []*Edge, target *Edge) []*Edge {
+	out := edges[:0]
+	for _, e := range edges {
+		if e != target {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Reachable returns the blocks reachable from Entry, in depth-first order
+func (g *Graph) Reachable() []*BasicBlock {
+	seen := make(map[*BasicBlock]bool)
+	var order []*BasicBlock
+	var visit func(*BasicBlock)
+	visit = func(blk *BasicBlock) {
+		if seen[blk] {
+			return
+		}
+		seen[blk] = true
+		order = append(order, blk)
+		for _, e := range blk.Succs {
+			visit(e.To)
+		}
+	}
+	visit(g.Entry)
+	return order
+}
+
+// DOT renders the graph in Graphviz DOT format
+func (g *Graph) DOT() string {
+	var sb strings.Builder
+	g.WriteDOT(&sb)
+	return sb.String()
+}
+
+// WriteDOT writes the graph in Graphviz DOT format to w. Yul function graphs
+// are drawn as clusters, with dashed edges from each call site to the callee.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "digraph %q {\n", g.Name)
+	sb.WriteString("  node [shape=box, fontname=\"monospace\"];\n")
+	prefixes := map[*Graph]string{g: "b"}
+	for i, fn := range g.Functions {
+		prefixes[fn] = fmt.Sprintf("f%d_b", i)
+	}
+	writeBlocks(&sb, g, "b", "  ")
+	for i, fn := range g.Functions {
+		fmt.Fprintf(&sb, "  subgraph cluster_f%d {\n", i)
+		fmt.Fprintf(&sb, "    label=%q;\n", fn.Name)
+		writeBlocks(&sb, fn, prefixes[fn], "    ")
+		sb.WriteString("  }\n")
+	}
+	for _, caller := range append([]*Graph{g}, g.Functions...) {
+		for _, call := range caller.Calls {
+			callee, ok := prefixes[call.Callee]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(&sb, "  %s%d -> %s%d [label=\"call\", style=dashed];\n",
+				prefixes[caller], call.Block.ID, callee, call.Callee.Entry.ID)
+		}
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeBlocks(sb *strings.Builder, g *Graph, prefix, indent string) {
+	for _, blk := range g.Blocks {
+		attrs := []string{fmt.Sprintf("label=%q", blockLabel(blk))}
+		switch blk.Kind {
+		case BlockEntry, BlockExit:
+			attrs = append(attrs, "shape=ellipse")
+		case BlockRevert:
+			attrs = append(attrs, "shape=octagon", "color=red")
+		case BlockHalt:
+			attrs = append(attrs, "shape=octagon")
+		case BlockCondition:
+			attrs = append(attrs, "shape=diamond")
+		}
+		if blk.Unchecked {
+			attrs = append(attrs, "style=dashed")
+		}
+		fmt.Fprintf(sb, "%s%s%d [%s];\n", indent, prefix, blk.ID, strings.Join(attrs, ", "))
+	}
+	for _, blk := range g.Blocks {
+		for _, e := range blk.Succs {
+			fmt.Fprintf(sb, "%s%s%d -> %s%d [label=%q];\n", indent, prefix, e.From.ID, prefix, e.To.ID, string(e.Kind))
+		}
+	}
+}
+
+func blockLabel(blk *BasicBlock) string {
+	var lines []string
+	switch blk.Kind {
+	case BlockEntry, BlockExit, BlockRevert, BlockHalt, BlockPlaceholder:
+		lines = append(lines, string(blk.Kind))
+	default:
+		lines = append(lines, fmt.Sprintf("#%d", blk.ID))
+	}
+	if blk.Modifier != "" {
+		lines[0] += " [" + blk.Modifier + "]"
+	}
+	if blk.Unchecked {
+		lines[0] += " unchecked"
+	}
+	for _, n := range blk.Nodes {
+		lines = append(lines, Describe(n))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Describe returns a short one-line description of a node for labels, e.g.
+// "ExpressionStatement require L12"
+func Describe(n ast.Node) string {
+	if n == nil {
+		return "<nil>"
+	}
+	desc := string(n.GetType())
+	switch v := n.(type) {
+	case *ast.ExpressionStatement:
+		if id, ok := v.Expression.(*ast.Identifier); ok {
+			desc += " " + id.Name
+		} else if name := calleeName(v.Expression); name != "" {
+			desc += " " + name
+		}
+	case *ast.FunctionCall:
+		if name := calleeName(v); name != "" {
+			desc += " " + name
+		}
+	case *ast.Identifier:
+		desc += " " + v.Name
+	case *ast.ModifierInvocation:
+		desc += " " + v.Name
+	case *ast.AssemblyCall:
+		desc += " " + v.FunctionName
+	case *ast.AssemblyIdentifier:
+		desc += " " + v.Name
+	case *ast.AssemblyLiteral:
+		desc += " " + v.Value
+	case *ast.AssemblyFunctionDefinition:
+		desc += " " + v.Name
+	}
+	if loc := n.GetLocation(); loc != nil {
+		desc += fmt.Sprintf(" L%d", loc.Start.Line)
+	}
+	return desc
+}
+
+// calleeName returns the name of the called function for calls such as
+// `require(x)` or `a.b.transfer(y)`, or "" if expr is not a call
+func calleeName(expr ast.Node) string {
+	call, ok := expr.(*ast.FunctionCall)
+	if !ok {
+		return ""
+	}
+	switch callee := call.Expression.(type) {
+	case *ast.Identifier:
+		return callee.Name
+	case *ast.MemberAccess:
+		return callee.MemberName
+	case *ast.FunctionCallOptions:
+		if ma, ok := callee.Expression.(*ast.MemberAccess); ok {
+			return ma.MemberName
+		}
+	}
+	return ""
+}
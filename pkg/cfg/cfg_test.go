@@ -0,0 +1,450 @@
+package cfg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+	"github.com/th13vn/solast-go/pkg/parser"
+)
+
+func buildGraphs(t *testing.T, src string) map[string]*Graph {
+	t.Helper()
+	unit, err := parser.Parse(src, &parser.Options{Loc: true})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	graphs := make(map[string]*Graph)
+	for _, g := range BuildAll(unit) {
+		graphs[g.Name] = g
+	}
+	return graphs
+}
+
+// countEdges returns how many edges of the given kind exist in g
+func countEdges(g *Graph, kind EdgeKind) int {
+	n := 0
+	for _, blk := range g.Blocks {
+		for _, e := range blk.Succs {
+			if e.Kind == kind {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// findNode returns the block holding a node matching pred
+func findNode(g *Graph, pred func(ast.Node) bool) *BasicBlock {
+	for _, blk := range g.Blocks {
+		for _, n := range blk.Nodes {
+			if pred(n) {
+				return blk
+			}
+		}
+	}
+	return nil
+}
+
+func isCall(name string) func(ast.Node) bool {
+	return func(n ast.Node) bool {
+		if stmt, ok := n.(*ast.ExpressionStatement); ok {
+			return calleeName(stmt.Expression) == name
+		}
+		return false
+	}
+}
+
+func TestIfAndReturn(t *testing.T) {
+	graphs := buildGraphs(t, `
+contract C {
+	function f(uint x) public returns (uint) {
+		if (x > 1) {
+			return 1;
+		} else {
+			x = 2;
+		}
+		return x;
+	}
+}`)
+	g := graphs["C.f"]
+	if g == nil {
+		t.Fatal("graph C.f not built")
+	}
+	if countEdges(g, EdgeTrue) != 1 || countEdges(g, EdgeFalse) != 1 {
+		t.Errorf("expected one true and one false edge, got %d/%d", countEdges(g, EdgeTrue), countEdges(g, EdgeFalse))
+	}
+	if got := countEdges(g, EdgeReturn); got != 2 {
+		t.Errorf("expected 2 return edges, got %d", got)
+	}
+	if len(g.Exit.Preds) != 2 {
+		t.Errorf("expected exit to have 2 predecessors, got %d", len(g.Exit.Preds))
+	}
+}
+
+func TestLoopsBreakContinue(t *testing.T) {
+	graphs := buildGraphs(t, `
+contract C {
+	function f(uint n) public {
+		for (uint i = 0; i < n; i++) {
+			if (i == 3) continue;
+			if (i == 5) break;
+		}
+		while (n > 0) { n--; }
+		do { n++; } while (n < 10);
+	}
+}`)
+	g := graphs["C.f"]
+	if countEdges(g, EdgeBreak) != 1 || countEdges(g, EdgeContinue) != 1 {
+		t.Errorf("expected one break and one continue edge, got %d/%d", countEdges(g, EdgeBreak), countEdges(g, EdgeContinue))
+	}
+	if got := countEdges(g, EdgeLoopBack); got != 2 {
+		t.Errorf("expected 2 loop-back edges (for latch, while body), got %d", got)
+	}
+	for _, e := range g.Exit.Preds {
+		if e.Kind != EdgeNormal {
+			t.Errorf("unexpected %s edge into exit", e.Kind)
+		}
+	}
+}
+
+func TestRequireAndRevertTerminate(t *testing.T) {
+	graphs := buildGraphs(t, `
+contract C {
+	error Bad();
+	function f(uint x) public {
+		require(x > 0, "zero");
+		assert(x != 1);
+		if (x == 2) revert Bad();
+		if (x == 3) revert("three");
+		x = 4;
+	}
+}`)
+	g := graphs["C.f"]
+	if got := len(g.Revert.Preds); got != 4 {
+		t.Errorf("expected 4 edges into revert, got %d", got)
+	}
+	blk := findNode(g, isCall("require"))
+	if blk == nil || blk.Kind != BlockCondition {
+		t.Fatal("require should end a condition block")
+	}
+}
+
+func TestTryCatchEdges(t *testing.T) {
+	graphs := buildGraphs(t, `
+interface I { function g() external returns (uint); }
+contract C {
+	function f(I i) public returns (uint) {
+		try i.g() returns (uint v) {
+			return v;
+		} catch Error(string memory) {
+			return 1;
+		} catch {
+			return 2;
+		}
+	}
+}`)
+	g := graphs["C.f"]
+	if countEdges(g, EdgeTrySuccess) != 1 {
+		t.Errorf("expected one try-success edge, got %d", countEdges(g, EdgeTrySuccess))
+	}
+	if countEdges(g, EdgeTryCatch) != 2 {
+		t.Errorf("expected two catch edges, got %d", countEdges(g, EdgeTryCatch))
+	}
+
+	// Typed clauses alone leave other failures uncaught
+	graphs = buildGraphs(t, `
+interface I { function g() external; }
+contract C {
+	function f(I i) public {
+		try i.g() {
+		} catch Error(string memory) {
+		} catch Panic(uint) {
+		}
+	}
+}`)
+	g = graphs["C.f"]
+	uncaught := 0
+	for _, e := range g.Revert.Preds {
+		if e.Kind == EdgeTryCatch {
+			uncaught++
+		}
+	}
+	if countEdges(g, EdgeTryCatch) != 3 || uncaught != 1 {
+		t.Errorf("expected two clause edges and one to revert, got %d catch edges, %d to revert", countEdges(g, EdgeTryCatch), uncaught)
+	}
+}
+
+func TestModifierInlining(t *testing.T) {
+	graphs := buildGraphs(t, `
+contract Base {
+	address owner;
+	modifier onlyOwner() {
+		require(msg.sender == owner);
+		_;
+	}
+}
+contract C is Base {
+	bool locked;
+	modifier lock() {
+		locked = true;
+		_;
+		locked = false;
+	}
+	function f() public onlyOwner lock returns (uint) {
+		return 1;
+	}
+}`)
+	g := graphs["C.f"]
+	body := findNode(g, func(n ast.Node) bool {
+		_, ok := n.(*ast.ReturnStatement)
+		return ok
+	})
+	if body == nil {
+		t.Fatal("function body not inlined")
+	}
+	// return inside the body continues in the lock modifier, not at exit
+	for _, e := range body.Succs {
+		if e.To == g.Exit {
+			t.Error("return inside inlined body should continue after `_`, not exit")
+		}
+		if e.To.Modifier != "lock" {
+			t.Errorf("expected return to land in lock modifier, got %q", e.To.Modifier)
+		}
+	}
+	if findNode(g, isCall("require")) == nil {
+		t.Error("base contract modifier onlyOwner not inlined")
+	}
+
+	mod := graphs["C.lock"]
+	if mod == nil {
+		t.Fatal("modifier graph not built")
+	}
+	placeholders := 0
+	for _, blk := range mod.Blocks {
+		if blk.Kind == BlockPlaceholder {
+			placeholders++
+		}
+	}
+	if placeholders != 1 {
+		t.Errorf("expected one placeholder block, got %d", placeholders)
+	}
+}
+
+func TestModifierOverrideInDiamond(t *testing.T) {
+	// D's linearization is D, C, B, A: B's override wins although C, the
+	// right-most base, reaches A's modifier first
+	graphs := buildGraphs(t, `
+contract A {
+	modifier m() virtual { fromA(); _; }
+	function fromA() internal returns (bool) { return true; }
+}
+contract B is A {
+	modifier m() virtual override { fromB(); _; }
+	function fromB() internal returns (bool) { return true; }
+}
+contract C is A {}
+contract D is B, C {
+	function f() public m {}
+}`)
+	g := graphs["D.f"]
+	if findNode(g, isCall("fromB")) == nil || findNode(g, isCall("fromA")) != nil {
+		t.Error("expected B's override of m to be inlined")
+	}
+}
+
+func TestUncheckedRegion(t *testing.T) {
+	graphs := buildGraphs(t, `
+contract C {
+	function f(uint a) public returns (uint) {
+		a = a + 1;
+		unchecked { a = a - 1; }
+		return a;
+	}
+}`)
+	g := graphs["C.f"]
+	unchecked := 0
+	for _, blk := range g.Blocks {
+		if blk.Unchecked {
+			unchecked++
+			if len(blk.Nodes) != 1 {
+				t.Errorf("unchecked block should hold only its own statement, got %d nodes", len(blk.Nodes))
+			}
+		}
+	}
+	if unchecked != 1 {
+		t.Errorf("expected one unchecked block, got %d", unchecked)
+	}
+}
+
+func TestDOT(t *testing.T) {
+	graphs := buildGraphs(t, `
+contract C {
+	function f(uint x) public {
+		if (x > 0) { x = 1; }
+	}
+}`)
+	dot := graphs["C.f"].DOT()
+	for _, want := range []string{`digraph "C.f"`, `label="true"`, `label="false"`, "shape=diamond", "->"} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output missing %q:\n%s", want, dot)
+		}
+	}
+}
+
+func buildAssembly(t *testing.T, src string) map[string]*Graph {
+	t.Helper()
+	unit, err := parser.Parse(src, &parser.Options{Loc: true})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	graphs := make(map[string]*Graph)
+	for _, g := range BuildAllAssembly(unit) {
+		graphs[g.Name] = g
+	}
+	return graphs
+}
+
+func TestYulBranches(t *testing.T) {
+	graphs := buildAssembly(t, `
+contract C {
+	function f(uint x) public {
+		assembly {
+			if iszero(x) { revert(0, 0) }
+			switch x
+			case 1 { x := 2 }
+			case 2 { x := 3 }
+			for { let i := 0 } lt(i, 10) { i := add(i, 1) } {
+				if eq(i, 3) { continue }
+				if eq(i, 5) { break }
+			}
+			return(0, 32)
+		}
+	}
+}`)
+	g := graphs["C.f#asm0"]
+	if g == nil {
+		t.Fatal("assembly graph C.f#asm0 not built")
+	}
+	if countEdges(g, EdgeSwitchCase) != 2 || countEdges(g, EdgeSwitchDefault) != 1 {
+		t.Errorf("expected 2 case edges and an implicit default, got %d/%d", countEdges(g, EdgeSwitchCase), countEdges(g, EdgeSwitchDefault))
+	}
+	if countEdges(g, EdgeBreak) != 1 || countEdges(g, EdgeContinue) != 1 || countEdges(g, EdgeLoopBack) != 1 {
+		t.Errorf("expected one break, continue and loop-back edge, got %d/%d/%d",
+			countEdges(g, EdgeBreak), countEdges(g, EdgeContinue), countEdges(g, EdgeLoopBack))
+	}
+	if len(g.Revert.Preds) != 1 || len(g.Halt.Preds) != 1 {
+		t.Errorf("expected one edge into revert and halt, got %d/%d", len(g.Revert.Preds), len(g.Halt.Preds))
+	}
+	if len(g.Exit.Preds) != 0 {
+		t.Errorf("return(0, 32) should halt, but exit has %d predecessors", len(g.Exit.Preds))
+	}
+}
+
+func TestYulFunctions(t *testing.T) {
+	graphs := buildAssembly(t, `
+contract C {
+	function f(uint x) public returns (uint r) {
+		assembly {
+			function fail() { revert(0, 0) }
+			function clamp(v) -> out {
+				out := v
+				if gt(v, 10) {
+					out := 10
+					leave
+				}
+				out := add(out, 1)
+			}
+			r := clamp(x)
+			if iszero(r) { fail() }
+		}
+	}
+}`)
+	g := graphs["C.f#asm0"]
+	if len(g.Functions) != 2 {
+		t.Fatalf("expected 2 Yul function graphs, got %d", len(g.Functions))
+	}
+	if len(g.Calls) != 2 {
+		t.Errorf("expected 2 call sites, got %d", len(g.Calls))
+	}
+	var clamp *Graph
+	for _, fn := range g.Functions {
+		if fn.Name == "C.f#asm0.clamp" {
+			clamp = fn
+		}
+	}
+	if clamp == nil || countEdges(clamp, EdgeLeave) != 1 || len(clamp.Exit.Preds) != 2 {
+		t.Error("clamp should leave early and fall through to its exit")
+	}
+	// fail() never returns, so the call site ends in revert
+	if len(g.Revert.Preds) != 1 {
+		t.Errorf("call to fail() should flow to revert, got %d edges", len(g.Revert.Preds))
+	}
+	dot := g.DOT()
+	for _, want := range []string{"subgraph cluster_f0", `label="call", style=dashed`} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output missing %q:\n%s", want, dot)
+		}
+	}
+}
+
+func TestYulFunctionScopes(t *testing.T) {
+	graphs := buildAssembly(t, `
+contract C {
+	function f(uint x, uint y) public {
+		assembly {
+			function g() -> r { r := 1 }
+			if x {
+				function h() { revert(0, 0) }
+				h()
+			}
+			if y {
+				function h() -> r { r := g() }
+				sstore(0, h())
+			}
+		}
+	}
+}`)
+	g := graphs["C.f#asm0"]
+	if len(g.Functions) != 3 || len(g.Calls) != 2 {
+		t.Fatalf("expected 3 Yul functions and 2 call sites, got %d/%d", len(g.Functions), len(g.Calls))
+	}
+	// Each h() resolves to the h of its own block
+	first, second := g.Calls[0].Callee, g.Calls[1].Callee
+	if first.Node == second.Node || len(first.Exit.Preds) != 0 || len(second.Exit.Preds) == 0 {
+		t.Error("calls to h() should resolve to the definition in their block")
+	}
+	if len(g.Revert.Preds) != 1 {
+		t.Errorf("only the first h() should flow to revert, got %d edges", len(g.Revert.Preds))
+	}
+	// g is visible in the body of a function defined in a nested block
+	if len(second.Calls) != 1 || second.Calls[0].Callee.Name != "C.f#asm0.g" {
+		t.Error("g() in the second h should resolve to the outer g")
+	}
+}
+
+func TestYulNonReturningCondition(t *testing.T) {
+	graphs := buildAssembly(t, `
+contract C {
+	function f() public {
+		assembly {
+			function fail() -> r { revert(0, 0) }
+			if fail() { sstore(0, 1) }
+			sstore(1, 1)
+		}
+	}
+}`)
+	g := graphs["C.f#asm0"]
+	// fail() never returns, so the if never branches
+	if countEdges(g, EdgeTrue) != 0 || countEdges(g, EdgeFalse) != 0 {
+		t.Errorf("expected no branch edges, got %d true / %d false", countEdges(g, EdgeTrue), countEdges(g, EdgeFalse))
+	}
+	if len(g.Revert.Preds) != 1 {
+		t.Errorf("expected the condition to flow to revert, got %d edges", len(g.Revert.Preds))
+	}
+	for _, blk := range g.Reachable() {
+		if blk == g.Exit {
+			t.Error("code after the if should be unreachable")
+		}
+	}
+}
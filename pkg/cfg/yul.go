@@ -0,0 +1,370 @@
+package cfg
+
+import (
+	"fmt"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// Yul builtins that end execution. return/stop/selfdestruct halt successfully
+// and flow to Graph.Halt; revert/invalid flow to Graph.Revert.
+var (
+	yulHalting   = map[string]bool{"return": true, "stop": true, "selfdestruct": true}
+	yulReverting = map[string]bool{"revert": true, "invalid": true}
+)
+
+// CallSite records a call from a block to a Yul function defined in the same
+// assembly body and visible at the call
+type CallSite struct {
+	Block  *BasicBlock
+	Call   *ast.AssemblyCall
+	Callee *Graph
+}
+
+// yulBuilder builds the graph of one assembly body or Yul function
+type yulBuilder struct {
+	*builder
+	funcs *yulFunctions
+	// scopes holds the functions of each enclosing Yul block, innermost last
+	scopes []yulScope
+	// inFunction is set while building a Yul function body, where `leave`
+	// returns to the function's Exit
+	inFunction bool
+	yulLoops   []loopTargets
+}
+
+// yulScope maps the names of the functions defined directly in one Yul block
+// to their definitions. A function is visible in its whole block, nested
+// blocks and function bodies included.
+type yulScope map[string]*ast.AssemblyFunctionDefinition
+
+// yulFunctions builds the Yul functions of one InlineAssembly on demand, so a
+// call site can see whether its callee ever returns
+type yulFunctions struct {
+	prefix   string
+	scopes   map[*ast.AssemblyBlock]yulScope
+	outer    map[*ast.AssemblyFunctionDefinition][]yulScope // scopes visible at the definition
+	defs     []*ast.AssemblyFunctionDefinition              // definition order
+	graphs   map[*ast.AssemblyFunctionDefinition]*Graph
+	building map[*ast.AssemblyFunctionDefinition]bool
+	order    []*Graph
+}
+
+// BuildAssembly builds the graph of an inline assembly body. Yul functions
+// defined anywhere in the body get their own graphs in Graph.Functions; calls
+// to them, resolved by block scope, are listed in Graph.Calls. name labels
+// the graph (e.g. "Contract.fn#asm0").
+func BuildAssembly(asm *ast.InlineAssembly, name string) *Graph {
+	funcs := &yulFunctions{
+		prefix:   name,
+		scopes:   make(map[*ast.AssemblyBlock]yulScope),
+		outer:    make(map[*ast.AssemblyFunctionDefinition][]yulScope),
+		graphs:   make(map[*ast.AssemblyFunctionDefinition]*Graph),
+		building: make(map[*ast.AssemblyFunctionDefinition]bool),
+	}
+	funcs.collect(asm.Body, nil)
+
+	g := newYulGraph(name, asm)
+	yb := &yulBuilder{builder: &builder{g: g}, funcs: funcs}
+	yb.cur = yb.newBlock()
+	g.connect(g.Entry, yb.cur, EdgeNormal)
+	yb.yulBlock(asm.Body)
+	yb.jump(g.Exit, EdgeNormal)
+	g.prune()
+
+	for _, def := range funcs.defs {
+		funcs.get(def)
+	}
+	g.Functions = funcs.order
+	return g
+}
+
+// BuildAllAssembly builds a graph for every inline assembly statement inside a
+// function or modifier body of the source unit, named "<body>#asm<N>"
+func BuildAllAssembly(unit *ast.SourceUnit) []*Graph {
+	var graphs []*Graph
+	collect := func(owner string, body *ast.Block) {
+		if body == nil {
+			return
+		}
+		n := 0
+		ast.WalkSimple(body, &ast.SimpleVisitor{
+			InlineAssemblyFn: func(asm *ast.InlineAssembly) {
+				graphs = append(graphs, BuildAssembly(asm, fmt.Sprintf("%s#asm%d", owner, n)))
+				n++
+			},
+		})
+	}
+	for _, child := range unit.Children {
+		switch n := child.(type) {
+		case *ast.FunctionDefinition:
+			collect(functionName(n), n.Body)
+		case *ast.ContractDefinition:
+			for _, sub := range n.SubNodes {
+				switch def := sub.(type) {
+				case *ast.FunctionDefinition:
+					collect(qualifiedName(n.Name, functionName(def)), def.Body)
+				case *ast.ModifierDefinition:
+					collect(qualifiedName(n.Name, def.Name), def.Body)
+				}
+			}
+		}
+	}
+	return graphs
+}
+
+func newYulGraph(name string, node ast.Node) *Graph {
+	g := newGraph(name, node)
+	g.Halt = g.newBlock(BlockHalt)
+	return g
+}
+
+// collect records the functions defined in blk and the blocks nested in it.
+// outer holds the scopes of the enclosing blocks.
+func (f *yulFunctions) collect(blk *ast.AssemblyBlock, outer []yulScope) {
+	if blk == nil {
+		return
+	}
+	scope := make(yulScope)
+	for _, op := range blk.Operations {
+		if def, ok := op.(*ast.AssemblyFunctionDefinition); ok {
+			if _, dup := scope[def.Name]; !dup {
+				scope[def.Name] = def
+			}
+		}
+	}
+	f.scopes[blk] = scope
+	visible := append(outer[:len(outer):len(outer)], scope)
+	for _, op := range blk.Operations {
+		switch s := op.(type) {
+		case *ast.AssemblyBlock:
+			f.collect(s, visible)
+		case *ast.AssemblyFunctionDefinition:
+			if scope[s.Name] == s {
+				f.defs = append(f.defs, s)
+				f.outer[s] = visible
+			}
+			f.collect(s.Body, visible)
+		case *ast.AssemblyIf:
+			f.collect(s.Body, visible)
+		case *ast.AssemblySwitch:
+			for _, c := range s.Cases {
+				f.collect(c.Body, visible)
+			}
+		case *ast.AssemblyFor:
+			f.collect(s.Pre, visible)
+			f.collect(s.Body, visible)
+			f.collect(s.Post, visible)
+		}
+	}
+}
+
+// lookup resolves the Yul function name from the innermost enclosing block
+// outwards
+func (yb *yulBuilder) lookup(name string) *ast.AssemblyFunctionDefinition {
+	for i := len(yb.scopes) - 1; i >= 0; i-- {
+		if def, ok := yb.scopes[i][name]; ok {
+			return def
+		}
+	}
+	return nil
+}
+
+// get returns the graph of Yul function def, building it on first use. A
+// recursive call seen while the callee is still being built returns nil.
+func (f *yulFunctions) get(def *ast.AssemblyFunctionDefinition) *Graph {
+	if g, ok := f.graphs[def]; ok {
+		return g
+	}
+	if f.building[def] {
+		return nil
+	}
+	f.building[def] = true
+	defer delete(f.building, def)
+
+	g := newYulGraph(f.prefix+"."+def.Name, def)
+	yb := &yulBuilder{builder: &builder{g: g}, funcs: f, scopes: f.outer[def], inFunction: true}
+	yb.cur = yb.newBlock()
+	g.connect(g.Entry, yb.cur, EdgeNormal)
+	yb.yulBlock(def.Body)
+	yb.jump(g.Exit, EdgeNormal)
+	g.prune()
+
+	f.graphs[def] = g
+	f.order = append(f.order, g)
+	return g
+}
+
+func (yb *yulBuilder) yulBlock(blk *ast.AssemblyBlock) {
+	if blk == nil {
+		return
+	}
+	yb.scopes = append(yb.scopes, yb.funcs.scopes[blk])
+	for _, op := range blk.Operations {
+		yb.yulStatement(op)
+	}
+	yb.scopes = yb.scopes[:len(yb.scopes)-1]
+}
+
+func (yb *yulBuilder) yulStatement(n ast.Node) {
+	switch s := n.(type) {
+	case nil:
+	case *ast.AssemblyBlock:
+		yb.yulBlock(s)
+	case *ast.AssemblyFunctionDefinition:
+		// Hoisted: built as a separate graph, not part of this flow
+	case *ast.AssemblyIf:
+		cond := yb.condition(s.Condition)
+		if yb.yulCalls(s.Condition) {
+			// The condition never returns: neither branch runs
+			return
+		}
+		join := yb.newBlock()
+		yb.cur = yb.newBlock()
+		yb.g.connect(cond, yb.cur, EdgeTrue)
+		yb.yulBlock(s.Body)
+		yb.jump(join, EdgeNormal)
+		yb.g.connect(cond, join, EdgeFalse)
+		yb.cur = join
+	case *ast.AssemblySwitch:
+		yb.yulSwitch(s)
+	case *ast.AssemblyFor:
+		yb.yulFor(s)
+	case *ast.BreakStatement:
+		yb.add(s)
+		if len(yb.yulLoops) > 0 {
+			yb.jump(yb.yulLoops[len(yb.yulLoops)-1].brk, EdgeBreak)
+		}
+	case *ast.ContinueStatement:
+		yb.add(s)
+		if len(yb.yulLoops) > 0 {
+			yb.jump(yb.yulLoops[len(yb.yulLoops)-1].cont, EdgeContinue)
+		}
+	case *ast.Identifier:
+		yb.yulIdentifierStatement(n, s.Name)
+	case *ast.AssemblyIdentifier:
+		yb.yulIdentifierStatement(n, s.Name)
+	case *ast.AssemblyCall:
+		yb.add(s)
+		if yb.yulCalls(s) {
+			return
+		}
+		switch {
+		case yulHalting[s.FunctionName]:
+			yb.jump(yb.g.Halt, EdgeHalt)
+		case yulReverting[s.FunctionName]:
+			yb.jump(yb.g.Revert, EdgeRevert)
+		}
+	case *ast.AssemblyLocalDefinition:
+		yb.add(s)
+		yb.yulCalls(s.Expression)
+	case *ast.AssemblyAssignment:
+		yb.add(s)
+		yb.yulCalls(s.Expression)
+	default:
+		yb.add(n)
+	}
+}
+
+// yulIdentifierStatement handles `leave`, which the parser emits as a bare
+// identifier statement
+func (yb *yulBuilder) yulIdentifierStatement(n ast.Node, name string) {
+	yb.add(n)
+	if name == "leave" && yb.inFunction {
+		yb.jump(yb.g.Exit, EdgeLeave)
+	}
+}
+
+func (yb *yulBuilder) yulSwitch(s *ast.AssemblySwitch) {
+	sw := yb.condition(s.Expression)
+	if yb.yulCalls(s.Expression) {
+		return
+	}
+	join := yb.newBlock()
+	hasDefault := false
+	for _, c := range s.Cases {
+		kind := EdgeSwitchCase
+		if c.Default {
+			kind = EdgeSwitchDefault
+			hasDefault = true
+		}
+		yb.cur = yb.newBlock()
+		if c.Value != nil {
+			yb.cur.Nodes = append(yb.cur.Nodes, c.Value)
+		}
+		yb.g.connect(sw, yb.cur, kind)
+		yb.yulBlock(c.Body)
+		yb.jump(join, EdgeNormal)
+	}
+	if !hasDefault {
+		// No case matched: control falls through past the switch
+		yb.g.connect(sw, join, EdgeSwitchDefault)
+	}
+	yb.cur = join
+}
+
+// yulFor models `for { pre } cond { post } { body }`: pre runs once, cond
+// heads the loop, post is the continue target and loops back to cond
+func (yb *yulBuilder) yulFor(s *ast.AssemblyFor) {
+	yb.yulBlock(s.Pre)
+	header := yb.condition(s.Condition)
+	if yb.yulCalls(s.Condition) {
+		return
+	}
+	after := yb.newBlock()
+	post := yb.newBlock()
+	yb.g.connect(header, after, EdgeFalse)
+
+	yb.cur = yb.newBlock()
+	yb.g.connect(header, yb.cur, EdgeTrue)
+	yb.yulLoops = append(yb.yulLoops, loopTargets{brk: after, cont: post})
+	yb.yulBlock(s.Body)
+	yb.yulLoops = yb.yulLoops[:len(yb.yulLoops)-1]
+	yb.jump(post, EdgeNormal)
+
+	yb.cur = post
+	yb.yulBlock(s.Post)
+	yb.jump(header, EdgeLoopBack)
+	yb.cur = after
+}
+
+// yulCalls records calls to Yul functions in expr against the current block.
+// If a callee can never return (every path reverts or halts), the current
+// block ends there and yulCalls reports true.
+func (yb *yulBuilder) yulCalls(expr ast.Node) bool {
+	if expr == nil || yb.cur == nil {
+		return false
+	}
+	var callees []*Graph
+	ast.WalkSimple(expr, &ast.SimpleVisitor{
+		AssemblyCallFn: func(call *ast.AssemblyCall) {
+			def := yb.lookup(call.FunctionName)
+			if def == nil {
+				return
+			}
+			callee := yb.funcs.get(def)
+			if callee == nil {
+				return
+			}
+			yb.g.Calls = append(yb.g.Calls, &CallSite{Block: yb.cur, Call: call, Callee: callee})
+			callees = append(callees, callee)
+		},
+	})
+	for _, callee := range callees {
+		if len(callee.Exit.Preds) > 0 {
+			continue
+		}
+		switch {
+		case len(callee.Revert.Preds) > 0 && len(callee.Halt.Preds) == 0:
+			yb.jump(yb.g.Revert, EdgeRevert)
+		case len(callee.Halt.Preds) > 0 && len(callee.Revert.Preds) == 0:
+			yb.jump(yb.g.Halt, EdgeHalt)
+		default:
+			from := yb.cur
+			yb.g.connect(from, yb.g.Revert, EdgeRevert)
+			yb.jump(yb.g.Halt, EdgeHalt)
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,266 @@
+// Package compat checks this parser's JSON output against that of the
+// TypeScript @solidity-parser/parser, the format package ast promises to
+// match. Diff compares two JSON documents field by field; Check parses a
+// source and compares it with the TypeScript parser's output for it.
+//
+// The expected output is kept under testdata/conformance, one JSON
+// file per contract under testdata/contracts, written by
+// scripts/conformance.sh.
+package compat
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/th13vn/solast-go/pkg/parser"
+)
+
+// Kind says how a field differs
+type Kind string
+
+// Mismatch kinds
+const (
+	// Missing: the expected output has the field, ours does not
+	Missing Kind = "missing"
+	// Extra: our output has a field the expected output does not
+	Extra Kind = "extra"
+	// Changed: both have the field, with different values
+	Changed Kind = "changed"
+)
+
+// Mismatch is one difference between the expected and actual output
+type Mismatch struct {
+	// Path: the JSON path of the field, such as children/1/subNodes/0/name
+	Path string `json:"path"`
+	// NodeType: the type of the node holding the field
+	NodeType string `json:"nodeType"`
+	// Field: the field name, or for an array element the array's
+	Field string `json:"field"`
+	Kind  Kind   `json:"kind"`
+	Want  any    `json:"want,omitempty"`
+	Got   any    `json:"got,omitempty"`
+}
+
+// String describes the mismatch on one line
+func (m Mismatch) String() string {
+	switch m.Kind {
+	case Missing:
+		return fmt.Sprintf("%s: %s.%s missing (want %s)", m.Path, m.NodeType, m.Field, brief(m.Want))
+	case Extra:
+		return fmt.Sprintf("%s: %s.%s extra (got %s)", m.Path, m.NodeType, m.Field, brief(m.Got))
+	}
+	return fmt.Sprintf("%s: %s.%s = %s, want %s", m.Path, m.NodeType, m.Field, brief(m.Got), brief(m.Want))
+}
+
+// brief is v as JSON, shortened to fit a line
+func brief(v any) string {
+	b, _ := json.Marshal(v)
+	if len(b) > 60 {
+		return string(b[:57]) + "..."
+	}
+	return string(b)
+}
+
+// Options configures Diff and Check
+type Options struct {
+	// IgnorePositions skips the loc and range fields
+	IgnorePositions bool
+	// IgnoreFields: field names to skip wherever they occur
+	IgnoreFields []string
+}
+
+// Diff compares the JSON documents want and got and returns their
+// differences in document order. opts may be nil.
+func Diff(want, got []byte, opts *Options) ([]Mismatch, error) {
+	var w, g any
+	if err := json.Unmarshal(want, &w); err != nil {
+		return nil, fmt.Errorf("compat: expected output: %w", err)
+	}
+	if err := json.Unmarshal(got, &g); err != nil {
+		return nil, fmt.Errorf("compat: actual output: %w", err)
+	}
+	d := &differ{ignore: map[string]bool{}}
+	if opts != nil {
+		for _, f := range opts.IgnoreFields {
+			d.ignore[f] = true
+		}
+		if opts.IgnorePositions {
+			d.ignore["loc"], d.ignore["range"] = true, true
+		}
+	}
+	d.diff(w, g, "", "", "")
+	return d.out, nil
+}
+
+type differ struct {
+	ignore map[string]bool
+	out    []Mismatch
+}
+
+// diff compares w and g found at path, in field of a nodeType node
+func (d *differ) diff(w, g any, path, nodeType, field string) {
+	add := func(kind Kind, want, got any) {
+		d.out = append(d.out, Mismatch{Path: path, NodeType: nodeType, Field: field, Kind: kind, Want: want, Got: got})
+	}
+	switch w := w.(type) {
+	case map[string]any:
+		g, ok := g.(map[string]any)
+		if !ok {
+			add(Changed, w, g)
+			return
+		}
+		if t, ok := w["type"].(string); ok {
+			nodeType = t
+		}
+		keys := make([]string, 0, len(w)+len(g))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if d.ignore[k] {
+				continue
+			}
+			wv, inW := w[k]
+			gv, inG := g[k]
+			at := join(path, k)
+			switch {
+			case !inG:
+				d.out = append(d.out, Mismatch{Path: at, NodeType: nodeType, Field: k, Kind: Missing, Want: wv})
+			case !inW:
+				d.out = append(d.out, Mismatch{Path: at, NodeType: nodeType, Field: k, Kind: Extra, Got: gv})
+			default:
+				d.diff(wv, gv, at, nodeType, k)
+			}
+		}
+	case []any:
+		g, ok := g.([]any)
+		if !ok {
+			add(Changed, w, g)
+			return
+		}
+		for i := 0; i < len(w) || i < len(g); i++ {
+			at := join(path, strconv.Itoa(i))
+			switch {
+			case i >= len(g):
+				d.out = append(d.out, Mismatch{Path: at, NodeType: nodeType, Field: field, Kind: Missing, Want: w[i]})
+			case i >= len(w):
+				d.out = append(d.out, Mismatch{Path: at, NodeType: nodeType, Field: field, Kind: Extra, Got: g[i]})
+			default:
+				d.diff(w[i], g[i], at, nodeType, field)
+			}
+		}
+	default:
+		if w != g {
+			add(Changed, w, g)
+		}
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "/" + key
+}
+
+// Check parses src and compares the result with golden, the TypeScript
+// parser's output for src with { loc: true, range: true }. Positions are
+// counted in UTF-16 code units, as JavaScript strings are.
+//
+// The TypeScript parser ends a range at the node's last character (ANTLR's
+// stop index) and a loc at the start of its last token, where ours end
+// just past the last character. Golden ends are mapped to ours, from the
+// range end, before the diff.
+func Check(src string, golden []byte, opts *Options) ([]Mismatch, error) {
+	unit, err := parser.Parse(src, &parser.Options{Loc: true, Range: true, PositionUnit: parser.UnitUTF16})
+	if err != nil {
+		return nil, err
+	}
+	got, err := json.Marshal(unit)
+	if err != nil {
+		return nil, err
+	}
+	want, err := halfOpen(golden, parser.NewPositionMapper(src))
+	if err != nil {
+		return nil, fmt.Errorf("compat: expected output: %w", err)
+	}
+	return Diff(want, got, opts)
+}
+
+// halfOpen rewrites the inclusive ends of the TypeScript parser's output:
+// a range [s, e] becomes [s, e+1] and its loc ends at the position of e+1
+func halfOpen(golden []byte, m *parser.PositionMapper) ([]byte, error) {
+	var doc any
+	if err := json.Unmarshal(golden, &doc); err != nil {
+		return nil, err
+	}
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if rng, ok := v["range"].([]any); ok && len(rng) == 2 {
+				if end, ok := rng[1].(float64); ok {
+					rng[1] = end + 1
+					if loc, ok := v["loc"].(map[string]any); ok {
+						p := m.Position(int(end)+1, parser.UnitUTF16)
+						loc["end"] = map[string]any{"line": p.Line, "column": p.Column}
+					}
+				}
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+	return json.Marshal(doc)
+}
+
+// Group counts the mismatches of one kind on one field of one node type
+type Group struct {
+	NodeType string `json:"nodeType"`
+	Field    string `json:"field"`
+	Kind     Kind   `json:"kind"`
+	Count    int    `json:"count"`
+}
+
+// String describes the group as "StringLiteral.isUnicode missing"
+func (g Group) String() string {
+	return fmt.Sprintf("%s.%s %s", g.NodeType, g.Field, g.Kind)
+}
+
+// Summarize groups mismatches by node type, field and kind, the most
+// frequent first
+func Summarize(ms []Mismatch) []Group {
+	index := map[Group]int{}
+	var groups []Group
+	for _, m := range ms {
+		key := Group{NodeType: m.NodeType, Field: m.Field, Kind: m.Kind}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, key)
+		}
+		groups[i].Count++
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].String() < groups[j].String()
+	})
+	return groups
+}
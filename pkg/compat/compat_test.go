@@ -0,0 +1,158 @@
+package compat
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/th13vn/solast-go/pkg/parser"
+)
+
+func TestDiff(t *testing.T) {
+	want := `{"type": "SourceUnit", "range": [0, 9], "children": [
+		{"type": "StringLiteral", "value": "a", "isUnicode": false, "parts": ["a"]},
+		{"type": "Identifier", "name": "x"}]}`
+	got := `{"type": "SourceUnit", "range": [0, 10], "children": [
+		{"type": "StringLiteral", "value": "b", "parts": ["a", "b"]},
+		{"type": "Identifier", "name": "x", "identifier": {"type": "Identifier", "name": "x"}},
+		null]}`
+	ms, err := Diff([]byte(want), []byte(got), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, m := range ms {
+		lines = append(lines, m.String())
+	}
+	wantLines := []string{
+		`children/0/isUnicode: StringLiteral.isUnicode missing (want false)`,
+		`children/0/parts/1: StringLiteral.parts extra (got "b")`,
+		`children/0/value: StringLiteral.value = "b", want "a"`,
+		`children/1/identifier: Identifier.identifier extra (got {"name":"x","type":"Identifier"})`,
+		`children/2: SourceUnit.children extra (got null)`,
+		`range/1: SourceUnit.range = 10, want 9`,
+	}
+	if strings.Join(lines, "\n") != strings.Join(wantLines, "\n") {
+		t.Errorf("Diff =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(wantLines, "\n"))
+	}
+
+	ms, _ = Diff([]byte(want), []byte(got), &Options{IgnorePositions: true, IgnoreFields: []string{"parts", "identifier"}})
+	if len(ms) != 3 || ms[0].Kind != Missing || ms[1].Kind != Changed || ms[2].Kind != Extra {
+		t.Errorf("Diff with options = %v", ms)
+	}
+
+	if ms, _ := Diff([]byte(`{"a": [1]}`), []byte(`{"a": {"b": 1}}`), nil); len(ms) != 1 || ms[0].Kind != Changed || ms[0].Field != "a" {
+		t.Errorf("Diff of an array and an object = %v", ms)
+	}
+	if _, err := Diff([]byte(`{`), []byte(`{}`), nil); err == nil || !strings.HasPrefix(err.Error(), "compat: expected output:") {
+		t.Errorf("Diff of bad JSON: err = %v", err)
+	}
+}
+
+// checkGolden is written by hand in the TypeScript parser's conventions:
+// ranges end at the last character and locs at the start of the last token
+// (EOF's, for the source unit), in UTF-16 units
+const checkGolden = `{"type": "SourceUnit", "loc": {"start": {"line": 1, "column": 0}, "end": {"line": 3, "column": 1}}, "range": [0, 31], "children": [
+	{"type": "ContractDefinition", "loc": {"start": {"line": 1, "column": 0}, "end": {"line": 3, "column": 0}}, "range": [0, 31], "name": "C", "baseContracts": [], "kind": "contract", "subNodes": [
+		{"type": "StateVariableDeclaration", "loc": {"start": {"line": 2, "column": 2}, "end": {"line": 2, "column": 16}}, "range": [15, 29],
+			"variables": [{"type": "VariableDeclaration", "loc": {"start": {"line": 2, "column": 2}, "end": {"line": 2, "column": 16}}, "range": [15, 29],
+				"typeName": {"type": "ElementaryTypeName", "loc": {"start": {"line": 2, "column": 2}, "end": {"line": 2, "column": 2}}, "range": [15, 20], "name": "string"},
+				"name": "s", "identifier": {"type": "Identifier", "loc": {"start": {"line": 2, "column": 9}, "end": {"line": 2, "column": 9}}, "range": [22, 22], "name": "s"},
+				"isStateVar": true, "isIndexed": false, "isImmutable": false}],
+			"initialValue": {"type": "StringLiteral", "loc": {"start": {"line": 2, "column": 13}, "end": {"line": 2, "column": 13}}, "range": [26, 28], "value": "é", "parts": ["é"], "isUnicode": false}}]}]}`
+
+func TestCheck(t *testing.T) {
+	src := "contract C {\n  string s = \"é\";\n}"
+	if ms, err := Check(src, []byte(checkGolden), nil); err != nil || len(ms) != 0 {
+		t.Fatalf("Check against the TypeScript conventions = %v, %v", ms, err)
+	}
+
+	// Our own half-open output, read as the TypeScript parser's, ends one
+	// past ours
+	unit, err := parser.Parse(src, &parser.Options{Loc: true, Range: true, PositionUnit: parser.UnitUTF16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, _ := json.Marshal(unit)
+	ms, err := Check(src, golden, nil)
+	if err != nil || len(ms) == 0 || ms[len(ms)-1].String() != "range/1: SourceUnit.range = 32, want 33" {
+		t.Fatalf("Check against half-open ends = %v, %v", ms, err)
+	}
+	if ms, _ := Check(src, golden, &Options{IgnorePositions: true}); len(ms) != 0 {
+		t.Errorf("Check ignoring positions = %v", ms)
+	}
+	if _, err := Check("contract {", golden, nil); err == nil {
+		t.Error("Check of a syntax error succeeded")
+	}
+	if _, err := Check(src, []byte("{"), nil); err == nil || !strings.HasPrefix(err.Error(), "compat: expected output:") {
+		t.Errorf("Check of bad JSON: err = %v", err)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	ms := []Mismatch{
+		{NodeType: "StringLiteral", Field: "isUnicode", Kind: Missing},
+		{NodeType: "Identifier", Field: "identifier", Kind: Extra},
+		{NodeType: "StringLiteral", Field: "isUnicode", Kind: Missing},
+		{NodeType: "Block", Field: "range", Kind: Changed},
+	}
+	var got []string
+	for _, g := range Summarize(ms) {
+		got = append(got, g.String()+" "+strconv.Itoa(g.Count))
+	}
+	want := "StringLiteral.isUnicode missing 2, Block.range changed 1, Identifier.identifier extra 1"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("Summarize = %s", strings.Join(got, ", "))
+	}
+}
+
+// TestConformance compares the output for every contract under
+// testdata/contracts with the TypeScript parser's, from the golden files
+// scripts/conformance.sh writes. A contract without a golden file fails.
+func TestConformance(t *testing.T) {
+	const src, golden = "../../testdata/contracts", "../../testdata/conformance"
+	if _, err := os.Stat(golden); err != nil {
+		t.Fatalf("no golden files: %v; run scripts/conformance.sh and commit its output", err)
+	}
+	if _, err := os.Stat(filepath.Join(golden, "PARSER_VERSION")); err != nil {
+		t.Errorf("no parser version for the golden files: %v; run scripts/conformance.sh", err)
+	}
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".sol" {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		want, err := os.ReadFile(filepath.Join(golden, strings.TrimSuffix(rel, ".sol")+".json"))
+		if os.IsNotExist(err) {
+			t.Errorf("%s: no golden file; run scripts/conformance.sh", rel)
+			return nil
+		} else if err != nil {
+			return err
+		}
+		t.Run(rel, func(t *testing.T) {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ms, err := Check(string(content), want, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, m := range ms {
+				if i == 20 {
+					t.Errorf("... %d more", len(ms)-i)
+					break
+				}
+				t.Error(m)
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
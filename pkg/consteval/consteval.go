@@ -0,0 +1,224 @@
+// Package consteval evaluates compile-time constant expressions over the AST.
+//
+// Literal arithmetic is exact, as in Solidity: rational literals stay
+// rational (1 / 2 * 2 == 1) until they meet a typed value. References to
+// constant state variables take the variable's declared type, so integer
+// division truncates and overflow is an error from then on. Covered are
+// arithmetic, shifts, bitwise and comparison operators, type conversions,
+// keccak256, abi.encodePacked and type(T).min/max, with constants resolved
+// through base contracts, at file level and across files.
+package consteval
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// Kind classifies a Value
+type Kind int
+
+// Value kinds
+const (
+	// KindInvalid is the zero Value, returned with every error
+	KindInvalid Kind = iota
+	// KindNumber is an integer or, for literals, a rational number
+	KindNumber
+	// KindBool is a boolean
+	KindBool
+	// KindBytes is a byte string: fixed bytes, string, bytes or a literal
+	KindBytes
+)
+
+// Value is the result of evaluating a constant expression
+type Value struct {
+	Kind Kind
+	// Type is the Solidity type, such as "uint256", "address", "bytes4",
+	// "string" or "bool"; empty for literals and expressions of literals
+	Type   string
+	Number *big.Rat
+	Bool   bool
+	Bytes  []byte
+}
+
+// Int returns the value of an integer number
+func (v Value) Int() (*big.Int, bool) {
+	if v.Kind != KindNumber || !v.Number.IsInt() {
+		return nil, false
+	}
+	return new(big.Int).Set(v.Number.Num()), true
+}
+
+// String formats numbers in decimal (fractions as "a/b") and byte strings
+// in hex with a 0x prefix
+func (v Value) String() string {
+	switch v.Kind {
+	case KindNumber:
+		return v.Number.RatString()
+	case KindBool:
+		return fmt.Sprint(v.Bool)
+	case KindBytes:
+		return "0x" + hex.EncodeToString(v.Bytes)
+	default:
+		return "<invalid>"
+	}
+}
+
+// Error reports why an expression could not be evaluated
+type Error struct {
+	Node    ast.Node
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Node != nil {
+		if loc := e.Node.GetLocation(); loc != nil {
+			return fmt.Sprintf("line %d:%d: %s", loc.Start.Line, loc.Start.Column, e.Message)
+		}
+	}
+	return e.Message
+}
+
+// Evaluator evaluates expressions against the constants declared in a set
+// of source units. Contracts and file-level constants are matched by name
+// across units; the first declaration wins.
+type Evaluator struct {
+	contracts map[string]*ast.ContractDefinition
+	globals   map[string]*ast.StateVariableDeclaration
+	values    map[*ast.VariableDeclaration]result
+	active    map[*ast.VariableDeclaration]bool
+}
+
+type result struct {
+	value Value
+	err   error
+}
+
+// New returns an Evaluator resolving names in units
+func New(units ...*ast.SourceUnit) *Evaluator {
+	e := &Evaluator{
+		contracts: make(map[string]*ast.ContractDefinition),
+		globals:   make(map[string]*ast.StateVariableDeclaration),
+		values:    make(map[*ast.VariableDeclaration]result),
+		active:    make(map[*ast.VariableDeclaration]bool),
+	}
+	for _, unit := range units {
+		if unit == nil {
+			continue
+		}
+		for _, child := range unit.Children {
+			switch n := child.(type) {
+			case *ast.ContractDefinition:
+				if _, ok := e.contracts[n.Name]; !ok {
+					e.contracts[n.Name] = n
+				}
+			case *ast.StateVariableDeclaration:
+				for _, v := range n.Variables {
+					if _, ok := e.globals[v.Name]; !ok {
+						e.globals[v.Name] = n
+					}
+				}
+			}
+		}
+	}
+	return e
+}
+
+// Eval evaluates expr with no names in scope
+func Eval(expr ast.Node) (Value, error) {
+	return New().Eval(expr, nil)
+}
+
+// Eval evaluates expr as written inside contract, or at file level if
+// contract is nil
+func (e *Evaluator) Eval(expr ast.Node, contract *ast.ContractDefinition) (Value, error) {
+	return e.eval(expr, contract)
+}
+
+// Constant returns the value of the constant name declared in contract or
+// one of its bases, or at file level if contract is empty
+func (e *Evaluator) Constant(contract, name string) (Value, error) {
+	var scope *ast.ContractDefinition
+	if contract != "" {
+		if scope = e.contracts[contract]; scope == nil {
+			return Value{}, &Error{Message: fmt.Sprintf("unknown contract %s", contract)}
+		}
+	}
+	return e.identifier(name, scope, nil)
+}
+
+// identifier evaluates the constant name visible in scope, looked up in the
+// contract and its bases before file level
+func (e *Evaluator) identifier(name string, scope *ast.ContractDefinition, at ast.Node) (Value, error) {
+	if scope != nil {
+		if decl, v, owner := e.member(scope, name, make(map[*ast.ContractDefinition]bool)); v != nil {
+			return e.constant(decl, v, owner, at)
+		}
+	}
+	if decl, ok := e.globals[name]; ok {
+		for _, v := range decl.Variables {
+			if v.Name == name {
+				return e.constant(decl, v, nil, at)
+			}
+		}
+	}
+	return Value{}, &Error{Node: at, Message: fmt.Sprintf("%s is not a constant", name)}
+}
+
+// member finds the state variable name declared in c or a base of c. Solidity
+// forbids shadowing state variables, so the search order does not matter.
+func (e *Evaluator) member(c *ast.ContractDefinition, name string, seen map[*ast.ContractDefinition]bool) (*ast.StateVariableDeclaration, *ast.VariableDeclaration, *ast.ContractDefinition) {
+	if seen[c] {
+		return nil, nil, nil
+	}
+	seen[c] = true
+	for _, sub := range c.SubNodes {
+		if decl, ok := sub.(*ast.StateVariableDeclaration); ok {
+			for _, v := range decl.Variables {
+				if v.Name == name {
+					return decl, v, c
+				}
+			}
+		}
+	}
+	for _, spec := range c.BaseContracts {
+		if spec.BaseName == nil {
+			continue
+		}
+		if base := e.contracts[spec.BaseName.NamePath]; base != nil {
+			if decl, v, owner := e.member(base, name, seen); v != nil {
+				return decl, v, owner
+			}
+		}
+	}
+	return nil, nil, nil
+}
+
+// constant evaluates the initial value of a constant variable in the scope
+// it is declared in and converts it to the declared type
+func (e *Evaluator) constant(decl *ast.StateVariableDeclaration, v *ast.VariableDeclaration, owner *ast.ContractDefinition, at ast.Node) (Value, error) {
+	if !v.IsDeclaredConst || decl.InitialValue == nil {
+		return Value{}, &Error{Node: at, Message: fmt.Sprintf("%s is not a constant", v.Name)}
+	}
+	if r, ok := e.values[v]; ok {
+		return r.value, r.err
+	}
+	if e.active[v] {
+		return Value{}, &Error{Node: at, Message: fmt.Sprintf("constant %s depends on itself", v.Name)}
+	}
+	e.active[v] = true
+	defer delete(e.active, v)
+
+	value, err := e.eval(decl.InitialValue, owner)
+	if err == nil {
+		if t, ok := v.TypeName.(*ast.ElementaryTypeName); ok {
+			if value, err = convert(value, elementary(t.Name), false); err != nil {
+				err = &Error{Node: decl.InitialValue, Message: err.Error()}
+			}
+		}
+	}
+	e.values[v] = result{value, err}
+	return value, err
+}
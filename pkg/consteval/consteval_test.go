@@ -0,0 +1,194 @@
+package consteval
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+	"github.com/th13vn/solast-go/pkg/parser"
+)
+
+func parse(t *testing.T, src string) *ast.SourceUnit {
+	t.Helper()
+	unit, err := parser.Parse(src, &parser.Options{Loc: true})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	return unit
+}
+
+func TestKeccak256(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"},
+		{"transfer(address,uint256)", "a9059cbb2ab09eb219583f4a59a5d0623ade346d962bcd4e46b11da047c9049b"},
+		// Around the 136-byte block size
+		{strings.Repeat("a", 135), "34367dc248bbd832f4e3e69dfaac2f92638bd0bbd18f2912ba4ef454919cf446"},
+		{strings.Repeat("a", 136), "a6c4d403279fe3e0af03729caada8374b5ca54d8065329a3ebcaeb4b60aa386e"},
+		{strings.Repeat("a", 137), "d869f639c7046b4929fc92a4d988a8b22c55fbadb802c0c66ebcd484f1915f39"},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(keccak256([]byte(tt.input))); got != tt.want {
+			t.Errorf("keccak256(%d bytes) = %s, want %s", len(tt.input), got, tt.want)
+		}
+	}
+}
+
+const tokenSrc = `
+import "./Slots.sol";
+
+uint constant FILE_LEN = 3;
+
+contract Base {
+	uint8 constant SMALL = 200;
+	bytes32 public constant ADMIN = keccak256("ADMIN_ROLE");
+}
+
+contract Token is Base {
+	uint constant HALF = 1 / 2 * 2;
+	uint constant TRUNC = SMALL / 3 * 3;
+	uint constant MAX = type(uint256).max;
+	int8 constant MIN = type(int8).min;
+	bytes4 constant SELECTOR = bytes4(keccak256("transfer(address,uint256)"));
+	uint constant TOP = 1 << 255;
+	uint constant SCALED = (2 ** 10 + 0x10) * 1 ether / 1e18;
+	uint constant DURATION = 1.5 days;
+	bytes32 constant SLOT = Slots.IMPLEMENTATION;
+	uint constant LEN = FILE_LEN * 2;
+	bool constant FLAG = LEN > 5 && !(SMALL == 0);
+	bytes32 constant PACKED = keccak256(abi.encodePacked(SMALL, "x"));
+	int16 constant NEG = -int16(SMALL);
+	uint constant WRAPPED = uint8(uint256(257));
+	uint[LEN] values;
+
+	uint constant LOOP_A = LOOP_B;
+	uint constant LOOP_B = LOOP_A;
+	uint constant OVERFLOW = SMALL * 2;
+	uint constant FRACTION = 1 / 3;
+	uint immutable IMMUTABLE = 1;
+	uint constant CALL = f();
+	uint8 constant CONVERTED = uint8(300);
+}
+`
+
+const slotsSrc = `
+library Slots {
+	bytes32 internal constant IMPLEMENTATION = bytes32(uint256(keccak256("eip1967.proxy.implementation")) - 1);
+}
+`
+
+func TestConstants(t *testing.T) {
+	e := New(
+		parse(t, tokenSrc),
+		parse(t, slotsSrc),
+	)
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"HALF", "1"},
+		{"TRUNC", "198"},
+		{"MAX", "115792089237316195423570985008687907853269984665640564039457584007913129639935"},
+		{"MIN", "-128"},
+		{"SELECTOR", "0xa9059cbb"},
+		{"TOP", "57896044618658097711785492504343953926634992332820282019728792003956564819968"},
+		{"SCALED", "1040"},
+		{"DURATION", "129600"},
+		{"SLOT", "0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc"},
+		{"ADMIN", "0xa49807205ce4d355092ef5a8a18f56e8913cf4a201fbe287825b095693c21775"},
+		{"LEN", "6"},
+		{"FLAG", "true"},
+		{"PACKED", "0x" + hex.EncodeToString(keccak256([]byte{200, 'x'}))},
+		{"NEG", "-200"},
+		{"WRAPPED", "1"},
+	}
+	for _, tt := range tests {
+		v, err := e.Constant("Token", tt.name)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if v.String() != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, v, tt.want)
+		}
+	}
+	if v, err := e.Constant("Token", "TRUNC"); err != nil || v.Type != "uint256" {
+		t.Errorf("TRUNC type = %q, %v", v.Type, err)
+	}
+
+	errs := map[string]string{
+		"LOOP_A":    "depends on itself",
+		"OVERFLOW":  "uint8 overflow",
+		"FRACTION":  "does not fit in uint256",
+		"IMMUTABLE": "not a constant",
+		"CALL":      "not constant",
+		"values":    "not a constant",
+		"MISSING":   "not a constant",
+		"CONVERTED": "does not fit in uint8",
+	}
+	for name, want := range errs {
+		if v, err := e.Constant("Token", name); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s = %v, %v; want error containing %q", name, v, err, want)
+		}
+	}
+	v, err := e.Constant("Token", "OVERFLOW")
+	if !strings.HasPrefix(err.Error(), "line 30:") {
+		t.Errorf("error not located: %v", err)
+	}
+	if _, ok := v.Int(); ok || v.Kind != KindInvalid || v.String() != "<invalid>" {
+		t.Errorf("value with an error = %s", v)
+	}
+}
+
+func TestArrayLength(t *testing.T) {
+	unit := parse(t, tokenSrc)
+	token := unit.Children[3].(*ast.ContractDefinition)
+	var length ast.Node
+	for _, sub := range token.SubNodes {
+		if decl, ok := sub.(*ast.StateVariableDeclaration); ok && decl.Variables[0].Name == "values" {
+			length = decl.Variables[0].TypeName.(*ast.ArrayTypeName).Length
+		}
+	}
+	v, err := New(unit).Eval(length, token)
+	if n, ok := v.Int(); err != nil || !ok || n.Int64() != 6 {
+		t.Errorf("length = %v, %v", v, err)
+	}
+}
+
+func TestEvalLiterals(t *testing.T) {
+	tests := map[string]string{
+		"(1 + 2) * 3 - 4 / 8":       "17/2",
+		"7 % -3":                    "1",
+		"-7 % 3":                    "-1",
+		"2 ** -2":                   "1/4",
+		"-8 >> 1":                   "-4",
+		"~0":                        "-1",
+		"0xff & 0x0f | 0x30 ^ 0x01": "63",
+		"1 > 2 ? 10 : 20":           "20",
+		"uint8(255) + 0 == 255":     "true",
+		"bytes2(0x1234) >> 8":       "0x0012",
+		"~bytes1(0x0f)":             "0xf0",
+		"bytes4(\"ab\")":            "0x61620000",
+		"hex\"00ff\"":               "0x00ff",
+		"type(uint8).max + 1":       "",
+		"1 / 0":                     "",
+		"int8(-1) + uint8(1)":       "",
+		"2 ** 10000":                "",
+	}
+	for src, want := range tests {
+		unit := parse(t, "uint constant X = "+src+";")
+		v, err := Eval(unit.Children[0].(*ast.StateVariableDeclaration).InitialValue)
+		if want == "" {
+			if err == nil {
+				t.Errorf("%s = %s, want an error", src, v)
+			}
+			continue
+		}
+		if err != nil || v.String() != want {
+			t.Errorf("%s = %s, %v; want %s", src, v, err, want)
+		}
+	}
+}
@@ -0,0 +1,723 @@
+package consteval
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// maxBits bounds the size of literal intermediate results, as solc does
+const maxBits = 4096
+
+// eval evaluates n, attributing errors without a node to n
+func (e *Evaluator) eval(n ast.Node, scope *ast.ContractDefinition) (Value, error) {
+	v, err := e.evalNode(n, scope)
+	if err != nil {
+		var evalErr *Error
+		if !errors.As(err, &evalErr) {
+			err = &Error{Node: n, Message: err.Error()}
+		}
+	}
+	return v, err
+}
+
+func (e *Evaluator) evalNode(n ast.Node, scope *ast.ContractDefinition) (Value, error) {
+	switch n := n.(type) {
+	case *ast.NumberLiteral:
+		r, ok := n.Rat()
+		if !ok {
+			return Value{}, fmt.Errorf("malformed number %s", n.Number)
+		}
+		return Value{Kind: KindNumber, Number: r}, nil
+	case *ast.BooleanLiteral:
+		return Value{Kind: KindBool, Type: "bool", Bool: n.Value}, nil
+	case *ast.StringLiteral:
+		return Value{Kind: KindBytes, Bytes: n.Bytes()}, nil
+	case *ast.HexLiteral:
+		b, ok := n.Bytes()
+		if !ok {
+			return Value{}, fmt.Errorf("malformed hex literal %s", n.Value)
+		}
+		return Value{Kind: KindBytes, Bytes: b}, nil
+	case *ast.Identifier:
+		return e.identifier(n.Name, scope, n)
+	case *ast.TupleExpression:
+		if len(n.Components) != 1 || n.IsArray || n.Components[0] == nil {
+			return Value{}, errors.New("tuples are not constant")
+		}
+		return e.eval(n.Components[0], scope)
+	case *ast.UnaryOperation:
+		x, err := e.eval(n.SubExpression, scope)
+		if err != nil {
+			return Value{}, err
+		}
+		return unaryOp(n.Operator, x)
+	case *ast.BinaryOperation:
+		l, err := e.eval(n.Left, scope)
+		if err != nil {
+			return Value{}, err
+		}
+		r, err := e.eval(n.Right, scope)
+		if err != nil {
+			return Value{}, err
+		}
+		return binaryOp(n.Operator, l, r)
+	case *ast.Conditional:
+		c, err := e.eval(n.Condition, scope)
+		if err != nil {
+			return Value{}, err
+		}
+		if c.Kind != KindBool {
+			return Value{}, errors.New("condition is not a boolean")
+		}
+		if c.Bool {
+			return e.eval(n.TrueExpression, scope)
+		}
+		return e.eval(n.FalseExpression, scope)
+	case *ast.FunctionCall:
+		return e.call(n, scope)
+	case *ast.MemberAccess:
+		return e.memberAccess(n, scope)
+	case nil:
+		return Value{}, errors.New("missing expression")
+	}
+	return Value{}, fmt.Errorf("%s is not constant", n.GetType())
+}
+
+// call evaluates conversions, keccak256 and abi.encodePacked
+func (e *Evaluator) call(n *ast.FunctionCall, scope *ast.ContractDefinition) (Value, error) {
+	args := make([]Value, len(n.Arguments))
+	for i, arg := range n.Arguments {
+		v, err := e.eval(arg, scope)
+		if err != nil {
+			return Value{}, err
+		}
+		args[i] = v
+	}
+
+	switch callee := n.Expression.(type) {
+	case *ast.ElementaryTypeName:
+		if len(args) != 1 {
+			return Value{}, errors.New("conversion takes one argument")
+		}
+		return convert(args[0], elementary(callee.Name), true)
+	case *ast.Identifier:
+		if callee.Name == "keccak256" {
+			if len(args) != 1 || args[0].Kind != KindBytes {
+				return Value{}, errors.New("keccak256 takes one byte string")
+			}
+			return Value{Kind: KindBytes, Type: "bytes32", Bytes: keccak256(args[0].Bytes)}, nil
+		}
+	case *ast.MemberAccess:
+		if id, ok := callee.Expression.(*ast.Identifier); ok && id.Name == "abi" && callee.MemberName == "encodePacked" {
+			return encodePacked(args)
+		}
+	}
+	return Value{}, errors.New("function calls are not constant")
+}
+
+// memberAccess evaluates type(T).min, type(T).max and C.X for a constant X
+// of contract C
+func (e *Evaluator) memberAccess(n *ast.MemberAccess, scope *ast.ContractDefinition) (Value, error) {
+	switch x := n.Expression.(type) {
+	case *ast.FunctionCall:
+		if id, ok := x.Expression.(*ast.Identifier); ok && id.Name == "type" && len(x.Arguments) == 1 {
+			t, ok := x.Arguments[0].(*ast.ElementaryTypeName)
+			if !ok {
+				break
+			}
+			name := elementary(t.Name)
+			it, ok := parseInt(name)
+			if !ok || name == "address" {
+				return Value{}, fmt.Errorf("type(%s) has no constant members", t.Name)
+			}
+			switch n.MemberName {
+			case "min":
+				return number(new(big.Int).Set(it.min()), name), nil
+			case "max":
+				return number(new(big.Int).Set(it.max()), name), nil
+			}
+		}
+	case *ast.Identifier:
+		if c := e.contracts[x.Name]; c != nil {
+			if decl, v, owner := e.member(c, n.MemberName, make(map[*ast.ContractDefinition]bool)); v != nil {
+				return e.constant(decl, v, owner, n)
+			}
+		}
+	}
+	return Value{}, fmt.Errorf("member %s is not constant", n.MemberName)
+}
+
+// encodePacked encodes typed values and byte strings without padding
+func encodePacked(args []Value) (Value, error) {
+	var out []byte
+	for _, arg := range args {
+		switch arg.Kind {
+		case KindBytes:
+			out = append(out, arg.Bytes...)
+		case KindBool:
+			if arg.Bool {
+				out = append(out, 1)
+			} else {
+				out = append(out, 0)
+			}
+		case KindNumber:
+			it, ok := parseInt(arg.Type)
+			if !ok {
+				return Value{}, errors.New("abi.encodePacked cannot encode a literal number")
+			}
+			i, _ := arg.Int()
+			out = append(out, bigEndian(i, it.bits/8)...)
+		}
+	}
+	return Value{Kind: KindBytes, Type: "bytes", Bytes: out}, nil
+}
+
+func unaryOp(op string, x Value) (Value, error) {
+	switch op {
+	case "!":
+		if x.Kind == KindBool {
+			return Value{Kind: KindBool, Type: "bool", Bool: !x.Bool}, nil
+		}
+	case "-":
+		if x.Kind != KindNumber {
+			break
+		}
+		if x.Type == "" {
+			return Value{Kind: KindNumber, Number: new(big.Rat).Neg(x.Number)}, nil
+		}
+		it, _ := parseInt(x.Type)
+		if !it.signed {
+			return Value{}, fmt.Errorf("unary - on %s", x.Type)
+		}
+		i, _ := x.Int()
+		return checked(i.Neg(i), x.Type)
+	case "~":
+		if x.Kind == KindBytes && isFixed(x.Type) {
+			out := make([]byte, len(x.Bytes))
+			for i := range out {
+				out[i] = ^x.Bytes[i]
+			}
+			return Value{Kind: KindBytes, Type: x.Type, Bytes: out}, nil
+		}
+		i, ok := x.Int()
+		if !ok {
+			break
+		}
+		i.Not(i)
+		if x.Type == "" {
+			return Value{Kind: KindNumber, Number: new(big.Rat).SetInt(i)}, nil
+		}
+		it, _ := parseInt(x.Type)
+		return number(it.wrap(i), x.Type), nil
+	}
+	return Value{}, fmt.Errorf("unary %s not supported on %s", op, describe(x))
+}
+
+func binaryOp(op string, l, r Value) (Value, error) {
+	switch op {
+	case "&&", "||":
+		if l.Kind != KindBool || r.Kind != KindBool {
+			return Value{}, fmt.Errorf("%s needs booleans", op)
+		}
+		if op == "&&" {
+			return Value{Kind: KindBool, Type: "bool", Bool: l.Bool && r.Bool}, nil
+		}
+		return Value{Kind: KindBool, Type: "bool", Bool: l.Bool || r.Bool}, nil
+	case "==", "!=", "<", ">", "<=", ">=":
+		return compare(op, l, r)
+	}
+
+	if l.Kind == KindBytes && isFixed(l.Type) {
+		return bytesOp(op, l, r)
+	}
+	if l.Kind != KindNumber || r.Kind != KindNumber {
+		return Value{}, fmt.Errorf("%s not supported on %s and %s", op, describe(l), describe(r))
+	}
+	switch op {
+	case "<<", ">>":
+		return shift(op, l, r)
+	case "**":
+		return power(l, r)
+	}
+
+	t, err := common(l, r)
+	if err != nil {
+		return Value{}, err
+	}
+	if t == "" {
+		return rational(op, l.Number, r.Number)
+	}
+	if t == "address" {
+		return Value{}, fmt.Errorf("%s not supported on address", op)
+	}
+	a, _ := l.Int()
+	b, _ := r.Int()
+	switch op {
+	case "+":
+		return checked(a.Add(a, b), t)
+	case "-":
+		return checked(a.Sub(a, b), t)
+	case "*":
+		return checked(a.Mul(a, b), t)
+	case "/", "%":
+		if b.Sign() == 0 {
+			return Value{}, errors.New("division by zero")
+		}
+		if op == "/" {
+			return checked(a.Quo(a, b), t)
+		}
+		return number(a.Rem(a, b), t), nil
+	case "&":
+		return number(a.And(a, b), t), nil
+	case "|":
+		return number(a.Or(a, b), t), nil
+	case "^":
+		return number(a.Xor(a, b), t), nil
+	}
+	return Value{}, fmt.Errorf("operator %s is not constant", op)
+}
+
+// rational applies op to two literals exactly
+func rational(op string, a, b *big.Rat) (Value, error) {
+	z := new(big.Rat)
+	switch op {
+	case "+":
+		z.Add(a, b)
+	case "-":
+		z.Sub(a, b)
+	case "*":
+		z.Mul(a, b)
+	case "/", "%":
+		if b.Sign() == 0 {
+			return Value{}, errors.New("division by zero")
+		}
+		z.Quo(a, b)
+		if op == "%" {
+			// a - trunc(a/b)*b, keeping the sign of a
+			q := new(big.Int).Quo(z.Num(), z.Denom())
+			z.Sub(a, new(big.Rat).Mul(new(big.Rat).SetInt(q), b))
+		}
+	case "&", "|", "^":
+		if !a.IsInt() || !b.IsInt() {
+			return Value{}, fmt.Errorf("%s needs integers", op)
+		}
+		x, y := new(big.Int).Set(a.Num()), b.Num()
+		switch op {
+		case "&":
+			x.And(x, y)
+		case "|":
+			x.Or(x, y)
+		default:
+			x.Xor(x, y)
+		}
+		z.SetInt(x)
+	default:
+		return Value{}, fmt.Errorf("operator %s is not constant", op)
+	}
+	if z.Num().BitLen() > maxBits || z.Denom().BitLen() > maxBits {
+		return Value{}, errors.New("literal too large")
+	}
+	return Value{Kind: KindNumber, Number: z}, nil
+}
+
+// power evaluates l ** r. A literal base with a typed exponent is computed
+// in uint256, or int256 if negative.
+func power(l, r Value) (Value, error) {
+	exp, ok := r.Int()
+	if !ok {
+		return Value{}, errors.New("exponent must be an integer")
+	}
+	if it, _ := parseInt(r.Type); it.signed {
+		return Value{}, errors.New("exponent must be unsigned")
+	}
+	if l.Type == "" && r.Type == "" {
+		return literalPower(l.Number, exp)
+	}
+	if exp.Sign() < 0 {
+		return Value{}, errors.New("negative exponent")
+	}
+
+	t := l.Type
+	if t == "" {
+		t = "uint256"
+		if l.Number.Sign() < 0 {
+			t = "int256"
+		}
+		var err error
+		if l, err = convert(l, t, false); err != nil {
+			return Value{}, err
+		}
+	}
+	it, ok := parseInt(t)
+	if !ok || t == "address" {
+		return Value{}, fmt.Errorf("** not supported on %s", t)
+	}
+	base, _ := l.Int()
+	if base.CmpAbs(big.NewInt(1)) > 0 && exp.Cmp(big.NewInt(int64(it.bits))) >= 0 {
+		return Value{}, fmt.Errorf("%s overflow", t)
+	}
+	return checked(base.Exp(base, exp, nil), t)
+}
+
+// literalPower raises a literal to an integer power exactly
+func literalPower(base *big.Rat, exp *big.Int) (Value, error) {
+	num, den := base.Num(), base.Denom()
+	size := num.BitLen()
+	if den.BitLen() > size {
+		size = den.BitLen()
+	}
+	// 0, 1 and -1 stay small whatever the exponent
+	if size > 1 && (!exp.IsInt64() || new(big.Int).Mul(big.NewInt(int64(size-1)), new(big.Int).Abs(exp)).Cmp(big.NewInt(maxBits)) > 0) {
+		return Value{}, errors.New("literal too large")
+	}
+	n := new(big.Int).Abs(exp)
+	if exp.Sign() < 0 {
+		if num.Sign() == 0 {
+			return Value{}, errors.New("division by zero")
+		}
+		num, den = den, num
+	}
+	z := new(big.Rat).SetFrac(new(big.Int).Exp(num, n, nil), new(big.Int).Exp(den, n, nil))
+	return Value{Kind: KindNumber, Number: z}, nil
+}
+
+// shift evaluates l << r and l >> r; >> rounds towards negative infinity
+func shift(op string, l, r Value) (Value, error) {
+	n, ok := r.Int()
+	if !ok || n.Sign() < 0 {
+		return Value{}, errors.New("shift amount must be a non-negative integer")
+	}
+	if r.Type != "" {
+		if it, _ := parseInt(r.Type); it.signed {
+			return Value{}, errors.New("shift amount must be unsigned")
+		}
+	}
+	if l.Type == "" && r.Type != "" {
+		t := "uint256"
+		if l.Number.Sign() < 0 {
+			t = "int256"
+		}
+		var err error
+		if l, err = convert(l, t, false); err != nil {
+			return Value{}, err
+		}
+	}
+	x, ok := l.Int()
+	if !ok {
+		return Value{}, errors.New("shifted value must be an integer")
+	}
+	if l.Type == "" {
+		if n.Cmp(big.NewInt(maxBits)) > 0 {
+			return Value{}, errors.New("literal too large")
+		}
+		if op == "<<" {
+			x.Lsh(x, uint(n.Uint64()))
+		} else {
+			x.Rsh(x, uint(n.Uint64()))
+		}
+		if x.BitLen() > maxBits {
+			return Value{}, errors.New("literal too large")
+		}
+		return Value{Kind: KindNumber, Number: new(big.Rat).SetInt(x)}, nil
+	}
+	it, ok := parseInt(l.Type)
+	if !ok || l.Type == "address" {
+		return Value{}, fmt.Errorf("%s not supported on %s", op, l.Type)
+	}
+	amount := uint(it.bits)
+	if n.Cmp(big.NewInt(int64(it.bits))) < 0 {
+		amount = uint(n.Uint64())
+	}
+	if op == "<<" {
+		return number(it.wrap(x.Lsh(x, amount)), l.Type), nil
+	}
+	return number(x.Rsh(x, amount), l.Type), nil
+}
+
+// bytesOp applies bitwise operators and shifts to fixed bytes
+func bytesOp(op string, l, r Value) (Value, error) {
+	size, _ := fixedSize(l.Type)
+	a := new(big.Int).SetBytes(l.Bytes)
+	switch op {
+	case "&", "|", "^":
+		if r.Type != l.Type {
+			return Value{}, fmt.Errorf("%s needs two %s", op, l.Type)
+		}
+		b := new(big.Int).SetBytes(r.Bytes)
+		switch op {
+		case "&":
+			a.And(a, b)
+		case "|":
+			a.Or(a, b)
+		default:
+			a.Xor(a, b)
+		}
+	case "<<", ">>":
+		n, ok := r.Int()
+		if !ok || n.Sign() < 0 {
+			return Value{}, errors.New("shift amount must be a non-negative integer")
+		}
+		amount := uint(size * 8)
+		if n.Cmp(big.NewInt(int64(amount))) < 0 {
+			amount = uint(n.Uint64())
+		}
+		if op == "<<" {
+			a.Lsh(a, amount)
+		} else {
+			a.Rsh(a, amount)
+		}
+	default:
+		return Value{}, fmt.Errorf("%s not supported on %s", op, l.Type)
+	}
+	return Value{Kind: KindBytes, Type: l.Type, Bytes: bigEndian(a, size)}, nil
+}
+
+func compare(op string, l, r Value) (Value, error) {
+	var c int
+	switch {
+	case l.Kind == KindNumber && r.Kind == KindNumber:
+		if _, err := common(l, r); err != nil {
+			return Value{}, err
+		}
+		c = l.Number.Cmp(r.Number)
+	case l.Kind == KindBool && r.Kind == KindBool && (op == "==" || op == "!="):
+		if l.Bool != r.Bool {
+			c = 1
+		}
+	case l.Kind == KindBytes && r.Kind == KindBytes && l.Type == r.Type && isFixed(l.Type):
+		c = bytes.Compare(l.Bytes, r.Bytes)
+	default:
+		return Value{}, fmt.Errorf("cannot compare %s and %s", describe(l), describe(r))
+	}
+	var b bool
+	switch op {
+	case "==":
+		b = c == 0
+	case "!=":
+		b = c != 0
+	case "<":
+		b = c < 0
+	case ">":
+		b = c > 0
+	case "<=":
+		b = c <= 0
+	default:
+		b = c >= 0
+	}
+	return Value{Kind: KindBool, Type: "bool", Bool: b}, nil
+}
+
+// common returns the type two numbers are combined in: empty for two
+// literals, else the typed operand's type, which a literal must fit, or the
+// wider of two integer types of the same signedness
+func common(l, r Value) (string, error) {
+	switch {
+	case l.Type == "" && r.Type == "":
+		return "", nil
+	case l.Type == "":
+		_, err := convert(l, r.Type, false)
+		return r.Type, err
+	case r.Type == "":
+		_, err := convert(r, l.Type, false)
+		return l.Type, err
+	case l.Type == r.Type:
+		return l.Type, nil
+	}
+	a, _ := parseInt(l.Type)
+	b, _ := parseInt(r.Type)
+	if a.signed != b.signed || l.Type == "address" || r.Type == "address" {
+		return "", fmt.Errorf("incompatible types %s and %s", l.Type, r.Type)
+	}
+	if a.bits >= b.bits {
+		return l.Type, nil
+	}
+	return r.Type, nil
+}
+
+// convert converts v to the elementary type t. Implicit conversions must
+// not lose information; explicit ones truncate integers and fixed bytes as
+// Solidity does.
+func convert(v Value, t string, explicit bool) (Value, error) {
+	if it, ok := parseInt(t); ok {
+		switch v.Kind {
+		case KindNumber:
+			i, ok := v.Int()
+			if !ok {
+				return Value{}, fmt.Errorf("fractional number %s does not fit in %s", v, t)
+			}
+			if v.Type == "" || !explicit {
+				if !it.fits(i) {
+					return Value{}, fmt.Errorf("%s does not fit in %s", v, t)
+				}
+				return number(i, t), nil
+			}
+			return number(it.wrap(i), t), nil
+		case KindBytes:
+			if n, ok := fixedSize(v.Type); ok && explicit && n*8 == it.bits {
+				return number(it.wrap(new(big.Int).SetBytes(v.Bytes)), t), nil
+			}
+		}
+	} else if size, ok := fixedSize(t); ok {
+		switch v.Kind {
+		case KindBytes:
+			n, fixed := fixedSize(v.Type)
+			if v.Type == "" && len(v.Bytes) > size {
+				return Value{}, fmt.Errorf("literal of %d bytes does not fit in %s", len(v.Bytes), t)
+			}
+			if v.Type == "" || fixed && (n == size || explicit) || v.Type == "bytes" && explicit {
+				out := make([]byte, size)
+				copy(out, v.Bytes)
+				return Value{Kind: KindBytes, Type: t, Bytes: out}, nil
+			}
+		case KindNumber:
+			i, ok := v.Int()
+			if v.Type == "" && ok && i.Sign() >= 0 && i.BitLen() <= size*8 {
+				return Value{Kind: KindBytes, Type: t, Bytes: bigEndian(i, size)}, nil
+			}
+			if it, isInt := parseInt(v.Type); ok && isInt && explicit && !it.signed && it.bits == size*8 {
+				return Value{Kind: KindBytes, Type: t, Bytes: bigEndian(i, size)}, nil
+			}
+		}
+	} else {
+		switch t {
+		case "bool":
+			if v.Kind == KindBool {
+				return v, nil
+			}
+		case "string", "bytes":
+			if v.Kind == KindBytes && (v.Type == "" || v.Type == t || explicit && (v.Type == "string" || v.Type == "bytes")) {
+				return Value{Kind: KindBytes, Type: t, Bytes: v.Bytes}, nil
+			}
+		}
+	}
+	return Value{}, fmt.Errorf("cannot convert %s to %s", describe(v), t)
+}
+
+// checked returns i as a t, or an overflow error if it does not fit
+func checked(i *big.Int, t string) (Value, error) {
+	it, _ := parseInt(t)
+	if !it.fits(i) {
+		return Value{}, fmt.Errorf("%s overflow", t)
+	}
+	return number(i, t), nil
+}
+
+func number(i *big.Int, t string) Value {
+	return Value{Kind: KindNumber, Type: t, Number: new(big.Rat).SetInt(i)}
+}
+
+// describe names the type of v for error messages
+func describe(v Value) string {
+	if v.Type != "" {
+		return v.Type
+	}
+	switch v.Kind {
+	case KindNumber:
+		return "literal " + v.String()
+	case KindBool:
+		return "bool"
+	}
+	return "literal string"
+}
+
+// bigEndian encodes i in size bytes, two's complement if negative
+func bigEndian(i *big.Int, size int) []byte {
+	if i.Sign() < 0 {
+		i = new(big.Int).Add(i, new(big.Int).Lsh(big.NewInt(1), uint(size*8)))
+	}
+	out := make([]byte, size)
+	return i.FillBytes(out)
+}
+
+// elementary normalizes the aliases of an elementary type name
+func elementary(name string) string {
+	switch name {
+	case "uint":
+		return "uint256"
+	case "int":
+		return "int256"
+	case "byte":
+		return "bytes1"
+	case "address payable":
+		return "address"
+	}
+	return name
+}
+
+// intType is an integer type; address is a uint160
+type intType struct {
+	bits   int
+	signed bool
+}
+
+func parseInt(t string) (intType, bool) {
+	var digits string
+	signed := false
+	switch {
+	case t == "address":
+		return intType{bits: 160}, true
+	case strings.HasPrefix(t, "uint"):
+		digits = t[len("uint"):]
+	case strings.HasPrefix(t, "int"):
+		digits, signed = t[len("int"):], true
+	default:
+		return intType{}, false
+	}
+	bits, err := strconv.Atoi(digits)
+	if err != nil || bits < 8 || bits > 256 || bits%8 != 0 {
+		return intType{}, false
+	}
+	return intType{bits: bits, signed: signed}, true
+}
+
+func (t intType) min() *big.Int {
+	if !t.signed {
+		return new(big.Int)
+	}
+	return new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), uint(t.bits-1)))
+}
+
+func (t intType) max() *big.Int {
+	bits := t.bits
+	if t.signed {
+		bits--
+	}
+	return new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(bits)), big.NewInt(1))
+}
+
+func (t intType) fits(i *big.Int) bool {
+	return i.Cmp(t.min()) >= 0 && i.Cmp(t.max()) <= 0
+}
+
+// wrap reduces i modulo 2^bits into the range of t
+func (t intType) wrap(i *big.Int) *big.Int {
+	modulus := new(big.Int).Lsh(big.NewInt(1), uint(t.bits))
+	i = new(big.Int).Mod(i, modulus)
+	if t.signed && i.Cmp(t.max()) > 0 {
+		i.Sub(i, modulus)
+	}
+	return i
+}
+
+func isFixed(t string) bool {
+	_, ok := fixedSize(t)
+	return ok
+}
+
+// fixedSize returns N of a bytesN type
+func fixedSize(t string) (int, bool) {
+	if !strings.HasPrefix(t, "bytes") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(t[len("bytes"):])
+	if err != nil || n < 1 || n > 32 {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,304 @@
+package dataflow
+
+import (
+	"strings"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+type accessKind int
+
+const (
+	accessRead accessKind = iota
+	accessWrite
+)
+
+// access is one read or write of a named variable by a CFG node
+type access struct {
+	kind accessKind
+	name string
+	// node is the Identifier for reads; for writes the assignment, ++/--,
+	// delete, push/pop call, or VariableDeclaration that defines the value
+	node ast.Node
+	// whole is set when a write replaces the variable (x = v), as opposed to
+	// updating part of it (x[i] = v, x.f = v, x.push(v))
+	whole bool
+	// value is the expression written; nil when there is none (delete, pop)
+	value ast.Node
+}
+
+var assignmentOperators = map[string]bool{
+	"=": true, "+=": true, "-=": true, "*=": true, "/=": true, "%=": true,
+	"|=": true, "&=": true, "^=": true, "<<=": true, ">>=": true, ">>>=": true,
+}
+
+// accesses lists the variable reads and writes of a CFG node in evaluation
+// order. Names of called functions (`f(x)`) are not reads.
+func accesses(n ast.Node) []access {
+	var out []access
+	scan(n, &out)
+	return out
+}
+
+// Access is one read or write of a named variable, as seen by the built-in
+// analyses
+type Access struct {
+	Write bool
+	Name  string
+	// Node is the Identifier for reads; for writes the assignment, ++/--,
+	// delete, push/pop call, or VariableDeclaration that defines the value
+	Node ast.Node
+	// Whole is set when a write replaces the variable rather than updating
+	// part of it
+	Whole bool
+	// Value is the expression written; nil when there is none (delete, pop)
+	Value ast.Node
+}
+
+// Accesses lists the variable reads and writes of a CFG node (a statement or
+// branch condition) in evaluation order. Writes are attributed to the root
+// variable of the target, so `s.balances[a] += v` reads and writes s.
+func Accesses(n ast.Node) []Access {
+	var out []Access
+	for _, acc := range accesses(n) {
+		out = append(out, Access{
+			Write: acc.kind == accessWrite,
+			Name:  acc.name,
+			Node:  acc.node,
+			Whole: acc.whole,
+			Value: acc.value,
+		})
+	}
+	return out
+}
+
+func scan(n ast.Node, out *[]access) {
+	switch v := n.(type) {
+	case nil:
+	case *ast.ExpressionStatement:
+		scan(v.Expression, out)
+	case *ast.VariableDeclarationStatement:
+		scan(v.InitialValue, out)
+		values := splitTuple(v.InitialValue, len(v.Variables))
+		for i, decl := range v.Variables {
+			if decl == nil {
+				continue
+			}
+			*out = append(*out, access{kind: accessWrite, name: decl.Name, node: decl, whole: true, value: values[i]})
+		}
+	case *ast.ReturnStatement:
+		scan(v.Expression, out)
+	case *ast.EmitStatement:
+		scan(v.EventCall, out)
+	case *ast.RevertStatement:
+		scan(v.RevertCall, out)
+	case *ast.InlineAssembly:
+		if v.Body != nil {
+			scanYul(v.Body, out)
+		}
+	case *ast.Identifier:
+		*out = append(*out, access{kind: accessRead, name: v.Name, node: v})
+	case *ast.MemberAccess:
+		scan(v.Expression, out)
+	case *ast.IndexAccess:
+		scan(v.Base, out)
+		scan(v.Index, out)
+	case *ast.IndexRangeAccess:
+		scan(v.Base, out)
+		scan(v.IndexStart, out)
+		scan(v.IndexEnd, out)
+	case *ast.BinaryOperation:
+		if assignmentOperators[v.Operator] {
+			scan(v.Right, out)
+			if v.Operator != "=" {
+				scan(v.Left, out)
+			} else {
+				scanTarget(v.Left, out)
+			}
+			assign(v.Left, v.Right, v, v.Operator == "=", out)
+			return
+		}
+		scan(v.Left, out)
+		scan(v.Right, out)
+	case *ast.UnaryOperation:
+		switch v.Operator {
+		case "++", "--":
+			scan(v.SubExpression, out)
+			writeTarget(v.SubExpression, v, v, out)
+		case "delete":
+			scanTarget(v.SubExpression, out)
+			writeTarget(v.SubExpression, v, nil, out)
+		default:
+			scan(v.SubExpression, out)
+		}
+	case *ast.Conditional:
+		scan(v.Condition, out)
+		scan(v.TrueExpression, out)
+		scan(v.FalseExpression, out)
+	case *ast.FunctionCall:
+		if _, named := v.Expression.(*ast.Identifier); !named {
+			scan(v.Expression, out)
+		}
+		for _, arg := range v.Arguments {
+			scan(arg, out)
+		}
+		if ma, ok := v.Expression.(*ast.MemberAccess); ok && (ma.MemberName == "push" || ma.MemberName == "pop") {
+			var value ast.Node
+			if ma.MemberName == "push" && len(v.Arguments) > 0 {
+				value = v.Arguments[0]
+			}
+			if name := baseName(ma.Expression); name != "" {
+				*out = append(*out, access{kind: accessWrite, name: name, node: v, value: value})
+			}
+		}
+	case *ast.ModifierInvocation:
+		for _, arg := range v.Arguments {
+			scan(arg, out)
+		}
+	case *ast.FunctionCallOptions:
+		scan(v.Expression, out)
+		for _, opt := range v.Options {
+			scan(opt, out)
+		}
+	case *ast.NameValueExpression:
+		scan(v.Expression, out)
+		if v.Arguments != nil {
+			for _, arg := range v.Arguments.Arguments {
+				scan(arg, out)
+			}
+		}
+	case *ast.TupleExpression:
+		for _, c := range v.Components {
+			scan(c, out)
+		}
+	}
+}
+
+// scanTarget records the reads made while locating an assignment target:
+// indices and the bases of member/index chains other than the assigned
+// variable itself
+func scanTarget(lhs ast.Node, out *[]access) {
+	switch v := lhs.(type) {
+	case *ast.IndexAccess:
+		scanTarget(v.Base, out)
+		scan(v.Index, out)
+	case *ast.MemberAccess:
+		scanTarget(v.Expression, out)
+	case *ast.TupleExpression:
+		for _, c := range v.Components {
+			scanTarget(c, out)
+		}
+	case *ast.Identifier, nil:
+	default:
+		scan(lhs, out)
+	}
+}
+
+// assign records the writes of `lhs op rhs`. A plain `=` to a tuple pairs
+// components up when both sides have the same arity.
+func assign(lhs, rhs, node ast.Node, plain bool, out *[]access) {
+	tuple, ok := lhs.(*ast.TupleExpression)
+	if !ok {
+		value := rhs
+		if !plain {
+			// x += v depends on the old x as well as v
+			value = node
+		}
+		writeTarget(lhs, node, value, out)
+		return
+	}
+	values := splitTuple(rhs, len(tuple.Components))
+	for i, c := range tuple.Components {
+		if c != nil {
+			writeTarget(c, node, values[i], out)
+		}
+	}
+}
+
+func writeTarget(lhs, node, value ast.Node, out *[]access) {
+	name := baseName(lhs)
+	if name == "" {
+		return
+	}
+	_, whole := lhs.(*ast.Identifier)
+	*out = append(*out, access{kind: accessWrite, name: name, node: node, whole: whole, value: value})
+}
+
+// splitTuple returns the value assigned to each of n targets: the matching
+// component of a same-arity tuple, else the whole expression
+func splitTuple(value ast.Node, n int) []ast.Node {
+	values := make([]ast.Node, n)
+	if tuple, ok := value.(*ast.TupleExpression); ok && len(tuple.Components) == n {
+		copy(values, tuple.Components)
+		return values
+	}
+	for i := range values {
+		values[i] = value
+	}
+	return values
+}
+
+// baseName returns the variable at the root of an lvalue such as x, x[i] or
+// x.f[i].g, or "" if there is none
+func baseName(n ast.Node) string {
+	switch v := n.(type) {
+	case *ast.Identifier:
+		return v.Name
+	case *ast.IndexAccess:
+		return baseName(v.Base)
+	case *ast.IndexRangeAccess:
+		return baseName(v.Base)
+	case *ast.MemberAccess:
+		return baseName(v.Expression)
+	}
+	return ""
+}
+
+// scanYul records the accesses of an assembly body. Names declared with `let`
+// are recorded too; analyses that track only Solidity variables ignore them.
+func scanYul(n ast.Node, out *[]access) {
+	switch v := n.(type) {
+	case nil:
+	case *ast.AssemblyBlock:
+		for _, op := range v.Operations {
+			scanYul(op, out)
+		}
+	case *ast.AssemblyIdentifier:
+		*out = append(*out, access{kind: accessRead, name: v.Name, node: v})
+	case *ast.Identifier:
+		*out = append(*out, access{kind: accessRead, name: v.Name, node: v})
+	case *ast.AssemblyMemberAccess:
+		scanYul(v.Expression, out)
+	case *ast.AssemblyCall:
+		for _, arg := range v.Arguments {
+			scanYul(arg, out)
+		}
+	case *ast.AssemblyLocalDefinition:
+		scanYul(v.Expression, out)
+		for _, id := range v.Names {
+			// p.slot := v repoints the storage reference p
+			name, _, _ := strings.Cut(id.Name, ".")
+			*out = append(*out, access{kind: accessWrite, name: name, node: v, whole: true, value: v.Expression})
+		}
+	case *ast.AssemblyAssignment:
+		scanYul(v.Expression, out)
+		for _, id := range v.Names {
+			// p.slot := v repoints the storage reference p
+			name, _, _ := strings.Cut(id.Name, ".")
+			*out = append(*out, access{kind: accessWrite, name: name, node: v, whole: true, value: v.Expression})
+		}
+	case *ast.AssemblyIf:
+		scanYul(v.Condition, out)
+		scanYul(v.Body, out)
+	case *ast.AssemblySwitch:
+		scanYul(v.Expression, out)
+		for _, c := range v.Cases {
+			scanYul(c.Body, out)
+		}
+	case *ast.AssemblyFor:
+		scanYul(v.Pre, out)
+		scanYul(v.Condition, out)
+		scanYul(v.Body, out)
+		scanYul(v.Post, out)
+	}
+}
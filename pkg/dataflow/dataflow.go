@@ -0,0 +1,235 @@
+// Package dataflow runs intra-procedural dataflow analyses over the control-flow
+// graphs of package cfg.
+//
+// Solve is a generic worklist engine: an Analysis supplies the lattice of facts
+// (Bottom/Join/Equal), a direction, the boundary fact, and a per-node transfer
+// function. The package ships reaching definitions, liveness and a configurable
+// taint analysis on top of it, so detectors become small queries over solved
+// facts rather than ad-hoc AST walks.
+package dataflow
+
+import (
+	"sort"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+	"github.com/th13vn/solast-go/pkg/cfg"
+)
+
+// Direction is the direction facts flow in
+type Direction int
+
+// Directions
+const (
+	Forward Direction = iota
+	Backward
+)
+
+// Lattice describes the facts an analysis computes. Join must be monotone and
+// the lattice of finite height, or Solve will not terminate.
+type Lattice[F any] interface {
+	// Bottom is the initial fact of every block
+	Bottom() F
+	// Join merges the facts of two incoming paths
+	Join(a, b F) F
+	// Equal reports whether two facts are the same
+	Equal(a, b F) bool
+}
+
+// Analysis is a dataflow problem over a single graph
+type Analysis[F any] interface {
+	Lattice[F]
+	Direction() Direction
+	// Boundary is the fact entering the graph: at blk == Entry for forward
+	// analyses, at every block without successors (Exit, Revert, Halt) for
+	// backward ones
+	Boundary(blk *cfg.BasicBlock) F
+	// Transfer returns the fact on the far side of n (after n for forward
+	// analyses, before n for backward ones). It must not modify in.
+	Transfer(n ast.Node, in F) F
+}
+
+// Result holds the solved facts of an analysis. In and Out are in program
+// order whatever the analysis direction: In is the fact at the start of a block
+// and Out the fact at its end.
+type Result[F any] struct {
+	Graph    *cfg.Graph
+	Analysis Analysis[F]
+	In       map[*cfg.BasicBlock]F
+	Out      map[*cfg.BasicBlock]F
+}
+
+// Solve runs a to a fixpoint over g with a worklist
+func Solve[F any](g *cfg.Graph, a Analysis[F]) *Result[F] {
+	r := &Result[F]{
+		Graph:    g,
+		Analysis: a,
+		In:       make(map[*cfg.BasicBlock]F, len(g.Blocks)),
+		Out:      make(map[*cfg.BasicBlock]F, len(g.Blocks)),
+	}
+	for _, blk := range g.Blocks {
+		r.In[blk] = a.Bottom()
+		r.Out[blk] = a.Bottom()
+	}
+
+	forward := a.Direction() == Forward
+	work := make([]*cfg.BasicBlock, 0, len(g.Blocks))
+	if forward {
+		work = append(work, g.Blocks...)
+	} else {
+		for i := len(g.Blocks) - 1; i >= 0; i-- {
+			work = append(work, g.Blocks[i])
+		}
+	}
+	queued := make(map[*cfg.BasicBlock]bool, len(g.Blocks))
+	for _, blk := range work {
+		queued[blk] = true
+	}
+	visited := make(map[*cfg.BasicBlock]bool, len(g.Blocks))
+
+	for len(work) > 0 {
+		blk := work[0]
+		work = work[1:]
+		queued[blk] = false
+
+		var next []*cfg.BasicBlock
+		if forward {
+			in := a.Bottom()
+			if blk == g.Entry {
+				in = a.Boundary(blk)
+			}
+			for _, e := range blk.Preds {
+				in = a.Join(in, r.Out[e.From])
+			}
+			out := in
+			for _, n := range blk.Nodes {
+				out = a.Transfer(n, out)
+			}
+			r.In[blk] = in
+			if visited[blk] && a.Equal(out, r.Out[blk]) {
+				continue
+			}
+			r.Out[blk] = out
+			for _, e := range blk.Succs {
+				next = append(next, e.To)
+			}
+		} else {
+			out := a.Bottom()
+			if len(blk.Succs) == 0 {
+				out = a.Boundary(blk)
+			}
+			for _, e := range blk.Succs {
+				out = a.Join(out, r.In[e.To])
+			}
+			in := out
+			for i := len(blk.Nodes) - 1; i >= 0; i-- {
+				in = a.Transfer(blk.Nodes[i], in)
+			}
+			r.Out[blk] = out
+			if visited[blk] && a.Equal(in, r.In[blk]) {
+				continue
+			}
+			r.In[blk] = in
+			for _, e := range blk.Preds {
+				next = append(next, e.From)
+			}
+		}
+		visited[blk] = true
+		for _, n := range next {
+			if !queued[n] {
+				queued[n] = true
+				work = append(work, n)
+			}
+		}
+	}
+	return r
+}
+
+// Before returns the fact just before blk.Nodes[i] in program order
+func (r *Result[F]) Before(blk *cfg.BasicBlock, i int) F {
+	if r.Analysis.Direction() == Forward {
+		f := r.In[blk]
+		for _, n := range blk.Nodes[:i] {
+			f = r.Analysis.Transfer(n, f)
+		}
+		return f
+	}
+	return r.After(blk, i-1)
+}
+
+// After returns the fact just after blk.Nodes[i] in program order
+func (r *Result[F]) After(blk *cfg.BasicBlock, i int) F {
+	if r.Analysis.Direction() == Forward {
+		return r.Before(blk, i+1)
+	}
+	f := r.Out[blk]
+	for j := len(blk.Nodes) - 1; j > i; j-- {
+		f = r.Analysis.Transfer(blk.Nodes[j], f)
+	}
+	return f
+}
+
+// Set is a finite set, the fact type of the bundled analyses
+type Set[T comparable] map[T]struct{}
+
+// NewSet returns a set holding items
+func NewSet[T comparable](items ...T) Set[T] {
+	s := make(Set[T], len(items))
+	for _, item := range items {
+		s[item] = struct{}{}
+	}
+	return s
+}
+
+// Has reports whether item is in s
+func (s Set[T]) Has(item T) bool {
+	_, ok := s[item]
+	return ok
+}
+
+// Copy returns a shallow copy of s
+func (s Set[T]) Copy() Set[T] {
+	c := make(Set[T], len(s))
+	for item := range s {
+		c[item] = struct{}{}
+	}
+	return c
+}
+
+// SetLattice is the powerset lattice ordered by inclusion, with union as join.
+// Analyses over sets embed it.
+type SetLattice[T comparable] struct{}
+
+// Bottom returns the empty set
+func (SetLattice[T]) Bottom() Set[T] { return Set[T]{} }
+
+// Join returns a new set holding the union of a and b
+func (SetLattice[T]) Join(a, b Set[T]) Set[T] {
+	u := a.Copy()
+	for item := range b {
+		u[item] = struct{}{}
+	}
+	return u
+}
+
+// Equal reports whether a and b hold the same items
+func (SetLattice[T]) Equal(a, b Set[T]) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for item := range a {
+		if !b.Has(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// Names returns the items of a string set in sorted order
+func Names(s Set[string]) []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
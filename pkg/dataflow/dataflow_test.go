@@ -0,0 +1,315 @@
+package dataflow
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+	"github.com/th13vn/solast-go/pkg/cfg"
+	"github.com/th13vn/solast-go/pkg/parser"
+)
+
+func buildGraph(t *testing.T, src, name string) (*ast.SourceUnit, *cfg.Graph) {
+	t.Helper()
+	unit, err := parser.Parse(src, &parser.Options{Loc: true})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	for _, g := range cfg.BuildAll(unit) {
+		if g.Name == name {
+			return unit, g
+		}
+	}
+	t.Fatalf("graph %s not built", name)
+	return nil, nil
+}
+
+// findNode returns the block and index of the first node matching pred
+func findNode(g *cfg.Graph, pred func(ast.Node) bool) (*cfg.BasicBlock, int) {
+	for _, blk := range g.Blocks {
+		for i, n := range blk.Nodes {
+			if pred(n) {
+				return blk, i
+			}
+		}
+	}
+	return nil, -1
+}
+
+func isReturn(n ast.Node) bool {
+	_, ok := n.(*ast.ReturnStatement)
+	return ok
+}
+
+func TestReachingDefinitions(t *testing.T) {
+	_, g := buildGraph(t, `
+contract C {
+	function f(uint a, bool c) public returns (uint) {
+		uint x = 1;
+		if (c) {
+			x = a;
+		}
+		uint[] memory arr = new uint[](2);
+		arr[0] = x;
+		return x;
+	}
+}`, "C.f")
+	r := Solve[Set[Definition]](g, NewReachingDefinitions(g))
+	blk, i := findNode(g, isReturn)
+	before := r.Before(blk, i)
+
+	if defs := DefinitionsOf(before, "x"); len(defs) != 2 {
+		t.Errorf("expected both definitions of x to reach the return, got %d", len(defs))
+	}
+	if defs := DefinitionsOf(before, "a"); len(defs) != 1 {
+		t.Errorf("expected the parameter definition of a, got %d", len(defs))
+	} else if _, ok := defs[0].Node.(*ast.VariableDeclaration); !ok {
+		t.Errorf("parameter definition should be its declaration, got %T", defs[0].Node)
+	}
+	// arr[0] = x updates arr without killing its declaration
+	if defs := DefinitionsOf(before, "arr"); len(defs) != 2 {
+		t.Errorf("expected declaration and element write of arr, got %d", len(defs))
+	}
+}
+
+func TestLivenessUncheckedReturn(t *testing.T) {
+	// An "unchecked return value" query: ok is dead right after the call
+	_, g := buildGraph(t, `
+contract C {
+	function f(address t) public returns (uint r) {
+		(bool ok, ) = t.call("");
+		uint y = 2;
+		r = y;
+	}
+}`, "C.f")
+	r := Solve[Set[string]](g, NewLiveness(g))
+	blk, i := findNode(g, func(n ast.Node) bool {
+		_, ok := n.(*ast.VariableDeclarationStatement)
+		return ok
+	})
+	if live := r.After(blk, i); live.Has("ok") {
+		t.Error("ok is never read and should be dead after the call")
+	}
+	if live := r.Before(blk, i); !live.Has("t") {
+		t.Error("t is read by the call and should be live before it")
+	}
+	// named return parameter r is live at exit
+	if !r.In[g.Exit].Has("r") {
+		t.Error("named return parameter should be live at exit")
+	}
+	if got := Names(r.In[g.Entry]); !reflect.DeepEqual(got, []string{"t"}) {
+		t.Errorf("expected only t live at entry, got %v", got)
+	}
+}
+
+func TestLivenessLoop(t *testing.T) {
+	_, g := buildGraph(t, `
+contract C {
+	function f(uint n) public returns (uint) {
+		uint sum = 0;
+		for (uint i = 0; i < n; i++) {
+			sum += i;
+		}
+		return sum;
+	}
+}`, "C.f")
+	r := Solve[Set[string]](g, NewLiveness(g))
+	for _, blk := range g.Blocks {
+		if blk.Kind != cfg.BlockCondition {
+			continue
+		}
+		live := r.In[blk]
+		for _, name := range []string{"i", "n", "sum"} {
+			if !live.Has(name) {
+				t.Errorf("%s should be live at the loop header, got %v", name, Names(live))
+			}
+		}
+	}
+}
+
+func TestTaintDelegatecallTarget(t *testing.T) {
+	unit, g := buildGraph(t, `
+contract Proxy {
+	address impl;
+	address owner;
+	function upgrade(address next) public {
+		impl = next;
+	}
+	function forward(address target, bytes calldata data) public payable {
+		address to = target;
+		to.delegatecall(data);
+		address stored = impl;
+		stored.delegatecall("");
+		to = stored;
+		to.delegatecall("");
+		payable(owner).call{value: msg.value}("");
+		selfdestruct(payable(msg.sender));
+	}
+}`, "Proxy.forward")
+	contract := unit.Children[0].(*ast.ContractDefinition)
+	conf := TaintConfig{
+		Sources:        SourceParameters | SourceMsgValue | SourceMsgSender,
+		Sinks:          AllSinks,
+		StateVariables: StateVariables(contract),
+	}
+	var got []string
+	for _, f := range Taint(g, conf) {
+		got = append(got, f.Sink.String()+":"+f.Role)
+	}
+	want := []string{"delegatecall:target", "delegatecall:argument", "call:value", "selfdestruct:argument"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+
+	// Only calldata parameters as sources: the target is no longer tainted
+	conf.Sources = SourceCalldata
+	got = got[:0]
+	for _, f := range Taint(g, conf) {
+		got = append(got, f.Sink.String()+":"+f.Role)
+	}
+	if !reflect.DeepEqual(got, []string{"delegatecall:argument"}) {
+		t.Errorf("calldata-only findings = %v", got)
+	}
+}
+
+func TestTaintStorageWrite(t *testing.T) {
+	unit, g := buildGraph(t, `
+contract C {
+	address owner;
+	mapping(address => uint) balances;
+	uint[] queue;
+	function f(uint amount) public {
+		balances[msg.sender] = balances[msg.sender] + 1;
+		owner = msg.sender;
+		queue.push(amount);
+	}
+}`, "C.f")
+	conf := TaintConfig{
+		Sources:        SourceMsgSender | SourceParameters,
+		Sinks:          SinkStorageWrite,
+		StateVariables: StateVariables(unit.Children[0].(*ast.ContractDefinition)),
+	}
+	var got []string
+	for _, f := range Taint(g, conf) {
+		got = append(got, f.Role)
+	}
+	if !reflect.DeepEqual(got, []string{"owner", "queue"}) {
+		t.Errorf("storage-write findings = %v, want [owner queue]", got)
+	}
+
+	// Parameters and locals shadow state variables of the same name, in
+	// their scope only
+	unit, g = buildGraph(t, `
+contract C {
+	address owner;
+	uint total;
+	function f(address owner, uint amount) public {
+		owner = msg.sender;
+		{
+			uint total = amount;
+			total = amount;
+		}
+		total = amount;
+	}
+}`, "C.f")
+	conf.StateVariables = StateVariables(unit.Children[0].(*ast.ContractDefinition))
+	got = got[:0]
+	for _, f := range Taint(g, conf) {
+		got = append(got, f.Role)
+	}
+	if !reflect.DeepEqual(got, []string{"total"}) || len(Taint(g, conf)) != 1 {
+		t.Errorf("storage-write findings with shadowing = %v, want [total]", got)
+	}
+}
+
+func TestTaintAssembly(t *testing.T) {
+	unit, g := buildGraph(t, `
+contract C {
+	address owner;
+	function f() public payable {
+		address target;
+		address who;
+		uint v;
+		assembly {
+			let x := calldataload(4)
+			target := x
+			who := caller()
+			v := add(callvalue(), 1)
+		}
+		target.delegatecall("");
+		owner = who;
+		payable(owner).call{value: v}("");
+		assembly { target := 0 }
+		target.delegatecall("");
+	}
+}`, "C.f")
+	conf := TaintConfig{
+		Sources:        AllSources,
+		Sinks:          AllSinks,
+		StateVariables: StateVariables(unit.Children[0].(*ast.ContractDefinition)),
+	}
+	var got []string
+	for _, f := range Taint(g, conf) {
+		got = append(got, f.Sink.String()+":"+f.Role)
+	}
+	want := []string{"delegatecall:target", "storage-write:owner", "call:target", "call:value"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+
+	// Each Yul builtin follows its source
+	conf.Sources = SourceCalldata
+	got = got[:0]
+	for _, f := range Taint(g, conf) {
+		got = append(got, f.Sink.String()+":"+f.Role)
+	}
+	if !reflect.DeepEqual(got, []string{"delegatecall:target"}) {
+		t.Errorf("calldata-only findings = %v", got)
+	}
+}
+
+func TestTaintAssemblySinks(t *testing.T) {
+	_, g := buildGraph(t, `
+contract C {
+	function f(address target, uint slot) public payable {
+		assembly {
+			let ok := call(gas(), target, callvalue(), 0, 0, 0, 0)
+			let impl := sload(0)
+			ok := delegatecall(gas(), impl, 0, 0, 0, 0)
+			let to := calldataload(4)
+			ok := staticcall(gas(), to, 0, 0, 0, 0)
+			sstore(slot, caller())
+			selfdestruct(to)
+		}
+	}
+}`, "C.f")
+	conf := TaintConfig{Sources: AllSources, Sinks: AllSinks}
+	var got []string
+	for _, f := range Taint(g, conf) {
+		got = append(got, f.Sink.String()+":"+f.Role)
+	}
+	want := []string{"call:target", "call:value", "staticcall:target", "storage-write:slot", "storage-write:value", "selfdestruct:argument"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+}
+
+func TestTaintAssemblyPaths(t *testing.T) {
+	_, g := buildGraph(t, `
+contract C {
+	function f(uint[] storage p, bytes calldata d) internal {
+		assembly {
+			sstore(p.slot, 0)
+			sstore(0, d.offset)
+		}
+	}
+}`, "C.f")
+	var got []string
+	for _, f := range Taint(g, TaintConfig{Sources: SourceParameters, Sinks: AllSinks}) {
+		got = append(got, f.Sink.String()+":"+f.Role)
+	}
+	// p.slot is fixed by the layout, d.offset follows d
+	if !reflect.DeepEqual(got, []string{"storage-write:value"}) {
+		t.Errorf("findings = %v, want [storage-write:value]", got)
+	}
+}
@@ -0,0 +1,79 @@
+package dataflow
+
+import (
+	"github.com/th13vn/solast-go/pkg/ast"
+	"github.com/th13vn/solast-go/pkg/cfg"
+)
+
+// Liveness is the backward analysis of which local variables may still be read
+// before being overwritten. Only parameters, return parameters and locals
+// declared in the graph are tracked; state variables are always live.
+type Liveness struct {
+	SetLattice[string]
+	g       *cfg.Graph
+	locals  Set[string]
+	returns []string
+}
+
+// NewLiveness returns the analysis for g. Named return parameters are live at
+// Exit, since they are returned implicitly.
+func NewLiveness(g *cfg.Graph) *Liveness {
+	a := &Liveness{g: g, locals: Set[string]{}}
+	for _, p := range parameters(g.Node, true) {
+		a.locals[p.Name] = struct{}{}
+	}
+	if fn, ok := g.Node.(*ast.FunctionDefinition); ok {
+		for _, p := range fn.ReturnParameters {
+			if p != nil && p.Name != "" {
+				a.returns = append(a.returns, p.Name)
+			}
+		}
+	}
+	for _, blk := range g.Blocks {
+		for _, n := range blk.Nodes {
+			if decl, ok := n.(*ast.VariableDeclarationStatement); ok {
+				for _, v := range decl.Variables {
+					if v != nil && v.Name != "" {
+						a.locals[v.Name] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+	return a
+}
+
+// Direction implements Analysis
+func (*Liveness) Direction() Direction { return Backward }
+
+// Boundary implements Analysis
+func (a *Liveness) Boundary(blk *cfg.BasicBlock) Set[string] {
+	if blk == a.g.Exit {
+		return NewSet(a.returns...)
+	}
+	return Set[string]{}
+}
+
+// Transfer implements Analysis
+func (a *Liveness) Transfer(n ast.Node, out Set[string]) Set[string] {
+	accs := accesses(n)
+	in := out.Copy()
+	for i := len(accs) - 1; i >= 0; i-- {
+		acc := accs[i]
+		if !a.locals.Has(acc.name) {
+			continue
+		}
+		switch {
+		case acc.kind == accessRead:
+			in[acc.name] = struct{}{}
+		case acc.whole:
+			delete(in, acc.name)
+		}
+	}
+	return in
+}
+
+// Locals returns the variables the analysis tracks
+func (a *Liveness) Locals() Set[string] {
+	return a.locals
+}
@@ -0,0 +1,141 @@
+package dataflow
+
+import (
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// write identifies one variable written by a CFG node
+type write struct {
+	node ast.Node
+	name string
+}
+
+// localWrites resolves the writes in the body of a function or modifier by
+// block scope: it returns those whose variable is a parameter or a local
+// declared in an enclosing scope, and so not a state variable of the same
+// name. Yul writes are always local; assembly reaches storage through sstore.
+func localWrites(def ast.Node) map[write]bool {
+	s := &scopes{local: make(map[write]bool)}
+	s.push()
+	for _, p := range parameters(def, true) {
+		s.declare(p.Name)
+	}
+	switch def := def.(type) {
+	case *ast.FunctionDefinition:
+		s.statement(def.Body)
+	case *ast.ModifierDefinition:
+		s.statement(def.Body)
+	}
+	return s.local
+}
+
+// scopes is the stack of names declared in the enclosing blocks
+type scopes struct {
+	stack []Set[string]
+	local map[write]bool
+}
+
+func (s *scopes) push() { s.stack = append(s.stack, Set[string]{}) }
+func (s *scopes) pop()  { s.stack = s.stack[:len(s.stack)-1] }
+
+func (s *scopes) declare(name string) {
+	if name != "" {
+		s.stack[len(s.stack)-1][name] = struct{}{}
+	}
+}
+
+func (s *scopes) declared(name string) bool {
+	for _, scope := range s.stack {
+		if scope.Has(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// statement declares what n declares and records the writes made in it
+func (s *scopes) statement(n ast.Node) {
+	switch v := n.(type) {
+	case nil:
+	case *ast.Block:
+		if v == nil {
+			return
+		}
+		s.push()
+		for _, stmt := range v.Statements {
+			s.statement(stmt)
+		}
+		s.pop()
+	case *ast.UncheckedBlock:
+		s.statement(v.Body)
+	case *ast.VariableDeclarationStatement:
+		for _, decl := range v.Variables {
+			if decl != nil {
+				s.declare(decl.Name)
+			}
+		}
+		s.record(v)
+	case *ast.IfStatement:
+		s.record(v.Condition)
+		s.scoped(v.TrueBody)
+		s.scoped(v.FalseBody)
+	case *ast.WhileStatement:
+		s.record(v.Condition)
+		s.scoped(v.Body)
+	case *ast.DoWhileStatement:
+		s.scoped(v.Body)
+		s.record(v.Condition)
+	case *ast.ForStatement:
+		s.push()
+		s.statement(v.InitExpression)
+		s.record(v.ConditionExpression)
+		s.record(v.LoopExpression)
+		s.scoped(v.Body)
+		s.pop()
+	case *ast.TryStatement:
+		s.record(v.Expression)
+		s.push()
+		for _, p := range v.ReturnParameters {
+			if p != nil {
+				s.declare(p.Name)
+			}
+		}
+		s.statement(v.Body)
+		s.pop()
+		for _, clause := range v.CatchClauses {
+			s.push()
+			for _, p := range clause.Parameters {
+				if p != nil {
+					s.declare(p.Name)
+				}
+			}
+			s.statement(clause.Body)
+			s.pop()
+		}
+	case *ast.InlineAssembly:
+		for _, acc := range accesses(v) {
+			if acc.kind == accessWrite {
+				s.local[write{acc.node, acc.name}] = true
+			}
+		}
+	default:
+		s.record(n)
+	}
+}
+
+// scoped handles a statement in a scope of its own, as a loop or branch body
+// that is not a block
+func (s *scopes) scoped(n ast.Node) {
+	s.push()
+	s.statement(n)
+	s.pop()
+}
+
+// record marks the writes of n to declared names as local
+func (s *scopes) record(n ast.Node) {
+	for _, acc := range accesses(n) {
+		if acc.kind == accessWrite && s.declared(acc.name) {
+			s.local[write{acc.node, acc.name}] = true
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package dataflow
+
+import (
+	"github.com/th13vn/solast-go/pkg/ast"
+	"github.com/th13vn/solast-go/pkg/cfg"
+)
+
+// Definition is a point that gives variable Var a value: a parameter's
+// VariableDeclaration, a local's VariableDeclaration, or the assignment,
+// ++/--, delete or push/pop expression
+type Definition struct {
+	Var  string
+	Node ast.Node
+}
+
+// ReachingDefinitions is the forward may-analysis of which definitions can
+// reach each point. Assigning a whole variable kills its earlier definitions;
+// writing part of it (x[i] = v) does not.
+type ReachingDefinitions struct {
+	SetLattice[Definition]
+	params []*ast.VariableDeclaration
+}
+
+// NewReachingDefinitions returns the analysis for g. Parameters and named
+// return parameters of the graph's function or modifier are defined at Entry.
+func NewReachingDefinitions(g *cfg.Graph) *ReachingDefinitions {
+	return &ReachingDefinitions{params: parameters(g.Node, true)}
+}
+
+// Direction implements Analysis
+func (*ReachingDefinitions) Direction() Direction { return Forward }
+
+// Boundary implements Analysis
+func (a *ReachingDefinitions) Boundary(*cfg.BasicBlock) Set[Definition] {
+	s := Set[Definition]{}
+	for _, p := range a.params {
+		s[Definition{Var: p.Name, Node: p}] = struct{}{}
+	}
+	return s
+}
+
+// Transfer implements Analysis
+func (a *ReachingDefinitions) Transfer(n ast.Node, in Set[Definition]) Set[Definition] {
+	out := in
+	copied := false
+	for _, acc := range accesses(n) {
+		if acc.kind != accessWrite {
+			continue
+		}
+		if !copied {
+			out = in.Copy()
+			copied = true
+		}
+		if acc.whole {
+			for d := range out {
+				if d.Var == acc.name {
+					delete(out, d)
+				}
+			}
+		}
+		out[Definition{Var: acc.name, Node: acc.node}] = struct{}{}
+	}
+	return out
+}
+
+// DefinitionsOf returns the definitions of name in s
+func DefinitionsOf(s Set[Definition], name string) []Definition {
+	var defs []Definition
+	for d := range s {
+		if d.Var == name {
+			defs = append(defs, d)
+		}
+	}
+	return defs
+}
+
+// parameters returns the named parameters (and, with returns, named return
+// parameters) of a FunctionDefinition or ModifierDefinition
+func parameters(n ast.Node, returns bool) []*ast.VariableDeclaration {
+	var params []*ast.VariableDeclaration
+	switch def := n.(type) {
+	case *ast.FunctionDefinition:
+		params = append(params, def.Parameters...)
+		if returns {
+			params = append(params, def.ReturnParameters...)
+		}
+	case *ast.ModifierDefinition:
+		params = append(params, def.Parameters...)
+	}
+	named := params[:0]
+	for _, p := range params {
+		if p != nil && p.Name != "" {
+			named = append(named, p)
+		}
+	}
+	return named
+}
@@ -0,0 +1,377 @@
+package dataflow
+
+import (
+	"github.com/th13vn/solast-go/pkg/ast"
+	"github.com/th13vn/solast-go/pkg/cfg"
+)
+
+// Source is a set of taint sources, combined with |
+type Source int
+
+// Taint sources
+const (
+	// SourceMsgSender taints `msg.sender` and `tx.origin`, and Yul's
+	// caller() and origin()
+	SourceMsgSender Source = 1 << iota
+	// SourceMsgValue taints `msg.value` and Yul's callvalue()
+	SourceMsgValue
+	// SourceParameters taints every parameter of the function
+	SourceParameters
+	// SourceCalldata taints `msg.data`, `calldata` parameters, and Yul's
+	// calldataload() and calldatasize()
+	SourceCalldata
+
+	AllSources = SourceMsgSender | SourceMsgValue | SourceParameters | SourceCalldata
+)
+
+// Sink is a set of taint sinks, combined with |
+type Sink int
+
+// Taint sinks
+const (
+	// SinkCall reports tainted targets, values and data of `.call`, and
+	// targets and values of Yul's call()
+	SinkCall Sink = 1 << iota
+	// SinkDelegatecall reports tainted targets and data of `.delegatecall`,
+	// and targets of Yul's delegatecall() and callcode()
+	SinkDelegatecall
+	// SinkSelfdestruct reports a tainted `selfdestruct` beneficiary, in
+	// Solidity or Yul
+	SinkSelfdestruct
+	// SinkStorageWrite reports tainted values written to state variables,
+	// and tainted slots and values of Yul's sstore()
+	SinkStorageWrite
+	// SinkStaticcall reports tainted targets and data of `.staticcall`, and
+	// targets of Yul's staticcall()
+	SinkStaticcall
+
+	AllSinks = SinkCall | SinkDelegatecall | SinkSelfdestruct | SinkStorageWrite | SinkStaticcall
+)
+
+// String returns the sink name
+func (s Sink) String() string {
+	switch s {
+	case SinkCall:
+		return "call"
+	case SinkDelegatecall:
+		return "delegatecall"
+	case SinkSelfdestruct:
+		return "selfdestruct"
+	case SinkStorageWrite:
+		return "storage-write"
+	case SinkStaticcall:
+		return "staticcall"
+	}
+	return "sinks"
+}
+
+// TaintConfig selects the sources and sinks of a taint analysis
+type TaintConfig struct {
+	Sources Source
+	Sinks   Sink
+	// StateVariables names the state variables visible to the function;
+	// writes to them are storage writes (see StateVariables)
+	StateVariables Set[string]
+}
+
+// Finding is a tainted value reaching a sink
+type Finding struct {
+	Sink Sink
+	// Role says which part of the sink is tainted: "target", "value",
+	// "argument", the written variable's name for storage writes, or "slot"
+	// and "value" for sstore
+	Role  string
+	Block *cfg.BasicBlock
+	// Node is the block node (statement or condition) holding the sink
+	Node ast.Node
+	// Expr is the tainted expression
+	Expr ast.Node
+}
+
+// TaintAnalysis is the forward may-analysis of which variables can hold
+// attacker-influenced values. Assigning an untainted value to a whole variable
+// clears its taint; implicit flows through branches are not tracked, and an
+// index does not taint the element it selects (balances[msg.sender] is not
+// tainted, balances[i] with a tainted balances is).
+type TaintAnalysis struct {
+	SetLattice[string]
+	Config TaintConfig
+	params []*ast.VariableDeclaration
+	// locals are the writes to parameters and locals, which shadow state
+	// variables of the same name
+	locals map[write]bool
+}
+
+// NewTaint returns the taint analysis for g
+func NewTaint(g *cfg.Graph, conf TaintConfig) *TaintAnalysis {
+	return &TaintAnalysis{Config: conf, params: parameters(g.Node, false), locals: localWrites(g.Node)}
+}
+
+// Direction implements Analysis
+func (*TaintAnalysis) Direction() Direction { return Forward }
+
+// Boundary implements Analysis
+func (a *TaintAnalysis) Boundary(*cfg.BasicBlock) Set[string] {
+	s := Set[string]{}
+	for _, p := range a.params {
+		if a.Config.Sources&SourceParameters != 0 ||
+			(a.Config.Sources&SourceCalldata != 0 && p.StorageLocation == "calldata") {
+			s[p.Name] = struct{}{}
+		}
+	}
+	return s
+}
+
+// Transfer implements Analysis
+func (a *TaintAnalysis) Transfer(n ast.Node, in Set[string]) Set[string] {
+	out := in
+	copied := false
+	for _, acc := range accesses(n) {
+		if acc.kind != accessWrite {
+			continue
+		}
+		tainted := a.Tainted(acc.value, out)
+		if tainted == out.Has(acc.name) || (!tainted && !acc.whole) {
+			continue
+		}
+		if !copied {
+			out = in.Copy()
+			copied = true
+		}
+		if tainted {
+			out[acc.name] = struct{}{}
+		} else {
+			delete(out, acc.name)
+		}
+	}
+	return out
+}
+
+// Tainted reports whether expr may carry a tainted value when the variables in
+// vars are tainted
+func (a *TaintAnalysis) Tainted(expr ast.Node, vars Set[string]) bool {
+	switch v := expr.(type) {
+	case nil:
+		return false
+	case *ast.Identifier:
+		return vars.Has(v.Name)
+	case *ast.MemberAccess:
+		if a.isSource(v) {
+			return true
+		}
+		return a.Tainted(v.Expression, vars)
+	case *ast.IndexAccess:
+		return a.Tainted(v.Base, vars)
+	case *ast.IndexRangeAccess:
+		return a.Tainted(v.Base, vars)
+	case *ast.BinaryOperation:
+		return a.Tainted(v.Left, vars) || a.Tainted(v.Right, vars)
+	case *ast.UnaryOperation:
+		return v.Operator != "delete" && a.Tainted(v.SubExpression, vars)
+	case *ast.Conditional:
+		return a.Tainted(v.TrueExpression, vars) || a.Tainted(v.FalseExpression, vars)
+	case *ast.TupleExpression:
+		for _, c := range v.Components {
+			if a.Tainted(c, vars) {
+				return true
+			}
+		}
+	case *ast.FunctionCall:
+		if _, named := v.Expression.(*ast.Identifier); !named && a.Tainted(v.Expression, vars) {
+			return true
+		}
+		for _, arg := range v.Arguments {
+			if a.Tainted(arg, vars) {
+				return true
+			}
+		}
+	case *ast.FunctionCallOptions:
+		return a.Tainted(v.Expression, vars)
+	case *ast.NameValueExpression:
+		return a.Tainted(v.Expression, vars)
+	case *ast.AssemblyIdentifier:
+		return vars.Has(v.Name)
+	case *ast.AssemblyMemberAccess:
+		// x.slot is fixed by the layout; x.offset and x.length follow x
+		return v.MemberName.Name != "slot" && a.Tainted(v.Expression, vars)
+	case *ast.AssemblyCall:
+		if a.isYulSource(v.FunctionName) {
+			return true
+		}
+		for _, arg := range v.Arguments {
+			if a.Tainted(arg, vars) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *TaintAnalysis) isSource(ma *ast.MemberAccess) bool {
+	id, ok := ma.Expression.(*ast.Identifier)
+	if !ok {
+		return false
+	}
+	switch id.Name + "." + ma.MemberName {
+	case "msg.sender", "tx.origin":
+		return a.Config.Sources&SourceMsgSender != 0
+	case "msg.value":
+		return a.Config.Sources&SourceMsgValue != 0
+	case "msg.data":
+		return a.Config.Sources&SourceCalldata != 0
+	}
+	return false
+}
+
+// isYulSource reports whether a Yul builtin reads a configured source
+func (a *TaintAnalysis) isYulSource(name string) bool {
+	switch name {
+	case "caller", "origin":
+		return a.Config.Sources&SourceMsgSender != 0
+	case "callvalue":
+		return a.Config.Sources&SourceMsgValue != 0
+	case "calldataload", "calldatasize":
+		return a.Config.Sources&SourceCalldata != 0
+	}
+	return false
+}
+
+// Findings returns the sinks reached by tainted values in a solved taint
+// result
+func (a *TaintAnalysis) Findings(r *Result[Set[string]]) []Finding {
+	var findings []Finding
+	for _, blk := range r.Graph.Blocks {
+		vars := r.In[blk]
+		for _, n := range blk.Nodes {
+			a.sinks(n, vars, func(sink Sink, role string, expr ast.Node) {
+				findings = append(findings, Finding{Sink: sink, Role: role, Block: blk, Node: n, Expr: expr})
+			})
+			vars = a.Transfer(n, vars)
+		}
+	}
+	return findings
+}
+
+// sinks calls report for every tainted sink operand inside node n, which runs
+// with the variables in vars tainted
+func (a *TaintAnalysis) sinks(n ast.Node, vars Set[string], report func(Sink, string, ast.Node)) {
+	if _, ok := n.(*ast.InlineAssembly); ok {
+		// Yul is not split into blocks: its sinks see whatever any of its
+		// statements may taint
+		vars = a.assemblyTaint(n, vars)
+	}
+	check := func(sink Sink, role string, expr ast.Node) {
+		if a.Config.Sinks&sink != 0 && a.Tainted(expr, vars) {
+			report(sink, role, expr)
+		}
+	}
+	for _, acc := range accesses(n) {
+		if acc.kind == accessWrite && a.Config.StateVariables.Has(acc.name) && !a.locals[write{acc.node, acc.name}] {
+			check(SinkStorageWrite, acc.name, acc.value)
+		}
+	}
+	ast.WalkSimple(n, &ast.SimpleVisitor{
+		FunctionCallFn: func(call *ast.FunctionCall) {
+			callee := call.Expression
+			var value ast.Node
+			if opts, ok := callee.(*ast.FunctionCallOptions); ok {
+				for i, name := range opts.Names {
+					if name == "value" && i < len(opts.Options) {
+						value = opts.Options[i]
+					}
+				}
+				callee = opts.Expression
+			}
+			switch c := callee.(type) {
+			case *ast.MemberAccess:
+				sink := SinkCall
+				switch c.MemberName {
+				case "call":
+				case "delegatecall":
+					sink = SinkDelegatecall
+				case "staticcall":
+					sink = SinkStaticcall
+				default:
+					return
+				}
+				check(sink, "target", c.Expression)
+				if value != nil {
+					check(sink, "value", value)
+				}
+				for _, arg := range call.Arguments {
+					check(sink, "argument", arg)
+				}
+			case *ast.Identifier:
+				if c.Name == "selfdestruct" || c.Name == "suicide" {
+					for _, arg := range call.Arguments {
+						check(SinkSelfdestruct, "argument", arg)
+					}
+				}
+			}
+		},
+		AssemblyCallFn: func(call *ast.AssemblyCall) {
+			// Data goes through memory, which is not tracked
+			args := call.Arguments
+			switch {
+			case call.FunctionName == "call" && len(args) == 7:
+				check(SinkCall, "target", args[1])
+				check(SinkCall, "value", args[2])
+			case call.FunctionName == "callcode" && len(args) == 7:
+				check(SinkDelegatecall, "target", args[1])
+			case call.FunctionName == "delegatecall" && len(args) == 6:
+				check(SinkDelegatecall, "target", args[1])
+			case call.FunctionName == "staticcall" && len(args) == 6:
+				check(SinkStaticcall, "target", args[1])
+			case call.FunctionName == "selfdestruct" && len(args) == 1:
+				check(SinkSelfdestruct, "argument", args[0])
+			case call.FunctionName == "sstore" && len(args) == 2:
+				check(SinkStorageWrite, "slot", args[0])
+				check(SinkStorageWrite, "value", args[1])
+			}
+		},
+	})
+}
+
+// assemblyTaint returns vars with every variable an assembly block may taint
+// added, whatever the order and repetition of its statements
+func (a *TaintAnalysis) assemblyTaint(n ast.Node, vars Set[string]) Set[string] {
+	out := vars.Copy()
+	for changed := true; changed; {
+		changed = false
+		for name := range a.Transfer(n, out) {
+			if !out.Has(name) {
+				out[name] = struct{}{}
+				changed = true
+			}
+		}
+	}
+	return out
+}
+
+// Taint solves the taint analysis of g and returns its findings
+func Taint(g *cfg.Graph, conf TaintConfig) []Finding {
+	a := NewTaint(g, conf)
+	return a.Findings(Solve[Set[string]](g, a))
+}
+
+// StateVariables returns the names of the state variables declared in
+// contracts. Pass a contract together with its bases to cover inherited
+// variables.
+func StateVariables(contracts ...*ast.ContractDefinition) Set[string] {
+	s := Set[string]{}
+	for _, c := range contracts {
+		if c == nil {
+			continue
+		}
+		for _, sub := range c.SubNodes {
+			if decl, ok := sub.(*ast.StateVariableDeclaration); ok {
+				for _, v := range decl.Variables {
+					if v != nil && v.Name != "" && !v.IsDeclaredConst && !v.IsImmutable {
+						s[v.Name] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+	return s
+}
@@ -0,0 +1,151 @@
+package lsp
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/th13vn/solast-go/internal/lexer"
+	"github.com/th13vn/solast-go/pkg/ast"
+	"github.com/th13vn/solast-go/pkg/parser"
+)
+
+// document is an open text document with its parse results. The AST keeps
+// byte positions; they are converted to LSP's UTF-16 positions on the way
+// out.
+type document struct {
+	uri     string
+	version int
+	text    string
+	unit    *ast.SourceUnit
+	errs    []*parser.Error
+	tokens  []lexer.Token
+	mapper  *parser.PositionMapper
+	index   *index
+}
+
+func newDocument(uri string, version int, text string) *document {
+	doc := &document{uri: uri, version: version, text: text, mapper: parser.NewPositionMapper(text)}
+	doc.tokens = lexer.New(text).Tokenize()
+	unit, errs, err := parser.ParseWithErrors(text, &parser.Options{Tolerant: true, Loc: true, Range: true})
+	if perr, ok := err.(*parser.ParserError); ok {
+		errs = perr.Errors
+	}
+	doc.unit, doc.errs = unit, errs
+	doc.index = resolve(unit, text, doc.tokens)
+	return doc
+}
+
+// position converts a byte offset to an LSP position
+func (doc *document) position(offset int) Position {
+	p := doc.mapper.Position(offset, parser.UnitByte)
+	p = doc.mapper.ConvertPosition(p, parser.UnitByte, parser.UnitUTF16)
+	return Position{Line: p.Line - 1, Character: p.Column}
+}
+
+// offset converts an LSP position to a byte offset. As LSP specifies, a
+// character outside its line is clamped to the line, and a line outside the
+// document to its start or end.
+func (doc *document) offset(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line+1 > doc.mapper.Position(len(doc.text), parser.UnitByte).Line {
+		return len(doc.text)
+	}
+	start := doc.mapper.Offset(ast.Position{Line: pos.Line + 1}, parser.UnitByte)
+	end := len(doc.text)
+	if i := strings.IndexByte(doc.text[start:], '\n'); i >= 0 {
+		end = start + i
+		if end > start && doc.text[end-1] == '\r' {
+			end--
+		}
+	}
+	if pos.Character <= 0 {
+		return start
+	}
+	p := doc.mapper.ConvertPosition(ast.Position{Line: pos.Line + 1, Column: pos.Character}, parser.UnitUTF16, parser.UnitByte)
+	if off := start + p.Column; off < end {
+		return off
+	}
+	return end
+}
+
+// span converts a byte range to an LSP range
+func (doc *document) span(start, end int) Range {
+	return Range{Start: doc.position(start), End: doc.position(end)}
+}
+
+// nodeSpan returns the LSP range of a node
+func (doc *document) nodeSpan(n ast.Node) Range {
+	if rng := n.GetRange(); rng != nil {
+		return doc.span(rng[0], rng[1])
+	}
+	return Range{}
+}
+
+// tokenAt returns the index of the first token ending after offset
+func (doc *document) tokenAt(offset int) int {
+	return sort.Search(len(doc.tokens), func(i int) bool { return doc.tokens[i].End > offset })
+}
+
+// diagnostics reports the parse errors, each spanning the token it was
+// raised at
+func (doc *document) diagnostics() []Diagnostic {
+	out := []Diagnostic{}
+	for _, e := range doc.errs {
+		start := doc.mapper.Offset(ast.Position{Line: e.Line, Column: e.Column}, parser.UnitByte)
+		if start > len(doc.text) {
+			start = len(doc.text)
+		}
+		end := start
+		if i := doc.tokenAt(start); i < len(doc.tokens) && doc.tokens[i].Start == start {
+			end = doc.tokens[i].End
+		}
+		out = append(out, Diagnostic{
+			Range:    doc.span(start, end),
+			Severity: SeverityError,
+			Code:     string(e.Code),
+			Source:   "solast",
+			Message:  e.Message,
+		})
+	}
+	return out
+}
+
+// comments returns the byte spans of the comments, found in the gaps the
+// lexer leaves between tokens
+func (doc *document) comments() [][2]int {
+	var out [][2]int
+	scan := func(from, to int) {
+		for i := from; i+1 < to; i++ {
+			switch doc.text[i : i+2] {
+			case "//":
+				end := strings.IndexByte(doc.text[i:to], '\n')
+				if end < 0 {
+					end = to - i
+				}
+				out = append(out, [2]int{i, i + end})
+				i += end
+			case "/*":
+				end := strings.Index(doc.text[i+2:to], "*/")
+				if end < 0 {
+					out = append(out, [2]int{i, to})
+					return
+				}
+				out = append(out, [2]int{i, i + 2 + end + 2})
+				i += 2 + end + 1
+			}
+		}
+	}
+	prev := 0
+	for _, tok := range doc.tokens {
+		if tok.Start > prev {
+			scan(prev, tok.Start)
+		}
+		if tok.End > prev {
+			prev = tok.End
+		}
+	}
+	scan(prev, len(doc.text))
+	return out
+}
@@ -0,0 +1,406 @@
+package lsp
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/th13vn/solast-go/internal/lexer"
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// symbols returns the document outline: file-level declarations, with the
+// members of contracts, structs and enums as children
+func (doc *document) symbols() []DocumentSymbol {
+	out := []DocumentSymbol{}
+	for _, d := range doc.index.top {
+		if s, ok := doc.symbol(d); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func (doc *document) symbol(d *decl) (DocumentSymbol, bool) {
+	if d.node.GetRange() == nil {
+		return DocumentSymbol{}, false
+	}
+	s := DocumentSymbol{Name: d.name, Range: doc.nodeSpan(d.node), SelectionRange: doc.nodeSpan(d.node)}
+	if d.end > d.start {
+		s.SelectionRange = doc.span(d.start, d.end)
+	}
+	switch d.kind {
+	case declContract, declInterface, declLibrary:
+		s.Kind, s.Detail = SymbolClass, d.node.(*ast.ContractDefinition).Kind
+		if d.kind == declInterface {
+			s.Kind = SymbolInterface
+		} else if d.kind == declLibrary {
+			s.Kind = SymbolModule
+		}
+	case declFunction:
+		s.Kind, s.Detail = SymbolFunction, doc.signature(d)
+		if d.name == "constructor" && d.special {
+			s.Kind = SymbolConstructor
+		}
+	case declModifier, declError:
+		s.Kind, s.Detail = SymbolFunction, doc.signature(d)
+		if d.kind == declError {
+			s.Kind = SymbolObject
+		}
+	case declEvent:
+		s.Kind, s.Detail = SymbolEvent, doc.signature(d)
+	case declStruct:
+		s.Kind = SymbolStruct
+	case declEnum:
+		s.Kind = SymbolEnum
+	case declEnumValue:
+		s.Kind = SymbolEnumMember
+	case declUserType:
+		s.Kind, s.Detail = SymbolClass, doc.signature(d)
+	case declStateVar, declMember:
+		s.Kind, s.Detail = SymbolField, doc.signature(d)
+		if d.readonly {
+			s.Kind = SymbolConstant
+		}
+	default:
+		return s, false
+	}
+	for _, m := range d.members {
+		if c, ok := doc.symbol(m); ok {
+			s.Children = append(s.Children, c)
+		}
+	}
+	return s, true
+}
+
+// foldingRanges returns foldable declarations and blocks, multi-line
+// comments, and runs of imports. A range ending in a closing brace stops
+// the line before it, so the brace stays visible.
+func (doc *document) foldingRanges() []FoldingRange {
+	out := []FoldingRange{}
+	seen := map[int]bool{}
+	add := func(start, end int, kind string) {
+		s, e := doc.position(start).Line, doc.position(end).Line
+		if end > 0 && doc.text[end-1] == '}' {
+			e--
+		}
+		if e > s && !seen[s] {
+			seen[s] = true
+			out = append(out, FoldingRange{StartLine: s, EndLine: e, Kind: kind})
+		}
+	}
+	var visit func(n ast.Node)
+	visit = func(n ast.Node) {
+		switch n.(type) {
+		case *ast.ContractDefinition, *ast.FunctionDefinition, *ast.ModifierDefinition,
+			*ast.StructDefinition, *ast.EnumDefinition, *ast.EventDefinition, *ast.ErrorDefinition,
+			*ast.Block, *ast.UncheckedBlock, *ast.AssemblyBlock, *ast.FunctionCall:
+			if rng := n.GetRange(); rng != nil {
+				add(rng[0], rng[1], "")
+			}
+		}
+		for _, child := range ast.Children(n) {
+			visit(child)
+		}
+	}
+	if doc.unit != nil {
+		var imports []ast.Node
+		flush := func() {
+			if len(imports) > 1 {
+				add(imports[0].GetRange()[0], imports[len(imports)-1].GetRange()[1], "imports")
+			}
+			imports = nil
+		}
+		for _, child := range doc.unit.Children {
+			if imp, ok := child.(*ast.ImportDirective); ok && imp.Range != nil {
+				imports = append(imports, imp)
+				continue
+			}
+			flush()
+		}
+		flush()
+		visit(doc.unit)
+	}
+
+	// Block comments, and runs of line comments on consecutive lines
+	comments := doc.comments()
+	for i := 0; i < len(comments); i++ {
+		c := comments[i]
+		if strings.HasPrefix(doc.text[c[0]:], "/*") {
+			add(c[0], c[1], "comment")
+			continue
+		}
+		j := i
+		for j+1 < len(comments) && strings.HasPrefix(doc.text[comments[j+1][0]:], "//") &&
+			doc.position(comments[j+1][0]).Line == doc.position(comments[j][0]).Line+1 {
+			j++
+		}
+		add(c[0], comments[j][1], "comment")
+		i = j
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].StartLine < out[j].StartLine })
+	return out
+}
+
+// definition returns the declaration of the name at offset
+func (doc *document) definition(offset int) *Location {
+	r := doc.index.at(offset)
+	if r == nil || r.decl.end <= r.decl.start {
+		return nil
+	}
+	return &Location{URI: doc.uri, Range: doc.span(r.decl.start, r.decl.end)}
+}
+
+// references returns the uses of the name at offset, with its declaration
+// when def is set
+func (doc *document) references(offset int, def bool) []Location {
+	out := []Location{}
+	r := doc.index.at(offset)
+	if r == nil {
+		return out
+	}
+	for _, u := range doc.index.uses(r.decl, def) {
+		out = append(out, Location{URI: doc.uri, Range: doc.span(u.start, u.end)})
+	}
+	return out
+}
+
+// hover shows the signature and NatSpec of the declaration named at offset
+func (doc *document) hover(offset int) *Hover {
+	r := doc.index.at(offset)
+	if r == nil {
+		return nil
+	}
+	text := "```solidity\n" + doc.signature(r.decl) + "\n```"
+	if rng := r.decl.node.GetRange(); rng != nil {
+		if notes := natspec(doc.text, rng[0]); notes != "" {
+			text += "\n\n" + notes
+		}
+	}
+	span := doc.span(r.start, r.end)
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: text}, Range: &span}
+}
+
+// signature renders a declaration from its source: the header up to the
+// body for callables and types, the whole declaration for variables
+func (doc *document) signature(d *decl) string {
+	rng := d.node.GetRange()
+	switch {
+	case d.kind == declEnumValue && d.parent != nil:
+		return d.parent.name + "." + d.name
+	case d.kind == declYulVar:
+		return "let " + d.name
+	case rng == nil:
+		return d.name
+	}
+	end := rng[1]
+	switch d.kind {
+	case declStateVar, declMember, declParam, declLocal:
+	default:
+		// Stop at the body or the terminating semicolon
+		depth := 0
+	scan:
+		for i := doc.tokenAt(rng[0]); i < len(doc.tokens) && doc.tokens[i].Start < rng[1]; i++ {
+			switch doc.tokens[i].Type {
+			case lexer.LPAREN:
+				depth++
+			case lexer.RPAREN:
+				depth--
+			case lexer.LBRACE, lexer.SEMICOLON:
+				if depth == 0 {
+					end = doc.tokens[i].Start
+					break scan
+				}
+			}
+		}
+	}
+	return strings.TrimSuffix(strings.Join(strings.Fields(doc.text[rng[0]:end]), " "), ";")
+}
+
+// natspec returns the documentation comment directly above the declaration
+// starting at offset: a run of /// lines or a /** */ block, rendered as
+// markdown
+func natspec(src string, offset int) string {
+	lineStart := strings.LastIndexByte(src[:offset], '\n') + 1
+	if strings.TrimSpace(src[lineStart:offset]) != "" {
+		return ""
+	}
+	var lines []string
+	for end := lineStart; end > 0; {
+		prev := strings.LastIndexByte(src[:end-1], '\n') + 1
+		line := strings.TrimSpace(src[prev : end-1])
+		if strings.HasPrefix(line, "///") {
+			lines = append([]string{strings.TrimSpace(line[3:])}, lines...)
+			end = prev
+			continue
+		}
+		if len(lines) == 0 && strings.HasSuffix(line, "*/") {
+			close := prev + strings.LastIndex(src[prev:end-1], "*/")
+			open := strings.LastIndex(src[:close], "/*")
+			if open >= 0 && strings.HasPrefix(src[open:], "/**") && open+3 <= close {
+				for _, l := range strings.Split(src[open+3:close], "\n") {
+					l = strings.TrimSpace(l)
+					lines = append(lines, strings.TrimSpace(strings.TrimPrefix(l, "*")))
+				}
+			}
+		}
+		break
+	}
+
+	// One paragraph per tag; untagged lines continue the previous one
+	var paras []string
+	cont := false
+	for _, l := range lines {
+		switch {
+		case l == "":
+			cont = false
+			continue
+		case strings.HasPrefix(l, "@"):
+			tag, rest := l, ""
+			if i := strings.IndexAny(l, " \t"); i > 0 {
+				tag, rest = l[:i], strings.TrimSpace(l[i:])
+			}
+			switch tag {
+			case "@notice":
+				l = rest
+			case "@param":
+				name, desc := rest, ""
+				if i := strings.IndexAny(rest, " \t"); i > 0 {
+					name, desc = rest[:i], strings.TrimSpace(rest[i:])
+				}
+				l = "*@param* `" + name + "` — " + desc
+			default:
+				l = "*" + tag + "* " + rest
+			}
+			paras = append(paras, l)
+		case cont:
+			paras[len(paras)-1] += " " + l
+		default:
+			paras = append(paras, l)
+		}
+		cont = true
+	}
+	return strings.Join(paras, "\n\n")
+}
+
+// Semantic token legend
+var (
+	tokenTypes = []string{
+		"keyword", "type", "number", "string", "comment",
+		"namespace", "class", "interface", "struct", "enum", "enumMember",
+		"event", "function", "decorator", "property", "parameter", "variable",
+	}
+	tokenModifiers = []string{"declaration", "readonly"}
+)
+
+// Indices into tokenTypes and bits of tokenModifiers
+const (
+	tokKeyword = iota
+	tokType
+	tokNumber
+	tokString
+	tokComment
+	tokNamespace
+	tokClass
+	tokInterface
+	tokStruct
+	tokEnum
+	tokEnumMember
+	tokEvent
+	tokFunction
+	tokDecorator
+	tokProperty
+	tokParameter
+	tokVariable
+
+	modDeclaration = 1 << 0
+	modReadonly    = 1 << 1
+)
+
+// declTokens maps declaration kinds to semantic token types
+var declTokens = map[declKind]int{
+	declContract:    tokClass,
+	declInterface:   tokInterface,
+	declLibrary:     tokNamespace,
+	declFunction:    tokFunction,
+	declModifier:    tokDecorator,
+	declEvent:       tokEvent,
+	declError:       tokType,
+	declStruct:      tokStruct,
+	declEnum:        tokEnum,
+	declEnumValue:   tokEnumMember,
+	declUserType:    tokType,
+	declStateVar:    tokProperty,
+	declMember:      tokProperty,
+	declParam:       tokParameter,
+	declLocal:       tokVariable,
+	declYulVar:      tokVariable,
+	declYulFunction: tokFunction,
+}
+
+// semanticTokens classifies the lexer tokens: resolved names by what they
+// declare, keywords, elementary types and literals by token type, plus the
+// comments between tokens. Unresolved names are left to the client.
+func (doc *document) semanticTokens() SemanticTokens {
+	type span struct{ start, end, typ, mods int }
+	var spans []span
+	refs := map[int]*ref{}
+	for i := range doc.index.refs {
+		refs[doc.index.refs[i].start] = &doc.index.refs[i]
+	}
+	for _, tok := range doc.tokens {
+		typ := -1
+		mods := 0
+		if r := refs[tok.Start]; r != nil && r.end == tok.End {
+			typ = declTokens[r.decl.kind]
+			if r.def {
+				mods |= modDeclaration
+			}
+			if r.decl.readonly {
+				mods |= modReadonly
+			}
+		} else {
+			switch tok.Type {
+			case lexer.ADDRESS, lexer.BOOL, lexer.BYTES, lexer.STRING_TYPE, lexer.FIXED, lexer.UFIXED,
+				lexer.INT, lexer.UINT, lexer.BYTE, lexer.BYTES_N, lexer.FIXED_N, lexer.UFIXED_N:
+				typ = tokType
+			case lexer.NUMBER, lexer.HEX_NUMBER:
+				typ = tokNumber
+			case lexer.STRING, lexer.HEX_STRING, lexer.UNICODE_STRING:
+				typ = tokString
+			default:
+				if lexer.IsKeyword(tok.Type) {
+					typ = tokKeyword
+				}
+			}
+		}
+		if typ >= 0 && tok.End > tok.Start {
+			spans = append(spans, span{tok.Start, tok.End, typ, mods})
+		}
+	}
+	// Tokens may not span lines, so comments are split per line
+	for _, c := range doc.comments() {
+		for start := c[0]; start < c[1]; {
+			end := start + strings.IndexByte(doc.text[start:c[1]], '\n')
+			if end < start {
+				end = c[1]
+			}
+			if end > start {
+				spans = append(spans, span{start, end, tokComment, 0})
+			}
+			start = end + 1
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	data := make([]int, 0, 5*len(spans))
+	var line, char int
+	for _, s := range spans {
+		p, e := doc.position(s.start), doc.position(s.end)
+		if p.Line != line {
+			char = 0
+		}
+		data = append(data, p.Line-line, p.Character-char, e.Character-p.Character, s.typ, s.mods)
+		line, char = p.Line, p.Character
+	}
+	return SemanticTokens{Data: data}
+}
@@ -0,0 +1,424 @@
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// client drives a Server in process over pipes
+type client struct {
+	t     *testing.T
+	conn  *conn
+	msgs  chan *message
+	notes []*message
+	next  int
+	done  chan error
+}
+
+func newClient(t *testing.T) *client {
+	t.Helper()
+	toServer, fromClient := io.Pipe()
+	fromServer, toClient := io.Pipe()
+	c := &client{t: t, conn: newConn(fromServer, fromClient), msgs: make(chan *message, 64), done: make(chan error, 1)}
+	go func() {
+		c.done <- NewServer().Serve(toServer, toClient)
+		toClient.Close()
+	}()
+	go func() {
+		defer close(c.msgs)
+		for {
+			body, err := c.conn.read()
+			if err != nil {
+				return
+			}
+			var m message
+			if err := json.Unmarshal(body, &m); err != nil {
+				t.Errorf("bad message %s: %v", body, err)
+				return
+			}
+			c.msgs <- &m
+		}
+	}()
+	return c
+}
+
+// call sends a request and decodes its result
+func (c *client) call(method string, params, result interface{}) *rpcError {
+	c.t.Helper()
+	c.next++
+	id := json.RawMessage(strings.TrimSpace(string(mustJSON(c.t, c.next))))
+	if err := c.conn.write(&message{ID: &id, Method: method, Params: mustJSON(c.t, params)}); err != nil {
+		c.t.Fatalf("%s: %v", method, err)
+	}
+	for m := range c.msgs {
+		if m.ID == nil {
+			c.notes = append(c.notes, m)
+			continue
+		}
+		if string(*m.ID) != string(id) {
+			c.t.Fatalf("%s: response for id %s, want %s", method, *m.ID, id)
+		}
+		if m.Error != nil {
+			return m.Error
+		}
+		if result != nil {
+			if err := json.Unmarshal(m.Result, result); err != nil {
+				c.t.Fatalf("%s: decoding %s: %v", method, m.Result, err)
+			}
+		}
+		return nil
+	}
+	c.t.Fatalf("%s: connection closed", method)
+	return nil
+}
+
+func (c *client) notify(method string, params interface{}) {
+	c.t.Helper()
+	if err := c.conn.write(&message{Method: method, Params: mustJSON(c.t, params)}); err != nil {
+		c.t.Fatalf("%s: %v", method, err)
+	}
+}
+
+// diagnostics waits for the next publishDiagnostics notification
+func (c *client) diagnostics() publishDiagnosticsParams {
+	c.t.Helper()
+	for {
+		var m *message
+		if len(c.notes) > 0 {
+			m, c.notes = c.notes[0], c.notes[1:]
+		} else if m = <-c.msgs; m == nil {
+			c.t.Fatal("connection closed waiting for diagnostics")
+		}
+		if m.Method == "textDocument/publishDiagnostics" {
+			var p publishDiagnosticsParams
+			if err := json.Unmarshal(m.Params, &p); err != nil {
+				c.t.Fatal(err)
+			}
+			return p
+		}
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) json.RawMessage {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// at returns the LSP position of the n-th (0-based) occurrence of needle,
+// plus skip bytes, with characters in UTF-16 units
+func at(t *testing.T, src, needle string, n, skip int) Position {
+	t.Helper()
+	off := -1
+	for i := 0; i <= n; i++ {
+		j := strings.Index(src[off+1:], needle)
+		if j < 0 {
+			t.Fatalf("occurrence %d of %q not found", n, needle)
+		}
+		off += 1 + j
+	}
+	off += skip
+	line := strings.Count(src[:off], "\n")
+	col := src[strings.LastIndexByte(src[:off], '\n')+1 : off]
+	return Position{Line: line, Character: len(utf16.Encode([]rune(col)))}
+}
+
+const uri = "file:///vault.sol"
+
+const vaultSrc = `pragma solidity ^0.8.20;
+
+import "./a.sol";
+import "./b.sol";
+
+/// @title Owned
+contract Owned {
+	address owner;
+	event OwnerChanged(address indexed next);
+
+	modifier onlyOwner() {
+		require(msg.sender == owner, "not owner");
+		_;
+	}
+}
+
+contract Vault is Owned {
+	struct Position { uint amount; uint since; }
+	enum State { Open, Closed }
+	mapping(address => Position) positions;
+	State state;
+	uint constant FEE = 1;
+
+	/// @notice Deposits into the caller's position
+	/// @param amount the amount, in wei
+	function deposit(uint amount) external onlyOwner {
+		Position storage p = positions[msg.sender];
+		p.amount += amount; /* é😀 */ p.since = block.timestamp;
+		state = State.Open;
+		emit OwnerChanged(owner);
+		uint total = amount;
+		{
+			uint total = FEE;
+			total += 1;
+		}
+		assembly {
+			let x := sload(total)
+			function twice(v) -> r { r := add(v, v) }
+			x := twice(x)
+			p.slot := x
+		}
+	}
+}
+`
+
+func TestServer(t *testing.T) {
+	c := newClient(t)
+	if rerr := c.call("textDocument/documentSymbol", map[string]interface{}{"textDocument": map[string]string{"uri": uri}}, nil); rerr == nil || rerr.Code != codeNotInitialized {
+		t.Errorf("request before initialize: %v", rerr)
+	}
+	var init struct {
+		Capabilities map[string]interface{} `json:"capabilities"`
+	}
+	if rerr := c.call("initialize", map[string]interface{}{}, &init); rerr != nil {
+		t.Fatal(rerr)
+	}
+	if init.Capabilities["hoverProvider"] != true || init.Capabilities["semanticTokensProvider"] == nil {
+		t.Errorf("capabilities = %v", init.Capabilities)
+	}
+	c.notify("initialized", map[string]interface{}{})
+
+	// Diagnostics
+	c.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "languageId": "solidity", "version": 1, "text": vaultSrc},
+	})
+	if d := c.diagnostics(); d.URI != uri || len(d.Diagnostics) != 0 {
+		t.Errorf("diagnostics for a clean file = %+v", d)
+	}
+	broken := strings.Replace(vaultSrc, "uint total = amount;", "uint total = ;", 1)
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": 2},
+		"contentChanges": []map[string]string{{"text": broken}},
+	})
+	d := c.diagnostics()
+	if d.Version != 2 || len(d.Diagnostics) == 0 || d.Diagnostics[0].Range.Start.Line != at(t, broken, "= ;", 0, 0).Line {
+		t.Errorf("diagnostics for a broken file = %+v", d)
+	}
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": 3},
+		"contentChanges": []map[string]string{{"text": vaultSrc}},
+	})
+	if d := c.diagnostics(); len(d.Diagnostics) != 0 {
+		t.Errorf("diagnostics after the fix = %+v", d)
+	}
+
+	doc := map[string]string{"uri": uri}
+	pos := func(p Position) map[string]interface{} {
+		return map[string]interface{}{"textDocument": doc, "position": p}
+	}
+
+	// Document symbols
+	var symbols []DocumentSymbol
+	if rerr := c.call("textDocument/documentSymbol", map[string]interface{}{"textDocument": doc}, &symbols); rerr != nil {
+		t.Fatal(rerr)
+	}
+	var names []string
+	for _, s := range symbols {
+		for _, child := range s.Children {
+			names = append(names, s.Name+"."+child.Name)
+		}
+	}
+	if got, want := strings.Join(names, " "), "Owned.owner Owned.OwnerChanged Owned.onlyOwner Vault.Position Vault.State Vault.positions Vault.state Vault.FEE Vault.deposit"; got != want {
+		t.Errorf("symbols = %s, want %s", got, want)
+	}
+	if symbols[0].Kind != SymbolClass || symbols[1].Children[0].Kind != SymbolStruct || symbols[1].Children[4].Kind != SymbolConstant {
+		t.Errorf("symbol kinds = %+v", symbols)
+	}
+	if symbols[1].SelectionRange.Start != at(t, vaultSrc, "Vault", 0, 0) {
+		t.Errorf("Vault selection range = %+v", symbols[1].SelectionRange)
+	}
+
+	// Go to definition
+	definitions := []struct {
+		name          string
+		use, declared Position
+	}{
+		{"inherited state variable", at(t, vaultSrc, "owner)", 0, 0), at(t, vaultSrc, "owner;", 0, 0)},
+		{"modifier", at(t, vaultSrc, "onlyOwner {", 0, 0), at(t, vaultSrc, "onlyOwner()", 0, 0)},
+		{"struct member through a storage pointer", at(t, vaultSrc, "p.amount", 0, 2), at(t, vaultSrc, "amount;", 0, 0)},
+		{"after a non-ASCII comment", at(t, vaultSrc, "p.since", 0, 2), at(t, vaultSrc, "since;", 0, 0)},
+		{"enum value", at(t, vaultSrc, "State.Open", 0, 6), at(t, vaultSrc, "Open,", 0, 0)},
+		{"event", at(t, vaultSrc, "OwnerChanged(owner)", 0, 0), at(t, vaultSrc, "OwnerChanged(address", 0, 0)},
+		{"shadowing local", at(t, vaultSrc, "total += 1", 0, 0), at(t, vaultSrc, "total = FEE", 0, 0)},
+		{"constant", at(t, vaultSrc, "FEE;", 0, 0), at(t, vaultSrc, "FEE =", 0, 0)},
+		{"Solidity local from assembly", at(t, vaultSrc, "sload(total)", 0, 6), at(t, vaultSrc, "total = amount", 0, 0)},
+		{"Yul function", at(t, vaultSrc, "twice(x)", 0, 0), at(t, vaultSrc, "twice(v)", 0, 0)},
+		{"Yul path target", at(t, vaultSrc, "p.slot", 0, 0), at(t, vaultSrc, "p = positions", 0, 0)},
+		{"base contract", at(t, vaultSrc, "is Owned", 0, 3), at(t, vaultSrc, "Owned {", 0, 0)},
+	}
+	for _, tc := range definitions {
+		var loc *Location
+		if rerr := c.call("textDocument/definition", pos(tc.use), &loc); rerr != nil {
+			t.Fatal(rerr)
+		}
+		if loc == nil || loc.URI != uri || loc.Range.Start != tc.declared {
+			t.Errorf("%s: definition = %+v, want %+v", tc.name, loc, tc.declared)
+		}
+	}
+	var none *Location
+	if c.call("textDocument/definition", pos(at(t, vaultSrc, "msg.sender", 0, 0)), &none); none != nil {
+		t.Errorf("builtin resolved to %+v", none)
+	}
+
+	// References
+	var refs []Location
+	params := pos(at(t, vaultSrc, "owner;", 0, 0))
+	params["context"] = map[string]bool{"includeDeclaration": true}
+	if rerr := c.call("textDocument/references", params, &refs); rerr != nil {
+		t.Fatal(rerr)
+	}
+	if len(refs) != 3 {
+		t.Errorf("references to owner = %+v, want 3", refs)
+	}
+	params["context"] = map[string]bool{"includeDeclaration": false}
+	c.call("textDocument/references", params, &refs)
+	if len(refs) != 2 {
+		t.Errorf("uses of owner = %d, want 2", len(refs))
+	}
+
+	// Hover
+	var hover *Hover
+	if rerr := c.call("textDocument/hover", pos(at(t, vaultSrc, "deposit", 0, 0)), &hover); rerr != nil {
+		t.Fatal(rerr)
+	}
+	if hover == nil || !strings.Contains(hover.Contents.Value, "function deposit(uint amount) external onlyOwner\n") ||
+		!strings.Contains(hover.Contents.Value, "Deposits into the caller's position") ||
+		!strings.Contains(hover.Contents.Value, "*@param* `amount` — the amount, in wei") {
+		t.Errorf("hover = %+v", hover)
+	}
+	c.call("textDocument/hover", pos(at(t, vaultSrc, "Owned", 1, 0)), &hover)
+	if hover == nil || !strings.Contains(hover.Contents.Value, "contract Owned\n") || !strings.Contains(hover.Contents.Value, "*@title* Owned") {
+		t.Errorf("hover on a base contract = %+v", hover)
+	}
+
+	// Folding ranges
+	var folds []FoldingRange
+	if rerr := c.call("textDocument/foldingRange", map[string]interface{}{"textDocument": doc}, &folds); rerr != nil {
+		t.Fatal(rerr)
+	}
+	want := map[FoldingRange]bool{
+		{StartLine: 2, EndLine: 3, Kind: "imports"}:                                                                                false,
+		{StartLine: at(t, vaultSrc, "contract Vault", 0, 0).Line, EndLine: strings.Count(vaultSrc, "\n") - 2}:                      false,
+		{StartLine: at(t, vaultSrc, "/// @notice", 0, 0).Line, EndLine: at(t, vaultSrc, "/// @param", 0, 0).Line, Kind: "comment"}: false,
+	}
+	for _, f := range folds {
+		if _, ok := want[f]; ok {
+			want[f] = true
+		}
+	}
+	for f, found := range want {
+		if !found {
+			t.Errorf("missing folding range %+v in %+v", f, folds)
+		}
+	}
+
+	// Semantic tokens
+	var tokens SemanticTokens
+	if rerr := c.call("textDocument/semanticTokens/full", map[string]interface{}{"textDocument": doc}, &tokens); rerr != nil {
+		t.Fatal(rerr)
+	}
+	decoded := map[Position][3]int{}
+	var line, char int
+	for i := 0; i+4 < len(tokens.Data); i += 5 {
+		if tokens.Data[i] > 0 {
+			char = 0
+		}
+		line += tokens.Data[i]
+		char += tokens.Data[i+1]
+		decoded[Position{line, char}] = [3]int{tokens.Data[i+2], tokens.Data[i+3], tokens.Data[i+4]}
+	}
+	checks := []struct {
+		name string
+		at   Position
+		want [3]int
+	}{
+		{"keyword", at(t, vaultSrc, "contract Owned", 0, 0), [3]int{8, tokKeyword, 0}},
+		{"contract declaration", at(t, vaultSrc, "Vault", 0, 0), [3]int{5, tokClass, modDeclaration}},
+		{"function declaration", at(t, vaultSrc, "deposit", 0, 0), [3]int{7, tokFunction, modDeclaration}},
+		{"modifier use", at(t, vaultSrc, "onlyOwner {", 0, 0), [3]int{9, tokDecorator, 0}},
+		{"parameter use", at(t, vaultSrc, "+= amount", 0, 3), [3]int{6, tokParameter, 0}},
+		{"constant", at(t, vaultSrc, "FEE;", 0, 0), [3]int{3, tokProperty, modReadonly}},
+		{"elementary type", at(t, vaultSrc, "uint amount)", 0, 0), [3]int{4, tokType, 0}},
+		{"string", at(t, vaultSrc, `"not owner"`, 0, 0), [3]int{11, tokString, 0}},
+		{"comment", at(t, vaultSrc, "/* é😀 */", 0, 0), [3]int{9, tokComment, 0}},
+		{"after a non-ASCII comment", at(t, vaultSrc, "p.since", 0, 0), [3]int{1, tokVariable, 0}},
+	}
+	for _, tc := range checks {
+		if got := decoded[tc.at]; got != tc.want {
+			t.Errorf("%s at %+v: token = %v, want %v", tc.name, tc.at, got, tc.want)
+		}
+	}
+
+	// Shutdown
+	if rerr := c.call("no/such/method", nil, nil); rerr == nil || rerr.Code != codeMethodNotFound {
+		t.Errorf("unknown method: %v", rerr)
+	}
+	c.notify("textDocument/didClose", map[string]interface{}{"textDocument": doc})
+	if d := c.diagnostics(); len(d.Diagnostics) != 0 {
+		t.Errorf("diagnostics on close = %+v", d)
+	}
+	if rerr := c.call("shutdown", nil, nil); rerr != nil {
+		t.Fatal(rerr)
+	}
+	c.notify("exit", nil)
+	if err := <-c.done; err != nil {
+		t.Errorf("Serve returned %v", err)
+	}
+}
+
+func TestNatspec(t *testing.T) {
+	src := "/**\n * @notice Adds.\n * More text.\n * @return sum\n */\nfunction add() {}\n// plain\nfunction sub() {}"
+	if got, want := natspec(src, strings.Index(src, "function add")), "Adds. More text.\n\n*@return* sum"; got != want {
+		t.Errorf("block natspec = %q, want %q", got, want)
+	}
+	if got := natspec(src, strings.Index(src, "function sub")); got != "" {
+		t.Errorf("plain comment taken as natspec: %q", got)
+	}
+}
+
+func TestDocumentOffset(t *testing.T) {
+	doc := newDocument(uri, 1, "ab😀c\nxy\n")
+	tests := []struct {
+		pos  Position
+		want int
+	}{
+		{Position{Line: 0, Character: 2}, 2},
+		{Position{Line: 0, Character: 4}, 6},
+		{Position{Line: 0, Character: 100}, 7}, // past the line end: its end, not line 1
+		{Position{Line: 0, Character: -1}, 0},
+		{Position{Line: 1, Character: -5}, 8},
+		{Position{Line: 1, Character: 9}, 10},
+		{Position{Line: 2, Character: 3}, 11},
+		{Position{Line: 7, Character: 0}, 11},
+		{Position{Line: -1, Character: 0}, 0},
+	}
+	for _, tt := range tests {
+		if got := doc.offset(tt.pos); got != tt.want {
+			t.Errorf("offset(%+v) = %d, want %d", tt.pos, got, tt.want)
+		}
+	}
+}
+
+func TestReplyNullID(t *testing.T) {
+	var out strings.Builder
+	if err := newConn(strings.NewReader(""), &out).reply(nil, nil, &rpcError{Code: codeParseError, Message: "bad"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"id":null`) {
+		t.Errorf("reply without an id = %s", out.String())
+	}
+}
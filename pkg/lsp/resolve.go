@@ -0,0 +1,790 @@
+package lsp
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/th13vn/solast-go/internal/lexer"
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// declKind classifies a declaration
+type declKind int
+
+const (
+	declContract declKind = iota
+	declInterface
+	declLibrary
+	declFunction
+	declModifier
+	declEvent
+	declError
+	declStruct
+	declEnum
+	declEnumValue
+	declUserType
+	declStateVar
+	declMember
+	declParam
+	declLocal
+	declYulVar
+	declYulFunction
+)
+
+// decl is a named declaration of one document
+type decl struct {
+	name string
+	kind declKind
+	node ast.Node
+	// start and end delimit the name in the source, in bytes
+	start, end int
+	// parent is the enclosing contract, struct or enum
+	parent  *decl
+	members []*decl
+	// bases are the contract's inheritance specifiers, by name path
+	bases []string
+	// typ is a variable's type name and scope the scope it is written in
+	typ   ast.Node
+	scope *scope
+	// special marks constructors, fallback and receive functions, which
+	// have no name of their own and cannot be referenced
+	special  bool
+	readonly bool
+}
+
+// ref is a resolved name occurrence: a use, or the declaration itself
+type ref struct {
+	start, end int
+	decl       *decl
+	def        bool
+}
+
+// index holds the declarations and resolved names of a document
+type index struct {
+	top  []*decl
+	refs []ref // sorted by start
+}
+
+// at returns the reference covering the byte offset, if any
+func (x *index) at(offset int) *ref {
+	i := sort.Search(len(x.refs), func(i int) bool { return x.refs[i].end >= offset })
+	for ; i < len(x.refs) && x.refs[i].start <= offset; i++ {
+		if offset <= x.refs[i].end {
+			return &x.refs[i]
+		}
+	}
+	return nil
+}
+
+// uses returns the references to d, with its declaration when def is set
+func (x *index) uses(d *decl, def bool) []ref {
+	var out []ref
+	for _, r := range x.refs {
+		if r.decl == d && (def || !r.def) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// scope is one level of name lookup: a block, a function, a contract (whose
+// members, including inherited ones, are consulted) or the file
+type scope struct {
+	parent   *scope
+	names    map[string][]*decl
+	contract *decl
+	r        *resolver
+}
+
+func (s *scope) child() *scope {
+	return &scope{parent: s, names: map[string][]*decl{}, r: s.r}
+}
+
+func (s *scope) add(d *decl) {
+	s.names[d.name] = append(s.names[d.name], d)
+}
+
+// lookup returns the innermost declarations of name
+func (s *scope) lookup(name string) []*decl {
+	for sc := s; sc != nil; sc = sc.parent {
+		if ds := sc.names[name]; len(ds) > 0 {
+			return ds
+		}
+		if sc.contract != nil {
+			if ds := sc.r.members(sc.contract, name, false); len(ds) > 0 {
+				return ds
+			}
+		}
+	}
+	return nil
+}
+
+// enclosing returns the contract the scope lies in
+func (s *scope) enclosing() *decl {
+	for sc := s; sc != nil; sc = sc.parent {
+		if sc.contract != nil {
+			return sc.contract
+		}
+	}
+	return nil
+}
+
+// value is what an expression denotes for member lookup: a type name node
+// read in the scope it was written in, or a declared type itself
+type value struct {
+	t  ast.Node
+	sc *scope
+	d  *decl
+}
+
+// resolver builds an index in two passes: declarations of the file and its
+// contracts first, so that uses may precede them, then every body in order
+// with block scoping
+type resolver struct {
+	src    string
+	tokens []lexer.Token
+	idx    *index
+	file   *scope
+	byNode map[ast.Node]*decl
+}
+
+func resolve(unit *ast.SourceUnit, src string, tokens []lexer.Token) *index {
+	r := &resolver{src: src, tokens: tokens, idx: &index{}, byNode: map[ast.Node]*decl{}}
+	r.file = &scope{names: map[string][]*decl{}, r: r}
+	if unit == nil {
+		return r.idx
+	}
+	for _, child := range unit.Children {
+		if d := r.declare(child, nil); d != nil {
+			d.scope = r.file
+			for _, m := range d.members {
+				m.scope = r.file
+			}
+			r.idx.top = append(r.idx.top, d)
+			r.file.add(d)
+		}
+	}
+	for _, child := range unit.Children {
+		r.node(child, r.file)
+	}
+	sort.SliceStable(r.idx.refs, func(i, j int) bool { return r.idx.refs[i].start < r.idx.refs[j].start })
+	return r.idx
+}
+
+// declare records a file- or contract-level declaration and its members
+func (r *resolver) declare(n ast.Node, parent *decl) *decl {
+	var d *decl
+	switch n := n.(type) {
+	case *ast.ContractDefinition:
+		kind := declContract
+		switch n.Kind {
+		case "interface":
+			kind = declInterface
+		case "library":
+			kind = declLibrary
+		}
+		d = r.named(n, n.Name, kind, parent)
+		for _, base := range n.BaseContracts {
+			if base != nil && base.BaseName != nil {
+				d.bases = append(d.bases, base.BaseName.NamePath)
+			}
+		}
+		for _, sub := range n.SubNodes {
+			if m := r.declare(sub, d); m != nil {
+				d.members = append(d.members, m)
+			}
+		}
+	case *ast.FunctionDefinition:
+		name, special := n.Name, false
+		switch {
+		case n.IsConstructor:
+			name, special = "constructor", true
+		case n.IsFallback:
+			name, special = "fallback", true
+		case n.IsReceiveEther:
+			name, special = "receive", true
+		}
+		if special {
+			d = &decl{name: name, kind: declFunction, node: n, parent: parent, special: true}
+			d.start, d.end = r.nameRange(n, name)
+			r.byNode[n] = d
+		} else {
+			d = r.named(n, name, declFunction, parent)
+		}
+	case *ast.ModifierDefinition:
+		d = r.named(n, n.Name, declModifier, parent)
+	case *ast.EventDefinition:
+		d = r.named(n, n.Name, declEvent, parent)
+	case *ast.ErrorDefinition:
+		d = r.named(n, n.Name, declError, parent)
+	case *ast.UserDefinedValueTypeDefinition:
+		d = r.named(n, n.Name, declUserType, parent)
+	case *ast.StructDefinition:
+		d = r.named(n, n.Name, declStruct, parent)
+		for _, m := range n.Members {
+			if v := r.variable(m, declMember, d); v != nil {
+				d.members = append(d.members, v)
+			}
+		}
+	case *ast.EnumDefinition:
+		d = r.named(n, n.Name, declEnum, parent)
+		for _, v := range n.Members {
+			if v != nil {
+				d.members = append(d.members, r.named(v, v.Name, declEnumValue, d))
+			}
+		}
+	case *ast.StateVariableDeclaration:
+		// One declaration per statement; a contract member list holds it
+		for _, v := range n.Variables {
+			d = r.variable(v, declStateVar, parent)
+		}
+	}
+	return d
+}
+
+// named records a declaration whose name is found among its tokens
+func (r *resolver) named(n ast.Node, name string, kind declKind, parent *decl) *decl {
+	d := &decl{name: name, kind: kind, node: n, parent: parent}
+	d.start, d.end = r.nameRange(n, name)
+	r.byNode[n] = d
+	if name != "" && d.end > d.start {
+		r.idx.refs = append(r.idx.refs, ref{start: d.start, end: d.end, decl: d, def: true})
+	}
+	return d
+}
+
+// variable records a variable declaration, located by its identifier
+func (r *resolver) variable(v *ast.VariableDeclaration, kind declKind, parent *decl) *decl {
+	if v == nil || v.Name == "" {
+		return nil
+	}
+	d := &decl{name: v.Name, kind: kind, node: v, parent: parent, typ: v.TypeName,
+		readonly: v.IsDeclaredConst || v.IsImmutable}
+	if v.Identifier != nil && v.Identifier.Range != nil {
+		d.start, d.end = v.Identifier.Range[0], v.Identifier.Range[1]
+	} else {
+		d.start, d.end = r.nameRange(v, v.Name)
+	}
+	r.byNode[v] = d
+	if d.end > d.start {
+		r.idx.refs = append(r.idx.refs, ref{start: d.start, end: d.end, decl: d, def: true})
+	}
+	return d
+}
+
+// nameRange finds the first token spelling name within n
+func (r *resolver) nameRange(n ast.Node, name string) (int, int) {
+	rng := n.GetRange()
+	if rng == nil || name == "" {
+		return 0, 0
+	}
+	i := sort.Search(len(r.tokens), func(i int) bool { return r.tokens[i].Start >= rng[0] })
+	for ; i < len(r.tokens) && r.tokens[i].Start < rng[1]; i++ {
+		tok := r.tokens[i]
+		if tok.Value == name && tok.End-tok.Start == len(name) {
+			return tok.Start, tok.End
+		}
+	}
+	return 0, 0
+}
+
+// use records a reference to d at [start, start+len(d.name)) when the source
+// there spells the name
+func (r *resolver) use(d *decl, start int) {
+	end := start + len(d.name)
+	if d.special || start < 0 || end > len(r.src) || r.src[start:end] != d.name {
+		return
+	}
+	r.idx.refs = append(r.idx.refs, ref{start: start, end: end, decl: d})
+}
+
+// members returns the declarations of name in contract c or, when c is a
+// contract, in its bases, most derived first. With basesOnly, c's own
+// members are skipped (super).
+func (r *resolver) members(c *decl, name string, basesOnly bool) []*decl {
+	seen := map[*decl]bool{}
+	var visit func(c *decl, own bool) []*decl
+	visit = func(c *decl, own bool) []*decl {
+		if c == nil || seen[c] {
+			return nil
+		}
+		seen[c] = true
+		if own {
+			var out []*decl
+			for _, m := range c.members {
+				if m.name == name && !m.special {
+					out = append(out, m)
+				}
+			}
+			if len(out) > 0 {
+				return out
+			}
+		}
+		// Solidity linearizes bases right to left
+		for i := len(c.bases) - 1; i >= 0; i-- {
+			if ds := visit(r.path(c.bases[i], -1, r.file), true); len(ds) > 0 {
+				return ds
+			}
+		}
+		return nil
+	}
+	return visit(c, !basesOnly)
+}
+
+// pick chooses among overloads by argument count, when known
+func pick(ds []*decl, argc int) *decl {
+	if len(ds) == 0 {
+		return nil
+	}
+	if argc >= 0 && len(ds) > 1 {
+		for _, d := range ds {
+			if params(d.node) == argc {
+				return d
+			}
+		}
+	}
+	return ds[0]
+}
+
+// params counts the parameters of a callable declaration
+func params(n ast.Node) int {
+	switch n := n.(type) {
+	case *ast.FunctionDefinition:
+		return len(n.Parameters)
+	case *ast.EventDefinition:
+		return len(n.Parameters)
+	case *ast.ErrorDefinition:
+		return len(n.Parameters)
+	case *ast.ModifierDefinition:
+		return len(n.Parameters)
+	case *ast.StructDefinition:
+		return len(n.Members)
+	}
+	return -1
+}
+
+// path resolves a dotted name path starting at byte offset start, recording
+// a reference per segment; a negative start records nothing
+func (r *resolver) path(p string, start int, sc *scope) *decl {
+	var d *decl
+	for i, seg := range strings.Split(p, ".") {
+		var ds []*decl
+		if i == 0 {
+			ds = sc.lookup(seg)
+		} else if d != nil {
+			ds = r.memberOf(d, seg)
+		}
+		d = pick(ds, -1)
+		if d == nil {
+			return nil
+		}
+		if start >= 0 {
+			r.use(d, start)
+			start += len(seg) + 1
+		}
+	}
+	return d
+}
+
+// memberOf looks name up among the members of a type declaration
+func (r *resolver) memberOf(t *decl, name string) []*decl {
+	switch t.kind {
+	case declContract, declInterface, declLibrary:
+		return r.members(t, name, false)
+	case declStruct, declEnum:
+		var out []*decl
+		for _, m := range t.members {
+			if m.name == name {
+				out = append(out, m)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// valueOf returns what a reference to d denotes
+func valueOf(d *decl) value {
+	switch d.kind {
+	case declContract, declInterface, declLibrary, declStruct, declEnum, declUserType:
+		return value{d: d}
+	case declStateVar, declMember, declParam, declLocal:
+		return value{t: d.typ, sc: d.scope}
+	}
+	return value{}
+}
+
+// typeDecl resolves the declaration a value's type names, without recording
+// references
+func (r *resolver) typeDecl(v value) *decl {
+	if v.d != nil {
+		return v.d
+	}
+	if t, ok := v.t.(*ast.UserDefinedTypeName); ok && v.sc != nil {
+		return r.path(t.NamePath, -1, v.sc)
+	}
+	return nil
+}
+
+// element returns the value of indexing v
+func element(v value) value {
+	switch t := v.t.(type) {
+	case *ast.ArrayTypeName:
+		return value{t: t.BaseTypeName, sc: v.sc}
+	case *ast.Mapping:
+		return value{t: t.ValueType, sc: v.sc}
+	}
+	return value{}
+}
+
+// node resolves the names under n
+func (r *resolver) node(n ast.Node, sc *scope) {
+	if isNil(n) {
+		return
+	}
+	switch n := n.(type) {
+	case *ast.PragmaDirective, *ast.ImportDirective:
+	case *ast.ContractDefinition:
+		d := r.byNode[n]
+		for _, base := range n.BaseContracts {
+			r.node(base, sc)
+		}
+		cs := sc.child()
+		cs.contract = d
+		if d != nil {
+			r.setScopes(d, cs)
+		}
+		for _, sub := range n.SubNodes {
+			r.node(sub, cs)
+		}
+	case *ast.FunctionDefinition:
+		fs := sc.child()
+		for _, p := range n.Parameters {
+			r.local(p, declParam, fs)
+		}
+		for _, p := range n.ReturnParameters {
+			r.local(p, declParam, fs)
+		}
+		for _, o := range n.Override {
+			r.node(o, sc)
+		}
+		for _, m := range n.Modifiers {
+			r.node(m, sc)
+		}
+		r.node(n.Body, fs)
+	case *ast.ModifierDefinition:
+		fs := sc.child()
+		for _, p := range n.Parameters {
+			r.local(p, declParam, fs)
+		}
+		for _, o := range n.Override {
+			r.node(o, sc)
+		}
+		r.node(n.Body, fs)
+	case *ast.EventDefinition:
+		es := sc.child()
+		for _, p := range n.Parameters {
+			r.local(p, declParam, es)
+		}
+	case *ast.ErrorDefinition:
+		es := sc.child()
+		for _, p := range n.Parameters {
+			r.local(p, declParam, es)
+		}
+	case *ast.StructDefinition:
+		for _, m := range n.Members {
+			if m != nil {
+				r.node(m.TypeName, sc)
+			}
+		}
+	case *ast.StateVariableDeclaration:
+		for _, v := range n.Variables {
+			if v != nil {
+				r.node(v.TypeName, sc)
+			}
+		}
+		r.node(n.InitialValue, sc)
+	case *ast.UsingForDeclaration:
+		if n.LibraryName != "" {
+			if start, _ := r.nameRange(n, strings.Split(n.LibraryName, ".")[0]); start > 0 {
+				r.path(n.LibraryName, start, sc)
+			}
+		}
+		r.node(n.TypeName, sc)
+	case *ast.ModifierInvocation:
+		if d := pick(sc.lookup(n.Name), len(n.Arguments)); d != nil && n.Range != nil {
+			r.path(n.Name, n.Range[0], sc)
+		}
+		for _, arg := range n.Arguments {
+			r.node(arg, sc)
+		}
+	case *ast.UserDefinedTypeName:
+		start := -1
+		if n.Range != nil {
+			start = n.Range[0]
+		}
+		r.path(n.NamePath, start, sc)
+	case *ast.Mapping:
+		// Key and value names are labels, not uses
+		r.node(n.KeyType, sc)
+		r.node(n.ValueType, sc)
+	case *ast.FunctionTypeName:
+		for _, p := range append(append([]*ast.VariableDeclaration{}, n.ParameterTypes...), n.ReturnTypes...) {
+			if p != nil {
+				r.node(p.TypeName, sc)
+			}
+		}
+	case *ast.VariableDeclaration:
+		r.node(n.TypeName, sc)
+		r.node(n.Expression, sc)
+	case *ast.Block:
+		bs := sc.child()
+		for _, s := range n.Statements {
+			r.node(s, bs)
+		}
+	case *ast.VariableDeclarationStatement:
+		r.node(n.InitialValue, sc)
+		for _, v := range n.Variables {
+			r.local(v, declLocal, sc)
+		}
+	case *ast.ForStatement:
+		fs := sc.child()
+		r.node(n.InitExpression, fs)
+		r.node(n.ConditionExpression, fs)
+		r.node(n.LoopExpression, fs)
+		r.node(n.Body, fs)
+	case *ast.TryStatement:
+		r.node(n.Expression, sc)
+		ts := sc.child()
+		for _, p := range n.ReturnParameters {
+			r.local(p, declLocal, ts)
+		}
+		r.node(n.Body, ts)
+		for _, c := range n.CatchClauses {
+			if c == nil {
+				continue
+			}
+			cs := sc.child()
+			for _, p := range c.Parameters {
+				r.local(p, declLocal, cs)
+			}
+			r.node(c.Body, cs)
+		}
+	case *ast.Identifier, *ast.MemberAccess, *ast.FunctionCall, *ast.IndexAccess:
+		r.expr(n, sc, -1)
+	case *ast.NameValueExpression:
+		r.node(n.Expression, sc)
+		if n.Arguments != nil {
+			for _, arg := range n.Arguments.Arguments {
+				r.node(arg, sc)
+			}
+		}
+	case *ast.InlineAssembly:
+		r.yul(n.Body, sc)
+	default:
+		for _, child := range ast.Children(n) {
+			r.node(child, sc)
+		}
+	}
+}
+
+// isNil reports whether n is nil or holds a nil pointer, as optional node
+// fields such as a function's Body do
+func isNil(n ast.Node) bool {
+	if n == nil {
+		return true
+	}
+	v := reflect.ValueOf(n)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// setScopes gives a contract's member declarations the contract scope, so
+// their types resolve lazily from any use
+func (r *resolver) setScopes(d *decl, cs *scope) {
+	for _, m := range d.members {
+		m.scope = cs
+		for _, sub := range m.members {
+			sub.scope = cs
+		}
+	}
+}
+
+// local declares a parameter or local variable in sc
+func (r *resolver) local(v *ast.VariableDeclaration, kind declKind, sc *scope) {
+	if v == nil {
+		return
+	}
+	r.node(v.TypeName, sc)
+	if d := r.variable(v, kind, sc.enclosing()); d != nil {
+		d.scope = sc
+		sc.add(d)
+	}
+}
+
+// expr resolves an expression, returning what it denotes and the declaration
+// it names, if any; argc selects among overloads when the expression is
+// called
+func (r *resolver) expr(n ast.Node, sc *scope, argc int) (value, *decl) {
+	if isNil(n) {
+		return value{}, nil
+	}
+	switch n := n.(type) {
+	case *ast.Identifier:
+		if n.Name == "this" {
+			return value{d: sc.enclosing()}, nil
+		}
+		d := pick(sc.lookup(n.Name), argc)
+		if d == nil || n.Range == nil {
+			return value{}, nil
+		}
+		r.use(d, n.Range[0])
+		return valueOf(d), d
+	case *ast.MemberAccess:
+		var ds []*decl
+		if id, ok := n.Expression.(*ast.Identifier); ok && id.Name == "super" {
+			if c := sc.enclosing(); c != nil {
+				ds = r.members(c, n.MemberName, true)
+			}
+		} else {
+			v, _ := r.expr(n.Expression, sc, -1)
+			if t := r.typeDecl(v); t != nil {
+				ds = r.memberOf(t, n.MemberName)
+			}
+		}
+		d := pick(ds, argc)
+		if d == nil || n.Range == nil {
+			return value{}, nil
+		}
+		r.use(d, n.Range[1]-len(n.MemberName))
+		return valueOf(d), d
+	case *ast.FunctionCall:
+		var callee *decl
+		var v value
+		switch e := n.Expression.(type) {
+		case *ast.Identifier, *ast.MemberAccess:
+			v, callee = r.expr(e, sc, len(n.Arguments))
+		case *ast.NewExpression:
+			r.node(e.TypeName, sc)
+			v = value{t: e.TypeName, sc: sc}
+		default:
+			r.node(e, sc)
+		}
+		// Argument names are labels, not uses
+		for _, arg := range n.Arguments {
+			r.node(arg, sc)
+		}
+		if callee == nil {
+			return v, nil
+		}
+		switch fn := callee.node.(type) {
+		case *ast.FunctionDefinition:
+			if len(fn.ReturnParameters) > 0 && fn.ReturnParameters[0] != nil {
+				return value{t: fn.ReturnParameters[0].TypeName, sc: callee.scope}, nil
+			}
+			return value{}, nil
+		}
+		return v, nil
+	case *ast.IndexAccess:
+		v, _ := r.expr(n.Base, sc, -1)
+		r.node(n.Index, sc)
+		return element(v), nil
+	case *ast.TupleExpression:
+		if len(n.Components) == 1 && !n.IsArray {
+			return r.expr(n.Components[0], sc, argc)
+		}
+	}
+	r.node(n, sc)
+	return value{}, nil
+}
+
+// yul resolves the names under an assembly node. Yul functions are visible
+// throughout their block; variables from their declaration on.
+func (r *resolver) yul(n ast.Node, sc *scope) {
+	if isNil(n) {
+		return
+	}
+	switch n := n.(type) {
+	case *ast.AssemblyBlock:
+		bs := sc.child()
+		r.hoist(n.Operations, bs)
+		for _, op := range n.Operations {
+			r.yul(op, bs)
+		}
+	case *ast.AssemblyFunctionDefinition:
+		fs := sc.child()
+		for _, id := range append(append([]*ast.Identifier{}, n.Arguments...), n.ReturnArguments...) {
+			r.yulVar(id, fs)
+		}
+		r.yul(n.Body, fs)
+	case *ast.AssemblyLocalDefinition:
+		r.yul(n.Expression, sc)
+		for _, id := range n.Names {
+			r.yulVar(id, sc)
+		}
+	case *ast.AssemblyAssignment:
+		for _, id := range n.Names {
+			r.yulUse(id.Name, id.Range, sc)
+		}
+		r.yul(n.Expression, sc)
+	case *ast.AssemblyIdentifier:
+		r.yulUse(n.Name, n.Range, sc)
+	case *ast.AssemblyMemberAccess:
+		if n.Expression != nil {
+			r.yulUse(n.Expression.Name, n.Expression.Range, sc)
+		}
+	case *ast.AssemblyCall:
+		if d := pick(sc.lookup(n.FunctionName), -1); d != nil && d.kind == declYulFunction && n.Range != nil {
+			r.use(d, n.Range[0])
+		}
+		for _, arg := range n.Arguments {
+			r.yul(arg, sc)
+		}
+	case *ast.AssemblyFor:
+		// Declarations in the init block are visible in the whole loop
+		fs := sc.child()
+		if n.Pre != nil {
+			r.hoist(n.Pre.Operations, fs)
+			for _, op := range n.Pre.Operations {
+				r.yul(op, fs)
+			}
+		}
+		r.yul(n.Condition, fs)
+		r.yul(n.Post, fs)
+		r.yul(n.Body, fs)
+	default:
+		for _, child := range ast.Children(n) {
+			r.yul(child, sc)
+		}
+	}
+}
+
+// hoist declares the Yul functions of a block
+func (r *resolver) hoist(ops []ast.Node, sc *scope) {
+	for _, op := range ops {
+		if fn, ok := op.(*ast.AssemblyFunctionDefinition); ok {
+			sc.add(r.named(fn, fn.Name, declYulFunction, sc.enclosing()))
+		}
+	}
+}
+
+func (r *resolver) yulVar(id *ast.Identifier, sc *scope) {
+	if id == nil || id.Range == nil || id.Name == "" {
+		return
+	}
+	d := &decl{name: id.Name, kind: declYulVar, node: id, start: id.Range[0], end: id.Range[1], parent: sc.enclosing(), scope: sc}
+	r.byNode[id] = d
+	r.idx.refs = append(r.idx.refs, ref{start: d.start, end: d.end, decl: d, def: true})
+	sc.add(d)
+}
+
+// yulUse resolves a Yul name or the first segment of a path such as x.slot
+func (r *resolver) yulUse(name string, rng *ast.Range, sc *scope) {
+	if rng == nil {
+		return
+	}
+	name = strings.Split(name, ".")[0]
+	if d := pick(sc.lookup(name), -1); d != nil {
+		r.use(d, rng[0])
+	}
+}
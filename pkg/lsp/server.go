@@ -0,0 +1,207 @@
+// Package lsp implements a Language Server Protocol server for Solidity on
+// top of the parser: diagnostics, document symbols, folding ranges,
+// go-to-definition, references, hover and semantic tokens. Names are
+// resolved within one document; imports are not followed.
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/th13vn/solast-go/pkg/parser"
+)
+
+// Server is a Solidity language server. It keeps every open document parsed
+// and answers requests from its AST and tokens.
+type Server struct {
+	// Version is reported to the client in serverInfo
+	Version string
+
+	conn        *conn
+	docs        map[string]*document
+	initialized bool
+	shutdown    bool
+}
+
+// NewServer returns a server with no open documents
+func NewServer() *Server {
+	return &Server{docs: map[string]*document{}}
+}
+
+// Serve reads requests from r and writes responses and notifications to w
+// until the client sends exit or r is closed
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.conn = newConn(r, w)
+	for {
+		body, err := s.conn.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var m message
+		if err := json.Unmarshal(body, &m); err != nil {
+			if err := s.conn.reply(nil, nil, &rpcError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		if m.Method == "exit" {
+			return nil
+		}
+		result, rerr := s.handle(&m)
+		if m.ID == nil {
+			continue // notifications get no response
+		}
+		if err := s.conn.reply(m.ID, result, rerr); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches one request or notification
+func (s *Server) handle(m *message) (interface{}, *rpcError) {
+	if !s.initialized && m.Method != "initialize" {
+		return nil, &rpcError{Code: codeNotInitialized, Message: "server not initialized"}
+	}
+	if s.shutdown {
+		return nil, &rpcError{Code: codeInvalidRequest, Message: "server is shutting down"}
+	}
+	switch m.Method {
+	case "initialize":
+		s.initialized = true
+		return s.capabilities(), nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		s.update(newDocument(p.TextDocument.URI, p.TextDocument.Version, p.TextDocument.Text))
+	case "textDocument/didChange":
+		var p didChangeParams
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		doc := s.docs[p.TextDocument.URI]
+		if doc == nil {
+			return nil, nil
+		}
+		text := doc.text
+		for _, c := range p.ContentChanges {
+			if c.Range == nil {
+				text = c.Text
+				continue
+			}
+			// Offsets must come from the text as changed so far
+			cur := &document{text: text, mapper: parser.NewPositionMapper(text)}
+			text = text[:cur.offset(c.Range.Start)] + c.Text + text[cur.offset(c.Range.End):]
+		}
+		s.update(newDocument(doc.uri, p.TextDocument.Version, text))
+	case "textDocument/didClose":
+		var p didCloseParams
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		delete(s.docs, p.TextDocument.URI)
+		s.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []Diagnostic{}})
+	case "textDocument/documentSymbol":
+		doc, rerr := s.document(m.Params)
+		if doc == nil {
+			return nil, rerr
+		}
+		return doc.symbols(), nil
+	case "textDocument/foldingRange":
+		doc, rerr := s.document(m.Params)
+		if doc == nil {
+			return nil, rerr
+		}
+		return doc.foldingRanges(), nil
+	case "textDocument/semanticTokens/full":
+		doc, rerr := s.document(m.Params)
+		if doc == nil {
+			return nil, rerr
+		}
+		return doc.semanticTokens(), nil
+	case "textDocument/definition", "textDocument/hover":
+		var p positionParams
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		doc := s.docs[p.TextDocument.URI]
+		if doc == nil {
+			return nil, nil
+		}
+		if m.Method == "textDocument/hover" {
+			if h := doc.hover(doc.offset(p.Position)); h != nil {
+				return h, nil
+			}
+		} else if loc := doc.definition(doc.offset(p.Position)); loc != nil {
+			return loc, nil
+		}
+		return nil, nil
+	case "textDocument/references":
+		var p referenceParams
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		doc := s.docs[p.TextDocument.URI]
+		if doc == nil {
+			return []Location{}, nil
+		}
+		return doc.references(doc.offset(p.Position), p.Context.IncludeDeclaration), nil
+	default:
+		if m.ID != nil {
+			return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + m.Method}
+		}
+	}
+	return nil, nil
+}
+
+// update stores a document and publishes its diagnostics
+func (s *Server) update(doc *document) {
+	s.docs[doc.uri] = doc
+	s.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         doc.uri,
+		Version:     doc.version,
+		Diagnostics: doc.diagnostics(),
+	})
+}
+
+// document returns the open document a request names
+func (s *Server) document(params json.RawMessage) (*document, *rpcError) {
+	var p documentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, invalidParams(err)
+	}
+	return s.docs[p.TextDocument.URI], nil
+}
+
+func (s *Server) capabilities() interface{} {
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"positionEncoding": "utf-16",
+			"textDocumentSync": map[string]interface{}{
+				"openClose": true,
+				"change":    1, // full text
+			},
+			"documentSymbolProvider": true,
+			"foldingRangeProvider":   true,
+			"definitionProvider":     true,
+			"referencesProvider":     true,
+			"hoverProvider":          true,
+			"semanticTokensProvider": map[string]interface{}{
+				"legend": SemanticTokensLegend{TokenTypes: tokenTypes, TokenModifiers: tokenModifiers},
+				"full":   true,
+			},
+		},
+		"serverInfo": map[string]interface{}{"name": "solast", "version": s.Version},
+	}
+}
+
+func invalidParams(err error) *rpcError {
+	return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+}
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"github.com/th13vn/solast-go/internal/builder"
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// The functions below parse a fragment of Solidity rather than a source
+// unit: the whole input must be exactly one construct of the kind named,
+// else a *ParserError holds the first lexical or syntax error. Options
+// apply as for Parse, but for Tolerant, which has no effect: a fragment is
+// too small to recover in.
+
+// ParseExpression parses an expression, such as a.b(c) or x + 1
+func ParseExpression(input string, opts *Options) (ast.Node, error) {
+	return parseFragment(input, opts, (*builder.Builder).BuildExpression)
+}
+
+// ParseStatement parses a statement, such as x = 1; or a block. Simple
+// statements need their ';'.
+func ParseStatement(input string, opts *Options) (ast.Node, error) {
+	return parseFragment(input, opts, (*builder.Builder).BuildStatement)
+}
+
+// ParseTypeName parses a type name, such as uint256[],
+// mapping(address => uint) or function (uint) external returns (bool)
+func ParseTypeName(input string, opts *Options) (ast.Node, error) {
+	return parseFragment(input, opts, (*builder.Builder).BuildTypeName)
+}
+
+// ParseVariableDeclaration parses a variable declaration as written in a
+// parameter list: a type name, then an optional data location and name,
+// such as uint256[] memory x
+func ParseVariableDeclaration(input string, opts *Options) (*ast.VariableDeclaration, error) {
+	n, err := parseFragment(input, opts, (*builder.Builder).BuildVariableDeclaration)
+	if err != nil {
+		return nil, err
+	}
+	return n.(*ast.VariableDeclaration), nil
+}
+
+// ParseContractBodyElement parses a contract member: a function,
+// constructor, modifier, state variable, struct, enum, event, error,
+// using-for directive or user-defined value type
+func ParseContractBodyElement(input string, opts *Options) (ast.Node, error) {
+	return parseFragment(input, opts, (*builder.Builder).BuildContractBodyElement)
+}
+
+// ParseSourceUnitElement parses a top-level element: a pragma, import,
+// contract, interface, library or file-level definition
+func ParseSourceUnitElement(input string, opts *Options) (ast.Node, error) {
+	return parseFragment(input, opts, (*builder.Builder).BuildSourceUnitElement)
+}
+
+func parseFragment(input string, opts *Options, build func(*builder.Builder) (ast.Node, error)) (ast.Node, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	b := builder.New(input, &builder.Options{
+		Loc:           opts.Loc,
+		Range:         opts.Range,
+		LiteralValues: opts.LiteralValues,
+	})
+
+	node, err := build(b)
+	if err != nil {
+		errs := collectErrors(nil, []*builder.Error{err.(*builder.Error)})
+		convertErrors(errs, input, opts.PositionUnit)
+		return nil, &ParserError{Errors: errs}
+	}
+	convertPositions(node, input, opts.PositionUnit)
+
+	if opts.VerifyPositions {
+		if errs := checkPositions(node, opts.Loc, opts.Range); len(errs) > 0 {
+			return nil, &ParserError{Errors: errs}
+		}
+	}
+
+	return node, nil
+}
@@ -0,0 +1,213 @@
+package parser
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// PositionUnit selects how columns and offsets are counted
+type PositionUnit int
+
+// Position units
+const (
+	// UnitByte counts UTF-8 bytes (the default, and Go string indices)
+	UnitByte PositionUnit = iota
+	// UnitRune counts Unicode code points
+	UnitRune
+	// UnitUTF16 counts UTF-16 code units, as LSP clients and JavaScript
+	// string indices (the TypeScript parser) do
+	UnitUTF16
+)
+
+// String returns the unit name: "byte", "rune" or "utf16"
+func (u PositionUnit) String() string {
+	switch u {
+	case UnitRune:
+		return "rune"
+	case UnitUTF16:
+		return "utf16"
+	}
+	return "byte"
+}
+
+// PositionMapper converts offsets and line/column positions in one source
+// text between byte, rune and UTF-16 units. Lines are 1-based and split on
+// '\n'; columns are 0-based, as in ast.Position. An offset inside a
+// multi-byte character maps to the start of that character.
+type PositionMapper struct {
+	src string
+	// starts[u][i] is the offset of line i+1 in unit u
+	starts [3][]int
+	ascii  bool
+}
+
+// NewPositionMapper indexes src for conversions
+func NewPositionMapper(src string) *PositionMapper {
+	m := &PositionMapper{src: src, ascii: true}
+	var counts [3]int
+	for u := range m.starts {
+		m.starts[u] = []int{0}
+	}
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		if size > 1 {
+			m.ascii = false
+		}
+		counts[UnitByte] += size
+		counts[UnitRune]++
+		counts[UnitUTF16] += utf16Len(r)
+		i += size
+		if r == '\n' {
+			for u := range m.starts {
+				m.starts[u] = append(m.starts[u], counts[u])
+			}
+		}
+	}
+	return m
+}
+
+// Convert converts an offset from one unit to another
+func (m *PositionMapper) Convert(offset int, from, to PositionUnit) int {
+	if from == to || m.ascii {
+		return offset
+	}
+	return m.Offset(m.ConvertPosition(m.Position(offset, from), from, to), to)
+}
+
+// ConvertPosition converts a line/column position from one unit to another
+func (m *PositionMapper) ConvertPosition(pos ast.Position, from, to PositionUnit) ast.Position {
+	if from == to || m.ascii {
+		return pos
+	}
+	line := pos.Line - 1
+	if line < 0 || line >= len(m.starts[UnitByte]) {
+		return pos
+	}
+	b := m.scan(line, pos.Column, from)
+	return ast.Position{Line: pos.Line, Column: m.width(line, b, to)}
+}
+
+// Position returns the line and column of an offset given in unit, with the
+// column in the same unit
+func (m *PositionMapper) Position(offset int, unit PositionUnit) ast.Position {
+	starts := m.starts[unit]
+	line := sort.Search(len(starts), func(i int) bool { return starts[i] > offset }) - 1
+	if line < 0 {
+		line = 0
+	}
+	return ast.Position{Line: line + 1, Column: offset - starts[line]}
+}
+
+// Offset returns the offset, in unit, of a line and column given in unit
+func (m *PositionMapper) Offset(pos ast.Position, unit PositionUnit) int {
+	line := pos.Line - 1
+	if line < 0 {
+		return 0
+	}
+	if line >= len(m.starts[unit]) {
+		line = len(m.starts[unit]) - 1
+	}
+	return m.starts[unit][line] + pos.Column
+}
+
+// scan returns the byte column reached after col units of unit on a line
+func (m *PositionMapper) scan(line, col int, unit PositionUnit) int {
+	start := m.starts[UnitByte][line]
+	if unit == UnitByte {
+		return col
+	}
+	n, i := 0, start
+	for i < len(m.src) && n < col {
+		r, size := utf8.DecodeRuneInString(m.src[i:])
+		if unit == UnitUTF16 {
+			if n+utf16Len(r) > col {
+				return i - start // inside a surrogate pair
+			}
+			n += utf16Len(r)
+		} else {
+			n++
+		}
+		i += size
+	}
+	return i - start + (col - n)
+}
+
+// width counts the units of unit in the first b bytes of a line
+func (m *PositionMapper) width(line, b int, unit PositionUnit) int {
+	if unit == UnitByte {
+		return b
+	}
+	start := m.starts[UnitByte][line]
+	end := start + b
+	if end > len(m.src) {
+		end = len(m.src)
+	}
+	n := 0
+	for i := start; i < end; {
+		r, size := utf8.DecodeRuneInString(m.src[i:])
+		if i+size > end {
+			break // end falls inside a multi-byte character
+		}
+		if unit == UnitUTF16 {
+			n += utf16Len(r)
+		} else {
+			n++
+		}
+		i += size
+	}
+	return n + (start + b - end)
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 && r <= utf8.MaxRune {
+		return 2
+	}
+	return 1
+}
+
+// convertPositions rewrites the byte-based positions of every node under
+// root into unit
+func convertPositions(root ast.Node, src string, unit PositionUnit) {
+	if unit == UnitByte || root == nil {
+		return
+	}
+	m := NewPositionMapper(src)
+	if m.ascii {
+		return
+	}
+	// A node can be reachable twice (a state variable's initial value is
+	// also its declaration's expression); convert each position once
+	seen := make(map[ast.Node]bool)
+	var visit func(n ast.Node)
+	visit = func(n ast.Node) {
+		if seen[n] {
+			return
+		}
+		seen[n] = true
+		if loc := n.GetLocation(); loc != nil {
+			loc.Start = m.ConvertPosition(loc.Start, UnitByte, unit)
+			loc.End = m.ConvertPosition(loc.End, UnitByte, unit)
+		}
+		if rng := n.GetRange(); rng != nil {
+			rng[0] = m.Convert(rng[0], UnitByte, unit)
+			rng[1] = m.Convert(rng[1], UnitByte, unit)
+		}
+		for _, child := range ast.Children(n) {
+			visit(child)
+		}
+	}
+	visit(root)
+}
+
+// convertErrors rewrites the byte columns of errs into unit
+func convertErrors(errs []*Error, src string, unit PositionUnit) {
+	if unit == UnitByte || len(errs) == 0 {
+		return
+	}
+	m := NewPositionMapper(src)
+	for _, e := range errs {
+		e.Column = m.ConvertPosition(ast.Position{Line: e.Line, Column: e.Column}, UnitByte, unit).Column
+	}
+}
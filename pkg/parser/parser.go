@@ -5,8 +5,10 @@ package parser
 import (
 	"encoding/json"
 	"io"
+	"sort"
 
 	"github.com/th13vn/solast-go/internal/builder"
+	"github.com/th13vn/solast-go/internal/lexer"
 	"github.com/th13vn/solast-go/pkg/ast"
 )
 
@@ -18,6 +20,19 @@ type Options struct {
 	Loc bool
 	// Range: add character range information to nodes
 	Range bool
+	// VerifyPositions: check that every node carries the positions enabled by
+	// Loc and Range and that each child lies within its parent. Violations
+	// are reported as errors prefixed "position check:"; Parse returns them
+	// with the tree when Tolerant is set, without it otherwise.
+	VerifyPositions bool
+	// PositionUnit: the unit of Loc columns, Range offsets and error columns.
+	// The default, UnitByte, counts UTF-8 bytes; UnitUTF16 matches the
+	// TypeScript parser and LSP clients.
+	PositionUnit PositionUnit
+	// LiteralValues: fill the decoded values of literals in the JSON output,
+	// NumberLiteral.Value and the HexValue of string and hex literals. The
+	// Rat and Bytes methods work either way.
+	LiteralValues bool
 }
 
 // ParserError represents a parsing error
@@ -32,11 +47,29 @@ func (e *ParserError) Error() string {
 	return e.Errors[0].Error()
 }
 
+// ErrorCode classifies an Error, so that callers can filter on it
+type ErrorCode string
+
+const (
+	// CodeSyntax: the tokens do not form valid Solidity
+	CodeSyntax ErrorCode = builder.CodeSyntax
+	// Lexical errors; the rest of the source is still parsed
+	CodeUnterminatedString  ErrorCode = lexer.CodeUnterminatedString
+	CodeUnterminatedComment ErrorCode = lexer.CodeUnterminatedComment
+	CodeMalformedNumber     ErrorCode = lexer.CodeMalformedNumber
+	CodeMalformedHexString  ErrorCode = lexer.CodeMalformedHexString
+	CodeInvalidEscape       ErrorCode = lexer.CodeInvalidEscape
+	CodeInvalidCharacter    ErrorCode = lexer.CodeInvalidCharacter
+	// CodePosition: a violation found by Options.VerifyPositions
+	CodePosition ErrorCode = "position"
+)
+
 // Error represents a single parsing error
 type Error struct {
-	Message string `json:"message"`
-	Line    int    `json:"line"`
-	Column  int    `json:"column"`
+	Code    ErrorCode `json:"code,omitempty"`
+	Message string    `json:"message"`
+	Line    int       `json:"line"`
+	Column  int       `json:"column"`
 }
 
 func (e *Error) Error() string {
@@ -53,34 +86,41 @@ func Parse(input string, opts *Options) (*ast.SourceUnit, error) {
 		Tolerant: opts.Tolerant,
 		Loc:      opts.Loc,
 		Range:    opts.Range,
+		LiteralValues: opts.LiteralValues,
 	})
 
 	result, err := b.Build()
 	if err != nil {
-		builderErr := err.(*builder.Error)
-		return nil, &ParserError{
-			Errors: []*Error{{
-				Message: builderErr.Message,
-				Line:    builderErr.Line,
-				Column:  builderErr.Column,
-			}},
-		}
+		errs := collectErrors(b.LexErrors(), []*builder.Error{err.(*builder.Error)})
+		convertErrors(errs, input, opts.PositionUnit)
+		return nil, &ParserError{Errors: errs}
 	}
+	convertPositions(result, input, opts.PositionUnit)
 
 	// Check for collected errors in tolerant mode
-	if len(b.Errors()) > 0 && !opts.Tolerant {
-		var errors []*Error
-		for _, e := range b.Errors() {
-			errors = append(errors, &Error{
-				Message: e.Message,
-				Line:    e.Line,
-				Column:  e.Column,
-			})
-		}
+	if errors := collectErrors(b.LexErrors(), b.Errors()); len(errors) > 0 && !opts.Tolerant {
+		convertErrors(errors, input, opts.PositionUnit)
 		return nil, &ParserError{Errors: errors}
 	}
 
-	return result, nil
+	return verify(result, opts)
+}
+
+// verify applies Options.VerifyPositions to a built tree. Violations are
+// returned as a ParserError; with Tolerant the tree is returned too, as it
+// is despite syntax errors.
+func verify(unit *ast.SourceUnit, opts *Options) (*ast.SourceUnit, error) {
+	if !opts.VerifyPositions {
+		return unit, nil
+	}
+	errs := checkPositions(unit, opts.Loc, opts.Range)
+	if len(errs) == 0 {
+		return unit, nil
+	}
+	if !opts.Tolerant {
+		return nil, &ParserError{Errors: errs}
+	}
+	return unit, &ParserError{Errors: errs}
 }
 
 // ParseWithErrors parses like Parse but ALSO returns the errors recovered during
@@ -88,7 +128,9 @@ func Parse(input string, opts *Options) (*ast.SourceUnit, error) {
 // returned and the error slice is nil). In tolerant mode the AST is returned
 // together with every recovered error, so callers can surface parse failures
 // that Parse would otherwise swallow silently — e.g. a desync that drops part of
-// a contract body. A nil/empty slice means a clean parse.
+// a contract body. A nil/empty slice means a clean parse. Lexical errors
+// (unterminated strings and comments, malformed literals, invalid escapes and
+// characters) are reported alongside, ordered by position; filter on Code.
 func ParseWithErrors(input string, opts *Options) (*ast.SourceUnit, []*Error, error) {
 	if opts == nil {
 		opts = &Options{}
@@ -98,27 +140,22 @@ func ParseWithErrors(input string, opts *Options) (*ast.SourceUnit, []*Error, er
 		Tolerant: opts.Tolerant,
 		Loc:      opts.Loc,
 		Range:    opts.Range,
+		LiteralValues: opts.LiteralValues,
 	})
 
 	result, err := b.Build()
 	if err != nil {
-		builderErr := err.(*builder.Error)
-		return nil, nil, &ParserError{
-			Errors: []*Error{{
-				Message: builderErr.Message,
-				Line:    builderErr.Line,
-				Column:  builderErr.Column,
-			}},
-		}
+		errs := collectErrors(b.LexErrors(), []*builder.Error{err.(*builder.Error)})
+		convertErrors(errs, input, opts.PositionUnit)
+		return nil, nil, &ParserError{Errors: errs}
 	}
+	convertPositions(result, input, opts.PositionUnit)
 
-	var errors []*Error
-	for _, e := range b.Errors() {
-		errors = append(errors, &Error{
-			Message: e.Message,
-			Line:    e.Line,
-			Column:  e.Column,
-		})
+	errors := collectErrors(b.LexErrors(), b.Errors())
+	convertErrors(errors, input, opts.PositionUnit)
+
+	if opts.VerifyPositions {
+		errors = append(errors, checkPositions(result, opts.Loc, opts.Range)...)
 	}
 
 	// Non-tolerant mode keeps the original contract: recovered errors are fatal.
@@ -129,6 +166,28 @@ func ParseWithErrors(input string, opts *Options) (*ast.SourceUnit, []*Error, er
 	return result, errors, nil
 }
 
+// collectErrors converts lexical and syntax errors, ordered by position
+func collectErrors(lexical, syntax []*builder.Error) []*Error {
+	var errors []*Error
+	for _, list := range [][]*builder.Error{lexical, syntax} {
+		for _, e := range list {
+			errors = append(errors, &Error{
+				Code:    ErrorCode(e.Code),
+				Message: e.Message,
+				Line:    e.Line,
+				Column:  e.Column,
+			})
+		}
+	}
+	sort.SliceStable(errors, func(i, j int) bool {
+		if errors[i].Line != errors[j].Line {
+			return errors[i].Line < errors[j].Line
+		}
+		return errors[i].Column < errors[j].Column
+	})
+	return errors
+}
+
 // ParseReader parses Solidity source from an io.Reader and returns an AST
 func ParseReader(r io.Reader, opts *Options) (*ast.SourceUnit, error) {
 	content, err := io.ReadAll(r)
@@ -165,4 +224,3 @@ type BaseVisitor = ast.BaseVisitor
 
 // SimpleVisitor is an alias for ast.SimpleVisitor
 type SimpleVisitor = ast.SimpleVisitor
-
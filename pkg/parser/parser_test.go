@@ -2,9 +2,15 @@ package parser
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/th13vn/solast-go/pkg/ast"
 )
@@ -696,3 +702,568 @@ func TestComplexContract(t *testing.T) {
 		t.Error("Expected contracts")
 	}
 }
+
+// TestParseAssemblyYul covers Yul forms the tolerant parser used to drop
+// silently: `:=` (lexed as COLON ASSIGN), builtins that share a name with a
+// Solidity keyword (revert, return), break/continue, and `->` return lists.
+func TestParseAssemblyYul(t *testing.T) {
+	source := `
+contract C {
+	function f(uint x) public returns (uint r) {
+		assembly {
+			function inc(v) -> out { out := add(v, 1) }
+			let y := inc(x)
+			r := y
+			for { } 1 { } { break }
+			if iszero(x) { revert(0, 0) }
+			return(0, 32)
+		}
+	}
+}`
+	result, err := Parse(source, nil)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	counts := make(map[ast.NodeType]int)
+	var calls []string
+	ast.WalkSimple(result, &ast.SimpleVisitor{
+		AssemblyFunctionDefinitionFn: func(n *ast.AssemblyFunctionDefinition) {
+			counts[n.Type]++
+			if len(n.ReturnArguments) != 1 {
+				t.Errorf("expected 1 return argument for %s, got %d", n.Name, len(n.ReturnArguments))
+			}
+		},
+		AssemblyLocalDefinitionFn: func(n *ast.AssemblyLocalDefinition) { counts[n.Type]++ },
+		AssemblyAssignmentFn:      func(n *ast.AssemblyAssignment) { counts[n.Type]++ },
+		BreakStatementFn:          func(n *ast.BreakStatement) { counts[n.Type]++ },
+		AssemblyCallFn: func(n *ast.AssemblyCall) {
+			calls = append(calls, n.FunctionName)
+		},
+	})
+
+	if counts[ast.NodeAssemblyFunctionDefinition] != 1 {
+		t.Error("expected the Yul function definition")
+	}
+	if counts[ast.NodeAssemblyLocalDefinition] != 1 || counts[ast.NodeAssemblyAssignment] != 2 {
+		t.Errorf("expected 1 let and 2 assignments, got %d/%d", counts[ast.NodeAssemblyLocalDefinition], counts[ast.NodeAssemblyAssignment])
+	}
+	if counts[ast.NodeBreakStatement] != 1 {
+		t.Error("expected a break statement inside the Yul for loop")
+	}
+	want := map[string]bool{"add": false, "inc": false, "iszero": false, "revert": false, "return": false}
+	for _, name := range calls {
+		want[name] = true
+	}
+	for name, seen := range want {
+		if !seen {
+			t.Errorf("expected a call to %s", name)
+		}
+	}
+}
+
+func TestParseAssemblyMemberAccess(t *testing.T) {
+	source := `
+contract C {
+	uint[] items;
+	function f() public {
+		uint[] storage p = items;
+		assembly {
+			sstore(items.slot, sload(p.slot))
+			p.slot := 0
+		}
+		p.push(1);
+	}
+}`
+	result, errs, err := ParseWithErrors(source, nil)
+	if err != nil || len(errs) > 0 {
+		t.Fatalf("ParseWithErrors failed: %v %v", err, errs)
+	}
+
+	var paths []string
+	var targets []string
+	pushed := false
+	ast.WalkSimple(result, &ast.SimpleVisitor{
+		AssemblyMemberAccessFn: func(n *ast.AssemblyMemberAccess) {
+			paths = append(paths, n.Expression.Name+"."+n.MemberName.Name)
+		},
+		AssemblyAssignmentFn: func(n *ast.AssemblyAssignment) {
+			for _, id := range n.Names {
+				targets = append(targets, id.Name)
+			}
+			for _, target := range n.Targets {
+				if path, ok := target.(*ast.AssemblyMemberAccess); ok {
+					targets = append(targets, path.Expression.Name+"/"+path.MemberName.Name)
+				}
+			}
+		},
+		MemberAccessFn: func(n *ast.MemberAccess) { pushed = pushed || n.MemberName == "push" },
+	})
+	if !reflect.DeepEqual(paths, []string{"items.slot", "p.slot"}) {
+		t.Errorf("expected Yul paths items.slot,p.slot, got %v", paths)
+	}
+	// Names keeps the joined path, Targets its parts
+	if !reflect.DeepEqual(targets, []string{"p.slot", "p/slot"}) {
+		t.Errorf("expected assignment to p.slot, got %v", targets)
+	}
+	if !pushed {
+		t.Error("statements after the assembly block were dropped")
+	}
+}
+
+// pathVisitor implements the optional AssemblyMemberAccessVisitor
+type pathVisitor struct {
+	ast.BaseVisitor
+	paths int
+	names []string
+}
+
+func (v *pathVisitor) VisitAssemblyMemberAccess(*ast.AssemblyMemberAccess) bool {
+	v.paths++
+	return false
+}
+
+func (v *pathVisitor) VisitIdentifier(n *ast.Identifier) bool {
+	v.names = append(v.names, n.Name)
+	return true
+}
+
+func TestWalkAssemblyMemberAccess(t *testing.T) {
+	result, err := Parse(`
+contract C {
+	function f(bytes calldata b) public {
+		assembly { let o := add(b.offset, x) }
+	}
+}`, nil)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	v := &pathVisitor{}
+	ast.Walk(result, v)
+	if v.paths != 1 || len(v.names) != 0 {
+		t.Errorf("Walk: %d paths, identifiers %v; want 1 path and no descent", v.paths, v.names)
+	}
+}
+
+func TestVerifyPositions(t *testing.T) {
+	source := `
+pragma solidity ^0.8.20;
+type Price is uint128;
+error Bad(uint code, address who);
+event Moved(address indexed from, uint amount);
+contract C {
+	enum S { A, B }
+	struct P { uint a; mapping(address k => uint v) m; }
+	uint[] arr;
+	function f(uint a) external payable returns (uint, bool) {
+		(uint x, , bool y) = (1, 2, true);
+		bytes memory d = msg.data[4:];
+		unchecked { x++; --x; }
+		x = a > 1 ? a ** 2 : 0;
+		try this.g(x) returns (uint r) { x = r; } catch Error(string memory reason) { revert(reason); }
+		(bool ok, ) = msg.sender.call{value: msg.value}("");
+		emit Moved(msg.sender, type(uint).max);
+		payable(msg.sender).transfer(1 wei);
+		revert Bad({code: 1, who: msg.sender});
+		assembly {
+			function h(p) -> q { q := add(p, 1) }
+			switch h(x) case 0 { x := 1 } default { x := 2 }
+			sstore(arr.slot, x)
+		}
+		return (x + d.length, !y && ok || "a" "b".length > 0);
+	}
+	function g(uint x) external returns (uint) { return x; }
+}`
+	result, err := Parse(source, &Options{Loc: true, Range: true, VerifyPositions: true})
+	if err != nil {
+		t.Fatalf("Parse with VerifyPositions failed: %v", err)
+	}
+
+	// Calls, literals and declaration names all carry positions
+	var calls, names int
+	ast.WalkSimple(result, &ast.SimpleVisitor{
+		FunctionCallFn: func(n *ast.FunctionCall) {
+			calls++
+			if n.Loc == nil || n.Loc.Start.Line == 0 {
+				t.Errorf("FunctionCall without a position")
+			}
+		},
+		VariableDeclarationFn: func(n *ast.VariableDeclaration) {
+			if n.Identifier != nil {
+				names++
+				if n.Identifier.Range == nil || source[n.Identifier.Range[0]:n.Identifier.Range[1]] != n.Name {
+					t.Errorf("identifier of %s does not cover its name", n.Name)
+				}
+			}
+		},
+	})
+	if calls == 0 || names == 0 {
+		t.Fatalf("expected calls and declarations, got %d/%d", calls, names)
+	}
+
+	// The check catches a child outside its parent
+	outer := &ast.ExpressionStatement{
+		BaseNode:   ast.BaseNode{Type: ast.NodeExpressionStatement, Range: &ast.Range{10, 20}},
+		Expression: &ast.Identifier{BaseNode: ast.BaseNode{Type: ast.NodeIdentifier, Range: &ast.Range{5, 12}}},
+	}
+	if errs := checkPositions(outer, false, true); len(errs) != 1 {
+		t.Errorf("expected one nesting violation, got %v", errs)
+	}
+	if errs := checkPositions(outer, true, false); len(errs) != 2 {
+		t.Errorf("expected two missing locations, got %d", len(errs))
+	}
+
+	// Violations keep the tree in tolerant mode only
+	unit := &ast.SourceUnit{
+		BaseNode: ast.BaseNode{Type: ast.NodeSourceUnit, Range: &ast.Range{0, 30}},
+		Children: []ast.Node{outer},
+	}
+	for _, tolerant := range []bool{false, true} {
+		got, err := verify(unit, &Options{Range: true, VerifyPositions: true, Tolerant: tolerant})
+		pe, ok := err.(*ParserError)
+		if !ok || len(pe.Errors) != 1 || !strings.HasPrefix(pe.Errors[0].Message, "position check:") {
+			t.Errorf("tolerant %v: expected one position error, got %v", tolerant, err)
+		}
+		if (got != nil) != tolerant {
+			t.Errorf("tolerant %v: unit returned: %v", tolerant, got != nil)
+		}
+	}
+}
+
+func TestPositionUnits(t *testing.T) {
+	// "é" is 2 bytes / 1 code unit; "😀" is 4 bytes / 2 code units / 1 rune
+	source := "// é😀\ncontract C { string s = \"é😀\"; uint x = 1; }"
+	// byte, rune and UTF-16 start and end of the string literal and of x
+	want := map[PositionUnit][4]int{
+		UnitByte:  {24, 32, 39, 40},
+		UnitRune:  {24, 28, 35, 36},
+		UnitUTF16: {24, 29, 36, 37},
+	}
+	for unit, w := range want {
+		result, err := Parse(source, &Options{Loc: true, Range: true, VerifyPositions: true, PositionUnit: unit})
+		if err != nil {
+			t.Fatalf("%s: Parse failed: %v", unit, err)
+		}
+		contract := result.Children[0].(*ast.ContractDefinition)
+		lit := contract.SubNodes[0].(*ast.StateVariableDeclaration).InitialValue
+		x := contract.SubNodes[1].(*ast.StateVariableDeclaration).Variables[0].Identifier
+		got := [4]int{lit.GetLocation().Start.Column, lit.GetLocation().End.Column, x.Loc.Start.Column, x.Loc.End.Column}
+		if got != w {
+			t.Errorf("%s: columns = %v, want %v", unit, got, w)
+		}
+		// line 1 is the comment, so offsets are columns plus its length
+		lineLen := map[PositionUnit]int{UnitByte: 10, UnitRune: 6, UnitUTF16: 7}[unit]
+		if r := x.Range; r == nil || r[0] != w[2]+lineLen || r[1] != w[3]+lineLen {
+			t.Errorf("%s: range of x = %v, want [%d, %d]", unit, r, w[2]+lineLen, w[3]+lineLen)
+		}
+	}
+
+	m := NewPositionMapper(source)
+	for offset := 0; offset <= len(source); offset++ {
+		if offset < len(source) && !utf8.RuneStart(source[offset]) {
+			continue
+		}
+		u := m.Convert(offset, UnitByte, UnitUTF16)
+		if back := m.Convert(u, UnitUTF16, UnitByte); back != offset {
+			t.Errorf("offset %d -> utf16 %d -> %d", offset, u, back)
+		}
+	}
+	// An offset inside a character maps to the character's start
+	if got := m.Convert(7, UnitByte, UnitRune); got != 4 {
+		t.Errorf("mid-character offset = %d, want 4", got)
+	}
+	pos := m.ConvertPosition(ast.Position{Line: 1, Column: 6}, UnitUTF16, UnitByte)
+	if pos != (ast.Position{Line: 1, Column: 9}) {
+		t.Errorf("utf16 column 6 = %v, want byte column 9", pos)
+	}
+
+	// Error columns follow the unit too
+	bad := "string constant s = \"😀\"; contract {"
+	_, err := Parse(bad, &Options{PositionUnit: UnitUTF16})
+	_, byteErr := Parse(bad, nil)
+	perr, ok := err.(*ParserError)
+	berr, bok := byteErr.(*ParserError)
+	if !ok || !bok || berr.Errors[0].Column-perr.Errors[0].Column != 2 {
+		t.Errorf("error columns: byte %v, utf16 %v", byteErr, err)
+	}
+}
+
+func TestReparse(t *testing.T) {
+	content, err := os.ReadFile("../../testdata/test-flatten.sol")
+	if err != nil {
+		t.Skipf("Cannot read file: %v", err)
+	}
+	src := string(content)
+	opts := &Options{Loc: true, Range: true}
+	unit, err := Parse(src, opts)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// Edits that keep the source valid, and some that break or merge tokens
+	snippets := []string{"\n\t", " ", "x", "_", "uint256 y = 1;", "}", "/*", "\"", "é", ""}
+	rng := rand.New(rand.NewSource(1))
+	incremental := 0
+	for i := 0; i < 200; i++ {
+		start := rng.Intn(len(src))
+		end := start
+		if rng.Intn(3) == 0 {
+			end += rng.Intn(4)
+			if end > len(src) {
+				end = len(src)
+			}
+		}
+		edit := TextEdit{Start: start, End: end, NewText: snippets[rng.Intn(len(snippets))]}
+		next := edit.Apply(src)
+
+		want, wantErr := Parse(next, opts)
+		got, gotErr := Reparse(unit, src, edit, opts)
+		if (wantErr != nil) != (gotErr != nil) {
+			t.Fatalf("edit %+v: Reparse error %v, Parse error %v", edit, gotErr, wantErr)
+		}
+		if wantErr != nil {
+			continue
+		}
+		wantJSON, _ := json.Marshal(want)
+		gotJSON, _ := json.Marshal(got)
+		if string(wantJSON) != string(gotJSON) {
+			t.Fatalf("edit %+v: Reparse differs from Parse", edit)
+		}
+		if got == unit {
+			incremental++
+		}
+		unit, src = got, next
+	}
+	if incremental < 50 {
+		t.Errorf("only %d of the edits were reparsed incrementally", incremental)
+	}
+}
+
+func TestReparseShiftsLaterPositions(t *testing.T) {
+	src := "contract A { function f() public { uint a = 1; } uint b; } contract B { uint c; }\n// é\ncontract C {}"
+	opts := &Options{Loc: true, Range: true}
+	unit, err := Parse(src, opts)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	first := unit.Children[1]
+
+	edit := TextEdit{Start: 44, End: 45, NewText: "2\n    + 3"}
+	got, err := Reparse(unit, src, edit, opts)
+	if err != nil {
+		t.Fatalf("Reparse failed: %v", err)
+	}
+	if got != unit || got.Children[1] != first {
+		t.Error("expected the later contracts to be reused")
+	}
+	want, err := Parse(edit.Apply(src), opts)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Error("Reparse differs from Parse")
+	}
+	if loc := got.Children[1].GetLocation(); loc.Start.Line != 2 || loc.Start.Column != 21 {
+		t.Errorf("contract B starts at %+v, want 2:21", loc.Start)
+	}
+}
+
+func TestLexicalErrors(t *testing.T) {
+	src := "contract C {\n    uint x = 1__0;\n    string s = \"a\\qb\";\n    uint y = 1 +;\n}"
+	_, errs, err := ParseWithErrors(src, &Options{Tolerant: true})
+	if err != nil {
+		t.Fatalf("ParseWithErrors failed: %v", err)
+	}
+	var got []string
+	for _, e := range errs {
+		got = append(got, fmt.Sprintf("%s %d:%d", e.Code, e.Line, e.Column))
+	}
+	// Ordered by position; recovery may add more syntax errors after the first
+	want := []string{"malformed-number 2:14", "invalid-escape 3:17", "syntax 4:16"}
+	if len(got) < len(want) || !reflect.DeepEqual(got[:len(want)], want) {
+		t.Errorf("errors = %v, want %v", got, want)
+	}
+
+	// Lexical errors are fatal without Tolerant, like recovered ones
+	_, err = Parse("contract C { uint x = 0x; }", nil)
+	perr, ok := err.(*ParserError)
+	if !ok || len(perr.Errors) != 1 || perr.Errors[0].Code != CodeMalformedNumber {
+		t.Errorf("Parse error = %v", err)
+	}
+}
+
+func TestLiteralValues(t *testing.T) {
+	src := `contract C {
+    uint a = 1_000 ether;
+    uint b = 2.5e-3;
+    uint c = 0xFF;
+    uint d = 2 days;
+    uint e = .5 gwei;
+    bytes s = "a\x00€" "b";
+    bytes h = hex"00ff" hex"01_02";
+}`
+	result, err := Parse(src, &Options{LiteralValues: true})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	contract := result.Children[0].(*ast.ContractDefinition)
+	value := func(i int) ast.Node {
+		return contract.SubNodes[i].(*ast.StateVariableDeclaration).InitialValue
+	}
+
+	numbers := []string{"1000000000000000000000", "1/400", "255", "172800", "500000000"}
+	for i, want := range numbers {
+		n := value(i).(*ast.NumberLiteral)
+		if n.Value != want {
+			t.Errorf("%s %s: value = %q, want %q", n.Number, n.SubDenomination, n.Value, want)
+		}
+		if r, ok := n.Rat(); !ok || r.RatString() != want {
+			t.Errorf("%s %s: Rat = %v, %v", n.Number, n.SubDenomination, r, ok)
+		}
+	}
+
+	str := value(5).(*ast.StringLiteral)
+	if got := string(str.Bytes()); got != "a\x00€b" || str.HexValue != "6100e282ac62" {
+		t.Errorf("string literal = %q, hexValue %q", got, str.HexValue)
+	}
+	hex := value(6).(*ast.HexLiteral)
+	if got, ok := hex.Bytes(); !ok || !reflect.DeepEqual(got, []byte{0, 0xff, 1, 2}) || hex.HexValue != "00ff0102" {
+		t.Errorf("hex literal = %v, hexValue %q", got, hex.HexValue)
+	}
+
+	// The JSON fields are opt-in
+	plain, err := ParseToJSON(src, nil)
+	if err != nil {
+		t.Fatalf("ParseToJSON failed: %v", err)
+	}
+	if strings.Contains(string(plain), "hexValue") || strings.Contains(string(plain), `"value": "1/400"`) {
+		t.Errorf("literal values present without LiteralValues")
+	}
+
+	for _, number := range []string{"0x", "1.", "1e", "1e99999", "1ee2"} {
+		if r, ok := (&ast.NumberLiteral{Number: number}).Rat(); ok {
+			t.Errorf("Rat(%q) = %v, want failure", number, r)
+		}
+	}
+}
+
+func TestParsePrefixedStringLiterals(t *testing.T) {
+	src := `contract C {
+    bytes h = hex"00ff" hex'01';
+    string u = unicode"é";
+    function f() public pure returns (uint r) { assembly { r := mload(hex"20") } }
+}`
+	result, err := Parse(src, &Options{Loc: true, Range: true, VerifyPositions: true})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	contract := result.Children[0].(*ast.ContractDefinition)
+
+	hex, ok := contract.SubNodes[0].(*ast.StateVariableDeclaration).InitialValue.(*ast.HexLiteral)
+	if !ok || hex.Value != "00ff" || !reflect.DeepEqual(hex.Parts, []string{"00ff", "01"}) {
+		t.Errorf("hex literal = %+v", hex)
+	}
+	if hex.Range[0] != 27 || hex.Range[1] != 44 {
+		t.Errorf("hex literal range = %v", hex.Range)
+	}
+	str, ok := contract.SubNodes[1].(*ast.StateVariableDeclaration).InitialValue.(*ast.StringLiteral)
+	if !ok || str.Value != "é" || !str.IsUnicode {
+		t.Errorf("unicode literal = %+v", str)
+	}
+	asm := contract.SubNodes[2].(*ast.FunctionDefinition).Body.Statements[0].(*ast.InlineAssembly)
+	call := asm.Body.Operations[0].(*ast.AssemblyAssignment).Expression.(*ast.AssemblyCall)
+	if lit, ok := call.Arguments[0].(*ast.AssemblyLiteral); !ok || lit.Kind != "hex" || lit.Value != "20" {
+		t.Errorf("assembly hex literal = %+v", call.Arguments[0])
+	}
+}
+
+func TestParseFragments(t *testing.T) {
+	tests := []struct {
+		parse func(string, *Options) (ast.Node, error)
+		input string
+		want  ast.NodeType
+	}{
+		{ParseExpression, "a.b(c)", ast.NodeFunctionCall},
+		{ParseExpression, "x + 1 * y", ast.NodeBinaryOperation},
+		{ParseExpression, "addr.call{value: 1}(\"\")", ast.NodeFunctionCall},
+		{ParseStatement, "x = 1;", ast.NodeExpressionStatement},
+		{ParseStatement, "uint256[] memory x = new uint256[](3);", ast.NodeVariableDeclarationStatement},
+		{ParseStatement, "{ if (a) { revert(); } }", ast.NodeBlock},
+		{ParseStatement, "unchecked { i++; }", ast.NodeUncheckedBlock},
+		{ParseTypeName, "uint256[]", ast.NodeArrayTypeName},
+		{ParseTypeName, "mapping(address => mapping(uint => bool))", ast.NodeMapping},
+		{ParseTypeName, "function (uint) external returns (bool)", ast.NodeFunctionTypeName},
+		{ParseTypeName, "IERC20.Permit", ast.NodeUserDefinedTypeName},
+		{ParseContractBodyElement, "function f() external {}", ast.NodeFunctionDefinition},
+		{ParseContractBodyElement, "uint public constant X = 1;", ast.NodeStateVariableDeclaration},
+		{ParseContractBodyElement, "event E(address indexed a);", ast.NodeEventDefinition},
+		{ParseSourceUnitElement, "pragma solidity ^0.8.0;", ast.NodePragmaDirective},
+		{ParseSourceUnitElement, "library L {}", ast.NodeContractDefinition},
+	}
+	for _, tt := range tests {
+		n, err := tt.parse(tt.input, &Options{Loc: true, Range: true, VerifyPositions: true})
+		if err != nil {
+			t.Errorf("%q: %v", tt.input, err)
+			continue
+		}
+		if n.GetType() != tt.want {
+			t.Errorf("%q: %s, want %s", tt.input, n.GetType(), tt.want)
+		}
+		if r := n.GetRange(); r == nil || r[0] != 0 || r[1] != len(tt.input) {
+			t.Errorf("%q: range %v, want the whole input", tt.input, r)
+		}
+	}
+
+	// A fragment parses as it does in context
+	expr, err := ParseExpression("a.b(c, 1)", nil)
+	if err != nil {
+		t.Fatalf("ParseExpression failed: %v", err)
+	}
+	unit, err := Parse("contract C { function f() public { a.b(c, 1); } }", nil)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	fn := unit.Children[0].(*ast.ContractDefinition).SubNodes[0].(*ast.FunctionDefinition)
+	if in := fn.Body.Statements[0].(*ast.ExpressionStatement).Expression; !ast.Equal(expr, in, nil) {
+		t.Error("ParseExpression differs from the expression parsed in a function")
+	}
+
+	v, err := ParseVariableDeclaration("uint256[] memory x", nil)
+	if err != nil {
+		t.Fatalf("ParseVariableDeclaration failed: %v", err)
+	}
+	if v.Name != "x" || v.StorageLocation != "memory" || v.TypeName.GetType() != ast.NodeArrayTypeName {
+		t.Errorf("ParseVariableDeclaration = %+v", v)
+	}
+
+	// Positions follow PositionUnit
+	n, err := ParseExpression(`"é" + x`, &Options{Range: true, Loc: true, PositionUnit: UnitUTF16})
+	if err != nil {
+		t.Fatalf("ParseExpression failed: %v", err)
+	}
+	if x := n.(*ast.BinaryOperation).Right; x.GetRange()[0] != 6 || x.GetLocation().Start.Column != 6 {
+		t.Errorf("x at %v %+v, want UTF-16 offset 6", x.GetRange(), x.GetLocation().Start)
+	}
+
+	// The whole input must be one construct
+	errs := []struct {
+		parse func(string, *Options) (ast.Node, error)
+		input string
+	}{
+		{ParseExpression, "a.b(c) d"},
+		{ParseExpression, "a +"},
+		{ParseExpression, `"open`},
+		{ParseStatement, "x = 1"},
+		{ParseStatement, "x = 1; y = 2;"},
+		{ParseTypeName, "uint256[] memory"},
+		{ParseContractBodyElement, "pragma solidity ^0.8.0;"},
+		{ParseSourceUnitElement, ""},
+	}
+	for _, tt := range errs {
+		n, err := tt.parse(tt.input, &Options{Tolerant: true})
+		var pe *ParserError
+		if !errors.As(err, &pe) || n != nil {
+			t.Errorf("%q: got %v, %v; want a *ParserError", tt.input, n, err)
+		}
+	}
+}
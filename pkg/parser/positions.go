@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// checkPositions verifies the positions of every node under root: each node
+// carries a Loc (when loc is set) and a Range (when rng is set), and each
+// child's positions lie within its parent's. It returns one error per
+// violation, located at the offending node or, failing that, its parent.
+func checkPositions(root ast.Node, loc, rng bool) []*Error {
+	var errs []*Error
+	report := func(n, parent ast.Node, format string, args ...interface{}) {
+		e := &Error{Code: CodePosition, Message: "position check: " + fmt.Sprintf(format, args...)}
+		for _, at := range []ast.Node{n, parent} {
+			if at != nil && at.GetLocation() != nil {
+				e.Line, e.Column = at.GetLocation().Start.Line, at.GetLocation().Start.Column
+				break
+			}
+		}
+		errs = append(errs, e)
+	}
+
+	var visit func(n, parent ast.Node)
+	visit = func(n, parent ast.Node) {
+		if loc && n.GetLocation() == nil {
+			report(n, parent, "%s has no location", n.GetType())
+		}
+		if rng && n.GetRange() == nil {
+			report(n, parent, "%s has no range", n.GetType())
+		}
+		if parent != nil {
+			if l, pl := n.GetLocation(), parent.GetLocation(); loc && l != nil && pl != nil &&
+				(before(l.Start, pl.Start) || before(pl.End, l.End)) {
+				report(n, parent, "%s at %d:%d-%d:%d lies outside its parent %s at %d:%d-%d:%d",
+					n.GetType(), l.Start.Line, l.Start.Column, l.End.Line, l.End.Column,
+					parent.GetType(), pl.Start.Line, pl.Start.Column, pl.End.Line, pl.End.Column)
+			}
+			if r, pr := n.GetRange(), parent.GetRange(); rng && r != nil && pr != nil &&
+				(r[0] < pr[0] || r[1] > pr[1]) {
+				report(n, parent, "%s range [%d, %d] lies outside its parent %s range [%d, %d]",
+					n.GetType(), r[0], r[1], parent.GetType(), pr[0], pr[1])
+			}
+		}
+		for _, child := range ast.Children(n) {
+			visit(child, n)
+		}
+	}
+	if root != nil {
+		visit(root, nil)
+	}
+	return errs
+}
+
+// before reports whether position a comes strictly before b
+func before(a, b ast.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+}
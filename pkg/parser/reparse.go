@@ -0,0 +1,198 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/th13vn/solast-go/internal/builder"
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// TextEdit replaces the bytes [Start, End) of a source with NewText
+type TextEdit struct {
+	Start   int
+	End     int
+	NewText string
+}
+
+// Apply returns src with the edit applied
+func (e TextEdit) Apply(src string) string {
+	return src[:e.Start] + e.NewText + src[e.End:]
+}
+
+// Reparse returns the AST of prevSource with edit applied, equal to what
+// Parse would return for the new source. prev must be the error-free result
+// of parsing prevSource with the same opts.
+//
+// With Range enabled and byte positions, only the contract body element or
+// top-level definition enclosing the edit is relexed and rebuilt; the other
+// subtrees of prev are reused and the positions after the edit shifted in
+// place, so prev must not be used afterwards. Edits spanning several
+// elements, or leaving the enclosing one unparsable on its own, fall back to
+// a full parse.
+func Reparse(prev *ast.SourceUnit, prevSource string, edit TextEdit, opts *Options) (*ast.SourceUnit, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if edit.Start < 0 || edit.End < edit.Start || edit.End > len(prevSource) {
+		return nil, fmt.Errorf("edit [%d, %d) outside source of length %d", edit.Start, edit.End, len(prevSource))
+	}
+	src := edit.Apply(prevSource)
+	if prev == nil || !opts.Range || opts.PositionUnit != UnitByte || !reparse(prev, prevSource, src, edit, opts) {
+		return Parse(src, opts)
+	}
+	return verify(prev, opts)
+}
+
+// reparse rebuilds the innermost element of unit enclosing edit, trying a
+// contract body element before its contract. It reports false, leaving unit
+// untouched, when neither can be rebuilt on its own.
+func reparse(unit *ast.SourceUnit, old, src string, edit TextEdit, opts *Options) bool {
+	i := enclosing(unit.Children, edit)
+	if i < 0 {
+		return false
+	}
+	if c, ok := unit.Children[i].(*ast.ContractDefinition); ok {
+		if j := enclosing(c.SubNodes, edit); j >= 0 {
+			if n, s := rebuild(c.SubNodes[j], old, src, edit, opts, true); n != nil {
+				c.SubNodes[j] = n
+				s.node(c, false)
+				s.nodes(c.SubNodes[j+1:])
+				s.nodes(unit.Children[i+1:])
+				span(unit, opts)
+				return true
+			}
+		}
+	}
+	n, s := rebuild(unit.Children[i], old, src, edit, opts, false)
+	if n == nil {
+		return false
+	}
+	unit.Children[i] = n
+	s.nodes(unit.Children[i+1:])
+	span(unit, opts)
+	return true
+}
+
+// span sets the positions of unit from its first and last children, as
+// Build does
+func span(unit *ast.SourceUnit, opts *Options) {
+	first, last := unit.Children[0], unit.Children[len(unit.Children)-1]
+	unit.Range = &ast.Range{first.GetRange()[0], last.GetRange()[1]}
+	if opts.Loc {
+		unit.Loc = &ast.Location{Start: first.GetLocation().Start, End: last.GetLocation().End}
+	}
+}
+
+// enclosing returns the index of the node whose range contains edit, or -1
+func enclosing(nodes []ast.Node, edit TextEdit) int {
+	for i, n := range nodes {
+		if rng := n.GetRange(); rng != nil && rng[0] <= edit.Start && edit.End <= rng[1] {
+			return i
+		}
+	}
+	return -1
+}
+
+// rebuild relexes and parses the text of n after the edit. It returns nil if
+// that text is no longer exactly one element.
+func rebuild(n ast.Node, old, src string, edit TextEdit, opts *Options, body bool) (ast.Node, *shift) {
+	rng := n.GetRange()
+	start, end := rng[0], rng[1]+len(edit.NewText)-(edit.End-edit.Start)
+	line, column := 1, 0
+	if opts.Loc {
+		loc := n.GetLocation()
+		if loc == nil {
+			return nil, nil
+		}
+		line, column = loc.Start.Line, loc.Start.Column
+	}
+
+	b := builder.NewRegion(src, start, end, line, column, &builder.Options{Loc: opts.Loc, Range: opts.Range, LiteralValues: opts.LiteralValues})
+	if !b.Closed() {
+		return nil, nil
+	}
+	var node ast.Node
+	var err error
+	if body {
+		node, err = b.BuildContractBodyElement()
+	} else {
+		node, err = b.BuildSourceUnitElement()
+	}
+	if err != nil {
+		return nil, nil
+	}
+
+	oldLine, oldColumn := advance(line, column, old[start:edit.End])
+	newLine, newColumn := advance(line, column, src[start:edit.Start+len(edit.NewText)])
+	return node, &shift{
+		from:    rng[1],
+		delta:   end - rng[1],
+		line:    oldLine,
+		lines:   newLine - oldLine,
+		columns: newColumn - oldColumn,
+		seen:    make(map[*ast.Range]bool, 256),
+	}
+}
+
+// advance returns the position reached from line and column after text
+func advance(line, column int, text string) (int, int) {
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			line, column = line+1, 0
+		} else {
+			column++
+		}
+	}
+	return line, column
+}
+
+// shift moves the positions at or after the old end of a rebuilt element by
+// the size of the edit inside it
+type shift struct {
+	from    int // old offset where shifting starts
+	delta   int
+	line    int // old line of the edit end; columns after it move too
+	lines   int
+	columns int
+	seen    map[*ast.Range]bool
+}
+
+func (s *shift) nodes(nodes []ast.Node) {
+	for _, n := range nodes {
+		s.node(n, true)
+	}
+}
+
+// node shifts the positions of n and, if deep, of its descendants. A node
+// can be reachable twice; its positions are shifted once.
+func (s *shift) node(n ast.Node, deep bool) {
+	rng, loc := n.GetRange(), n.GetLocation()
+	if rng != nil && !s.seen[rng] {
+		s.seen[rng] = true
+		if rng[0] >= s.from {
+			rng[0] += s.delta
+			if loc != nil {
+				loc.Start = s.position(loc.Start)
+			}
+		}
+		if rng[1] >= s.from {
+			rng[1] += s.delta
+			if loc != nil {
+				loc.End = s.position(loc.End)
+			}
+		}
+	}
+	if deep {
+		for _, child := range ast.Children(n) {
+			s.node(child, true)
+		}
+	}
+}
+
+func (s *shift) position(p ast.Position) ast.Position {
+	if p.Line == s.line {
+		p.Column += s.columns
+	}
+	p.Line += s.lines
+	return p
+}
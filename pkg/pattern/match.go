@@ -0,0 +1,279 @@
+package pattern
+
+import (
+	"reflect"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// Binding is what a metavariable matched
+type Binding struct {
+	// Node: the expression, type name or statement a $X matched
+	Node ast.Node `json:"node,omitempty"`
+	// Nodes: the nodes a $...X matched, possibly none
+	Nodes []ast.Node `json:"nodes,omitempty"`
+	// Name: the name a $X matched where the pattern has a name rather than
+	// an expression, as in function $F or x.$M
+	Name string `json:"name,omitempty"`
+}
+
+// Bindings maps metavariables, such as $X and $...ARGS, to what they
+// matched
+type Bindings map[string]Binding
+
+// Match is one place a pattern matched
+type Match struct {
+	// Node: the matched node; for KindStatements the first statement
+	Node ast.Node
+	// Nodes: for KindStatements the statements from the first to the last
+	// matched, those skipped by ... included; Node alone otherwise
+	Nodes    []ast.Node
+	Bindings Bindings
+}
+
+// FindAll returns the matches of p at or below root, in source order
+// (pre-order). Matches may overlap or nest.
+func (p *Pattern) FindAll(root ast.Node) []*Match {
+	var out []*Match
+	seen := make(map[ast.Node]bool)
+	var visit func(n ast.Node)
+	visit = func(n ast.Node) {
+		if seen[n] {
+			return
+		}
+		seen[n] = true
+		if p.kind == KindStatements {
+			if block, ok := n.(*ast.Block); ok {
+				out = append(out, p.sequences(block.Statements)...)
+			}
+		} else if b, ok := match(p.root, n, Bindings{}); ok {
+			out = append(out, &Match{Node: n, Nodes: []ast.Node{n}, Bindings: b})
+		}
+		for _, child := range ast.Children(n) {
+			visit(child)
+		}
+	}
+	if !isNil(root) {
+		visit(root)
+	}
+	return out
+}
+
+// Match matches n against p, which must not be of KindStatements, and
+// returns the bindings
+func (p *Pattern) Match(n ast.Node) (Bindings, bool) {
+	if p.kind == KindStatements {
+		return nil, false
+	}
+	return match(p.root, n, Bindings{})
+}
+
+// sequences matches a KindStatements pattern from each statement of a block
+func (p *Pattern) sequences(statements []ast.Node) []*Match {
+	var out []*Match
+	for i := range statements {
+		if b, n, ok := list(p.statements, statements[i:], Bindings{}, true); ok {
+			out = append(out, &Match{Node: statements[i], Nodes: statements[i : i+n], Bindings: b})
+		}
+	}
+	return out
+}
+
+// match matches the pattern node p against t. Bindings are never changed in
+// place, so that a failed alternative leaves no trace.
+func match(p, t ast.Node, b Bindings) (Bindings, bool) {
+	if isNil(p) {
+		return b, isNil(t)
+	}
+	if isNil(t) {
+		return nil, false
+	}
+	if name, ok := ellipsis(p); ok {
+		if name == "" {
+			return b, true
+		}
+		return bind(b, name, Binding{Nodes: []ast.Node{t}})
+	}
+	if name := nameOf(p); metavariable(name) {
+		return bind(b, name, Binding{Node: t})
+	}
+	if p.GetType() != t.GetType() {
+		return nil, false
+	}
+	return fields(reflect.ValueOf(p).Elem(), reflect.ValueOf(t).Elem(), b)
+}
+
+// fields matches the fields of two structs of the same type, the embedded
+// BaseNode aside
+func fields(p, t reflect.Value, b Bindings) (Bindings, bool) {
+	for i := 0; i < p.NumField(); i++ {
+		if f := p.Type().Field(i); !f.IsExported() || f.Anonymous || derived[p.Type()] == f.Name {
+			continue
+		}
+		var ok bool
+		if b, ok = value(p.Field(i), t.Field(i), b); !ok {
+			return nil, false
+		}
+	}
+	return b, true
+}
+
+// derived are the literal fields parser.Options.LiteralValues fills in.
+// They follow from the fields written in the source, which are compared
+// instead, so a pattern matches whether or not the target has them.
+var derived = map[reflect.Type]string{
+	reflect.TypeOf(ast.NumberLiteral{}): "Value",
+	reflect.TypeOf(ast.StringLiteral{}): "HexValue",
+	reflect.TypeOf(ast.HexLiteral{}):    "HexValue",
+}
+
+var nodeInterface = reflect.TypeOf((*ast.Node)(nil)).Elem()
+
+// value matches two values of the same type
+func value(p, t reflect.Value, b Bindings) (Bindings, bool) {
+	if p.Type().Implements(nodeInterface) {
+		return match(asNode(p), asNode(t), b)
+	}
+	switch p.Kind() {
+	case reflect.Ptr:
+		if p.IsNil() || t.IsNil() {
+			return b, p.IsNil() && t.IsNil()
+		}
+		return value(p.Elem(), t.Elem(), b)
+	case reflect.Slice:
+		if p.Type().Elem().Implements(nodeInterface) {
+			b, n, ok := list(nodes(p), nodes(t), b, false)
+			return b, ok && n == t.Len()
+		}
+		if p.Len() != t.Len() {
+			return nil, false
+		}
+		for i := 0; i < p.Len(); i++ {
+			var ok bool
+			if b, ok = value(p.Index(i), t.Index(i), b); !ok {
+				return nil, false
+			}
+		}
+		return b, true
+	case reflect.Struct:
+		return fields(p, t, b)
+	case reflect.String:
+		if metavariable(p.String()) {
+			return bind(b, p.String(), Binding{Name: t.String()})
+		}
+	}
+	return b, p.Interface() == t.Interface()
+}
+
+// list matches the pattern nodes ps against ts, ellipses taking as few
+// nodes as they can. With open set, ts may go on past the match. It
+// returns how many of ts were matched.
+func list(ps, ts []ast.Node, b Bindings, open bool) (Bindings, int, bool) {
+	if len(ps) == 0 {
+		return b, 0, open || len(ts) == 0
+	}
+	if name, ok := ellipsis(ps[0]); ok {
+		for k := 0; k <= len(ts); k++ {
+			with := b
+			if name != "" {
+				if with, ok = bind(b, name, Binding{Nodes: ts[:k:k]}); !ok {
+					continue
+				}
+			}
+			if rest, n, ok := list(ps[1:], ts[k:], with, open); ok {
+				return rest, k + n, true
+			}
+		}
+		return nil, 0, false
+	}
+	if len(ts) == 0 {
+		return nil, 0, false
+	}
+	b, ok := match(ps[0], ts[0], b)
+	if !ok {
+		return nil, 0, false
+	}
+	b, n, ok := list(ps[1:], ts[1:], b, open)
+	return b, n + 1, ok
+}
+
+// bind binds name to v, or checks v against an earlier binding of name
+func bind(b Bindings, name string, v Binding) (Bindings, bool) {
+	if old, ok := b[name]; ok {
+		return b, same(old, v)
+	}
+	out := make(Bindings, len(b)+1)
+	for k, x := range b {
+		out[k] = x
+	}
+	out[name] = v
+	return out, true
+}
+
+// same reports whether two bindings of a metavariable are the same code. A
+// name and an identifier or type name of that name are the same.
+func same(a, b Binding) bool {
+	eq := &ast.EqualOptions{IgnorePositions: true}
+	switch {
+	case a.Nodes != nil || b.Nodes != nil:
+		if len(a.Nodes) != len(b.Nodes) {
+			return false
+		}
+		for i := range a.Nodes {
+			if !ast.Equal(a.Nodes[i], b.Nodes[i], eq) {
+				return false
+			}
+		}
+		return true
+	case a.Node != nil && b.Node != nil:
+		if ast.Equal(a.Node, b.Node, eq) {
+			return true
+		}
+	}
+	an, bn := a.Name, b.Name
+	if a.Node != nil {
+		an = nameOf(a.Node)
+	}
+	if b.Node != nil {
+		bn = nameOf(b.Node)
+	}
+	return an != "" && an == bn
+}
+
+// nameOf is the name of an identifier or user-defined type name, else ""
+func nameOf(n ast.Node) string {
+	switch n := n.(type) {
+	case *ast.Identifier:
+		return n.Name
+	case *ast.UserDefinedTypeName:
+		return n.NamePath
+	}
+	return ""
+}
+
+// nodes returns the elements of a slice of nodes, typed nils as nil
+func nodes(v reflect.Value) []ast.Node {
+	out := make([]ast.Node, v.Len())
+	for i := range out {
+		out[i] = asNode(v.Index(i))
+	}
+	return out
+}
+
+// asNode returns the node v holds, nil for a nil interface or pointer
+func asNode(v reflect.Value) ast.Node {
+	if (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) && v.IsNil() {
+		return nil
+	}
+	n, _ := v.Interface().(ast.Node)
+	return n
+}
+
+// isNil reports whether n is nil or a nil pointer in a Node interface
+func isNil(n ast.Node) bool {
+	if n == nil {
+		return true
+	}
+	v := reflect.ValueOf(n)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
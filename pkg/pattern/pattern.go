@@ -0,0 +1,266 @@
+// Package pattern matches Solidity code against Solidity fragments with
+// metavariables, in the manner of semgrep:
+//
+//	$X.call{value: $V}($...ARGS)
+//	require($COND, ...);
+//	function $F(...) external { ... }
+//
+// A pattern is an expression, one or more statements, or a definition,
+// parsed with the fragment entry points of package parser. In it:
+//
+//   - $X (a $ and an upper-case name) matches any expression, type name or
+//     name and binds it; a metavariable used twice must bind equal code
+//   - ... matches any number of arguments, parameters, list elements or
+//     statements; $...X also binds them
+//
+// Everything else must match exactly, positions aside: a call's options,
+// a function's modifiers and attributes are compared as written. A pattern
+// of several statements matches consecutive statements of a block, with
+// ... between them skipping any.
+package pattern
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+	"github.com/th13vn/solast-go/pkg/parser"
+)
+
+// Kind classifies a Pattern by what it was parsed as
+type Kind int
+
+// Pattern kinds
+const (
+	// KindExpression matches expressions anywhere
+	KindExpression Kind = iota
+	// KindStatement matches single statements
+	KindStatement
+	// KindStatements matches runs of statements within a block
+	KindStatements
+	// KindDefinition matches contract members or top-level definitions
+	KindDefinition
+)
+
+// Pattern is a compiled pattern
+type Pattern struct {
+	src  string
+	kind Kind
+	// root is the pattern, or for KindStatements nil
+	root ast.Node
+	// statements are the statements of a KindStatements pattern, leading
+	// unnamed ellipses removed
+	statements []ast.Node
+}
+
+// Compile parses a pattern. It is tried as an expression, as statements,
+// as a contract member and as a top-level definition, in that order; if it
+// is none, the error is the one found furthest into the pattern.
+func Compile(src string) (*Pattern, error) {
+	text := rewrite(src)
+	var last *parser.Error
+	fail := func(err error, line int) {
+		pe, ok := err.(*parser.ParserError)
+		if !ok || len(pe.Errors) == 0 {
+			return
+		}
+		e := *pe.Errors[0]
+		e.Line -= line
+		// Past the end, as at the closing brace of the block, is at the end
+		if lines := strings.Split(src, "\n"); e.Line > len(lines) {
+			e.Line, e.Column = len(lines), len(lines[len(lines)-1])
+		}
+		if last == nil || e.Line > last.Line || e.Line == last.Line && e.Column > last.Column {
+			last = &e
+		}
+	}
+
+	nothing := fmt.Errorf("pattern: %q matches nothing in particular", src)
+	n, err := parser.ParseExpression(text, nil)
+	if err == nil {
+		if _, ok := ellipsis(n); ok {
+			return nil, nothing
+		}
+		return &Pattern{src: src, kind: KindExpression, root: n}, nil
+	}
+	fail(err, 0)
+
+	// Statements are parsed as a block; the brace has a line of its own
+	// so that error positions need only a line shift
+	n, err = parser.ParseStatement("{\n"+text+"\n}", nil)
+	if err == nil {
+		statements := n.(*ast.Block).Statements
+		if onlyEllipses(statements) {
+			return nil, nothing
+		}
+		if len(statements) == 1 {
+			return &Pattern{src: src, kind: KindStatement, root: statements[0]}, nil
+		}
+		for len(statements) > 0 {
+			if name, ok := ellipsis(statements[0]); !ok || name != "" {
+				break
+			}
+			statements = statements[1:]
+		}
+		return &Pattern{src: src, kind: KindStatements, statements: statements}, nil
+	}
+	fail(err, 1)
+
+	for _, parse := range []func(string, *parser.Options) (ast.Node, error){
+		parser.ParseContractBodyElement,
+		parser.ParseSourceUnitElement,
+	} {
+		n, err := parse(text, nil)
+		if err == nil {
+			return &Pattern{src: src, kind: KindDefinition, root: n}, nil
+		}
+		fail(err, 0)
+	}
+	if last == nil {
+		return nil, fmt.Errorf("pattern: %q is not an expression, statement or definition", src)
+	}
+	return nil, fmt.Errorf("pattern: line %d:%d: %w", last.Line, last.Column, last)
+}
+
+// MustCompile is Compile for patterns known to be valid; it panics on
+// error
+func MustCompile(src string) *Pattern {
+	p, err := Compile(src)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the pattern's source
+func (p *Pattern) String() string {
+	return p.src
+}
+
+// Kind returns what the pattern was parsed as
+func (p *Pattern) Kind() Kind {
+	return p.kind
+}
+
+// Names of the identifiers that stand for ellipses in parsed patterns.
+// Both have the length of what they replace, so that error positions hold.
+const (
+	// ellipsisName replaces ...
+	ellipsisName = "$__"
+	// namedEllipsisPrefix replaces the $... of $...X
+	namedEllipsisPrefix = "$___"
+)
+
+// rewrite replaces the ellipses of a pattern, which Solidity has no syntax
+// for, with identifiers: ... with $__ and $...X with $___X. An ellipsis
+// standing as a statement, after a ';', '{' or '}' or at the start, gets
+// the ';' that makes it an expression statement.
+func rewrite(src string) string {
+	var sb strings.Builder
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '"' || c == '\'':
+			// Copy string literals as they are
+			j := i + 1
+			for j < len(src) && src[j] != c {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < len(src) {
+				j++
+			}
+			sb.WriteString(src[i:j])
+			i = j
+			continue
+		case strings.HasPrefix(src[i:], "$..."):
+			sb.WriteString(namedEllipsisPrefix)
+			j := i + 4
+			for j < len(src) && isNameByte(src[j]) {
+				j++
+			}
+			sb.WriteString(src[i+4 : j])
+			i = j
+		case strings.HasPrefix(src[i:], "..."):
+			sb.WriteString(ellipsisName)
+			i += 3
+		default:
+			sb.WriteByte(c)
+			i++
+			continue
+		}
+		// An ellipsis was written: end it as a statement if it is one
+		before := strings.TrimRight(sb.String()[:sb.Len()-len(lastWord(sb.String()))], " \t\r\n")
+		after := strings.TrimLeft(src[i:], " \t\r\n")
+		if (before == "" || strings.ContainsAny(before[len(before)-1:], ";{}")) && !strings.HasPrefix(after, ";") {
+			sb.WriteByte(';')
+		}
+	}
+	return sb.String()
+}
+
+// lastWord is the identifier at the end of s
+func lastWord(s string) string {
+	i := len(s)
+	for i > 0 && (isNameByte(s[i-1]) || s[i-1] == '$') {
+		i--
+	}
+	return s[i:]
+}
+
+func isNameByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_'
+}
+
+// metavariable reports whether name is a metavariable, such as $X or
+// $TOKEN_2
+func metavariable(name string) bool {
+	if len(name) < 2 || name[0] != '$' || name[1] < 'A' || name[1] > 'Z' {
+		return false
+	}
+	for i := 2; i < len(name); i++ {
+		if c := name[i]; !('A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// onlyEllipses reports whether statements are all ellipses, a pattern that
+// would match anything
+func onlyEllipses(statements []ast.Node) bool {
+	for _, s := range statements {
+		if _, ok := ellipsis(s); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ellipsis reports whether n stands for an ellipsis, as an expression, a
+// statement or a parameter, and returns the name it binds: "" for ...,
+// "$...X" for $...X
+func ellipsis(n ast.Node) (string, bool) {
+	var name string
+	switch n := n.(type) {
+	case *ast.Identifier:
+		name = n.Name
+	case *ast.ExpressionStatement:
+		if id, ok := n.Expression.(*ast.Identifier); ok {
+			name = id.Name
+		}
+	case *ast.VariableDeclaration:
+		if t, ok := n.TypeName.(*ast.UserDefinedTypeName); ok && n.Name == "" {
+			name = t.NamePath
+		}
+	}
+	if name == ellipsisName {
+		return "", true
+	}
+	if rest := strings.TrimPrefix(name, namedEllipsisPrefix); rest != name && metavariable("$"+rest) {
+		return "$..." + rest, true
+	}
+	return "", false
+}
@@ -0,0 +1,230 @@
+package pattern
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+	"github.com/th13vn/solast-go/pkg/parser"
+)
+
+const bankSrc = `
+contract Bank {
+	mapping(address => uint) balances;
+	address owner;
+
+	function withdraw(uint amount) external {
+		require(balances[msg.sender] >= amount, "low");
+		(bool ok, ) = msg.sender.call{value: amount}("");
+		require(ok);
+		balances[msg.sender] -= amount;
+	}
+
+	function sweep(address payable to) external {
+		require(msg.sender == owner);
+		to.transfer(address(this).balance);
+	}
+
+	function pay(address to, uint amount, bytes memory data) internal {
+		to.call{value: amount}(data);
+		to.call{value: amount, gas: 5000}(data);
+		balances[to] = balances[to];
+	}
+}
+`
+
+// text is the source of n
+func text(src string, n ast.Node) string {
+	r := n.GetRange()
+	return src[r[0]:r[1]]
+}
+
+// find returns each match of pattern in src as its source, followed by the
+// source of its bindings in name order
+func find(t *testing.T, pattern, src string) []string {
+	t.Helper()
+	unit, err := parser.Parse(src, &parser.Options{Range: true})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	p, err := Compile(pattern)
+	if err != nil {
+		t.Fatalf("Compile(%q): %v", pattern, err)
+	}
+	var out []string
+	for _, m := range p.FindAll(unit) {
+		first, last := m.Nodes[0].GetRange(), m.Nodes[len(m.Nodes)-1].GetRange()
+		s := src[first[0]:last[1]]
+		var names []string
+		for name := range m.Bindings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			b := m.Bindings[name]
+			var v string
+			switch {
+			case b.Node != nil:
+				v = text(src, b.Node)
+			case b.Nodes != nil || b.Name == "":
+				var parts []string
+				for _, n := range b.Nodes {
+					parts = append(parts, text(src, n))
+				}
+				v = "[" + strings.Join(parts, ", ") + "]"
+			default:
+				v = b.Name
+			}
+			s += " | " + name + "=" + v
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestFindAll(t *testing.T) {
+	tests := []struct {
+		pattern string
+		kind    Kind
+		want    []string
+	}{
+		// Expressions, with ellipses in argument lists
+		{`$X.call{value: $V}($...ARGS)`, KindExpression, []string{
+			`msg.sender.call{value: amount}("") | $...ARGS=[""] | $V=amount | $X=msg.sender`,
+			`to.call{value: amount}(data) | $...ARGS=[data] | $V=amount | $X=to`,
+		}},
+		{`require(...)`, KindExpression, []string{
+			`require(balances[msg.sender] >= amount, "low")`, `require(ok)`, `require(msg.sender == owner)`,
+		}},
+		{`require($COND, ...)`, KindExpression, []string{
+			`require(balances[msg.sender] >= amount, "low") | $COND=balances[msg.sender] >= amount`,
+			`require(ok) | $COND=ok`,
+			`require(msg.sender == owner) | $COND=msg.sender == owner`,
+		}},
+		{`require(msg.sender == $O)`, KindExpression, []string{`require(msg.sender == owner) | $O=owner`}},
+		// A name in member position binds the name
+		{`$X.$M(address(this).balance)`, KindExpression, []string{`to.transfer(address(this).balance) | $M=transfer | $X=to`}},
+		// A metavariable used twice must bind equal code
+		{`$A = $A`, KindExpression, []string{`balances[to] = balances[to] | $A=balances[to]`}},
+		{`$A -= $B`, KindExpression, []string{`balances[msg.sender] -= amount | $A=balances[msg.sender] | $B=amount`}},
+		// Statements
+		{`require($C);`, KindStatement, []string{`require(ok); | $C=ok`, `require(msg.sender == owner); | $C=msg.sender == owner`}},
+		{"$X.call{value: $V}($...A);\n...\nbalances[$K] -= $V;", KindStatements, nil},
+		{"(bool $OK, ) = $X.call{value: $V}(...);\nrequire($OK);\n...\nbalances[$K] -= $V;", KindStatements, []string{
+			"(bool ok, ) = msg.sender.call{value: amount}(\"\");\n\t\trequire(ok);\n\t\tbalances[msg.sender] -= amount; | $K=msg.sender | $OK=ok | $V=amount | $X=msg.sender",
+		}},
+		{"... require($C); ... $X.transfer($Y);", KindStatements, []string{
+			"require(msg.sender == owner);\n\t\tto.transfer(address(this).balance); | $C=msg.sender == owner | $X=to | $Y=address(this).balance",
+		}},
+		{"require($C);\n$...REST", KindStatements, []string{
+			"require(ok); | $...REST=[] | $C=ok",
+			"require(msg.sender == owner); | $...REST=[] | $C=msg.sender == owner",
+		}},
+		// Definitions, with ellipses in parameter lists and bodies
+		{`function $F(...) external { ... }`, KindDefinition, []string{
+			"function withdraw(uint amount) external {\n\t\trequire(balances[msg.sender] >= amount, \"low\");\n\t\t(bool ok, ) = msg.sender.call{value: amount}(\"\");\n\t\trequire(ok);\n\t\tbalances[msg.sender] -= amount;\n\t} | $F=withdraw",
+			"function sweep(address payable to) external {\n\t\trequire(msg.sender == owner);\n\t\tto.transfer(address(this).balance);\n\t} | $F=sweep",
+		}},
+		{`function $F(address $TO, ...) internal { $TO.call{value: $V}(...); ... }`, KindDefinition, []string{
+			"function pay(address to, uint amount, bytes memory data) internal {\n\t\tto.call{value: amount}(data);\n\t\tto.call{value: amount, gas: 5000}(data);\n\t\tbalances[to] = balances[to];\n\t} | $F=pay | $TO=to | $V=amount",
+		}},
+	}
+	for _, tt := range tests {
+		p, err := Compile(tt.pattern)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.pattern, err)
+			continue
+		}
+		if p.Kind() != tt.kind {
+			t.Errorf("%q: Kind() = %d, want %d", tt.pattern, p.Kind(), tt.kind)
+		}
+		if got := find(t, tt.pattern, bankSrc); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %q\nwant %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	p := MustCompile(`$X + $X`)
+	for src, want := range map[string]bool{"a + a": true, "a + b": false, "f(1) + f( 1 )": true, "a - a": false} {
+		n, err := parser.ParseExpression(src, nil)
+		if err != nil {
+			t.Fatalf("ParseExpression(%q): %v", src, err)
+		}
+		if _, ok := p.Match(n); ok != want {
+			t.Errorf("Match(%q) = %v, want %v", src, ok, want)
+		}
+	}
+	if _, ok := MustCompile("a; b;").Match(nil); ok {
+		t.Error("a statements pattern matched a single node")
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	tests := map[string]string{
+		`f(...)`:                   `f($__)`,
+		`f($...ARGS, x)`:           `f($___ARGS, x)`,
+		"a();\n...\nb();":          "a();\n$__;\nb();",
+		"{ ... }":                  "{ $__; }",
+		"...; a();":                "$__; a();",
+		`require(x, "...")`:        `require(x, "...")`,
+		`f('it\'s...', ...)`:       `f('it\'s...', $__)`,
+		"$...S\nreturn $X;":        "$___S;\nreturn $X;",
+		`function $F(...) { ... }`: `function $F($__) { $__; }`,
+	}
+	for in, want := range tests {
+		if got := rewrite(in); got != want {
+			t.Errorf("rewrite(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := map[string]string{
+		"a +":            "pattern: line 1:3:",
+		"a();\nb(;":      "pattern: line 2:2:",
+		"function f( {}": "pattern: line 1:",
+		"...\n...":       `pattern: "...\n..." matches nothing in particular`,
+		"...":            `pattern: "..." matches nothing in particular`,
+		"...;":           `pattern: "...;" matches nothing in particular`,
+		"$...X":          `pattern: "$...X" matches nothing in particular`,
+		"$...X\n...":     `pattern: "$...X\n..." matches nothing in particular`,
+	}
+	for src, want := range tests {
+		_, err := Compile(src)
+		if err == nil || !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("Compile(%q) = %v, want %s...", src, err, want)
+		}
+	}
+}
+
+func TestLiteralValues(t *testing.T) {
+	src := `
+contract C {
+	uint x;
+	uint y;
+	uint z;
+	function f(uint a) public {
+		if (a == 1) { x = 1; z = 3; y = 2; }
+		string memory s = "abc";
+		bytes memory h = hex"00ff";
+		bytes1 b = 0x01;
+		uint e = 1 ether;
+	}
+}`
+	// Patterns are parsed without literal values; targets may have them
+	for _, pattern := range []string{"$A == 1", `"abc"`, `hex"00ff"`, "0x01", "1 ether", "x = 1;\n...\ny = 2;"} {
+		p := MustCompile(pattern)
+		for _, values := range []bool{false, true} {
+			unit, err := parser.Parse(src, &parser.Options{LiteralValues: values})
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if n := len(p.FindAll(unit)); n != 1 {
+				t.Errorf("%q with LiteralValues %v: %d matches, want 1", pattern, values, n)
+			}
+		}
+	}
+}
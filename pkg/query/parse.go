@@ -0,0 +1,249 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// selectorParser reads a selector by recursive descent
+type selectorParser struct {
+	src string
+	pos int
+}
+
+func (p *selectorParser) errorf(format string, args ...any) error {
+	return &Error{Offset: p.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// peek returns the next byte, or 0 at the end
+func (p *selectorParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+// space skips white space and reports whether there was any
+func (p *selectorParser) space() bool {
+	start := p.pos
+	for p.pos < len(p.src) && strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func (p *selectorParser) expect(c byte) error {
+	p.space()
+	if p.peek() != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+// name reads an identifier, empty if there is none
+func (p *selectorParser) name() string {
+	start := p.pos
+	for p.pos < len(p.src) && isNameByte(p.src[p.pos], p.pos > start) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func isNameByte(c byte, inside bool) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || inside && '0' <= c && c <= '9'
+}
+
+// list reads selectors separated by commas; relative ones, as in :has, may
+// start with a combinator
+func (p *selectorParser) list(relative bool) ([]*complexSelector, error) {
+	var out []*complexSelector
+	for {
+		c, err := p.complex(relative)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+		p.space()
+		if p.peek() != ',' {
+			return out, nil
+		}
+		p.pos++
+	}
+}
+
+func (p *selectorParser) complex(relative bool) (*complexSelector, error) {
+	c := &complexSelector{}
+	p.space()
+	if relative {
+		c.lead = ' '
+		if p.peek() == '>' {
+			c.lead = '>'
+			p.pos++
+			p.space()
+		}
+	}
+	for {
+		part, err := p.compound()
+		if err != nil {
+			return nil, err
+		}
+		c.parts = append(c.parts, part)
+
+		spaced := p.space()
+		switch next := p.peek(); {
+		case next == '>':
+			p.pos++
+			p.space()
+			c.combinators = append(c.combinators, '>')
+		case spaced && next != 0 && next != ',' && next != ')':
+			c.combinators = append(c.combinators, ' ')
+		default:
+			return c, nil
+		}
+	}
+}
+
+func (p *selectorParser) compound() (*compound, error) {
+	c := &compound{}
+	start := p.pos
+	var fields reflect.Type // the node struct, when the type is named
+	if p.peek() == '*' {
+		p.pos++
+	} else if name := p.name(); name != "" {
+		n := ast.NewNode(ast.NodeType(name))
+		if n == nil {
+			p.pos = start
+			return nil, p.errorf("unknown node type %q", name)
+		}
+		c.nodeType = ast.NodeType(name)
+		fields = reflect.TypeOf(n).Elem()
+	}
+	for {
+		switch p.peek() {
+		case '[':
+			a, err := p.attr(fields)
+			if err != nil {
+				return nil, err
+			}
+			c.attrs = append(c.attrs, a)
+			continue
+		case ':':
+			ps, err := p.pseudo()
+			if err != nil {
+				return nil, err
+			}
+			c.pseudos = append(c.pseudos, ps)
+			continue
+		}
+		break
+	}
+	if p.pos == start {
+		return nil, p.errorf("expected a node type, '*', '[' or ':'")
+	}
+	return c, nil
+}
+
+// attrOps are the attribute operators, longest first
+var attrOps = []string{"!=", "^=", "$=", "*=", "~=", "="}
+
+// attr reads [field], [field op value]; fields, if known, is the node
+// struct the first step of the field must belong to
+func (p *selectorParser) attr(fields reflect.Type) (*attr, error) {
+	p.pos++ // [
+	p.space()
+	a := &attr{}
+	for {
+		start := p.pos
+		step := p.name()
+		if step == "" {
+			return nil, p.errorf("expected a field name")
+		}
+		if len(a.path) == 0 && fields != nil && step != "type" {
+			if _, ok := fieldIndex(fields, step); !ok {
+				p.pos = start
+				return nil, p.errorf("%s has no field %q", fields.Name(), step)
+			}
+		}
+		a.path = append(a.path, step)
+		if p.peek() != '.' {
+			break
+		}
+		p.pos++
+	}
+	p.space()
+	if p.peek() == ']' {
+		p.pos++
+		return a, nil
+	}
+	for _, op := range attrOps {
+		if strings.HasPrefix(p.src[p.pos:], op) {
+			a.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if a.op == "" {
+		return nil, p.errorf("expected ']' or an operator")
+	}
+	p.space()
+	start := p.pos
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	a.value = value
+	if a.op == "~=" {
+		if a.re, err = regexp.Compile(value); err != nil {
+			p.pos = start
+			return nil, p.errorf("%v", err)
+		}
+	}
+	return a, p.expect(']')
+}
+
+// value reads a quoted string or everything up to the closing bracket
+func (p *selectorParser) value() (string, error) {
+	if q := p.peek(); q == '"' || q == '\'' {
+		end := strings.IndexByte(p.src[p.pos+1:], q)
+		if end < 0 {
+			return "", p.errorf("unterminated string")
+		}
+		value := p.src[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return value, nil
+	}
+	end := strings.IndexByte(p.src[p.pos:], ']')
+	if end < 0 {
+		p.pos = len(p.src)
+		return "", p.errorf("expected ']'")
+	}
+	value := strings.TrimSpace(p.src[p.pos : p.pos+end])
+	p.pos += end
+	return value, nil
+}
+
+func (p *selectorParser) pseudo() (*pseudo, error) {
+	p.pos++ // :
+	start := p.pos
+	ps := &pseudo{name: p.name()}
+	switch ps.name {
+	case "not", "is", "has":
+	default:
+		p.pos = start
+		return nil, p.errorf("unknown pseudo-class %q (want not, is or has)", ps.name)
+	}
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	list, err := p.list(ps.name == "has")
+	if err != nil {
+		return nil, err
+	}
+	ps.list = list
+	return ps, p.expect(')')
+}
@@ -0,0 +1,349 @@
+// Package query finds AST nodes by structure with CSS-like selectors, so
+// that code can be searched the way grep searches text:
+//
+//	FunctionDefinition[visibility=external]:not(:has(ModifierInvocation[name=onlyOwner])) FunctionCall > MemberAccess[memberName=delegatecall]
+//
+// A selector names a node type (or * for any) followed by attribute tests
+// and pseudo-classes, and joins such steps with combinators:
+//
+//	A B          B below A at any depth
+//	A > B        B held directly by A
+//	A, B         either
+//	[f]          field f is set: not nil, empty, "" or false
+//	[f=v]        equal; also != (or unset), ^= (prefix), $= (suffix),
+//	             *= (substring) and ~= (regular expression)
+//	:not(S)      the node does not match S
+//	:is(S)       the node matches S
+//	:has(S)      a node below it matches S; :has(> S) a child does
+//
+// Fields are named as in the JSON AST. A dotted field such as
+// expression.memberName follows node fields, a field holding a list tests
+// each element (modifiers.name=onlyOwner), a field holding a node compares
+// by its type (expression=Identifier), and type is the node's own type.
+// Values may be quoted with ' or ".
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// Error reports a malformed selector
+type Error struct {
+	// Offset: the byte offset in the selector where the problem was found
+	Offset  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("offset %d: %s", e.Offset, e.Message)
+}
+
+// Selector is a compiled selector
+type Selector struct {
+	src  string
+	list []*complexSelector
+}
+
+// complexSelector is a chain of compound selectors joined by combinators
+type complexSelector struct {
+	parts []*compound
+	// combinators[i] joins parts[i] and parts[i+1]: ' ' or '>'
+	combinators []byte
+	// lead is the combinator before parts[0] in :has, relating it to the
+	// node being tested; 0 elsewhere
+	lead byte
+}
+
+// compound tests one node: its type, attributes and pseudo-classes
+type compound struct {
+	nodeType ast.NodeType // "" for *
+	attrs    []*attr
+	pseudos  []*pseudo
+}
+
+type attr struct {
+	path  []string
+	op    string // "" tests that the field is set
+	value string
+	re    *regexp.Regexp // for ~=
+}
+
+type pseudo struct {
+	name string // not, is or has
+	list []*complexSelector
+}
+
+// Compile parses a selector. Node types and the first step of each field
+// are checked against the AST, so that a misspelling is an error rather
+// than a query that never matches.
+func Compile(selector string) (*Selector, error) {
+	p := &selectorParser{src: selector}
+	list, err := p.list(false)
+	if err != nil {
+		return nil, err
+	}
+	p.space()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos])
+	}
+	return &Selector{src: selector, list: list}, nil
+}
+
+// MustCompile is Compile for selectors known to be valid; it panics on
+// error
+func MustCompile(selector string) *Selector {
+	s, err := Compile(selector)
+	if err != nil {
+		panic("query: " + selector + ": " + err.Error())
+	}
+	return s
+}
+
+// String returns the selector's source
+func (s *Selector) String() string {
+	return s.src
+}
+
+// All returns the nodes at or below root that match s, in source order
+// (pre-order)
+func (s *Selector) All(root ast.Node) []ast.Node {
+	m := &matcher{ix: ast.NewIndex(root)}
+	var out []ast.Node
+	seen := make(map[ast.Node]bool)
+	var visit func(n ast.Node)
+	visit = func(n ast.Node) {
+		if seen[n] {
+			return
+		}
+		seen[n] = true
+		if m.list(s.list, n, nil) {
+			out = append(out, n)
+		}
+		for _, child := range ast.Children(n) {
+			visit(child)
+		}
+	}
+	visit(root)
+	return out
+}
+
+// Match reports whether n matches s. ix must index a tree holding n; the
+// combinators look no higher than its root.
+func (s *Selector) Match(n ast.Node, ix *ast.Index) bool {
+	return (&matcher{ix: ix}).list(s.list, n, nil)
+}
+
+type matcher struct {
+	ix *ast.Index
+}
+
+// list reports whether n matches any selector of list; scope is the node
+// tested by :has, nil elsewhere
+func (m *matcher) list(list []*complexSelector, n, scope ast.Node) bool {
+	for _, c := range list {
+		if m.complex(c, len(c.parts)-1, n, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// complex matches parts[:i+1] of c, right to left, with parts[i] at n.
+// Ancestors are searched up to, not including, scope.
+func (m *matcher) complex(c *complexSelector, i int, n, scope ast.Node) bool {
+	if !m.compound(c.parts[i], n) {
+		return false
+	}
+	if i == 0 {
+		return c.lead != '>' || m.ix.Parent(n) == scope
+	}
+	if c.combinators[i-1] == '>' {
+		p := m.ix.Parent(n)
+		return p != nil && p != scope && m.complex(c, i-1, p, scope)
+	}
+	for p := m.ix.Parent(n); p != nil && p != scope; p = m.ix.Parent(p) {
+		if m.complex(c, i-1, p, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *matcher) compound(c *compound, n ast.Node) bool {
+	if c.nodeType != "" && n.GetType() != c.nodeType {
+		return false
+	}
+	for _, a := range c.attrs {
+		if !a.match(n) {
+			return false
+		}
+	}
+	for _, p := range c.pseudos {
+		var ok bool
+		switch p.name {
+		case "not":
+			ok = !m.list(p.list, n, nil)
+		case "is":
+			ok = m.list(p.list, n, nil)
+		case "has":
+			ok = m.has(p.list, n)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// has reports whether a node below n matches list, relative to n
+func (m *matcher) has(list []*complexSelector, n ast.Node) bool {
+	var below func(parent ast.Node) bool
+	below = func(parent ast.Node) bool {
+		for _, child := range ast.Children(parent) {
+			if m.list(list, child, n) || below(child) {
+				return true
+			}
+		}
+		return false
+	}
+	return below(n)
+}
+
+func (a *attr) match(n ast.Node) bool {
+	values := resolve(reflect.ValueOf(n), a.path)
+	if a.op == "" {
+		for _, v := range values {
+			if v != "" && v != "false" {
+				return true
+			}
+		}
+		return false
+	}
+	if a.op == "!=" {
+		return !a.test("=", values)
+	}
+	return a.test(a.op, values)
+}
+
+// test reports whether any of values passes op
+func (a *attr) test(op string, values []string) bool {
+	for _, v := range values {
+		var ok bool
+		switch op {
+		case "=":
+			ok = v == a.value
+		case "^=":
+			ok = strings.HasPrefix(v, a.value)
+		case "$=":
+			ok = strings.HasSuffix(v, a.value)
+		case "*=":
+			ok = strings.Contains(v, a.value)
+		case "~=":
+			ok = a.re.MatchString(v)
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve follows path from v and returns the values found as text; none
+// when a step is missing or nil
+func resolve(v reflect.Value, path []string) []string {
+	if !v.IsValid() {
+		return nil
+	}
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil
+	}
+	if v.Kind() == reflect.Interface {
+		return resolve(v.Elem(), path)
+	}
+	n, isNode := v.Interface().(ast.Node)
+	if v.Kind() != reflect.Ptr {
+		isNode = false
+	}
+	if len(path) == 0 {
+		return text(v, n, isNode)
+	}
+	if isNode && path[0] == "type" {
+		return resolve(reflect.ValueOf(string(n.GetType())), path[1:])
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		return resolve(v.Elem(), path)
+	case reflect.Slice:
+		var out []string
+		for i := 0; i < v.Len(); i++ {
+			out = append(out, resolve(v.Index(i), path)...)
+		}
+		return out
+	case reflect.Struct:
+		if index, ok := fieldIndex(v.Type(), path[0]); ok {
+			return resolve(v.FieldByIndex(index), path[1:])
+		}
+	}
+	return nil
+}
+
+// text is the value at the end of a field path: a node by its type, a list
+// by its elements
+func text(v reflect.Value, n ast.Node, isNode bool) []string {
+	if isNode {
+		return []string{string(n.GetType())}
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		return resolve(v.Elem(), nil)
+	case reflect.String:
+		return []string{v.String()}
+	case reflect.Bool:
+		return []string{strconv.FormatBool(v.Bool())}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []string{strconv.FormatInt(v.Int(), 10)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []string{strconv.FormatUint(v.Uint(), 10)}
+	case reflect.Slice, reflect.Array:
+		var out []string
+		for i := 0; i < v.Len(); i++ {
+			out = append(out, resolve(v.Index(i), nil)...)
+		}
+		return out
+	}
+	return nil
+}
+
+// fieldCache maps a struct type to its fields by JSON name
+var fieldCache sync.Map
+
+// fieldIndex finds the field of struct type t with the given JSON name,
+// including the fields of embedded structs
+func fieldIndex(t reflect.Type, name string) ([]int, bool) {
+	cached, ok := fieldCache.Load(t)
+	if !ok {
+		byName := make(map[string][]int)
+		for _, f := range reflect.VisibleFields(t) {
+			if !f.IsExported() || f.Anonymous {
+				continue
+			}
+			tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if tag == "" {
+				tag = f.Name
+			}
+			if _, dup := byName[tag]; !dup {
+				byName[tag] = f.Index
+			}
+		}
+		cached, _ = fieldCache.LoadOrStore(t, byName)
+	}
+	index, ok := cached.(map[string][]int)[name]
+	return index, ok
+}
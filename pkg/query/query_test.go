@@ -0,0 +1,156 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+	"github.com/th13vn/solast-go/pkg/parser"
+)
+
+const proxySrc = `
+contract Proxy {
+	address owner;
+	address impl;
+	bool public paused;
+
+	modifier onlyOwner() { require(msg.sender == owner); _; }
+
+	function upgrade(address next, bytes calldata data) external onlyOwner {
+		impl = next;
+		next.delegatecall(data);
+	}
+
+	function forward(bytes calldata data) external {
+		impl.delegatecall(data);
+		if (data.length > 0) { (bool ok, ) = impl.call(data); require(ok); }
+	}
+
+	function _exec(bytes memory data) internal {
+		impl.delegatecall(data);
+	}
+
+	function poke() external virtual {
+		assembly { sstore(impl.slot, 0) }
+	}
+}
+`
+
+// find returns the source text of each node matching selector
+func find(t *testing.T, selector, src string) []string {
+	t.Helper()
+	unit, err := parser.Parse(src, &parser.Options{Range: true})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	s, err := Compile(selector)
+	if err != nil {
+		t.Fatalf("Compile(%q): %v", selector, err)
+	}
+	var out []string
+	for _, n := range s.All(unit) {
+		r := n.GetRange()
+		out = append(out, src[r[0]:r[1]])
+	}
+	return out
+}
+
+func TestSelectors(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		// The example from the package documentation
+		{
+			"FunctionDefinition[visibility=external]:not(:has(ModifierInvocation[name=onlyOwner])) FunctionCall > MemberAccess[memberName=delegatecall]",
+			[]string{"impl.delegatecall"},
+		},
+		{"MemberAccess[memberName=delegatecall]", []string{"next.delegatecall", "impl.delegatecall", "impl.delegatecall"}},
+		{"FunctionDefinition[visibility=internal] MemberAccess[memberName=delegatecall]", []string{"impl.delegatecall"}},
+		// Child and descendant combinators
+		{"IfStatement > Identifier", nil},
+		{"IfStatement Identifier[name=ok]", []string{"ok", "ok"}},
+		{"Block > ExpressionStatement > BinaryOperation[operator='=']", []string{"impl = next"}},
+		// Attribute operators
+		{"FunctionDefinition[name^=_] > Block", []string{"{\n\t\timpl.delegatecall(data);\n\t}"}},
+		{"MemberAccess[memberName$=call]", []string{"next.delegatecall", "impl.delegatecall", "impl.call", "impl.delegatecall"}},
+		{"MemberAccess[memberName*=leg][expression.name=next]", []string{"next.delegatecall"}},
+		{"Identifier[name~='^(ok|next)$']", []string{"next", "next", "next", "ok", "ok"}},
+		{"VariableDeclaration[isStateVar][visibility!=public]", []string{"address owner;", "address impl;"}},
+		{"StateVariableDeclaration:has(> VariableDeclaration[visibility=public])", []string{"bool public paused;"}},
+		// Presence: unset, false and empty fields do not count
+		{"FunctionDefinition[isVirtual] > Block > InlineAssembly", []string{"assembly { sstore(impl.slot, 0) }"}},
+		{"FunctionDefinition[modifiers]", []string{"function upgrade(address next, bytes calldata data) external onlyOwner {\n\t\timpl = next;\n\t\tnext.delegatecall(data);\n\t}"}},
+		// Lists, nodes and type
+		{"FunctionDefinition[modifiers.name=onlyOwner] > VariableDeclaration[storageLocation=calldata]", []string{"bytes calldata data"}},
+		{"FunctionCall[expression=MemberAccess][expression.expression.name=impl]", []string{"impl.delegatecall(data)", "impl.call(data)", "impl.delegatecall(data)"}},
+		{"*[type=ModifierInvocation]", []string{"onlyOwner"}},
+		// Pseudo-classes
+		{"FunctionDefinition:has(> VariableDeclaration[storageLocation=memory]) > Block", []string{"{\n\t\timpl.delegatecall(data);\n\t}"}},
+		{"*:has(> Identifier[name=ok])", []string{"bool ok", "require(ok)"}},
+		{"FunctionDefinition:has(InlineAssembly) AssemblyMemberAccess", []string{"impl.slot"}},
+		{"ExpressionStatement > :is(FunctionCall[expression.memberName=call], BinaryOperation)", []string{"impl = next"}},
+		{"FunctionCall:not([expression=MemberAccess]) > Identifier:not([name=require])", []string{"ok"}},
+		// Lists of selectors keep source order and report a node once
+		{"ModifierInvocation, ModifierDefinition, ModifierInvocation", []string{
+			"modifier onlyOwner() { require(msg.sender == owner); _; }", "onlyOwner"}},
+	}
+	for _, tt := range tests {
+		if got := find(t, tt.selector, proxySrc); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %q\nwant %q", tt.selector, got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	unit, err := parser.Parse(proxySrc, nil)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	ix := ast.NewIndex(unit)
+	s := MustCompile("ContractDefinition FunctionDefinition[name=poke]")
+	var matched []string
+	for _, n := range unit.Children[0].(*ast.ContractDefinition).SubNodes {
+		if s.Match(n, ix) {
+			matched = append(matched, n.(*ast.FunctionDefinition).Name)
+		}
+	}
+	if !reflect.DeepEqual(matched, []string{"poke"}) {
+		t.Errorf("Match: %q, want [poke]", matched)
+	}
+	if s.String() != "ContractDefinition FunctionDefinition[name=poke]" {
+		t.Errorf("String() = %q", s.String())
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		selector string
+		offset   int
+		message  string
+	}{
+		{"FunctionDefinitoin", 0, `unknown node type "FunctionDefinitoin"`},
+		{"FunctionDefinition[visibilty=external]", 19, `FunctionDefinition has no field "visibilty"`},
+		{"Identifier[name", 15, `expected ']' or an operator`},
+		{"Identifier[name=x", 17, `expected ']'`},
+		{"Identifier[name='x]", 16, `unterminated string`},
+		{"Identifier[name~=(]", 17, "error parsing regexp: missing closing ): `(`"},
+		{"Identifier:first-child", 11, `unknown pseudo-class "first" (want not, is or has)`},
+		{"Identifier:not(Identifier", 25, `expected ')'`},
+		{"Block >", 7, `expected a node type, '*', '[' or ':'`},
+		{"Block )", 6, `unexpected ')'`},
+		{"", 0, `expected a node type, '*', '[' or ':'`},
+	}
+	for _, tt := range tests {
+		_, err := Compile(tt.selector)
+		var qe *Error
+		if !errors.As(err, &qe) {
+			t.Errorf("Compile(%q) = %v, want an *Error", tt.selector, err)
+			continue
+		}
+		if qe.Offset != tt.offset || qe.Message != tt.message {
+			t.Errorf("Compile(%q): offset %d: %s\nwant offset %d: %s", tt.selector, qe.Offset, qe.Message, tt.offset, tt.message)
+		}
+	}
+}
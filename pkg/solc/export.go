@@ -0,0 +1,772 @@
+package solc
+
+import (
+	"encoding/hex"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+func (e *exporter) sourceUnit(unit *ast.SourceUnit) Node {
+	out := e.node("SourceUnit", e.src(unit))
+	e.unitID = out["id"].(int)
+	e.scope = e.unitID
+	out["absolutePath"] = e.opts.AbsolutePath
+	nodes := []any{}
+	exported := Node{}
+	for _, child := range unit.Children {
+		n := e.definition(child)
+		nodes = append(nodes, n)
+		if n, ok := n.(Node); ok {
+			if name, _ := n["name"].(string); name != "" && exportable[n["nodeType"].(string)] {
+				ids, _ := exported[name].([]any)
+				exported[name] = append(ids, n["id"])
+			}
+		}
+	}
+	out["exportedSymbols"] = exported
+	out["nodes"] = nodes
+	return out
+}
+
+// exportable are the top-level definitions listed in exportedSymbols
+var exportable = map[string]bool{
+	"ContractDefinition":             true,
+	"FunctionDefinition":             true,
+	"StructDefinition":               true,
+	"EnumDefinition":                 true,
+	"EventDefinition":                true,
+	"ErrorDefinition":                true,
+	"UserDefinedValueTypeDefinition": true,
+	"VariableDeclaration":            true,
+}
+
+// definition converts a top-level element or contract member
+func (e *exporter) definition(n ast.Node) any {
+	switch n := n.(type) {
+	case *ast.PragmaDirective:
+		out := e.node("PragmaDirective", e.src(n))
+		out["literals"] = pragmaLiterals(n.Name, n.Value)
+		return out
+	case *ast.ImportDirective:
+		return e.importDirective(n)
+	case *ast.ContractDefinition:
+		return e.contractDefinition(n)
+	case *ast.FunctionDefinition:
+		return e.functionDefinition(n)
+	case *ast.ModifierDefinition:
+		return e.modifierDefinition(n)
+	case *ast.StateVariableDeclaration:
+		if len(n.Variables) == 0 || n.Variables[0] == nil {
+			return nil
+		}
+		out := e.variable(n.Variables[0])
+		if n.InitialValue != nil && out["value"] == nil {
+			out["value"] = e.expression(n.InitialValue)
+		}
+		// A state variable spans its whole declaration
+		out["src"] = e.src(n)
+		return out
+	case *ast.StructDefinition:
+		out := e.node("StructDefinition", e.src(n))
+		out["canonicalName"] = e.canonicalName(n.Name)
+		out["members"] = e.variables(n.Members)
+		out["name"] = n.Name
+		out["nameLocation"] = e.nameLocation(n, n.Name)
+		out["scope"] = e.scope
+		out["visibility"] = "public"
+		return out
+	case *ast.EnumDefinition:
+		out := e.node("EnumDefinition", e.src(n))
+		out["canonicalName"] = e.canonicalName(n.Name)
+		members := []any{}
+		for _, m := range n.Members {
+			v := e.node("EnumValue", e.src(m))
+			v["name"] = m.Name
+			v["nameLocation"] = e.src(m)
+			members = append(members, v)
+		}
+		out["members"] = members
+		out["name"] = n.Name
+		out["nameLocation"] = e.nameLocation(n, n.Name)
+		return out
+	case *ast.EventDefinition:
+		out := e.node("EventDefinition", e.src(n))
+		out["anonymous"] = n.IsAnonymous
+		out["name"] = n.Name
+		out["nameLocation"] = e.nameLocation(n, n.Name)
+		params := e.parameterList(n.Parameters)
+		for i, p := range params["parameters"].([]any) {
+			if p, ok := p.(Node); ok {
+				p["indexed"] = n.Parameters[i].IsIndexed
+			}
+		}
+		out["parameters"] = params
+		return out
+	case *ast.ErrorDefinition:
+		out := e.node("ErrorDefinition", e.src(n))
+		out["name"] = n.Name
+		out["nameLocation"] = e.nameLocation(n, n.Name)
+		out["parameters"] = e.parameterList(n.Parameters)
+		return out
+	case *ast.UserDefinedValueTypeDefinition:
+		out := e.node("UserDefinedValueTypeDefinition", e.src(n))
+		out["name"] = n.Name
+		out["nameLocation"] = e.nameLocation(n, n.Name)
+		out["underlyingType"] = e.typeName(n.UnderlyingType)
+		return out
+	case *ast.UsingForDeclaration:
+		return e.usingFor(n)
+	}
+	return e.statement(n)
+}
+
+func (e *exporter) canonicalName(name string) string {
+	if e.contract == nil {
+		return name
+	}
+	return e.contract.Name + "." + name
+}
+
+func (e *exporter) importDirective(n *ast.ImportDirective) Node {
+	out := e.node("ImportDirective", e.src(n))
+	out["absolutePath"] = n.Path
+	out["file"] = n.Path
+	aliases := []any{}
+	if len(n.SymbolAliasesIdentifiers) > 0 {
+		for _, a := range n.SymbolAliasesIdentifiers {
+			alias := Node{"foreign": e.identifier(a.Symbol), "local": nil, "nameLocation": "-1:-1:-1"}
+			if a.Alias != nil {
+				alias["local"] = a.Alias.Name
+				alias["nameLocation"] = e.src(a.Alias)
+			}
+			aliases = append(aliases, alias)
+		}
+	} else {
+		for _, a := range n.SymbolAliases {
+			alias := Node{"foreign": e.identifier(&ast.Identifier{Name: a.Symbol}), "local": nil, "nameLocation": "-1:-1:-1"}
+			if a.Alias != "" {
+				alias["local"] = a.Alias
+			}
+			aliases = append(aliases, alias)
+		}
+	}
+	out["symbolAliases"] = aliases
+	out["unitAlias"] = n.UnitAlias
+	return out
+}
+
+func (e *exporter) contractDefinition(c *ast.ContractDefinition) Node {
+	out := e.node("ContractDefinition", e.src(c))
+	if _, ok := e.contracts[c.Name]; !ok {
+		e.contracts[c.Name] = out
+	}
+	kind := c.Kind
+	if kind == "abstract" {
+		kind = "contract"
+	}
+	out["abstract"] = c.Kind == "abstract"
+	bases := []any{}
+	for _, b := range c.BaseContracts {
+		spec := e.node("InheritanceSpecifier", e.src(b))
+		if b.BaseName != nil {
+			spec["baseName"] = e.identifierPath(b.BaseName.NamePath, e.src(b.BaseName))
+		}
+		if b.Arguments != nil {
+			spec["arguments"] = e.list(b.Arguments)
+		}
+		bases = append(bases, spec)
+	}
+	out["baseContracts"] = bases
+	out["contractDependencies"] = []any{}
+	out["contractKind"] = kind
+	out["name"] = c.Name
+	out["nameLocation"] = e.nameLocation(c, c.Name)
+	out["scope"] = e.unitID
+
+	saved, savedScope := e.contract, e.scope
+	e.contract, e.scope = c, out["id"].(int)
+	nodes := []any{}
+	for _, m := range c.SubNodes {
+		nodes = append(nodes, e.definition(m))
+	}
+	e.contract, e.scope = saved, savedScope
+	out["nodes"] = nodes
+	out["usedErrors"] = []any{}
+	out["usedEvents"] = []any{}
+	return out
+}
+
+func (e *exporter) functionDefinition(fn *ast.FunctionDefinition) Node {
+	out := e.node("FunctionDefinition", e.src(fn))
+	kind, visibility := "function", "public"
+	switch {
+	case fn.IsConstructor:
+		kind = "constructor"
+	case fn.IsFallback:
+		kind, visibility = "fallback", "external"
+	case fn.IsReceiveEther:
+		kind, visibility = "receive", "external"
+	case e.contract == nil:
+		kind, visibility = "freeFunction", "internal"
+	case e.contract.Kind == "interface":
+		visibility = "external"
+	}
+	if fn.Visibility != "" {
+		visibility = fn.Visibility
+	}
+	out["implemented"] = fn.Body != nil
+	out["kind"] = kind
+	modifiers := []any{}
+	for _, m := range fn.Modifiers {
+		mod := e.node("ModifierInvocation", e.src(m))
+		mod["modifierName"] = e.identifierPath(m.Name, e.prefix(m, len(m.Name)))
+		mod["arguments"] = nil
+		if m.Arguments != nil {
+			mod["arguments"] = e.list(m.Arguments)
+		}
+		modifiers = append(modifiers, mod)
+	}
+	out["modifiers"] = modifiers
+	out["name"] = fn.Name
+	out["nameLocation"] = e.nameLocation(fn, fn.Name)
+	if fn.Override != nil {
+		out["overrides"] = e.overrides(fn.Override)
+	}
+	out["parameters"] = e.parameterList(fn.Parameters)
+	returns := e.parameterList(fn.ReturnParameters)
+	out["returnParameters"] = returns
+	out["scope"] = e.scope
+	out["stateMutability"] = mutability(fn.StateMutability)
+	out["virtual"] = fn.IsVirtual
+	out["visibility"] = visibility
+	if fn.Body != nil {
+		saved := e.returns
+		e.returns = returns["id"]
+		out["body"] = e.block(fn.Body)
+		e.returns = saved
+	}
+	return out
+}
+
+func (e *exporter) modifierDefinition(m *ast.ModifierDefinition) Node {
+	out := e.node("ModifierDefinition", e.src(m))
+	out["name"] = m.Name
+	out["nameLocation"] = e.nameLocation(m, m.Name)
+	if m.Override != nil {
+		out["overrides"] = e.overrides(m.Override)
+	}
+	out["parameters"] = e.parameterList(m.Parameters)
+	out["virtual"] = m.IsVirtual
+	out["visibility"] = "internal"
+	if m.Body != nil {
+		out["body"] = e.block(m.Body)
+	}
+	return out
+}
+
+func (e *exporter) usingFor(u *ast.UsingForDeclaration) Node {
+	out := e.node("UsingForDirective", e.src(u))
+	if u.LibraryName != "" {
+		out["libraryName"] = e.identifierPath(u.LibraryName, "-1:-1:-1")
+	} else {
+		functions := []any{}
+		for i, f := range u.Functions {
+			path := e.identifierPath(f, "-1:-1:-1")
+			if i < len(u.Operators) && u.Operators[i] != "" {
+				functions = append(functions, Node{"definition": path, "operator": u.Operators[i]})
+			} else {
+				functions = append(functions, Node{"function": path})
+			}
+		}
+		out["functionList"] = functions
+	}
+	out["global"] = u.IsGlobal
+	if u.TypeName != nil {
+		out["typeName"] = e.typeName(u.TypeName)
+	}
+	return out
+}
+
+func (e *exporter) overrides(bases []ast.Node) Node {
+	out := e.node("OverrideSpecifier", "-1:-1:-1")
+	paths := []any{}
+	for _, b := range bases {
+		if t, ok := b.(*ast.UserDefinedTypeName); ok {
+			paths = append(paths, e.identifierPath(t.NamePath, e.src(t)))
+		}
+	}
+	out["overrides"] = paths
+	return out
+}
+
+func (e *exporter) identifierPath(name, src string) Node {
+	out := e.node("IdentifierPath", src)
+	out["name"] = name
+	return out
+}
+
+func (e *exporter) parameterList(params []*ast.VariableDeclaration) Node {
+	out := e.node("ParameterList", e.span(params))
+	out["parameters"] = e.variables(params)
+	return out
+}
+
+func (e *exporter) variables(vars []*ast.VariableDeclaration) []any {
+	out := []any{}
+	for _, v := range vars {
+		if v == nil {
+			out = append(out, nil)
+		} else {
+			out = append(out, e.variable(v))
+		}
+	}
+	return out
+}
+
+func (e *exporter) variable(v *ast.VariableDeclaration) Node {
+	out := e.node("VariableDeclaration", e.src(v))
+	mutability, location := "mutable", v.StorageLocation
+	switch {
+	case v.IsDeclaredConst:
+		mutability = "constant"
+	case v.IsImmutable:
+		mutability = "immutable"
+	case location == "transient":
+		mutability = "transient"
+	}
+	if location == "" || location == "transient" {
+		location = "default"
+	}
+	visibility := v.Visibility
+	if visibility == "" {
+		visibility = "internal"
+	}
+	out["constant"] = v.IsDeclaredConst
+	out["mutability"] = mutability
+	out["name"] = v.Name
+	out["nameLocation"] = e.src(v.Identifier)
+	if v.Override != nil {
+		out["overrides"] = e.overrides(v.Override)
+	}
+	if v.IsStateVar {
+		out["scope"] = e.scope
+	}
+	out["stateVariable"] = v.IsStateVar
+	out["storageLocation"] = location
+	out["typeDescriptions"] = Node{}
+	out["typeName"] = e.typeName(v.TypeName)
+	if v.Expression != nil {
+		out["value"] = e.expression(v.Expression)
+	}
+	out["visibility"] = visibility
+	return out
+}
+
+// mutability is a state mutability, nonpayable if none is written
+func mutability(m string) string {
+	if m == "" {
+		return "nonpayable"
+	}
+	return m
+}
+
+func (e *exporter) typeName(n ast.Node) any {
+	switch n := n.(type) {
+	case *ast.ElementaryTypeName:
+		out := e.node("ElementaryTypeName", e.src(n))
+		out["name"] = n.Name
+		if n.StateMutability != "" {
+			out["stateMutability"] = n.StateMutability
+		}
+		out["typeDescriptions"] = Node{}
+		return out
+	case *ast.UserDefinedTypeName:
+		out := e.node("UserDefinedTypeName", e.src(n))
+		out["pathNode"] = e.identifierPath(n.NamePath, e.src(n))
+		out["typeDescriptions"] = Node{}
+		return out
+	case *ast.Mapping:
+		out := e.node("Mapping", e.src(n))
+		out["keyType"] = e.typeName(n.KeyType)
+		out["keyName"], out["keyNameLocation"] = "", "-1:-1:-1"
+		if n.KeyName != nil {
+			out["keyName"], out["keyNameLocation"] = n.KeyName.Name, e.src(n.KeyName)
+		}
+		out["valueType"] = e.typeName(n.ValueType)
+		out["valueName"], out["valueNameLocation"] = "", "-1:-1:-1"
+		if n.ValueName != nil {
+			out["valueName"], out["valueNameLocation"] = n.ValueName.Name, e.src(n.ValueName)
+		}
+		out["typeDescriptions"] = Node{}
+		return out
+	case *ast.ArrayTypeName:
+		out := e.node("ArrayTypeName", e.src(n))
+		out["baseType"] = e.typeName(n.BaseTypeName)
+		if n.Length != nil {
+			out["length"] = e.expression(n.Length)
+		}
+		out["typeDescriptions"] = Node{}
+		return out
+	case *ast.FunctionTypeName:
+		out := e.node("FunctionTypeName", e.src(n))
+		out["parameterTypes"] = e.parameterList(n.ParameterTypes)
+		out["returnParameterTypes"] = e.parameterList(n.ReturnTypes)
+		out["stateMutability"] = mutability(n.StateMutability)
+		visibility := n.Visibility
+		if visibility == "" {
+			visibility = "internal"
+		}
+		out["visibility"] = visibility
+		out["typeDescriptions"] = Node{}
+		return out
+	}
+	return e.expression(n)
+}
+
+func (e *exporter) block(b *ast.Block) any {
+	if b == nil {
+		return nil
+	}
+	out := e.node("Block", e.src(b))
+	out["statements"] = e.statements(b.Statements)
+	return out
+}
+
+func (e *exporter) statements(stmts []ast.Node) []any {
+	out := []any{}
+	for _, s := range stmts {
+		out = append(out, e.statement(s))
+	}
+	return out
+}
+
+func (e *exporter) statement(n ast.Node) any {
+	if isNil(n) {
+		return nil
+	}
+	switch n := n.(type) {
+	case *ast.Block:
+		return e.block(n)
+	case *ast.UncheckedBlock:
+		out := e.node("UncheckedBlock", e.src(n))
+		out["statements"] = []any{}
+		if n.Body != nil {
+			out["statements"] = e.statements(n.Body.Statements)
+		}
+		return out
+	case *ast.ExpressionStatement:
+		if id, ok := n.Expression.(*ast.Identifier); ok && id.Name == "_" {
+			return e.node("PlaceholderStatement", e.src(n))
+		}
+		out := e.node("ExpressionStatement", e.src(n))
+		out["expression"] = e.expression(n.Expression)
+		return out
+	case *ast.VariableDeclarationStatement:
+		out := e.node("VariableDeclarationStatement", e.src(n))
+		declarations := e.variables(n.Variables)
+		assignments := []any{}
+		for _, d := range declarations {
+			if d, ok := d.(Node); ok {
+				assignments = append(assignments, d["id"])
+			} else {
+				assignments = append(assignments, nil)
+			}
+		}
+		out["assignments"] = assignments
+		out["declarations"] = declarations
+		if n.InitialValue != nil {
+			out["initialValue"] = e.expression(n.InitialValue)
+		}
+		return out
+	case *ast.IfStatement:
+		out := e.node("IfStatement", e.src(n))
+		out["condition"] = e.expression(n.Condition)
+		out["trueBody"] = e.statement(n.TrueBody)
+		if n.FalseBody != nil {
+			out["falseBody"] = e.statement(n.FalseBody)
+		}
+		return out
+	case *ast.WhileStatement:
+		out := e.node("WhileStatement", e.src(n))
+		out["condition"] = e.expression(n.Condition)
+		out["body"] = e.statement(n.Body)
+		return out
+	case *ast.DoWhileStatement:
+		out := e.node("DoWhileStatement", e.src(n))
+		out["body"] = e.statement(n.Body)
+		out["condition"] = e.expression(n.Condition)
+		return out
+	case *ast.ForStatement:
+		out := e.node("ForStatement", e.src(n))
+		if n.InitExpression != nil {
+			out["initializationExpression"] = e.statement(n.InitExpression)
+		}
+		if n.ConditionExpression != nil {
+			out["condition"] = e.expression(n.ConditionExpression)
+		}
+		if n.LoopExpression != nil {
+			out["loopExpression"] = e.statement(n.LoopExpression)
+		}
+		out["body"] = e.statement(n.Body)
+		return out
+	case *ast.ContinueStatement:
+		return e.node("Continue", e.src(n))
+	case *ast.BreakStatement:
+		return e.node("Break", e.src(n))
+	case *ast.ReturnStatement:
+		out := e.node("Return", e.src(n))
+		if n.Expression != nil {
+			out["expression"] = e.expression(n.Expression)
+		}
+		if e.returns != nil {
+			out["functionReturnParameters"] = e.returns
+		}
+		return out
+	case *ast.EmitStatement:
+		out := e.node("EmitStatement", e.src(n))
+		out["eventCall"] = e.expression(n.EventCall)
+		return out
+	case *ast.RevertStatement:
+		return e.revert(n)
+	case *ast.TryStatement:
+		return e.tryStatement(n)
+	case *ast.InlineAssembly:
+		out := e.node("InlineAssembly", e.src(n))
+		out["AST"] = e.yulBlock(n.Body)
+		out["externalReferences"] = []any{}
+		return out
+	}
+	// An expression in statement position, such as a for loop's update
+	out := e.node("ExpressionStatement", e.src(n))
+	out["expression"] = e.expression(n)
+	return out
+}
+
+// revert converts `revert E(...)` to a RevertStatement and the revert
+// builtin, `revert("reason")` or `revert()`, to a call of it. A builtin
+// revert whose one argument is itself a call cannot be told apart from a
+// custom error without name resolution, and is taken for one.
+func (e *exporter) revert(n *ast.RevertStatement) Node {
+	if call, ok := n.RevertCall.(*ast.FunctionCall); ok {
+		out := e.node("RevertStatement", e.src(n))
+		out["errorCall"] = e.expression(call)
+		return out
+	}
+	out := e.node("ExpressionStatement", e.src(n))
+	call := e.node("FunctionCall", e.src(n))
+	callee := e.node("Identifier", e.prefix(n, len("revert")))
+	callee["name"] = "revert"
+	callee["overloadedDeclarations"] = []any{}
+	callee["typeDescriptions"] = Node{}
+	call["expression"] = callee
+	var args []ast.Node
+	switch arg := n.RevertCall.(type) {
+	case nil:
+	case *ast.TupleExpression:
+		args = arg.Components
+	default:
+		args = []ast.Node{arg}
+	}
+	call["arguments"] = e.list(args)
+	call["kind"] = "functionCall"
+	call["names"] = []any{}
+	call["tryCall"] = false
+	call["typeDescriptions"] = Node{}
+	out["expression"] = call
+	return out
+}
+
+func (e *exporter) tryStatement(n *ast.TryStatement) Node {
+	out := e.node("TryStatement", e.src(n))
+	call := e.expression(n.Expression)
+	if call, ok := call.(Node); ok && call["nodeType"] == "FunctionCall" {
+		call["tryCall"] = true
+	}
+	out["externalCall"] = call
+	success := e.node("TryCatchClause", e.src(n.Body))
+	success["errorName"] = ""
+	success["parameters"] = nil
+	if len(n.ReturnParameters) > 0 {
+		success["parameters"] = e.parameterList(n.ReturnParameters)
+	}
+	success["block"] = e.block(n.Body)
+	clauses := []any{success}
+	for _, c := range n.CatchClauses {
+		clause := e.node("TryCatchClause", e.src(c))
+		clause["errorName"] = c.Kind
+		clause["parameters"] = nil
+		if c.Parameters != nil {
+			clause["parameters"] = e.parameterList(c.Parameters)
+		}
+		clause["block"] = e.block(c.Body)
+		clauses = append(clauses, clause)
+	}
+	out["clauses"] = clauses
+	return out
+}
+
+func (e *exporter) list(nodes []ast.Node) []any {
+	out := []any{}
+	for _, n := range nodes {
+		out = append(out, e.expression(n))
+	}
+	return out
+}
+
+// assignments are the operators solc represents as Assignment
+var assignments = map[string]bool{
+	"=": true, "+=": true, "-=": true, "*=": true, "/=": true, "%=": true,
+	"|=": true, "&=": true, "^=": true, "<<=": true, ">>=": true, ">>>=": true,
+}
+
+func (e *exporter) expression(n ast.Node) any {
+	if isNil(n) {
+		return nil
+	}
+	var out Node
+	switch n := n.(type) {
+	case *ast.BinaryOperation:
+		if assignments[n.Operator] {
+			out = e.node("Assignment", e.src(n))
+			out["leftHandSide"] = e.expression(n.Left)
+			out["operator"] = n.Operator
+			out["rightHandSide"] = e.expression(n.Right)
+		} else {
+			out = e.node("BinaryOperation", e.src(n))
+			out["leftExpression"] = e.expression(n.Left)
+			out["operator"] = n.Operator
+			out["rightExpression"] = e.expression(n.Right)
+		}
+	case *ast.UnaryOperation:
+		out = e.node("UnaryOperation", e.src(n))
+		out["operator"] = n.Operator
+		out["prefix"] = n.IsPrefix
+		out["subExpression"] = e.expression(n.SubExpression)
+	case *ast.Conditional:
+		out = e.node("Conditional", e.src(n))
+		out["condition"] = e.expression(n.Condition)
+		out["trueExpression"] = e.expression(n.TrueExpression)
+		out["falseExpression"] = e.expression(n.FalseExpression)
+	case *ast.FunctionCall:
+		out = e.node("FunctionCall", e.src(n))
+		out["expression"] = e.expression(n.Expression)
+		out["arguments"] = e.list(n.Arguments)
+		out["kind"] = "functionCall"
+		if _, ok := n.Expression.(*ast.ElementaryTypeName); ok {
+			out["kind"] = "typeConversion"
+		}
+		names := []any{}
+		for _, name := range n.Names {
+			names = append(names, name)
+		}
+		out["names"] = names
+		out["tryCall"] = false
+	case *ast.FunctionCallOptions:
+		out = e.node("FunctionCallOptions", e.src(n))
+		out["expression"] = e.expression(n.Expression)
+		out["names"] = stringList(n.Names)
+		out["options"] = e.list(n.Options)
+	case *ast.NameValueExpression:
+		out = e.node("FunctionCallOptions", e.src(n))
+		out["expression"] = e.expression(n.Expression)
+		out["names"], out["options"] = []any{}, []any{}
+		if n.Arguments != nil {
+			out["names"] = stringList(n.Arguments.Names)
+			out["options"] = e.list(n.Arguments.Arguments)
+		}
+	case *ast.MemberAccess:
+		out = e.node("MemberAccess", e.src(n))
+		out["expression"] = e.expression(n.Expression)
+		out["memberName"] = n.MemberName
+	case *ast.IndexAccess:
+		out = e.node("IndexAccess", e.src(n))
+		out["baseExpression"] = e.expression(n.Base)
+		if n.Index != nil {
+			out["indexExpression"] = e.expression(n.Index)
+		}
+	case *ast.IndexRangeAccess:
+		out = e.node("IndexRangeAccess", e.src(n))
+		out["baseExpression"] = e.expression(n.Base)
+		if n.IndexStart != nil {
+			out["startExpression"] = e.expression(n.IndexStart)
+		}
+		if n.IndexEnd != nil {
+			out["endExpression"] = e.expression(n.IndexEnd)
+		}
+	case *ast.NewExpression:
+		out = e.node("NewExpression", e.src(n))
+		out["typeName"] = e.typeName(n.TypeName)
+	case *ast.TupleExpression:
+		out = e.node("TupleExpression", e.src(n))
+		out["components"] = e.list(n.Components)
+		out["isInlineArray"] = n.IsArray
+	case *ast.Identifier:
+		return e.identifier(n)
+	case *ast.ElementaryTypeName:
+		out = e.node("ElementaryTypeNameExpression", e.src(n))
+		out["typeName"] = e.typeName(n)
+	case *ast.UserDefinedTypeName, *ast.Mapping, *ast.ArrayTypeName, *ast.FunctionTypeName:
+		return e.typeName(n)
+	case *ast.NumberLiteral:
+		out = e.literal(n, "number", n.Number, []byte(n.Number))
+		if n.SubDenomination != "" {
+			out["subdenomination"] = n.SubDenomination
+		}
+		return out
+	case *ast.BooleanLiteral:
+		v := strconv.FormatBool(n.Value)
+		return e.literal(n, "bool", v, []byte(v))
+	case *ast.StringLiteral:
+		kind := "string"
+		if n.IsUnicode {
+			kind = "unicodeString"
+		}
+		b := n.Bytes()
+		return e.literal(n, kind, text(b), b)
+	case *ast.HexLiteral:
+		b, _ := n.Bytes()
+		return e.literal(n, "hexString", text(b), b)
+	default:
+		// Nodes solc has no expression for keep their type name
+		return e.node(string(n.GetType()), e.src(n))
+	}
+	out["typeDescriptions"] = Node{}
+	return out
+}
+
+func (e *exporter) identifier(id *ast.Identifier) Node {
+	out := e.node("Identifier", e.src(id))
+	out["name"] = id.Name
+	out["overloadedDeclarations"] = []any{}
+	out["typeDescriptions"] = Node{}
+	return out
+}
+
+// literal makes a Literal; solc's hexValue is the hex of the literal's
+// bytes, and of the digits as written for numbers
+func (e *exporter) literal(n ast.Node, kind string, value any, b []byte) Node {
+	out := e.node("Literal", e.src(n))
+	out["hexValue"] = hex.EncodeToString(b)
+	out["kind"] = kind
+	out["typeDescriptions"] = Node{}
+	out["value"] = value
+	return out
+}
+
+// text is b as a string value, or nil if it is not UTF-8, as solc has it
+func text(b []byte) any {
+	if !utf8.Valid(b) {
+		return nil
+	}
+	return string(b)
+}
+
+func stringList(s []string) []any {
+	out := []any{}
+	for _, v := range s {
+		out = append(out, v)
+	}
+	return out
+}
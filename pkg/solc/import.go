@@ -0,0 +1,984 @@
+package solc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+	"github.com/th13vn/solast-go/pkg/callgraph"
+	"github.com/th13vn/solast-go/pkg/parser"
+)
+
+// Program is the source units of one solc compilation, as Import reads them
+type Program struct {
+	// Paths: the source paths in source id order
+	Paths []string
+	// Units: the source units by path
+	Units map[string]*ast.SourceUnit
+	// Nodes: the imported nodes by solc id. Ids are unique across a
+	// compilation.
+	Nodes map[int]ast.Node
+	// References: solc's referencedDeclaration for identifiers, member
+	// accesses, user-defined type names, modifier invocations and Yul
+	// identifiers naming Solidity variables. Negative ids are builtins such
+	// as msg.
+	References map[ast.Node]int
+}
+
+// Declaration returns the node n refers to, or nil if solc did not resolve
+// it to a node of the program
+func (p *Program) Declaration(n ast.Node) ast.Node {
+	id, ok := p.References[n]
+	if !ok {
+		return nil
+	}
+	return p.Nodes[id]
+}
+
+// Files returns the units in source id order, as callgraph.Build and the
+// analyses built on it take them
+func (p *Program) Files() []callgraph.File {
+	files := make([]callgraph.File, 0, len(p.Paths))
+	for _, path := range p.Paths {
+		files = append(files, callgraph.File{Path: path, Unit: p.Units[path]})
+	}
+	return files
+}
+
+// ImportOptions configures Import
+type ImportOptions struct {
+	// Sources: source texts by path. The nodes of a unit whose text is given
+	// get a Loc as well as a Range.
+	Sources map[string]string
+}
+
+// Import reads the ASTs of solc's standard JSON output (sources.*.ast), or
+// a single compact JSON SourceUnit such as Export writes, into ast trees
+// shaped as the parser builds them. Every node's Range is its src; nodes
+// solc has no counterpart for, such as the Block of an UncheckedBlock, take
+// the range of the node they come from. opts may be nil.
+//
+// Import fails on output without ASTs, reporting the first error solc gave.
+func Import(data []byte, opts *ImportOptions) (*Program, error) {
+	if opts == nil {
+		opts = &ImportOptions{}
+	}
+	var doc struct {
+		NodeType string `json:"nodeType"`
+		Sources  map[string]struct {
+			ID  int  `json:"id"`
+			AST Node `json:"ast"`
+		} `json:"sources"`
+		Errors []struct {
+			Severity         string `json:"severity"`
+			FormattedMessage string `json:"formattedMessage"`
+			Message          string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("solc: %w", err)
+	}
+	p := &Program{Units: map[string]*ast.SourceUnit{}, Nodes: map[int]ast.Node{}, References: map[ast.Node]int{}}
+	if doc.NodeType == "SourceUnit" {
+		var n Node
+		if err := json.Unmarshal(data, &n); err != nil {
+			return nil, fmt.Errorf("solc: %w", err)
+		}
+		path, _ := n["absolutePath"].(string)
+		p.Paths = []string{path}
+		return p, p.add(path, n, opts)
+	}
+
+	ids := map[string]int{}
+	for path, s := range doc.Sources {
+		if s.AST != nil {
+			p.Paths = append(p.Paths, path)
+			ids[path] = s.ID
+		}
+	}
+	if len(p.Paths) == 0 {
+		for _, e := range doc.Errors {
+			if e.Severity == "error" {
+				return nil, fmt.Errorf("solc: %s", strings.TrimSpace(e.FormattedMessage+" "+e.Message))
+			}
+		}
+		return nil, fmt.Errorf("solc: no source ASTs in output")
+	}
+	sort.Slice(p.Paths, func(i, j int) bool { return ids[p.Paths[i]] < ids[p.Paths[j]] })
+	for _, path := range p.Paths {
+		if err := p.add(path, doc.Sources[path].AST, opts); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// add imports one source unit
+func (p *Program) add(path string, n Node, opts *ImportOptions) error {
+	im := &importer{prog: p}
+	if src, ok := opts.Sources[path]; ok {
+		im.mapper = parser.NewPositionMapper(src)
+	}
+	unit := im.sourceUnit(n)
+	if im.err != nil && path == "" {
+		return fmt.Errorf("solc: %w", im.err)
+	} else if im.err != nil {
+		return fmt.Errorf("solc: %s: %w", path, im.err)
+	}
+	p.Units[path] = unit
+	return nil
+}
+
+type importer struct {
+	prog   *Program
+	mapper *parser.PositionMapper // nil without the source text
+	err    error                  // the first error
+	yul    map[string]ast.Node    // Yul identifiers of the current assembly block by src
+}
+
+// base makes the BaseNode of a node converted from n
+func (im *importer) base(t ast.NodeType, n Node) ast.BaseNode {
+	src, _ := n["src"].(string)
+	return im.at(t, src)
+}
+
+// at makes a BaseNode positioned at src
+func (im *importer) at(t ast.NodeType, src string) ast.BaseNode {
+	b := ast.BaseNode{Type: t}
+	start, end, ok := parseSrc(src)
+	if !ok {
+		return b
+	}
+	b.Range = &ast.Range{start, end}
+	if im.mapper != nil {
+		b.Loc = &ast.Location{Start: im.mapper.Position(start, parser.UnitByte), End: im.mapper.Position(end, parser.UnitByte)}
+	}
+	return b
+}
+
+// parseSrc reads "start:length:fileIndex"; unknown locations are -1
+func parseSrc(src string) (start, end int, ok bool) {
+	parts := strings.Split(src, ":")
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	start, err1 := strconv.Atoi(parts[0])
+	length, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || start < 0 || length < 0 {
+		return 0, 0, false
+	}
+	return start, start + length, true
+}
+
+// record indexes node under n's id and notes what n refers to
+func (im *importer) record(n Node, node ast.Node) {
+	if id, ok := n["id"].(float64); ok {
+		im.prog.Nodes[int(id)] = node
+	}
+	im.refer(n, node)
+}
+
+// refer notes the declaration n refers to as node's
+func (im *importer) refer(n Node, node ast.Node) {
+	if ref, ok := n["referencedDeclaration"].(float64); ok {
+		im.prog.References[node] = int(ref)
+	}
+}
+
+func (im *importer) fail(n Node, format string, args ...any) {
+	if im.err == nil {
+		im.err = fmt.Errorf("%s at %v", fmt.Sprintf(format, args...), n["src"])
+	}
+}
+
+// Field accessors; a missing or mistyped field reads as the zero value
+
+func obj(n Node, key string) Node {
+	m, _ := n[key].(map[string]any)
+	return m
+}
+
+func list(n Node, key string) []any {
+	a, _ := n[key].([]any)
+	return a
+}
+
+func str(n Node, key string) string {
+	s, _ := n[key].(string)
+	return s
+}
+
+func flag(n Node, key string) bool {
+	b, _ := n[key].(bool)
+	return b
+}
+
+func (im *importer) sourceUnit(n Node) *ast.SourceUnit {
+	unit := &ast.SourceUnit{BaseNode: im.base(ast.NodeSourceUnit, n)}
+	im.record(n, unit)
+	for _, child := range list(n, "nodes") {
+		if c, ok := child.(map[string]any); ok {
+			if node := im.definition(c); node != nil {
+				unit.Children = append(unit.Children, node)
+			}
+		}
+	}
+	return unit
+}
+
+// definition converts a top-level element or contract member
+func (im *importer) definition(n Node) ast.Node {
+	switch n["nodeType"] {
+	case "PragmaDirective":
+		node := &ast.PragmaDirective{BaseNode: im.base(ast.NodePragmaDirective, n)}
+		literals := stringsOf(list(n, "literals"))
+		if len(literals) > 0 {
+			// The parser joins the value's tokens without spaces
+			node.Name, node.Value = literals[0], strings.Join(literals[1:], "")
+		}
+		im.record(n, node)
+		return node
+	case "ImportDirective":
+		node := &ast.ImportDirective{BaseNode: im.base(ast.NodeImportDirective, n), Path: str(n, "file"), UnitAlias: str(n, "unitAlias")}
+		if aliases := list(n, "symbolAliases"); len(aliases) > 0 {
+			node.SymbolAliases = []*ast.ImportSymbol{}
+			for _, a := range aliases {
+				a, _ := a.(map[string]any)
+				node.SymbolAliases = append(node.SymbolAliases, &ast.ImportSymbol{Symbol: str(obj(a, "foreign"), "name"), Alias: str(a, "local")})
+			}
+		}
+		im.record(n, node)
+		return node
+	case "ContractDefinition":
+		return im.contractDefinition(n)
+	case "FunctionDefinition":
+		return im.functionDefinition(n)
+	case "ModifierDefinition":
+		node := &ast.ModifierDefinition{
+			BaseNode:   im.base(ast.NodeModifierDefinition, n),
+			Name:       str(n, "name"),
+			Parameters: im.parameterList(obj(n, "parameters")),
+			IsVirtual:  flag(n, "virtual"),
+			Override:   im.overrides(obj(n, "overrides")),
+			Body:       im.block(obj(n, "body")),
+		}
+		im.record(n, node)
+		return node
+	case "VariableDeclaration":
+		// File-level constants too are state variables to the parser
+		v := im.variable(n)
+		v.IsStateVar = true
+		node := &ast.StateVariableDeclaration{BaseNode: v.BaseNode, Variables: []*ast.VariableDeclaration{v}, InitialValue: v.Expression}
+		node.Type = ast.NodeStateVariableDeclaration
+		v.Expression = nil
+		return node
+	case "StructDefinition":
+		node := &ast.StructDefinition{BaseNode: im.base(ast.NodeStructDefinition, n), Name: str(n, "name"), Members: im.variables(list(n, "members"))}
+		im.record(n, node)
+		return node
+	case "EnumDefinition":
+		node := &ast.EnumDefinition{BaseNode: im.base(ast.NodeEnumDefinition, n), Name: str(n, "name")}
+		for _, m := range list(n, "members") {
+			m, _ := m.(map[string]any)
+			value := &ast.EnumValue{BaseNode: im.base(ast.NodeEnumValue, m), Name: str(m, "name")}
+			im.record(m, value)
+			node.Members = append(node.Members, value)
+		}
+		im.record(n, node)
+		return node
+	case "EventDefinition":
+		node := &ast.EventDefinition{
+			BaseNode:    im.base(ast.NodeEventDefinition, n),
+			Name:        str(n, "name"),
+			Parameters:  im.parameterList(obj(n, "parameters")),
+			IsAnonymous: flag(n, "anonymous"),
+		}
+		im.record(n, node)
+		return node
+	case "ErrorDefinition":
+		node := &ast.ErrorDefinition{BaseNode: im.base(ast.NodeErrorDefinition, n), Name: str(n, "name"), Parameters: im.parameterList(obj(n, "parameters"))}
+		im.record(n, node)
+		return node
+	case "UserDefinedValueTypeDefinition":
+		node := &ast.UserDefinedValueTypeDefinition{
+			BaseNode:       im.base(ast.NodeUserDefinedValueTypeDefinition, n),
+			Name:           str(n, "name"),
+			UnderlyingType: im.typeName(obj(n, "underlyingType")),
+		}
+		im.record(n, node)
+		return node
+	case "UsingForDirective":
+		return im.usingFor(n)
+	}
+	return im.statement(n)
+}
+
+func (im *importer) contractDefinition(n Node) *ast.ContractDefinition {
+	node := &ast.ContractDefinition{
+		BaseNode:      im.base(ast.NodeContractDefinition, n),
+		Name:          str(n, "name"),
+		Kind:          str(n, "contractKind"),
+		BaseContracts: []*ast.InheritanceSpecifier{},
+		SubNodes:      []ast.Node{},
+	}
+	if flag(n, "abstract") {
+		node.Kind = "abstract"
+	}
+	im.record(n, node)
+	for _, b := range list(n, "baseContracts") {
+		b, _ := b.(map[string]any)
+		spec := &ast.InheritanceSpecifier{BaseNode: im.base(ast.NodeInheritanceSpecifier, b), BaseName: im.userDefinedTypeName(obj(b, "baseName"))}
+		if args, ok := b["arguments"].([]any); ok {
+			spec.Arguments = im.expressions(args)
+		}
+		im.record(b, spec)
+		node.BaseContracts = append(node.BaseContracts, spec)
+	}
+	for _, m := range list(n, "nodes") {
+		if m, ok := m.(map[string]any); ok {
+			if member := im.definition(m); member != nil {
+				node.SubNodes = append(node.SubNodes, member)
+			}
+		}
+	}
+	return node
+}
+
+func (im *importer) functionDefinition(n Node) *ast.FunctionDefinition {
+	node := &ast.FunctionDefinition{
+		BaseNode:         im.base(ast.NodeFunctionDefinition, n),
+		Name:             str(n, "name"),
+		Parameters:       im.parameterList(obj(n, "parameters")),
+		ReturnParameters: im.parameterList(obj(n, "returnParameters")),
+		Visibility:       str(n, "visibility"),
+		Override:         im.overrides(obj(n, "overrides")),
+		IsVirtual:        flag(n, "virtual"),
+		StateMutability:  writtenMutability(str(n, "stateMutability")),
+	}
+	im.record(n, node)
+	switch str(n, "kind") {
+	case "constructor":
+		node.IsConstructor = true
+	case "fallback":
+		node.IsFallback = true
+	case "receive":
+		node.IsReceiveEther = true
+	case "freeFunction":
+		// Free functions take no visibility
+		node.Visibility = ""
+	}
+	for _, m := range list(n, "modifiers") {
+		m, _ := m.(map[string]any)
+		mod := &ast.ModifierInvocation{BaseNode: im.base(ast.NodeModifierInvocation, m), Name: str(obj(m, "modifierName"), "name")}
+		if args, ok := m["arguments"].([]any); ok {
+			mod.Arguments = append([]ast.Node{}, im.expressions(args)...)
+		}
+		im.record(m, mod)
+		im.refer(obj(m, "modifierName"), mod)
+		node.Modifiers = append(node.Modifiers, mod)
+	}
+	if body := obj(n, "body"); body != nil {
+		node.Body = im.block(body)
+	}
+	return node
+}
+
+// writtenMutability is a state mutability as the parser records it: the
+// default, nonpayable, cannot be written
+func writtenMutability(m string) string {
+	if m == "nonpayable" {
+		return ""
+	}
+	return m
+}
+
+func (im *importer) usingFor(n Node) *ast.UsingForDeclaration {
+	node := &ast.UsingForDeclaration{BaseNode: im.base(ast.NodeUsingForDeclaration, n), IsGlobal: flag(n, "global")}
+	if lib := obj(n, "libraryName"); lib != nil {
+		node.LibraryName = pathName(lib)
+	}
+	for _, f := range list(n, "functionList") {
+		f, _ := f.(map[string]any)
+		if fn := obj(f, "function"); fn != nil {
+			node.Functions = append(node.Functions, pathName(fn))
+		} else {
+			node.Functions = append(node.Functions, pathName(obj(f, "definition")))
+			node.Operators = append(node.Operators, str(f, "operator"))
+		}
+	}
+	if t := obj(n, "typeName"); t != nil {
+		node.TypeName = im.typeName(t)
+	}
+	im.record(n, node)
+	return node
+}
+
+// pathName is the name of an IdentifierPath, or of a UserDefinedTypeName as
+// solc before 0.8 writes it
+func pathName(n Node) string {
+	if path := obj(n, "pathNode"); path != nil {
+		return str(path, "name")
+	}
+	return str(n, "name")
+}
+
+func (im *importer) overrides(n Node) []ast.Node {
+	if n == nil {
+		return nil
+	}
+	out := []ast.Node{}
+	for _, o := range list(n, "overrides") {
+		o, _ := o.(map[string]any)
+		out = append(out, im.userDefinedTypeName(o))
+	}
+	return out
+}
+
+func (im *importer) parameterList(n Node) []*ast.VariableDeclaration {
+	if n == nil {
+		return nil
+	}
+	return im.variables(list(n, "parameters"))
+}
+
+func (im *importer) variables(vars []any) []*ast.VariableDeclaration {
+	var out []*ast.VariableDeclaration
+	for _, v := range vars {
+		if v, ok := v.(map[string]any); ok {
+			out = append(out, im.variable(v))
+		} else {
+			out = append(out, nil)
+		}
+	}
+	return out
+}
+
+func (im *importer) variable(n Node) *ast.VariableDeclaration {
+	node := &ast.VariableDeclaration{
+		BaseNode:        im.base(ast.NodeVariableDeclaration, n),
+		TypeName:        im.typeName(obj(n, "typeName")),
+		Name:            str(n, "name"),
+		StorageLocation: str(n, "storageLocation"),
+		IsStateVar:      flag(n, "stateVariable"),
+		IsIndexed:       flag(n, "indexed"),
+		Override:        im.overrides(obj(n, "overrides")),
+		IsDeclaredConst: flag(n, "constant"),
+	}
+	switch str(n, "mutability") {
+	case "constant":
+		node.IsDeclaredConst = true
+	case "immutable":
+		node.IsImmutable = true
+	case "transient":
+		node.StorageLocation = "transient"
+	}
+	if node.StorageLocation == "default" {
+		node.StorageLocation = ""
+	}
+	// Only state variables can be given a visibility
+	if node.IsStateVar {
+		node.Visibility = str(n, "visibility")
+	}
+	if node.Name != "" {
+		node.Identifier = &ast.Identifier{BaseNode: im.at(ast.NodeIdentifier, str(n, "nameLocation")), Name: node.Name}
+	}
+	if value := obj(n, "value"); value != nil {
+		node.Expression = im.expression(value)
+	}
+	im.record(n, node)
+	return node
+}
+
+func (im *importer) typeName(n Node) ast.Node {
+	if n == nil {
+		return nil
+	}
+	switch n["nodeType"] {
+	case "ElementaryTypeName":
+		node := &ast.ElementaryTypeName{BaseNode: im.base(ast.NodeElementaryTypeName, n), Name: str(n, "name"), StateMutability: str(n, "stateMutability")}
+		// solc writes "address payable" as the name of a payable address
+		if name, mut, ok := strings.Cut(node.Name, " "); ok {
+			node.Name, node.StateMutability = name, mut
+		}
+		im.record(n, node)
+		return node
+	case "UserDefinedTypeName", "IdentifierPath":
+		return im.userDefinedTypeName(n)
+	case "Mapping":
+		node := &ast.Mapping{BaseNode: im.base(ast.NodeMapping, n), KeyType: im.typeName(obj(n, "keyType")), ValueType: im.typeName(obj(n, "valueType"))}
+		if name := str(n, "keyName"); name != "" {
+			node.KeyName = &ast.Identifier{BaseNode: im.at(ast.NodeIdentifier, str(n, "keyNameLocation")), Name: name}
+		}
+		if name := str(n, "valueName"); name != "" {
+			node.ValueName = &ast.Identifier{BaseNode: im.at(ast.NodeIdentifier, str(n, "valueNameLocation")), Name: name}
+		}
+		im.record(n, node)
+		return node
+	case "ArrayTypeName":
+		node := &ast.ArrayTypeName{BaseNode: im.base(ast.NodeArrayTypeName, n), BaseTypeName: im.typeName(obj(n, "baseType"))}
+		if length := obj(n, "length"); length != nil {
+			node.Length = im.expression(length)
+		}
+		im.record(n, node)
+		return node
+	case "FunctionTypeName":
+		node := &ast.FunctionTypeName{
+			BaseNode:        im.base(ast.NodeFunctionTypeName, n),
+			ParameterTypes:  im.parameterList(obj(n, "parameterTypes")),
+			ReturnTypes:     im.parameterList(obj(n, "returnParameterTypes")),
+			Visibility:      str(n, "visibility"),
+			StateMutability: writtenMutability(str(n, "stateMutability")),
+		}
+		im.record(n, node)
+		return node
+	}
+	return im.expression(n)
+}
+
+// userDefinedTypeName converts a UserDefinedTypeName or an IdentifierPath,
+// which the parser has no node of its own for
+func (im *importer) userDefinedTypeName(n Node) *ast.UserDefinedTypeName {
+	if n == nil {
+		return nil
+	}
+	node := &ast.UserDefinedTypeName{BaseNode: im.base(ast.NodeUserDefinedTypeName, n), NamePath: pathName(n)}
+	im.record(n, node)
+	return node
+}
+
+func (im *importer) block(n Node) *ast.Block {
+	if n == nil {
+		return nil
+	}
+	node := &ast.Block{BaseNode: im.base(ast.NodeBlock, n), Statements: im.statements(list(n, "statements"))}
+	im.record(n, node)
+	return node
+}
+
+func (im *importer) statements(stmts []any) []ast.Node {
+	out := []ast.Node{}
+	for _, s := range stmts {
+		if s, ok := s.(map[string]any); ok {
+			out = append(out, im.statement(s))
+		}
+	}
+	return out
+}
+
+func (im *importer) statement(n Node) ast.Node {
+	if n == nil {
+		return nil
+	}
+	var node ast.Node
+	switch n["nodeType"] {
+	case "Block":
+		return im.block(n)
+	case "UncheckedBlock":
+		body := &ast.Block{BaseNode: im.base(ast.NodeBlock, n), Statements: im.statements(list(n, "statements"))}
+		node = &ast.UncheckedBlock{BaseNode: im.base(ast.NodeUncheckedBlock, n), Body: body}
+	case "PlaceholderStatement":
+		placeholder := &ast.Identifier{BaseNode: im.base(ast.NodeIdentifier, n), Name: "_"}
+		if placeholder.Range != nil {
+			placeholder.Range = &ast.Range{placeholder.Range[0], placeholder.Range[0] + 1}
+			if placeholder.Loc != nil {
+				placeholder.Loc = &ast.Location{Start: placeholder.Loc.Start, End: ast.Position{Line: placeholder.Loc.Start.Line, Column: placeholder.Loc.Start.Column + 1}}
+			}
+		}
+		node = &ast.ExpressionStatement{BaseNode: im.base(ast.NodeExpressionStatement, n), Expression: placeholder}
+	case "ExpressionStatement":
+		expr := obj(n, "expression")
+		if revert := im.builtinRevert(n, expr); revert != nil {
+			node = revert
+			break
+		}
+		node = &ast.ExpressionStatement{BaseNode: im.base(ast.NodeExpressionStatement, n), Expression: im.expression(expr)}
+	case "VariableDeclarationStatement":
+		stmt := &ast.VariableDeclarationStatement{BaseNode: im.base(ast.NodeVariableDeclarationStatement, n), Variables: im.variables(list(n, "declarations"))}
+		if value := obj(n, "initialValue"); value != nil {
+			stmt.InitialValue = im.expression(value)
+		}
+		node = stmt
+	case "IfStatement":
+		stmt := &ast.IfStatement{BaseNode: im.base(ast.NodeIfStatement, n), Condition: im.expression(obj(n, "condition")), TrueBody: im.statement(obj(n, "trueBody"))}
+		if f := obj(n, "falseBody"); f != nil {
+			stmt.FalseBody = im.statement(f)
+		}
+		node = stmt
+	case "WhileStatement":
+		node = &ast.WhileStatement{BaseNode: im.base(ast.NodeWhileStatement, n), Condition: im.expression(obj(n, "condition")), Body: im.statement(obj(n, "body"))}
+	case "DoWhileStatement":
+		node = &ast.DoWhileStatement{BaseNode: im.base(ast.NodeDoWhileStatement, n), Condition: im.expression(obj(n, "condition")), Body: im.statement(obj(n, "body"))}
+	case "ForStatement":
+		stmt := &ast.ForStatement{BaseNode: im.base(ast.NodeForStatement, n), Body: im.statement(obj(n, "body"))}
+		if init := obj(n, "initializationExpression"); init != nil {
+			stmt.InitExpression = im.statement(init)
+		}
+		if cond := obj(n, "condition"); cond != nil {
+			stmt.ConditionExpression = im.expression(cond)
+		}
+		// The parser keeps the update as a bare expression
+		if loop := obj(n, "loopExpression"); loop != nil {
+			stmt.LoopExpression = im.expression(obj(loop, "expression"))
+		}
+		node = stmt
+	case "Continue":
+		node = &ast.ContinueStatement{BaseNode: im.base(ast.NodeContinueStatement, n)}
+	case "Break":
+		node = &ast.BreakStatement{BaseNode: im.base(ast.NodeBreakStatement, n)}
+	case "Return":
+		stmt := &ast.ReturnStatement{BaseNode: im.base(ast.NodeReturnStatement, n)}
+		if expr := obj(n, "expression"); expr != nil {
+			stmt.Expression = im.expression(expr)
+		}
+		node = stmt
+	case "EmitStatement":
+		node = &ast.EmitStatement{BaseNode: im.base(ast.NodeEmitStatement, n), EventCall: im.expression(obj(n, "eventCall"))}
+	case "RevertStatement":
+		node = &ast.RevertStatement{BaseNode: im.base(ast.NodeRevertStatement, n), RevertCall: im.expression(obj(n, "errorCall"))}
+	case "TryStatement":
+		node = im.tryStatement(n)
+	case "InlineAssembly":
+		asm := &ast.InlineAssembly{BaseNode: im.base(ast.NodeInlineAssembly, n)}
+		im.yul = map[string]ast.Node{}
+		if body := obj(n, "AST"); body != nil {
+			asm.Body = im.yulBlock(body)
+		}
+		for _, ref := range list(n, "externalReferences") {
+			ref, _ := ref.(map[string]any)
+			if id, ok := im.yul[str(ref, "src")]; ok {
+				if decl, ok := ref["declaration"].(float64); ok {
+					im.prog.References[id] = int(decl)
+				}
+			}
+		}
+		im.yul = nil
+		node = asm
+	default:
+		im.fail(n, "unknown node type %q", n["nodeType"])
+		return nil
+	}
+	im.record(n, node)
+	return node
+}
+
+// builtinRevert converts a call of the revert builtin, a statement of its
+// own to the parser, to a RevertStatement; it returns nil for other
+// statements
+func (im *importer) builtinRevert(stmt, expr Node) *ast.RevertStatement {
+	if expr["nodeType"] != "FunctionCall" {
+		return nil
+	}
+	callee := obj(expr, "expression")
+	if callee["nodeType"] != "Identifier" || str(callee, "name") != "revert" {
+		return nil
+	}
+	node := &ast.RevertStatement{BaseNode: im.base(ast.NodeRevertStatement, stmt)}
+	args := im.expressions(list(expr, "arguments"))
+	if len(args) == 1 {
+		node.RevertCall = args[0]
+	} else {
+		node.RevertCall = &ast.TupleExpression{BaseNode: im.base(ast.NodeTupleExpression, expr), Components: append([]ast.Node{}, args...)}
+	}
+	im.record(expr, node)
+	return node
+}
+
+func (im *importer) tryStatement(n Node) *ast.TryStatement {
+	node := &ast.TryStatement{BaseNode: im.base(ast.NodeTryStatement, n), Expression: im.expression(obj(n, "externalCall")), CatchClauses: []*ast.CatchClause{}}
+	for i, c := range list(n, "clauses") {
+		c, _ := c.(map[string]any)
+		if i == 0 {
+			// The first clause is the success block
+			node.ReturnParameters = im.parameterList(obj(c, "parameters"))
+			node.Body = im.block(obj(c, "block"))
+			im.record(c, node.Body)
+			continue
+		}
+		clause := &ast.CatchClause{
+			BaseNode:   im.base(ast.NodeCatchClause, c),
+			Kind:       str(c, "errorName"),
+			Parameters: im.parameterList(obj(c, "parameters")),
+			Body:       im.block(obj(c, "block")),
+		}
+		clause.IsReasonStringType = clause.Kind == "Error"
+		im.record(c, clause)
+		node.CatchClauses = append(node.CatchClauses, clause)
+	}
+	return node
+}
+
+func (im *importer) expressions(exprs []any) []ast.Node {
+	var out []ast.Node
+	for _, e := range exprs {
+		if e, ok := e.(map[string]any); ok {
+			out = append(out, im.expression(e))
+		} else {
+			out = append(out, nil)
+		}
+	}
+	return out
+}
+
+func (im *importer) expression(n Node) ast.Node {
+	if n == nil {
+		return nil
+	}
+	var node ast.Node
+	switch n["nodeType"] {
+	case "Assignment":
+		node = &ast.BinaryOperation{
+			BaseNode: im.base(ast.NodeBinaryOperation, n),
+			Operator: str(n, "operator"),
+			Left:     im.expression(obj(n, "leftHandSide")),
+			Right:    im.expression(obj(n, "rightHandSide")),
+		}
+	case "BinaryOperation":
+		node = &ast.BinaryOperation{
+			BaseNode: im.base(ast.NodeBinaryOperation, n),
+			Operator: str(n, "operator"),
+			Left:     im.expression(obj(n, "leftExpression")),
+			Right:    im.expression(obj(n, "rightExpression")),
+		}
+	case "UnaryOperation":
+		node = &ast.UnaryOperation{
+			BaseNode:      im.base(ast.NodeUnaryOperation, n),
+			Operator:      str(n, "operator"),
+			SubExpression: im.expression(obj(n, "subExpression")),
+			IsPrefix:      flag(n, "prefix"),
+		}
+	case "Conditional":
+		node = &ast.Conditional{
+			BaseNode:        im.base(ast.NodeConditional, n),
+			Condition:       im.expression(obj(n, "condition")),
+			TrueExpression:  im.expression(obj(n, "trueExpression")),
+			FalseExpression: im.expression(obj(n, "falseExpression")),
+		}
+	case "FunctionCall":
+		node = &ast.FunctionCall{
+			BaseNode:   im.base(ast.NodeFunctionCall, n),
+			Expression: im.expression(obj(n, "expression")),
+			Arguments:  im.expressions(list(n, "arguments")),
+			Names:      stringsOf(list(n, "names")),
+		}
+	case "FunctionCallOptions":
+		node = &ast.FunctionCallOptions{
+			BaseNode:   im.base(ast.NodeFunctionCallOptions, n),
+			Expression: im.expression(obj(n, "expression")),
+			Names:      stringsOf(list(n, "names")),
+			Options:    im.expressions(list(n, "options")),
+		}
+	case "MemberAccess":
+		node = &ast.MemberAccess{BaseNode: im.base(ast.NodeMemberAccess, n), Expression: im.expression(obj(n, "expression")), MemberName: str(n, "memberName")}
+	case "IndexAccess":
+		access := &ast.IndexAccess{BaseNode: im.base(ast.NodeIndexAccess, n), Base: im.expression(obj(n, "baseExpression"))}
+		if index := obj(n, "indexExpression"); index != nil {
+			access.Index = im.expression(index)
+		}
+		node = access
+	case "IndexRangeAccess":
+		access := &ast.IndexRangeAccess{BaseNode: im.base(ast.NodeIndexRangeAccess, n), Base: im.expression(obj(n, "baseExpression"))}
+		if start := obj(n, "startExpression"); start != nil {
+			access.IndexStart = im.expression(start)
+		}
+		if end := obj(n, "endExpression"); end != nil {
+			access.IndexEnd = im.expression(end)
+		}
+		node = access
+	case "NewExpression":
+		node = &ast.NewExpression{BaseNode: im.base(ast.NodeNewExpression, n), TypeName: im.typeName(obj(n, "typeName"))}
+	case "TupleExpression":
+		node = &ast.TupleExpression{
+			BaseNode:   im.base(ast.NodeTupleExpression, n),
+			Components: append([]ast.Node{}, im.expressions(list(n, "components"))...),
+			IsArray:    flag(n, "isInlineArray"),
+		}
+	case "Identifier":
+		node = &ast.Identifier{BaseNode: im.base(ast.NodeIdentifier, n), Name: str(n, "name")}
+	case "ElementaryTypeNameExpression":
+		// The parser has the type name itself in expressions
+		t := im.typeName(obj(n, "typeName"))
+		if e, ok := t.(*ast.ElementaryTypeName); ok {
+			e.BaseNode = im.base(ast.NodeElementaryTypeName, n)
+		}
+		node = t
+	case "Literal":
+		node = im.literal(n)
+	case "ElementaryTypeName", "UserDefinedTypeName", "IdentifierPath", "Mapping", "ArrayTypeName", "FunctionTypeName":
+		return im.typeName(n)
+	default:
+		im.fail(n, "unknown node type %q", n["nodeType"])
+		return nil
+	}
+	im.record(n, node)
+	return node
+}
+
+// literal converts a Literal by its kind. String contents come from
+// hexValue, as value is null when they are not UTF-8.
+func (im *importer) literal(n Node) ast.Node {
+	b, _ := hex.DecodeString(str(n, "hexValue"))
+	switch str(n, "kind") {
+	case "number":
+		return &ast.NumberLiteral{BaseNode: im.base(ast.NodeNumberLiteral, n), Number: str(n, "value"), SubDenomination: str(n, "subdenomination")}
+	case "bool":
+		return &ast.BooleanLiteral{BaseNode: im.base(ast.NodeBooleanLiteral, n), Value: str(n, "value") == "true"}
+	case "hexString":
+		value := str(n, "hexValue")
+		return &ast.HexLiteral{BaseNode: im.base(ast.NodeHexLiteral, n), Value: value, Parts: []string{value}}
+	}
+	value := string(b)
+	return &ast.StringLiteral{BaseNode: im.base(ast.NodeStringLiteral, n), Value: value, Parts: []string{value}, IsUnicode: str(n, "kind") == "unicodeString"}
+}
+
+func stringsOf(values []any) []string {
+	var out []string
+	for _, v := range values {
+		s, _ := v.(string)
+		out = append(out, s)
+	}
+	return out
+}
+
+func (im *importer) yulBlock(n Node) *ast.AssemblyBlock {
+	if n == nil {
+		return nil
+	}
+	node := &ast.AssemblyBlock{BaseNode: im.base(ast.NodeAssemblyBlock, n), Operations: []ast.Node{}}
+	for _, s := range list(n, "statements") {
+		if s, ok := s.(map[string]any); ok {
+			node.Operations = append(node.Operations, im.yulStatement(s))
+		}
+	}
+	return node
+}
+
+func (im *importer) yulStatement(n Node) ast.Node {
+	switch n["nodeType"] {
+	case "YulBlock":
+		return im.yulBlock(n)
+	case "YulVariableDeclaration":
+		node := &ast.AssemblyLocalDefinition{BaseNode: im.base(ast.NodeAssemblyLocalDefinition, n), Names: im.yulNames(list(n, "variables"))}
+		if value := obj(n, "value"); value != nil {
+			node.Expression = im.yulExpression(value)
+		}
+		return node
+	case "YulAssignment":
+		var targets []ast.Node
+		for _, name := range list(n, "variableNames") {
+			if name, _ := name.(map[string]any); strings.Contains(str(name, "name"), ".") {
+				// p.slot := v, as the parser keeps it: the joined path in
+				// Names and the member access in Targets
+				targets = append(targets, im.yulExpression(name))
+			}
+		}
+		return &ast.AssemblyAssignment{
+			BaseNode:   im.base(ast.NodeAssemblyAssignment, n),
+			Names:      im.yulNames(list(n, "variableNames")),
+			Targets:    targets,
+			Expression: im.yulExpression(obj(n, "value")),
+		}
+	case "YulIf":
+		return &ast.AssemblyIf{BaseNode: im.base(ast.NodeAssemblyIf, n), Condition: im.yulExpression(obj(n, "condition")), Body: im.yulBlock(obj(n, "body"))}
+	case "YulSwitch":
+		node := &ast.AssemblySwitch{BaseNode: im.base(ast.NodeAssemblySwitch, n), Expression: im.yulExpression(obj(n, "expression")), Cases: []*ast.AssemblyCase{}}
+		for _, c := range list(n, "cases") {
+			c, _ := c.(map[string]any)
+			yc := &ast.AssemblyCase{BaseNode: im.base(ast.NodeAssemblyCase, c), Body: im.yulBlock(obj(c, "body"))}
+			if value := obj(c, "value"); value != nil {
+				yc.Value = im.yulExpression(value)
+			} else {
+				yc.Default = true
+			}
+			node.Cases = append(node.Cases, yc)
+		}
+		return node
+	case "YulForLoop":
+		return &ast.AssemblyFor{
+			BaseNode:  im.base(ast.NodeAssemblyFor, n),
+			Pre:       im.yulBlock(obj(n, "pre")),
+			Condition: im.yulExpression(obj(n, "condition")),
+			Post:      im.yulBlock(obj(n, "post")),
+			Body:      im.yulBlock(obj(n, "body")),
+		}
+	case "YulFunctionDefinition":
+		return &ast.AssemblyFunctionDefinition{
+			BaseNode:        im.base(ast.NodeAssemblyFunctionDefinition, n),
+			Name:            str(n, "name"),
+			Arguments:       im.yulNames(list(n, "parameters")),
+			ReturnArguments: im.yulNames(list(n, "returnVariables")),
+			Body:            im.yulBlock(obj(n, "body")),
+		}
+	case "YulBreak":
+		return &ast.BreakStatement{BaseNode: im.base(ast.NodeBreakStatement, n)}
+	case "YulContinue":
+		return &ast.ContinueStatement{BaseNode: im.base(ast.NodeContinueStatement, n)}
+	case "YulLeave":
+		// The parser reads leave as an identifier
+		return &ast.Identifier{BaseNode: im.base(ast.NodeIdentifier, n), Name: "leave"}
+	case "YulExpressionStatement":
+		// and has no statement node around calls
+		return im.yulExpression(obj(n, "expression"))
+	}
+	im.fail(n, "unknown node type %q", n["nodeType"])
+	return nil
+}
+
+// yulNames converts YulTypedNames, or the YulIdentifiers assigned to
+func (im *importer) yulNames(names []any) []*ast.Identifier {
+	var out []*ast.Identifier
+	for _, name := range names {
+		name, _ := name.(map[string]any)
+		id := &ast.Identifier{BaseNode: im.base(ast.NodeIdentifier, name), Name: str(name, "name")}
+		im.yul[str(name, "src")] = id
+		out = append(out, id)
+	}
+	return out
+}
+
+func (im *importer) yulExpression(n Node) ast.Node {
+	switch n["nodeType"] {
+	case "YulFunctionCall":
+		node := &ast.AssemblyCall{BaseNode: im.base(ast.NodeAssemblyCall, n), FunctionName: str(obj(n, "functionName"), "name"), Arguments: []ast.Node{}}
+		for _, arg := range list(n, "arguments") {
+			arg, _ := arg.(map[string]any)
+			node.Arguments = append(node.Arguments, im.yulExpression(arg))
+		}
+		return node
+	case "YulIdentifier":
+		var node ast.Node
+		name := str(n, "name")
+		if base, member, ok := strings.Cut(name, "."); ok {
+			// x.slot: the parser has a node for each part
+			access := &ast.AssemblyMemberAccess{
+				BaseNode:   im.base(ast.NodeAssemblyMemberAccess, n),
+				Expression: &ast.Identifier{BaseNode: im.base(ast.NodeIdentifier, n), Name: base},
+				MemberName: &ast.Identifier{BaseNode: im.base(ast.NodeIdentifier, n), Name: member},
+			}
+			if r := access.Range; r != nil {
+				access.Expression.BaseNode = im.at(ast.NodeIdentifier, fmt.Sprintf("%d:%d", r[0], len(base)))
+				access.MemberName.BaseNode = im.at(ast.NodeIdentifier, fmt.Sprintf("%d:%d", r[0]+len(base)+1, len(member)))
+			}
+			node = access
+		} else {
+			node = &ast.AssemblyIdentifier{BaseNode: im.base(ast.NodeAssemblyIdentifier, n), Name: name}
+		}
+		im.yul[str(n, "src")] = node
+		return node
+	case "YulLiteral":
+		node := &ast.AssemblyLiteral{BaseNode: im.base(ast.NodeAssemblyLiteral, n), Kind: str(n, "kind"), Value: str(n, "value")}
+		switch {
+		case node.Kind == "bool":
+			node.Kind = "boolean"
+		case node.Kind == "string" && n["value"] == nil:
+			// A hex literal, or a string that is not UTF-8
+			node.Kind, node.Value = "hex", str(n, "hexValue")
+		}
+		return node
+	}
+	im.fail(n, "unknown node type %q", n["nodeType"])
+	return nil
+}
@@ -0,0 +1,208 @@
+// Package solc converts between this module's AST and the Solidity
+// compiler's compact JSON AST, the format of `solc --ast-compact-json` and of
+// the "ast" entries of standard JSON output.
+//
+// Export is syntactic and best-effort: nodes take solc's shapes and names
+// (nodeType, src as "start:length:fileIndex", ids, nodes arrays, Assignment,
+// Literal, ParameterList, Yul nodes), so that tools reading solc ASTs can run
+// on code that does not compile. Fields that need name resolution or type
+// checking, such as referencedDeclaration and typeDescriptions, are left
+// out or empty.
+//
+// Import goes the other way, so that analyses written against package ast
+// can run on compiler output, with solc's referencedDeclaration links kept
+// as a name resolution oracle.
+package solc
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/th13vn/solast-go/internal/inheritance"
+	"github.com/th13vn/solast-go/internal/lexer"
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// Node is a solc AST node. As a map it encodes with sorted keys, as solc's
+// output does.
+type Node map[string]any
+
+// Options configures Export
+type Options struct {
+	// AbsolutePath: the file's name, recorded as the SourceUnit's
+	// absolutePath
+	AbsolutePath string
+	// FileIndex: the source index in every src, "start:length:fileIndex"
+	FileIndex int
+	// FirstID: the SourceUnit's id; the other nodes are numbered on from it
+	// in pre-order. Give each file of a project its own range of ids.
+	FirstID int
+	// Source: the text unit was parsed from. Declarations whose name has no
+	// node of its own (contracts, functions, events, ...) need it for their
+	// nameLocation, else "-1:-1:-1".
+	Source string
+}
+
+// Export converts unit to a solc SourceUnit. Offsets in src come from the
+// nodes' Range, so unit should be parsed with Range in bytes (the default
+// PositionUnit); nodes without a Range get "-1:-1:-1", as solc writes for
+// unknown locations. opts may be nil.
+func Export(unit *ast.SourceUnit, opts *Options) Node {
+	if opts == nil {
+		opts = &Options{}
+	}
+	e := &exporter{opts: opts, next: opts.FirstID, contracts: make(map[string]Node)}
+	out := e.sourceUnit(unit)
+	e.linearize(unit)
+	return out
+}
+
+type exporter struct {
+	opts *Options
+	next int // the next node id
+
+	unitID   int
+	contract *ast.ContractDefinition // nil at file level
+	scope    int                     // id of the contract, or of the unit
+	returns  any                     // id of the current function's returnParameters
+
+	contracts map[string]Node // by name, first declaration wins
+}
+
+// node starts a solc node with a fresh id
+func (e *exporter) node(nodeType, src string) Node {
+	id := e.next
+	e.next++
+	return Node{"id": id, "nodeType": nodeType, "src": src}
+}
+
+// src formats n's Range as solc does
+func (e *exporter) src(n ast.Node) string {
+	if isNil(n) || n.GetRange() == nil {
+		return "-1:-1:-1"
+	}
+	r := n.GetRange()
+	return fmt.Sprintf("%d:%d:%d", r[0], r[1]-r[0], e.opts.FileIndex)
+}
+
+// prefix is the src of the first length bytes of n, for names that have no
+// node of their own, such as a modifier invocation's name
+func (e *exporter) prefix(n ast.Node, length int) string {
+	if isNil(n) || n.GetRange() == nil {
+		return "-1:-1:-1"
+	}
+	return fmt.Sprintf("%d:%d:%d", n.GetRange()[0], length, e.opts.FileIndex)
+}
+
+// nameLocation is the src of the name of declaration n: the first token of
+// its source text that spells name
+func (e *exporter) nameLocation(n ast.Node, name string) string {
+	if isNil(n) || n.GetRange() == nil || name == "" {
+		return "-1:-1:-1"
+	}
+	r := n.GetRange()
+	if r[0] < 0 || r[0] > r[1] || r[1] > len(e.opts.Source) {
+		return "-1:-1:-1"
+	}
+	for _, tok := range lexer.New(e.opts.Source[r[0]:r[1]]).Tokenize() {
+		if tok.Type != lexer.EOF && tok.Value == name {
+			return fmt.Sprintf("%d:%d:%d", r[0]+tok.Start, tok.End-tok.Start, e.opts.FileIndex)
+		}
+	}
+	return "-1:-1:-1"
+}
+
+// span is the src from the first to the last positioned node of nodes
+func (e *exporter) span(nodes []*ast.VariableDeclaration) string {
+	start, end := -1, -1
+	for _, n := range nodes {
+		if n == nil || n.Range == nil {
+			continue
+		}
+		if start < 0 {
+			start = n.Range[0]
+		}
+		end = n.Range[1]
+	}
+	if start < 0 {
+		return "-1:-1:-1"
+	}
+	return fmt.Sprintf("%d:%d:%d", start, end-start, e.opts.FileIndex)
+}
+
+// linearize fills in linearizedBaseContracts, once every contract has an
+// id. Bases declared in other files are left out.
+func (e *exporter) linearize(unit *ast.SourceUnit) {
+	defs := make(map[string]*ast.ContractDefinition)
+	for _, child := range unit.Children {
+		if def, ok := child.(*ast.ContractDefinition); ok {
+			if _, seen := defs[def.Name]; !seen {
+				defs[def.Name] = def
+			}
+		}
+	}
+	lin := inheritance.New(func(name string) *ast.ContractDefinition {
+		return defs[name[strings.LastIndexByte(name, '.')+1:]]
+	})
+	for name, c := range e.contracts {
+		ids := []any{c["id"]}
+		for _, base := range lin.Linearize(defs[name])[1:] {
+			ids = append(ids, e.contracts[base.Name]["id"])
+		}
+		c["linearizedBaseContracts"] = ids
+	}
+}
+
+// isNil reports whether n is nil or a nil pointer in a Node interface
+func isNil(n ast.Node) bool {
+	if n == nil {
+		return true
+	}
+	v := reflect.ValueOf(n)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// pragmaLiterals splits a pragma into tokens as solc's scanner does: in
+// `solidity ^0.8.0` a number takes at most one dot, giving solidity, ^,
+// 0.8 and .0
+func pragmaLiterals(name, value string) []string {
+	out := []string{name}
+	for i := 0; i < len(value); {
+		c := value[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+			continue
+		case isDigit(c) || c == '.' && i+1 < len(value) && isDigit(value[i+1]):
+			j, dot := i, false
+			for j < len(value) && (isDigit(value[j]) || value[j] == '.' && !dot && j+1 < len(value) && isDigit(value[j+1])) {
+				dot = dot || value[j] == '.'
+				j++
+			}
+			out = append(out, value[i:j])
+			i = j
+		case isLetter(c):
+			j := i
+			for j < len(value) && (isLetter(value[j]) || isDigit(value[j])) {
+				j++
+			}
+			out = append(out, value[i:j])
+			i = j
+		default:
+			j := i + 1
+			if j < len(value) && (value[j] == '=' || value[j] == '|' && c == '|') {
+				j++
+			}
+			out = append(out, value[i:j])
+			i = j
+		}
+	}
+	return out
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || c == '$'
+}
@@ -0,0 +1,493 @@
+package solc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+	"github.com/th13vn/solast-go/pkg/parser"
+)
+
+const src = `// SPDX-License-Identifier: MIT
+pragma solidity >=0.8.4 <0.9.0;
+
+import {Base as B} from "./base.sol";
+
+error Low(uint have);
+
+contract A {}
+
+contract Vault is A, B {
+    mapping(address owner => uint) public balances;
+    uint constant FEE = 1 ether;
+
+    modifier only() { _; }
+
+    function withdraw(uint amount) external only returns (bool ok) {
+        if (balances[msg.sender] < amount) revert Low(balances[msg.sender]);
+        balances[msg.sender] -= amount;
+        (bool sent, ) = msg.sender.call{value: amount}("");
+        require(sent);
+        try this.ping() returns (uint v) { v; } catch Error(string memory) { revert("failed"); }
+        assembly { let x := sload(0) if iszero(x) { leave } }
+        return address(this).balance > 0;
+    }
+
+    function ping() external pure returns (uint) { return 0x2a; }
+}
+`
+
+func export(t *testing.T) Node {
+	t.Helper()
+	unit, err := parser.Parse(src, &parser.Options{Range: true})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	out := Export(unit, &Options{AbsolutePath: "vault.sol", FileIndex: 3, FirstID: 100, Source: src})
+	// Compare as encoded, the way consumers see it
+	data, err := json.Marshal(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Node
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+// nodesOf returns the nodes of a nodeType below n, in source order
+func nodesOf(n any, nodeType string) []map[string]any {
+	var out []map[string]any
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case Node:
+			walk(map[string]any(v))
+		case map[string]any:
+			if v["nodeType"] == nodeType {
+				out = append(out, v)
+			}
+			for _, c := range v {
+				walk(c)
+			}
+		case []any:
+			for _, c := range v {
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	sort.SliceStable(out, func(i, j int) bool { return start(out[i]) < start(out[j]) })
+	return out
+}
+
+func start(n map[string]any) int {
+	s, _, _ := strings.Cut(n["src"].(string), ":")
+	i, _ := strconv.Atoi(s)
+	return i
+}
+
+// srcText returns the source a src field points at
+func srcText(t *testing.T, n map[string]any) string {
+	t.Helper()
+	parts := strings.Split(n["src"].(string), ":")
+	start, _ := strconv.Atoi(parts[0])
+	length, _ := strconv.Atoi(parts[1])
+	if parts[2] != "3" || start < 0 {
+		t.Fatalf("src = %v", n["src"])
+	}
+	return src[start : start+length]
+}
+
+func TestExport(t *testing.T) {
+	unit := export(t)
+	if unit["nodeType"] != "SourceUnit" || unit["absolutePath"] != "vault.sol" || unit["id"] != 100.0 {
+		t.Fatalf("unit = %v %v %v", unit["nodeType"], unit["absolutePath"], unit["id"])
+	}
+
+	// Ids are unique and numbered from FirstID
+	seen := map[float64]bool{}
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if id, ok := v["id"].(float64); ok {
+				if seen[id] || id < 100 {
+					t.Errorf("id %v reused or below FirstID", id)
+				}
+				seen[id] = true
+			}
+			for _, c := range v {
+				walk(c)
+			}
+		case []any:
+			for _, c := range v {
+				walk(c)
+			}
+		}
+	}
+	walk(map[string]any(unit))
+
+	pragma := nodesOf(unit, "PragmaDirective")[0]
+	if got := pragma["literals"]; !reflect.DeepEqual(got, []any{"solidity", ">=", "0.8", ".4", "<", "0.9", ".0"}) {
+		t.Errorf("pragma literals = %v", got)
+	}
+	imp := nodesOf(unit, "ImportDirective")[0]
+	alias := imp["symbolAliases"].([]any)[0].(map[string]any)
+	if alias["local"] != "B" || alias["foreign"].(map[string]any)["name"] != "Base" {
+		t.Errorf("symbol alias = %v", alias)
+	}
+	if syms := unit["exportedSymbols"].(map[string]any); len(syms) != 3 || syms["Vault"] == nil || syms["Low"] == nil {
+		t.Errorf("exportedSymbols = %v", syms)
+	}
+
+	contracts := nodesOf(unit, "ContractDefinition")
+	vault := contracts[1]
+	// Linearization: Vault, then the bases in this file (B is imported)
+	lin := vault["linearizedBaseContracts"].([]any)
+	if len(lin) != 2 || lin[0] != vault["id"] || lin[1] != contracts[0]["id"] {
+		t.Errorf("linearizedBaseContracts = %v", lin)
+	}
+	if vault["contractKind"] != "contract" || len(vault["nodes"].([]any)) != 5 {
+		t.Errorf("contract = %v, %d nodes", vault["contractKind"], len(vault["nodes"].([]any)))
+	}
+
+	// State variables are VariableDeclarations spanning their declaration
+	vars := vault["nodes"].([]any)
+	balances := vars[0].(map[string]any)
+	if balances["nodeType"] != "VariableDeclaration" || balances["visibility"] != "public" || !balances["stateVariable"].(bool) ||
+		srcText(t, balances) != "mapping(address owner => uint) public balances;" {
+		t.Errorf("balances = %v %q", balances, srcText(t, balances))
+	}
+	if m := balances["typeName"].(map[string]any); m["nodeType"] != "Mapping" || m["keyName"] != "owner" || m["valueName"] != "" {
+		t.Errorf("mapping = %v", m)
+	}
+	fee := vars[1].(map[string]any)
+	value := fee["value"].(map[string]any)
+	if fee["mutability"] != "constant" || value["nodeType"] != "Literal" || value["subdenomination"] != "ether" || value["hexValue"] != "31" {
+		t.Errorf("FEE = %v, value %v", fee["mutability"], value)
+	}
+
+	if p := nodesOf(unit, "PlaceholderStatement"); len(p) != 1 || srcText(t, p[0]) != "_;" {
+		t.Errorf("placeholders = %v", p)
+	}
+
+	withdraw := nodesOf(unit, "FunctionDefinition")[0]
+	if withdraw["kind"] != "function" || withdraw["visibility"] != "external" || withdraw["stateMutability"] != "nonpayable" {
+		t.Errorf("withdraw = %v %v %v", withdraw["kind"], withdraw["visibility"], withdraw["stateMutability"])
+	}
+	mod := withdraw["modifiers"].([]any)[0].(map[string]any)
+	if name := mod["modifierName"].(map[string]any); name["name"] != "only" || srcText(t, name) != "only" || mod["arguments"] != nil {
+		t.Errorf("modifier = %v", mod)
+	}
+	returns := withdraw["returnParameters"].(map[string]any)
+	if srcText(t, returns) != "bool ok" {
+		t.Errorf("returnParameters src = %q", srcText(t, returns))
+	}
+	for _, r := range nodesOf(withdraw, "Return") {
+		if r["functionReturnParameters"] != returns["id"] {
+			t.Errorf("functionReturnParameters = %v", r["functionReturnParameters"])
+		}
+	}
+
+	// Assignments are their own node type, apart from declarations
+	assign := nodesOf(withdraw, "Assignment")
+	if len(assign) != 1 || assign[0]["operator"] != "-=" || srcText(t, assign[0]) != "balances[msg.sender] -= amount" {
+		t.Errorf("assignments = %v", assign)
+	}
+	decl := nodesOf(withdraw, "VariableDeclarationStatement")[0]
+	if a := decl["assignments"].([]any); len(a) != 1 || a[0] != decl["declarations"].([]any)[0].(map[string]any)["id"] {
+		t.Errorf("declaration assignments = %v", a)
+	}
+	if opts := nodesOf(decl, "FunctionCallOptions"); len(opts) != 1 || opts[0]["names"].([]any)[0] != "value" {
+		t.Errorf("call options = %v", opts)
+	}
+
+	// A custom error is a RevertStatement, the builtin a call
+	if r := nodesOf(withdraw, "RevertStatement"); len(r) != 1 || r[0]["errorCall"].(map[string]any)["nodeType"] != "FunctionCall" {
+		t.Errorf("revert statements = %v", r)
+	}
+	var builtin map[string]any
+	for _, call := range nodesOf(withdraw, "FunctionCall") {
+		if callee := call["expression"].(map[string]any); callee["name"] == "revert" {
+			builtin = call
+		}
+	}
+	if builtin == nil || builtin["arguments"].([]any)[0].(map[string]any)["value"] != "failed" {
+		t.Errorf("builtin revert = %v", builtin)
+	}
+
+	try := nodesOf(withdraw, "TryStatement")[0]
+	clauses := try["clauses"].([]any)
+	if len(clauses) != 2 || clauses[0].(map[string]any)["errorName"] != "" || clauses[1].(map[string]any)["errorName"] != "Error" ||
+		try["externalCall"].(map[string]any)["tryCall"] != true {
+		t.Errorf("try = %v", try)
+	}
+
+	if conv := nodesOf(withdraw, "ElementaryTypeNameExpression"); len(conv) != 1 || srcText(t, conv[0]) != "address" {
+		t.Errorf("type conversions = %v", conv)
+	}
+
+	yul := nodesOf(withdraw, "InlineAssembly")[0]["AST"].(map[string]any)
+	stmts := yul["statements"].([]any)
+	if yul["nodeType"] != "YulBlock" || yul["id"] != nil || len(stmts) != 2 {
+		t.Fatalf("yul = %v", yul)
+	}
+	call := stmts[0].(map[string]any)["value"].(map[string]any)
+	if name := call["functionName"].(map[string]any); call["nodeType"] != "YulFunctionCall" || name["name"] != "sload" || srcText(t, name) != "sload" {
+		t.Errorf("yul call = %v", call)
+	}
+	if leave := nodesOf(yul, "YulLeave"); len(leave) != 1 {
+		t.Errorf("leave = %v", leave)
+	}
+
+	// Declarations find their names in Options.Source
+	for _, nodeType := range []string{"ContractDefinition", "FunctionDefinition", "ModifierDefinition", "ErrorDefinition"} {
+		decls := nodesOf(unit, nodeType)
+		if len(decls) == 0 {
+			t.Errorf("no %s", nodeType)
+		}
+		for _, n := range decls {
+			if got := srcText(t, map[string]any{"src": n["nameLocation"]}); got != n["name"] {
+				t.Errorf("%s %v: nameLocation points at %q", nodeType, n["name"], got)
+			}
+		}
+	}
+}
+
+func TestLinearization(t *testing.T) {
+	unit, err := parser.Parse(`
+contract A {}
+contract B is A {}
+contract C is A {}
+contract D is B, C {}`, &parser.Options{Range: true})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	out := Export(unit, nil)
+	names := make(map[any]string)
+	var d Node
+	for _, n := range out["nodes"].([]any) {
+		c := n.(Node)
+		names[c["id"]] = c["name"].(string)
+		if c["name"] == "D" {
+			d = c
+		}
+	}
+	var got []string
+	for _, id := range d["linearizedBaseContracts"].([]any) {
+		got = append(got, names[id])
+	}
+	// C3: the base listed last is the most derived
+	if want := []string{"D", "C", "B", "A"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("linearizedBaseContracts of D = %v, want %v", got, want)
+	}
+}
+
+func TestPragmaLiterals(t *testing.T) {
+	for _, tc := range []struct {
+		name, value string
+		want        []string
+	}{
+		{"solidity", "^0.8.20", []string{"solidity", "^", "0.8", ".20"}},
+		{"solidity", "0.8", []string{"solidity", "0.8"}},
+		{"solidity", ">=0.6.0 || ~0.5.1", []string{"solidity", ">=", "0.6", ".0", "||", "~", "0.5", ".1"}},
+		{"abicoder", "v2", []string{"abicoder", "v2"}},
+		{"experimental", "ABIEncoderV2", []string{"experimental", "ABIEncoderV2"}},
+	} {
+		if got := pragmaLiterals(tc.name, tc.value); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("pragmaLiterals(%q) = %q", tc.value, got)
+		}
+	}
+}
+
+// Importing what Export wrote and exporting it again gives the same AST
+func TestImportRoundTrip(t *testing.T) {
+	err := filepath.WalkDir("../../testdata", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".sol" {
+			return err
+		}
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			unit, err := parser.Parse(string(content), &parser.Options{Range: true, Tolerant: true})
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			opts := &Options{AbsolutePath: path, FirstID: 1, Source: string(content)}
+			want, err := json.Marshal(Export(unit, opts))
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := Import(want, &ImportOptions{Sources: map[string]string{path: string(content)}})
+			if err != nil {
+				t.Fatalf("Import failed: %v", err)
+			}
+			imported := p.Units[path]
+			if len(p.Paths) != 1 || imported == nil || imported.Loc == nil {
+				t.Fatalf("Paths = %v", p.Paths)
+			}
+			got, err := json.Marshal(Export(imported, opts))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("re-export differs:\n got %.300s\nwant %.300s", got, want)
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+const stdSrc = "contract C {\n    uint x;\n    function f(uint a) public {\n        x = a;\n        assembly { sstore(x.slot, a) }\n        revert(\"no\");\n    }\n}\n"
+
+// stdOutput is solc's standard JSON output for stdSrc, trimmed
+const stdOutput = `{"sources": {"C.sol": {"id": 0, "ast": {
+  "absolutePath": "C.sol", "id": 20, "nodeType": "SourceUnit", "src": "0:141:0", "exportedSymbols": {"C": [19]},
+  "nodes": [{"id": 19, "nodeType": "ContractDefinition", "src": "0:140:0", "name": "C", "nameLocation": "9:1:0",
+    "abstract": false, "baseContracts": [], "contractKind": "contract", "linearizedBaseContracts": [19], "scope": 20,
+    "nodes": [
+      {"id": 3, "nodeType": "VariableDeclaration", "src": "17:6:0", "name": "x", "nameLocation": "22:1:0",
+        "constant": false, "mutability": "mutable", "scope": 19, "stateVariable": true, "storageLocation": "default",
+        "typeDescriptions": {"typeIdentifier": "t_uint256", "typeString": "uint256"},
+        "typeName": {"id": 2, "name": "uint", "nodeType": "ElementaryTypeName", "src": "17:4:0"}, "visibility": "internal"},
+      {"id": 18, "nodeType": "FunctionDefinition", "src": "29:109:0", "name": "f", "nameLocation": "38:1:0",
+        "implemented": true, "kind": "function", "modifiers": [], "scope": 19, "stateMutability": "nonpayable",
+        "virtual": false, "visibility": "public",
+        "parameters": {"id": 6, "nodeType": "ParameterList", "src": "39:8:0", "parameters": [
+          {"id": 5, "nodeType": "VariableDeclaration", "src": "40:6:0", "name": "a", "nameLocation": "45:1:0",
+            "constant": false, "mutability": "mutable", "scope": 18, "stateVariable": false, "storageLocation": "default",
+            "typeName": {"id": 4, "name": "uint", "nodeType": "ElementaryTypeName", "src": "40:4:0"}, "visibility": "internal"}]},
+        "returnParameters": {"id": 7, "nodeType": "ParameterList", "src": "55:0:0", "parameters": []},
+        "body": {"id": 17, "nodeType": "Block", "src": "55:83:0", "statements": [
+          {"id": 11, "nodeType": "ExpressionStatement", "src": "65:6:0", "expression": {
+            "id": 10, "nodeType": "Assignment", "src": "65:5:0", "operator": "=",
+            "leftHandSide": {"id": 8, "name": "x", "nodeType": "Identifier", "overloadedDeclarations": [], "referencedDeclaration": 3, "src": "65:1:0"},
+            "rightHandSide": {"id": 9, "name": "a", "nodeType": "Identifier", "overloadedDeclarations": [], "referencedDeclaration": 5, "src": "69:1:0"}}},
+          {"id": 12, "nodeType": "InlineAssembly", "src": "80:30:0", "evmVersion": "cancun",
+            "externalReferences": [
+              {"declaration": 5, "isOffset": false, "isSlot": false, "src": "106:1:0", "valueSize": 1},
+              {"declaration": 3, "isOffset": false, "isSlot": true, "src": "98:6:0", "suffix": "slot", "valueSize": 1}],
+            "AST": {"nodeType": "YulBlock", "src": "89:21:0", "statements": [
+              {"nodeType": "YulExpressionStatement", "src": "91:17:0", "expression": {
+                "nodeType": "YulFunctionCall", "src": "91:17:0",
+                "functionName": {"name": "sstore", "nodeType": "YulIdentifier", "src": "91:6:0"},
+                "arguments": [
+                  {"name": "x.slot", "nodeType": "YulIdentifier", "src": "98:6:0"},
+                  {"name": "a", "nodeType": "YulIdentifier", "src": "106:1:0"}]}}]}},
+          {"id": 16, "nodeType": "ExpressionStatement", "src": "119:13:0", "expression": {
+            "id": 15, "nodeType": "FunctionCall", "src": "119:12:0", "kind": "functionCall", "names": [], "tryCall": false,
+            "expression": {"id": 13, "name": "revert", "nodeType": "Identifier", "overloadedDeclarations": [-19, -19], "referencedDeclaration": -19, "src": "119:6:0"},
+            "arguments": [{"id": 14, "nodeType": "Literal", "src": "126:4:0", "hexValue": "6e6f", "kind": "string", "value": "no"}]}}]}}],
+    "usedErrors": [], "usedEvents": []}]}}},
+  "contracts": {}}`
+
+func TestImportStandardJSON(t *testing.T) {
+	p, err := Import([]byte(stdOutput), &ImportOptions{Sources: map[string]string{"C.sol": stdSrc}})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	unit := p.Units["C.sol"]
+	if len(p.Paths) != 1 || unit == nil || len(p.Files()) != 1 {
+		t.Fatalf("Paths = %v", p.Paths)
+	}
+	c := unit.Children[0].(*ast.ContractDefinition)
+	x := c.SubNodes[0].(*ast.StateVariableDeclaration).Variables[0]
+	f := c.SubNodes[1].(*ast.FunctionDefinition)
+	if x.Identifier.Range == nil || *x.Identifier.Range != (ast.Range{22, 23}) || x.Visibility != "internal" || !x.IsStateVar {
+		t.Errorf("x = %+v", x)
+	}
+	if f.StateMutability != "" || f.Visibility != "public" || f.Parameters[0].Visibility != "" || p.Nodes[18] != f {
+		t.Errorf("f = %+v", f)
+	}
+
+	// Positions come from src, with lines and columns from the text
+	assign := f.Body.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.BinaryOperation)
+	if assign.Loc == nil || assign.Loc.Start != (ast.Position{Line: 4, Column: 8}) || assign.Loc.End != (ast.Position{Line: 4, Column: 13}) {
+		t.Errorf("assignment Loc = %+v", assign.Loc)
+	}
+	if p.Declaration(assign.Left) != x || p.Declaration(assign.Right) != f.Parameters[0] {
+		t.Errorf("references = %v, %v", p.Declaration(assign.Left), p.Declaration(assign.Right))
+	}
+
+	// Yul identifiers resolve through the assembly's external references
+	call := f.Body.Statements[1].(*ast.InlineAssembly).Body.Operations[0].(*ast.AssemblyCall)
+	slot := call.Arguments[0].(*ast.AssemblyMemberAccess)
+	if call.FunctionName != "sstore" || slot.Expression.Name != "x" || *slot.MemberName.Range != (ast.Range{100, 104}) {
+		t.Errorf("sstore = %+v", call)
+	}
+	if p.Declaration(slot) != x || p.Declaration(call.Arguments[1]) != f.Parameters[0] {
+		t.Errorf("Yul references = %v, %v", p.Declaration(slot), p.Declaration(call.Arguments[1]))
+	}
+
+	revert, ok := f.Body.Statements[2].(*ast.RevertStatement)
+	if !ok || revert.RevertCall.(*ast.StringLiteral).Value != "no" {
+		t.Errorf("revert = %#v", f.Body.Statements[2])
+	}
+
+	// The tree is the one the parser builds, positions and the implicit
+	// visibility solc spells out aside
+	parsed, err := parser.Parse(stdSrc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.Visibility = ""
+	if !ast.Equal(unit, parsed, &ast.EqualOptions{IgnorePositions: true}) {
+		t.Error("imported tree differs from the parsed one")
+	}
+}
+
+func TestImportPathTarget(t *testing.T) {
+	src := "contract C {\n    function f() public {\n        assembly { let p := 0 p.slot := 1 }\n    }\n}\n"
+	parsed, err := parser.Parse(src, &parser.Options{Range: true})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	data, err := json.Marshal(Export(parsed, &Options{AbsolutePath: "C.sol"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Import(data, nil)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	unit := p.Units["C.sol"]
+	f := unit.Children[0].(*ast.ContractDefinition).SubNodes[0].(*ast.FunctionDefinition)
+	assign := f.Body.Statements[0].(*ast.InlineAssembly).Body.Operations[1].(*ast.AssemblyAssignment)
+	if len(assign.Targets) != 1 || assign.Names[0].Name != "p.slot" {
+		t.Fatalf("assignment = %+v", assign)
+	}
+	if path, ok := assign.Targets[0].(*ast.AssemblyMemberAccess); !ok || path.Expression.Name != "p" || path.MemberName.Name != "slot" {
+		t.Errorf("target = %+v", assign.Targets[0])
+	}
+	if !ast.Equal(unit, parsed, &ast.EqualOptions{IgnorePositions: true}) {
+		t.Error("imported tree differs from the parsed one")
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	for _, tc := range []struct{ data, want string }{
+		{`{"errors": [{"severity": "warning", "message": "w"}, {"severity": "error", "formattedMessage": "ParserError: Expected ';'"}]}`, "solc: ParserError: Expected ';'"},
+		{`{"sources": {}}`, "solc: no source ASTs in output"},
+		{`{"nodeType": "SourceUnit", "src": "0:1:0", "nodes": [{"nodeType": "Frobnicate", "src": "0:1:0"}]}`, `solc: unknown node type "Frobnicate" at 0:1:0`},
+		{`[`, "solc: unexpected end of JSON input"},
+	} {
+		if _, err := Import([]byte(tc.data), nil); err == nil || err.Error() != tc.want {
+			t.Errorf("Import(%.40s) error = %v, want %s", tc.data, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package solc
+
+import (
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// Yul nodes have no ids in solc's output
+
+func (e *exporter) yulNode(nodeType string, n ast.Node) Node {
+	return Node{"nodeType": nodeType, "src": e.src(n)}
+}
+
+func (e *exporter) yulBlock(b *ast.AssemblyBlock) any {
+	if b == nil {
+		return nil
+	}
+	out := e.yulNode("YulBlock", b)
+	statements := []any{}
+	for _, op := range b.Operations {
+		statements = append(statements, e.yulStatement(op))
+	}
+	out["statements"] = statements
+	return out
+}
+
+func (e *exporter) yulStatement(n ast.Node) any {
+	if isNil(n) {
+		return nil
+	}
+	switch n := n.(type) {
+	case *ast.AssemblyBlock:
+		return e.yulBlock(n)
+	case *ast.AssemblyLocalDefinition:
+		out := e.yulNode("YulVariableDeclaration", n)
+		variables := []any{}
+		for _, name := range n.Names {
+			v := e.yulNode("YulTypedName", name)
+			v["name"] = name.Name
+			v["type"] = ""
+			variables = append(variables, v)
+		}
+		out["variables"] = variables
+		if n.Expression != nil {
+			out["value"] = e.yulExpression(n.Expression)
+		}
+		return out
+	case *ast.AssemblyAssignment:
+		out := e.yulNode("YulAssignment", n)
+		names := []any{}
+		for _, name := range n.Names {
+			names = append(names, e.yulIdentifier(name.Name, name))
+		}
+		out["variableNames"] = names
+		out["value"] = e.yulExpression(n.Expression)
+		return out
+	case *ast.AssemblyIf:
+		out := e.yulNode("YulIf", n)
+		out["condition"] = e.yulExpression(n.Condition)
+		out["body"] = e.yulBlock(n.Body)
+		return out
+	case *ast.AssemblySwitch:
+		out := e.yulNode("YulSwitch", n)
+		out["expression"] = e.yulExpression(n.Expression)
+		cases := []any{}
+		for _, c := range n.Cases {
+			yc := e.yulNode("YulCase", c)
+			if c.Default {
+				yc["value"] = "default"
+			} else {
+				yc["value"] = e.yulExpression(c.Value)
+			}
+			yc["body"] = e.yulBlock(c.Body)
+			cases = append(cases, yc)
+		}
+		out["cases"] = cases
+		return out
+	case *ast.AssemblyFor:
+		out := e.yulNode("YulForLoop", n)
+		out["pre"] = e.yulBlock(n.Pre)
+		out["condition"] = e.yulExpression(n.Condition)
+		out["post"] = e.yulBlock(n.Post)
+		out["body"] = e.yulBlock(n.Body)
+		return out
+	case *ast.AssemblyFunctionDefinition:
+		out := e.yulNode("YulFunctionDefinition", n)
+		out["name"] = n.Name
+		if len(n.Arguments) > 0 {
+			out["parameters"] = e.yulTypedNames(n.Arguments)
+		}
+		if len(n.ReturnArguments) > 0 {
+			out["returnVariables"] = e.yulTypedNames(n.ReturnArguments)
+		}
+		out["body"] = e.yulBlock(n.Body)
+		return out
+	case *ast.BreakStatement:
+		return e.yulNode("YulBreak", n)
+	case *ast.ContinueStatement:
+		return e.yulNode("YulContinue", n)
+	case *ast.Identifier:
+		if n.Name == "leave" {
+			return e.yulNode("YulLeave", n)
+		}
+	}
+	out := e.yulNode("YulExpressionStatement", n)
+	out["expression"] = e.yulExpression(n)
+	return out
+}
+
+func (e *exporter) yulTypedNames(names []*ast.Identifier) []any {
+	out := []any{}
+	for _, name := range names {
+		v := e.yulNode("YulTypedName", name)
+		v["name"] = name.Name
+		v["type"] = ""
+		out = append(out, v)
+	}
+	return out
+}
+
+func (e *exporter) yulExpression(n ast.Node) any {
+	if isNil(n) {
+		return nil
+	}
+	switch n := n.(type) {
+	case *ast.AssemblyCall:
+		out := e.yulNode("YulFunctionCall", n)
+		name := e.yulIdentifier(n.FunctionName, nil)
+		name["src"] = e.prefix(n, len(n.FunctionName))
+		out["functionName"] = name
+		arguments := []any{}
+		for _, arg := range n.Arguments {
+			arguments = append(arguments, e.yulExpression(arg))
+		}
+		out["arguments"] = arguments
+		return out
+	case *ast.AssemblyIdentifier:
+		return e.yulIdentifier(n.Name, n)
+	case *ast.Identifier:
+		return e.yulIdentifier(n.Name, n)
+	case *ast.AssemblyMemberAccess:
+		// solc keeps x.slot as one identifier
+		var name string
+		if n.Expression != nil && n.MemberName != nil {
+			name = n.Expression.Name + "." + n.MemberName.Name
+		}
+		return e.yulIdentifier(name, n)
+	case *ast.AssemblyLiteral:
+		out := e.yulNode("YulLiteral", n)
+		out["type"] = ""
+		switch n.Kind {
+		case "boolean":
+			out["kind"], out["value"] = "bool", n.Value
+		case "hex":
+			out["kind"], out["hexValue"] = "string", n.Value
+		default:
+			out["kind"], out["value"] = n.Kind, n.Value
+		}
+		return out
+	}
+	return e.yulNode(string(n.GetType()), n)
+}
+
+func (e *exporter) yulIdentifier(name string, n ast.Node) Node {
+	out := e.yulNode("YulIdentifier", n)
+	out["name"] = name
+	return out
+}
@@ -0,0 +1,145 @@
+package summary
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/th13vn/solast-go/pkg/ast"
+)
+
+// render prints an expression as Solidity source. Constructs a condition
+// rarely holds (function types, assembly) are printed as "...".
+func render(n ast.Node) string {
+	switch v := n.(type) {
+	case nil:
+		return ""
+	case *ast.Identifier:
+		return v.Name
+	case *ast.BooleanLiteral:
+		return fmt.Sprint(v.Value)
+	case *ast.NumberLiteral:
+		if v.SubDenomination != "" {
+			return v.Number + " " + v.SubDenomination
+		}
+		return v.Number
+	case *ast.StringLiteral:
+		return fmt.Sprintf("%q", v.Value)
+	case *ast.HexLiteral:
+		return "hex\"" + v.Value + "\""
+	case *ast.ElementaryTypeName:
+		return v.Name
+	case *ast.UserDefinedTypeName:
+		return v.NamePath
+	case *ast.MemberAccess:
+		return render(v.Expression) + "." + v.MemberName
+	case *ast.IndexAccess:
+		return render(v.Base) + "[" + render(v.Index) + "]"
+	case *ast.IndexRangeAccess:
+		return render(v.Base) + "[" + render(v.IndexStart) + ":" + render(v.IndexEnd) + "]"
+	case *ast.BinaryOperation:
+		return render(v.Left) + " " + v.Operator + " " + render(v.Right)
+	case *ast.UnaryOperation:
+		if !v.IsPrefix {
+			return render(v.SubExpression) + v.Operator
+		}
+		if v.Operator == "delete" {
+			return "delete " + render(v.SubExpression)
+		}
+		return v.Operator + render(v.SubExpression)
+	case *ast.Conditional:
+		return render(v.Condition) + " ? " + render(v.TrueExpression) + " : " + render(v.FalseExpression)
+	case *ast.TupleExpression:
+		parts := make([]string, len(v.Components))
+		for i, c := range v.Components {
+			parts[i] = render(c)
+		}
+		if v.IsArray {
+			return "[" + strings.Join(parts, ", ") + "]"
+		}
+		return "(" + strings.Join(parts, ", ") + ")"
+	case *ast.FunctionCall:
+		args := make([]string, len(v.Arguments))
+		for i, arg := range v.Arguments {
+			args[i] = render(arg)
+			if i < len(v.Names) {
+				args[i] = v.Names[i] + ": " + args[i]
+			}
+		}
+		if len(v.Names) > 0 {
+			return render(v.Expression) + "({" + strings.Join(args, ", ") + "})"
+		}
+		return render(v.Expression) + "(" + strings.Join(args, ", ") + ")"
+	case *ast.FunctionCallOptions:
+		opts := make([]string, len(v.Options))
+		for i, opt := range v.Options {
+			name := ""
+			if i < len(v.Names) {
+				name = v.Names[i]
+			}
+			opts[i] = name + ": " + render(opt)
+		}
+		return render(v.Expression) + "{" + strings.Join(opts, ", ") + "}"
+	case *ast.NewExpression:
+		return "new " + render(v.TypeName)
+	}
+	return "..."
+}
+
+// WriteTable writes one table per contract with the functions' modifiers,
+// state variables read and written, and conditions on msg.sender
+func (s *Summary) WriteTable(w io.Writer) error {
+	var sb strings.Builder
+	for i, c := range s.Contracts {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "Contract %s\n", c.Name)
+		rows := [][]string{{"Function", "Modifiers", "State variables read", "State variables written", "Conditions on msg.sender"}}
+		for _, f := range c.Functions {
+			name := strings.TrimPrefix(f.ID, f.Contract+".")
+			if f.Contract != c.Name {
+				name = f.ID
+			}
+			rows = append(rows, []string{
+				name,
+				strings.Join(f.Modifiers, ", "),
+				strings.Join(f.Reads, ", "),
+				strings.Join(f.Writes, ", "),
+				strings.Join(f.SenderConditions, "; "),
+			})
+		}
+		writeGrid(&sb, rows)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeGrid draws rows as an ASCII grid, the first row as the header
+func writeGrid(sb *strings.Builder, rows [][]string) {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+	rule := "+"
+	for _, w := range widths {
+		rule += strings.Repeat("-", w+2) + "+"
+	}
+	rule += "\n"
+	sb.WriteString(rule)
+	for r, row := range rows {
+		sb.WriteString("|")
+		for i, cell := range row {
+			fmt.Fprintf(sb, " %-*s |", widths[i], cell)
+		}
+		sb.WriteString("\n")
+		if r == 0 {
+			sb.WriteString(rule)
+		}
+	}
+	sb.WriteString(rule)
+}
@@ -0,0 +1,448 @@
+// Package summary reports, for every function of a project, which state
+// variables it reads and writes and which conditions it places on msg.sender.
+//
+// Accesses made by invoked modifiers and by internal, super and library
+// callees are folded into the caller, so the summary of an external entry
+// point covers everything it can touch without a message call. This is the
+// view auditors use to map access control (CLI: `solast summary`), in the
+// spirit of Slither's vars-and-auth printer.
+package summary
+
+import (
+	"github.com/th13vn/solast-go/pkg/ast"
+	"github.com/th13vn/solast-go/pkg/callgraph"
+	"github.com/th13vn/solast-go/pkg/cfg"
+	"github.com/th13vn/solast-go/pkg/dataflow"
+)
+
+// Function is the state access summary of one function or modifier
+type Function struct {
+	// Node is the call graph node of the function
+	Node *callgraph.Node `json:"-"`
+	ID   string          `json:"id"`
+	// Contract is the declaring contract, which differs from the listing
+	// contract for inherited functions
+	Contract   string `json:"contract,omitempty"`
+	Name       string `json:"name"`
+	Kind       string `json:"kind"`
+	Visibility string `json:"visibility,omitempty"`
+	// Modifiers are the modifiers invoked by the function, in order
+	Modifiers []string `json:"modifiers"`
+	// Reads and Writes are the state variables accessed by the function, its
+	// modifiers and its internal callees, sorted
+	Reads  []string `json:"reads"`
+	Writes []string `json:"writes"`
+	// DirectReads and DirectWrites are the accesses of the body alone
+	DirectReads  []string `json:"directReads"`
+	DirectWrites []string `json:"directWrites"`
+	// SenderConditions are the require/assert/if conditions mentioning
+	// msg.sender, including those of modifiers and internal callees
+	SenderConditions []string `json:"senderConditions"`
+}
+
+// Contract lists the functions callable on a contract, inherited ones
+// included
+type Contract struct {
+	Name      string      `json:"name"`
+	Kind      string      `json:"kind"`
+	Functions []*Function `json:"functions"`
+}
+
+// Summary is the state access summary of a project
+type Summary struct {
+	Contracts []*Contract `json:"contracts"`
+	// Graph is the call graph the summary was folded over
+	Graph *callgraph.Graph `json:"-"`
+}
+
+// direct is the body-only summary of one call graph node
+type direct struct {
+	reads, writes dataflow.Set[string]
+	conditions    []string
+}
+
+// folding edges carry state accesses back to the caller; external calls and
+// creations run in another contract's storage
+var folding = map[callgraph.EdgeKind]bool{
+	callgraph.EdgeInternal: true,
+	callgraph.EdgeSuper:    true,
+	callgraph.EdgeModifier: true,
+	callgraph.EdgeLibrary:  true,
+}
+
+// Build summarizes files. Contracts are matched by name across files as in
+// callgraph.Build; interfaces are skipped.
+func Build(files ...callgraph.File) *Summary {
+	g := callgraph.Build(files...)
+	contracts := make(map[string]*ast.ContractDefinition)
+	var order []*ast.ContractDefinition
+	for _, f := range files {
+		if f.Unit == nil {
+			continue
+		}
+		for _, child := range f.Unit.Children {
+			if c, ok := child.(*ast.ContractDefinition); ok {
+				if _, dup := contracts[c.Name]; !dup {
+					contracts[c.Name] = c
+					order = append(order, c)
+				}
+			}
+		}
+	}
+
+	// State variables visible in each contract, through its bases
+	visible := make(map[string]dataflow.Set[string])
+	for _, c := range order {
+		var defs []*ast.ContractDefinition
+		for _, name := range g.Linearization(c.Name) {
+			defs = append(defs, contracts[name])
+		}
+		visible[c.Name] = dataflow.StateVariables(defs...)
+	}
+
+	directs := make(map[*callgraph.Node]*direct)
+	for _, n := range g.Nodes {
+		directs[n] = summarize(n, visible[n.Contract])
+	}
+
+	s := &Summary{Graph: g}
+	for _, c := range order {
+		if c.Kind == "interface" {
+			continue
+		}
+		sc := &Contract{Name: c.Name, Kind: c.Kind}
+		for _, n := range callable(g, c.Name) {
+			sc.Functions = append(sc.Functions, fold(g, n, directs))
+		}
+		s.Contracts = append(s.Contracts, sc)
+	}
+	return s
+}
+
+// Contract returns the summary of the named contract, or nil
+func (s *Summary) Contract(name string) *Contract {
+	for _, c := range s.Contracts {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Function returns the summary of the function with the given name or call
+// graph ID ("f(uint256)" or "Base.f(uint256)"), or nil. A bare name matches
+// the first overload.
+func (c *Contract) Function(name string) *Function {
+	for _, f := range c.Functions {
+		if f.Name == name || f.ID == name || f.ID == f.Contract+"."+name {
+			return f
+		}
+	}
+	return nil
+}
+
+// callable returns the functions of a contract in linearization order, most
+// derived first: its own functions, then inherited ones not overridden.
+// Modifiers and base constructors are left out.
+func callable(g *callgraph.Graph, contract string) []*callgraph.Node {
+	byContract := make(map[string][]*callgraph.Node)
+	for _, n := range g.Nodes {
+		byContract[n.Contract] = append(byContract[n.Contract], n)
+	}
+	seen := make(map[string]bool)
+	var out []*callgraph.Node
+	for i, name := range g.Linearization(contract) {
+		for _, n := range byContract[name] {
+			if n.Kind == callgraph.KindModifier || n.Implicit || (i > 0 && n.Kind == callgraph.KindConstructor) {
+				continue
+			}
+			sig := n.ID[len(n.Contract)+1:]
+			if seen[sig] {
+				continue
+			}
+			seen[sig] = true
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// fold combines the direct summary of n with those of everything it reaches
+// through folding edges
+func fold(g *callgraph.Graph, n *callgraph.Node, directs map[*callgraph.Node]*direct) *Function {
+	f := &Function{
+		Node:       n,
+		ID:         n.ID,
+		Contract:   n.Contract,
+		Name:       n.Name,
+		Kind:       n.Kind,
+		Visibility: n.Visibility,
+		Modifiers:  []string{},
+	}
+	for _, e := range g.Callees(n) {
+		if e.Kind == callgraph.EdgeModifier {
+			f.Modifiers = append(f.Modifiers, e.To.Name)
+		}
+	}
+	d := directs[n]
+	f.DirectReads = dataflow.Names(d.reads)
+	f.DirectWrites = dataflow.Names(d.writes)
+
+	reads, writes := dataflow.Set[string]{}, dataflow.Set[string]{}
+	conditions := []string{}
+	seenCond := make(map[string]bool)
+	visited := make(map[*callgraph.Node]bool)
+	var visit func(m *callgraph.Node)
+	visit = func(m *callgraph.Node) {
+		if visited[m] {
+			return
+		}
+		visited[m] = true
+		dm := directs[m]
+		for v := range dm.reads {
+			reads[v] = struct{}{}
+		}
+		for v := range dm.writes {
+			writes[v] = struct{}{}
+		}
+		for _, c := range dm.conditions {
+			if !seenCond[c] {
+				seenCond[c] = true
+				conditions = append(conditions, c)
+			}
+		}
+		for _, e := range g.Callees(m) {
+			if folding[e.Kind] {
+				visit(e.To)
+			}
+		}
+	}
+	visit(n)
+	f.Reads = dataflow.Names(reads)
+	f.Writes = dataflow.Names(writes)
+	f.SenderConditions = conditions
+	return f
+}
+
+// summarize computes the body-only summary of n given the state variables
+// visible in its contract
+func summarize(n *callgraph.Node, vars dataflow.Set[string]) *direct {
+	d := &direct{reads: dataflow.Set[string]{}, writes: dataflow.Set[string]{}}
+	var g *cfg.Graph
+	var body *ast.Block
+	var params []*ast.VariableDeclaration
+	switch def := n.Definition.(type) {
+	case *ast.FunctionDefinition:
+		if def.Body == nil {
+			return d
+		}
+		g = cfg.Build(def, n.Contract, nil)
+		body = def.Body
+		params = append(append(params, def.Parameters...), def.ReturnParameters...)
+	case *ast.ModifierDefinition:
+		if def.Body == nil {
+			return d
+		}
+		g = cfg.BuildModifier(def, n.Contract)
+		body = def.Body
+		params = def.Parameters
+	default:
+		return d
+	}
+
+	a := newAnalyzer(vars, params, body)
+	var nodes []ast.Node
+	for _, blk := range g.Blocks {
+		nodes = append(nodes, blk.Nodes...)
+	}
+	a.resolvePointers(nodes)
+	for _, node := range nodes {
+		a.record(node, d)
+	}
+	d.conditions = senderConditions(body)
+	return d
+}
+
+// analyzer resolves the names used in one body to state variables
+type analyzer struct {
+	vars dataflow.Set[string]
+	// locals shadow state variables of the same name
+	locals dataflow.Set[string]
+	// pointers are the local `storage` references; targets maps each to the
+	// state variables it may point into
+	pointers dataflow.Set[string]
+	targets  map[string]dataflow.Set[string]
+	// slots are the identifiers of Yul `x.slot`/`x.offset` paths, which name
+	// a location rather than read it
+	slots map[*ast.Identifier]bool
+}
+
+func newAnalyzer(vars dataflow.Set[string], params []*ast.VariableDeclaration, body *ast.Block) *analyzer {
+	a := &analyzer{
+		vars:     vars,
+		locals:   dataflow.Set[string]{},
+		pointers: dataflow.Set[string]{},
+		targets:  make(map[string]dataflow.Set[string]),
+		slots:    make(map[*ast.Identifier]bool),
+	}
+	declare := func(v *ast.VariableDeclaration) {
+		if v == nil || v.Name == "" {
+			return
+		}
+		a.locals[v.Name] = struct{}{}
+		if v.StorageLocation == "storage" {
+			a.pointers[v.Name] = struct{}{}
+		}
+	}
+	for _, p := range params {
+		declare(p)
+	}
+	ast.WalkSimple(body, &ast.SimpleVisitor{
+		VariableDeclarationFn: declare,
+		AssemblyMemberAccessFn: func(ma *ast.AssemblyMemberAccess) {
+			a.slots[ma.Expression] = true
+		},
+	})
+	return a
+}
+
+// resolvePointers computes what each storage pointer may refer to, following
+// every assignment to it until nothing changes
+func (a *analyzer) resolvePointers(nodes []ast.Node) {
+	for changed := true; changed; {
+		changed = false
+		for _, node := range nodes {
+			for _, acc := range dataflow.Accesses(node) {
+				if !acc.Write || !acc.Whole || !a.pointers.Has(acc.Name) {
+					continue
+				}
+				for _, root := range roots(acc.Value) {
+					for v := range a.resolve(root) {
+						if a.targets[acc.Name] == nil {
+							a.targets[acc.Name] = dataflow.Set[string]{}
+						}
+						if !a.targets[acc.Name].Has(v) {
+							a.targets[acc.Name][v] = struct{}{}
+							changed = true
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// resolve returns the state variables a name stands for: itself when it is a
+// state variable, the targets of a storage pointer, or nothing
+func (a *analyzer) resolve(name string) dataflow.Set[string] {
+	if a.pointers.Has(name) {
+		return a.targets[name]
+	}
+	if a.locals.Has(name) || !a.vars.Has(name) {
+		return nil
+	}
+	return dataflow.Set[string]{name: struct{}{}}
+}
+
+// record adds the state accesses of one CFG node to d
+func (a *analyzer) record(node ast.Node, d *direct) {
+	for _, acc := range dataflow.Accesses(node) {
+		if id, ok := acc.Node.(*ast.Identifier); ok && a.slots[id] {
+			continue
+		}
+		// Rebinding a pointer (p = s[i]) does not write storage
+		if acc.Write && acc.Whole && a.pointers.Has(acc.Name) {
+			continue
+		}
+		set := d.reads
+		if acc.Write {
+			set = d.writes
+		}
+		for v := range a.resolve(acc.Name) {
+			set[v] = struct{}{}
+		}
+	}
+	if asm, ok := node.(*ast.InlineAssembly); ok && asm.Body != nil {
+		ast.WalkSimple(asm.Body, &ast.SimpleVisitor{
+			AssemblyCallFn: func(call *ast.AssemblyCall) {
+				var set dataflow.Set[string]
+				switch call.FunctionName {
+				case "sload", "tload":
+					set = d.reads
+				case "sstore", "tstore":
+					set = d.writes
+				default:
+					return
+				}
+				if len(call.Arguments) == 0 {
+					return
+				}
+				if ma, ok := call.Arguments[0].(*ast.AssemblyMemberAccess); ok && ma.MemberName.Name == "slot" {
+					for v := range a.resolve(ma.Expression.Name) {
+						set[v] = struct{}{}
+					}
+				}
+			},
+		})
+	}
+}
+
+// roots returns the variables an lvalue or pointer expression is rooted at:
+// s for s[i].f, both branches of a conditional
+func roots(n ast.Node) []string {
+	switch v := n.(type) {
+	case *ast.Identifier:
+		return []string{v.Name}
+	case *ast.IndexAccess:
+		return roots(v.Base)
+	case *ast.IndexRangeAccess:
+		return roots(v.Base)
+	case *ast.MemberAccess:
+		return roots(v.Expression)
+	case *ast.Conditional:
+		return append(roots(v.TrueExpression), roots(v.FalseExpression)...)
+	case *ast.TupleExpression:
+		if len(v.Components) == 1 {
+			return roots(v.Components[0])
+		}
+	}
+	return nil
+}
+
+// senderConditions returns the rendered require/assert/if/while conditions of
+// body that mention msg.sender, in source order
+func senderConditions(body *ast.Block) []string {
+	var out []string
+	add := func(cond ast.Node) {
+		if mentionsSender(cond) {
+			out = append(out, render(cond))
+		}
+	}
+	ast.WalkSimple(body, &ast.SimpleVisitor{
+		IfStatementFn:    func(s *ast.IfStatement) { add(s.Condition) },
+		WhileStatementFn: func(s *ast.WhileStatement) { add(s.Condition) },
+		FunctionCallFn: func(call *ast.FunctionCall) {
+			if id, ok := call.Expression.(*ast.Identifier); ok && (id.Name == "require" || id.Name == "assert") && len(call.Arguments) > 0 {
+				add(call.Arguments[0])
+			}
+		},
+	})
+	return out
+}
+
+func mentionsSender(n ast.Node) bool {
+	found := false
+	ast.WalkSimple(n, &ast.SimpleVisitor{
+		MemberAccessFn: func(ma *ast.MemberAccess) {
+			if id, ok := ma.Expression.(*ast.Identifier); ok && id.Name == "msg" && ma.MemberName == "sender" {
+				found = true
+			}
+		},
+		FunctionCallFn: func(call *ast.FunctionCall) {
+			if id, ok := call.Expression.(*ast.Identifier); ok && id.Name == "_msgSender" {
+				found = true
+			}
+		},
+	})
+	return found
+}
@@ -0,0 +1,150 @@
+package summary
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/th13vn/solast-go/pkg/callgraph"
+	"github.com/th13vn/solast-go/pkg/parser"
+)
+
+func build(t *testing.T, src string) *Summary {
+	t.Helper()
+	unit, err := parser.Parse(src, &parser.Options{Loc: true})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	return Build(callgraph.File{Path: "a.sol", Unit: unit})
+}
+
+const vaultSrc = `
+contract Ownable {
+	address owner;
+	uint constant FEE = 1;
+	modifier onlyOwner() { require(msg.sender == owner, "not owner"); _; }
+	function transferOwnership(address next) public onlyOwner { owner = next; }
+}
+
+contract Vault is Ownable {
+	struct Position { uint amount; uint[] history; }
+	mapping(address => Position) positions;
+	uint[] queue;
+	uint total;
+	bool paused;
+	uint lock;
+
+	function deposit(uint amount) external {
+		Position storage p = positions[msg.sender];
+		p.amount += amount;
+		p.history.push(amount);
+		_account(amount);
+	}
+
+	function _account(uint amount) internal {
+		total += amount;
+	}
+
+	function reset(address who) external onlyOwner {
+		delete positions[who];
+		queue.pop();
+	}
+
+	function shadow(uint total) external pure returns (uint) {
+		return total + FEE;
+	}
+
+	function view_() external view returns (uint t) {
+		Position memory copy = positions[msg.sender];
+		t = copy.amount + total;
+	}
+
+	function pause() external {
+		if (msg.sender != owner) revert();
+		paused = true;
+	}
+
+	function raw() external {
+		assembly {
+			let v := sload(total.slot)
+			sstore(lock.slot, v)
+			tstore(paused.slot, 1)
+		}
+	}
+}
+`
+
+func TestReadsAndWrites(t *testing.T) {
+	s := build(t, vaultSrc)
+	vault := s.Contract("Vault")
+	if vault == nil {
+		t.Fatal("Vault not summarized")
+	}
+	cases := []struct {
+		fn            string
+		reads, writes []string
+	}{
+		// storage pointer, compound assignment, push, internal callee
+		{"deposit", []string{"positions", "total"}, []string{"positions", "total"}},
+		// modifier reads, delete and pop
+		{"reset", []string{"owner", "queue"}, []string{"positions", "queue"}},
+		// a parameter shadows the state variable; constants are not state
+		{"shadow", []string{}, []string{}},
+		// a memory copy reads storage once and writes nothing
+		{"view_", []string{"positions", "total"}, []string{}},
+		{"raw", []string{"total"}, []string{"lock", "paused"}},
+		{"transferOwnership", []string{"owner"}, []string{"owner"}},
+	}
+	for _, tc := range cases {
+		f := vault.Function(tc.fn)
+		if f == nil {
+			t.Errorf("%s not summarized", tc.fn)
+			continue
+		}
+		if got := f.Reads; !reflect.DeepEqual(got, tc.reads) {
+			t.Errorf("%s reads = %v, want %v", tc.fn, got, tc.reads)
+		}
+		if got := f.Writes; !reflect.DeepEqual(got, tc.writes) {
+			t.Errorf("%s writes = %v, want %v", tc.fn, got, tc.writes)
+		}
+	}
+
+	deposit := vault.Function("deposit")
+	if !reflect.DeepEqual(deposit.DirectWrites, []string{"positions"}) {
+		t.Errorf("deposit direct writes = %v, want [positions]", deposit.DirectWrites)
+	}
+	reset := vault.Function("reset")
+	if !reflect.DeepEqual(reset.Modifiers, []string{"onlyOwner"}) {
+		t.Errorf("reset modifiers = %v", reset.Modifiers)
+	}
+	if want := []string{"msg.sender == owner"}; !reflect.DeepEqual(reset.SenderConditions, want) {
+		t.Errorf("reset conditions = %v, want %v", reset.SenderConditions, want)
+	}
+	if want := []string{"msg.sender != owner"}; !reflect.DeepEqual(vault.Function("pause").SenderConditions, want) {
+		t.Errorf("pause conditions = %v", vault.Function("pause").SenderConditions)
+	}
+	// inherited functions are listed under the derived contract
+	if f := vault.Function("transferOwnership"); f == nil || f.Contract != "Ownable" {
+		t.Error("expected inherited transferOwnership under Vault")
+	}
+}
+
+func TestTable(t *testing.T) {
+	s := build(t, vaultSrc)
+	var sb strings.Builder
+	if err := s.WriteTable(&sb); err != nil {
+		t.Fatalf("WriteTable failed: %v", err)
+	}
+	out := sb.String()
+	for _, want := range []string{
+		"Contract Vault",
+		"| Function ",
+		"| reset(address) ",
+		"| Ownable.transferOwnership(address) ",
+		"msg.sender == owner",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package token
+
+import "github.com/th13vn/solast-go/internal/lexer"
+
+// Kind is the specific keyword, operator or punctuation of a token, so that
+// clients can tell `contract` from `function` or `+=` from `==` without
+// comparing text. Identifiers, literals, elementary types, booleans and
+// trivia have NoKind. The values are stable; new kinds are only ever
+// appended.
+type Kind int
+
+const (
+	NoKind Kind = iota
+
+	// Keywords, contextual ones included
+	Abstract
+	Anonymous
+	As
+	Assembly
+	Break
+	Calldata
+	Case
+	Catch
+	Constant
+	Constructor
+	Continue
+	Contract
+	Default
+	Delete
+	Do
+	Else
+	Emit
+	Enum
+	Error
+	Event
+	External
+	Fallback
+	For
+	From
+	Function
+	Global
+	Hex
+	If
+	Immutable
+	Import
+	Indexed
+	Interface
+	Internal
+	Is
+	Let
+	Library
+	Mapping
+	Memory
+	Modifier
+	New
+	Override
+	Payable
+	Pragma
+	Private
+	Public
+	Pure
+	Receive
+	Return
+	Returns
+	Revert
+	Storage
+	Struct
+	Switch
+	Transient
+	Try
+	Type
+	Unchecked
+	Unicode
+	Using
+	View
+	Virtual
+	While
+	Layout
+	At
+
+	// Operators
+	Colon
+	Question
+	Arrow
+	RightArrow
+	Assign
+	AddAssign
+	SubAssign
+	MulAssign
+	DivAssign
+	ModAssign
+	AndAssign
+	OrAssign
+	XorAssign
+	ShlAssign
+	ShrAssign
+	SarAssign
+	Eq
+	Neq
+	Lt
+	Gt
+	Lte
+	Gte
+	LogicalAnd
+	LogicalOr
+	Not
+	BitAnd
+	BitOr
+	BitXor
+	BitNot
+	Shl
+	Shr
+	Sar
+	Add
+	Sub
+	Mul
+	Div
+	Mod
+	Exp
+	Inc
+	Dec
+
+	// Punctuation
+	LParen
+	RParen
+	LBrack
+	RBrack
+	LBrace
+	RBrace
+	Semicolon
+	Comma
+	Period
+)
+
+var kindNames = [...]string{
+	NoKind:      "",
+	Abstract:    "abstract",
+	Anonymous:   "anonymous",
+	As:          "as",
+	Assembly:    "assembly",
+	Break:       "break",
+	Calldata:    "calldata",
+	Case:        "case",
+	Catch:       "catch",
+	Constant:    "constant",
+	Constructor: "constructor",
+	Continue:    "continue",
+	Contract:    "contract",
+	Default:     "default",
+	Delete:      "delete",
+	Do:          "do",
+	Else:        "else",
+	Emit:        "emit",
+	Enum:        "enum",
+	Error:       "error",
+	Event:       "event",
+	External:    "external",
+	Fallback:    "fallback",
+	For:         "for",
+	From:        "from",
+	Function:    "function",
+	Global:      "global",
+	Hex:         "hex",
+	If:          "if",
+	Immutable:   "immutable",
+	Import:      "import",
+	Indexed:     "indexed",
+	Interface:   "interface",
+	Internal:    "internal",
+	Is:          "is",
+	Let:         "let",
+	Library:     "library",
+	Mapping:     "mapping",
+	Memory:      "memory",
+	Modifier:    "modifier",
+	New:         "new",
+	Override:    "override",
+	Payable:     "payable",
+	Pragma:      "pragma",
+	Private:     "private",
+	Public:      "public",
+	Pure:        "pure",
+	Receive:     "receive",
+	Return:      "return",
+	Returns:     "returns",
+	Revert:      "revert",
+	Storage:     "storage",
+	Struct:      "struct",
+	Switch:      "switch",
+	Transient:   "transient",
+	Try:         "try",
+	Type:        "type",
+	Unchecked:   "unchecked",
+	Unicode:     "unicode",
+	Using:       "using",
+	View:        "view",
+	Virtual:     "virtual",
+	While:       "while",
+	Layout:      "layout",
+	At:          "at",
+	Colon:       ":",
+	Question:    "?",
+	Arrow:       "=>",
+	RightArrow:  "->",
+	Assign:      "=",
+	AddAssign:   "+=",
+	SubAssign:   "-=",
+	MulAssign:   "*=",
+	DivAssign:   "/=",
+	ModAssign:   "%=",
+	AndAssign:   "&=",
+	OrAssign:    "|=",
+	XorAssign:   "^=",
+	ShlAssign:   "<<=",
+	ShrAssign:   ">>=",
+	SarAssign:   ">>>=",
+	Eq:          "==",
+	Neq:         "!=",
+	Lt:          "<",
+	Gt:          ">",
+	Lte:         "<=",
+	Gte:         ">=",
+	LogicalAnd:  "&&",
+	LogicalOr:   "||",
+	Not:         "!",
+	BitAnd:      "&",
+	BitOr:       "|",
+	BitXor:      "^",
+	BitNot:      "~",
+	Shl:         "<<",
+	Shr:         ">>",
+	Sar:         ">>>",
+	Add:         "+",
+	Sub:         "-",
+	Mul:         "*",
+	Div:         "/",
+	Mod:         "%",
+	Exp:         "**",
+	Inc:         "++",
+	Dec:         "--",
+	LParen:      "(",
+	RParen:      ")",
+	LBrack:      "[",
+	RBrack:      "]",
+	LBrace:      "{",
+	RBrace:      "}",
+	Semicolon:   ";",
+	Comma:       ",",
+	Period:      ".",
+}
+
+// String returns the kind's lexeme, or "" for NoKind
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return "unknown"
+	}
+	return kindNames[k]
+}
+
+var kinds = map[lexer.TokenType]Kind{
+	lexer.ABSTRACT:    Abstract,
+	lexer.ANONYMOUS:   Anonymous,
+	lexer.AS:          As,
+	lexer.ASSEMBLY:    Assembly,
+	lexer.BREAK:       Break,
+	lexer.CALLDATA:    Calldata,
+	lexer.CASE:        Case,
+	lexer.CATCH:       Catch,
+	lexer.CONSTANT:    Constant,
+	lexer.CONSTRUCTOR: Constructor,
+	lexer.CONTINUE:    Continue,
+	lexer.CONTRACT:    Contract,
+	lexer.DEFAULT:     Default,
+	lexer.DELETE:      Delete,
+	lexer.DO:          Do,
+	lexer.ELSE:        Else,
+	lexer.EMIT:        Emit,
+	lexer.ENUM:        Enum,
+	lexer.ERROR:       Error,
+	lexer.EVENT:       Event,
+	lexer.EXTERNAL:    External,
+	lexer.FALLBACK:    Fallback,
+	lexer.FOR:         For,
+	lexer.FROM:        From,
+	lexer.FUNCTION:    Function,
+	lexer.GLOBAL:      Global,
+	lexer.HEX:         Hex,
+	lexer.IF:          If,
+	lexer.IMMUTABLE:   Immutable,
+	lexer.IMPORT:      Import,
+	lexer.INDEXED:     Indexed,
+	lexer.INTERFACE:   Interface,
+	lexer.INTERNAL:    Internal,
+	lexer.IS:          Is,
+	lexer.LET:         Let,
+	lexer.LIBRARY:     Library,
+	lexer.MAPPING:     Mapping,
+	lexer.MEMORY:      Memory,
+	lexer.MODIFIER:    Modifier,
+	lexer.NEW:         New,
+	lexer.OVERRIDE:    Override,
+	lexer.PAYABLE:     Payable,
+	lexer.PRAGMA:      Pragma,
+	lexer.PRIVATE:     Private,
+	lexer.PUBLIC:      Public,
+	lexer.PURE:        Pure,
+	lexer.RECEIVE:     Receive,
+	lexer.RETURN:      Return,
+	lexer.RETURNS:     Returns,
+	lexer.REVERT:      Revert,
+	lexer.STORAGE:     Storage,
+	lexer.STRUCT:      Struct,
+	lexer.SWITCH:      Switch,
+	lexer.TRANSIENT:   Transient,
+	lexer.TRY:         Try,
+	lexer.TYPE:        Type,
+	lexer.UNCHECKED:   Unchecked,
+	lexer.UNICODE:     Unicode,
+	lexer.USING:       Using,
+	lexer.VIEW:        View,
+	lexer.VIRTUAL:     Virtual,
+	lexer.WHILE:       While,
+	lexer.LAYOUT:      Layout,
+	lexer.AT:          At,
+	lexer.COLON:       Colon,
+	lexer.QUESTION:    Question,
+	lexer.ARROW:       Arrow,
+	lexer.RIGHT_ARROW: RightArrow,
+	lexer.ASSIGN:      Assign,
+	lexer.ASSIGN_ADD:  AddAssign,
+	lexer.ASSIGN_SUB:  SubAssign,
+	lexer.ASSIGN_MUL:  MulAssign,
+	lexer.ASSIGN_DIV:  DivAssign,
+	lexer.ASSIGN_MOD:  ModAssign,
+	lexer.ASSIGN_AND:  AndAssign,
+	lexer.ASSIGN_OR:   OrAssign,
+	lexer.ASSIGN_XOR:  XorAssign,
+	lexer.ASSIGN_SHL:  ShlAssign,
+	lexer.ASSIGN_SHR:  ShrAssign,
+	lexer.ASSIGN_SAR:  SarAssign,
+	lexer.EQ:          Eq,
+	lexer.NEQ:         Neq,
+	lexer.LT:          Lt,
+	lexer.GT:          Gt,
+	lexer.LTE:         Lte,
+	lexer.GTE:         Gte,
+	lexer.AND:         LogicalAnd,
+	lexer.OR:          LogicalOr,
+	lexer.NOT:         Not,
+	lexer.BIT_AND:     BitAnd,
+	lexer.BIT_OR:      BitOr,
+	lexer.BIT_XOR:     BitXor,
+	lexer.BIT_NOT:     BitNot,
+	lexer.SHL:         Shl,
+	lexer.SHR:         Shr,
+	lexer.SAR:         Sar,
+	lexer.ADD:         Add,
+	lexer.SUB:         Sub,
+	lexer.MUL:         Mul,
+	lexer.DIV:         Div,
+	lexer.MOD:         Mod,
+	lexer.EXP:         Exp,
+	lexer.INC:         Inc,
+	lexer.DEC:         Dec,
+	lexer.LPAREN:      LParen,
+	lexer.RPAREN:      RParen,
+	lexer.LBRACK:      LBrack,
+	lexer.RBRACK:      RBrack,
+	lexer.LBRACE:      LBrace,
+	lexer.RBRACE:      RBrace,
+	lexer.SEMICOLON:   Semicolon,
+	lexer.COMMA:       Comma,
+	lexer.PERIOD:      Period,
+}
@@ -0,0 +1,141 @@
+// Package token exposes the Solidity lexer: the tokens of a source with
+// their positions, optionally with comments and whitespace.
+package token
+
+import "github.com/th13vn/solast-go/internal/lexer"
+
+// TokenType classifies a token. The values are stable; new types are only
+// ever appended.
+type TokenType int
+
+const (
+	Illegal TokenType = iota
+	Comment
+	Whitespace
+	Identifier
+	Keyword
+	ElementaryType
+	Boolean
+	Number
+	String
+	HexString
+	UnicodeString
+	Operator
+	Punctuation
+)
+
+var typeNames = [...]string{
+	Illegal:        "illegal",
+	Comment:        "comment",
+	Whitespace:     "whitespace",
+	Identifier:     "identifier",
+	Keyword:        "keyword",
+	ElementaryType: "elementaryType",
+	Boolean:        "boolean",
+	Number:         "number",
+	String:         "string",
+	HexString:      "hexString",
+	UnicodeString:  "unicodeString",
+	Operator:       "operator",
+	Punctuation:    "punctuation",
+}
+
+func (t TokenType) String() string {
+	if t < 0 || int(t) >= len(typeNames) {
+		return "unknown"
+	}
+	return typeNames[t]
+}
+
+// IsTrivia reports whether t is a comment or whitespace
+func (t TokenType) IsTrivia() bool {
+	return t == Comment || t == Whitespace
+}
+
+// Token is one lexed unit of a source. Contextual keywords (from, error,
+// revert, global, transient, layout, at, ...) are Keywords even where they
+// name something.
+type Token struct {
+	Type TokenType `json:"type"`
+	// Kind is the specific keyword, operator or punctuation
+	Kind Kind `json:"kind,omitempty"`
+	// Text is the exact source text, quotes and prefixes included
+	Text   string `json:"text"`
+	Line   int    `json:"line"`   // 1-based
+	Column int    `json:"column"` // 0-based, in bytes
+	Start  int    `json:"start"`  // byte offset
+	End    int    `json:"end"`    // byte offset after the token
+}
+
+// Options selects the trivia returned with the tokens
+type Options struct {
+	Comments   bool
+	Whitespace bool
+}
+
+// Tokenize returns the tokens of src in order, without an end-of-file token.
+// With both kinds of trivia the tokens cover src exactly.
+func Tokenize(src string, opts *Options) []Token {
+	if opts == nil {
+		opts = &Options{}
+	}
+	lex := lexer.New(src)
+	if opts.Comments || opts.Whitespace {
+		lex = lexer.NewTrivia(src)
+	}
+	var tokens []Token
+	for {
+		tok := lex.NextToken()
+		if tok.Type == lexer.EOF {
+			return tokens
+		}
+		t := classify(tok.Type)
+		if t == Comment && !opts.Comments || t == Whitespace && !opts.Whitespace {
+			continue
+		}
+		tokens = append(tokens, Token{
+			Type:   t,
+			Kind:   kinds[tok.Type],
+			Text:   src[tok.Start:tok.End],
+			Line:   tok.Line,
+			Column: tok.Column,
+			Start:  tok.Start,
+			End:    tok.End,
+		})
+	}
+}
+
+func classify(t lexer.TokenType) TokenType {
+	switch t {
+	case lexer.ILLEGAL:
+		return Illegal
+	case lexer.COMMENT:
+		return Comment
+	case lexer.WHITESPACE:
+		return Whitespace
+	case lexer.IDENTIFIER:
+		return Identifier
+	case lexer.NUMBER, lexer.HEX_NUMBER:
+		return Number
+	case lexer.STRING:
+		return String
+	case lexer.HEX_STRING:
+		return HexString
+	case lexer.UNICODE_STRING:
+		return UnicodeString
+	case lexer.TRUE, lexer.FALSE:
+		return Boolean
+	case lexer.ADDRESS, lexer.BOOL, lexer.BYTES, lexer.STRING_TYPE, lexer.FIXED, lexer.UFIXED,
+		lexer.INT, lexer.UINT, lexer.BYTE, lexer.BYTES_N, lexer.FIXED_N, lexer.UFIXED_N:
+		return ElementaryType
+	case lexer.LPAREN, lexer.RPAREN, lexer.LBRACK, lexer.RBRACK, lexer.LBRACE, lexer.RBRACE,
+		lexer.SEMICOLON, lexer.COMMA, lexer.PERIOD:
+		return Punctuation
+	case lexer.LAYOUT, lexer.AT:
+		return Keyword
+	}
+	if lexer.IsKeyword(t) {
+		return Keyword
+	}
+	return Operator
+}
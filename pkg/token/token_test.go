@@ -0,0 +1,89 @@
+package token
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	src := "contract C { /// doc\n  uint256 x = 1e3 + 0x1f; bool b = true; bytes h = hex\"00\"; string u = unicode\"é\"; }"
+	var got []string
+	for _, tok := range Tokenize(src, nil) {
+		got = append(got, tok.Type.String()+":"+tok.Text)
+	}
+	want := []string{
+		"keyword:contract", "identifier:C", "punctuation:{",
+		"elementaryType:uint256", "identifier:x", "operator:=", "number:1e3", "operator:+", "number:0x1f", "punctuation:;",
+		"elementaryType:bool", "identifier:b", "operator:=", "boolean:true", "punctuation:;",
+		"elementaryType:bytes", "identifier:h", "operator:=", "hexString:hex\"00\"", "punctuation:;",
+		"elementaryType:string", "identifier:u", "operator:=", "unicodeString:unicode\"é\"", "punctuation:;",
+		"punctuation:}",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got  %v\nwant %v", got, want)
+	}
+
+	toks := Tokenize(src, &Options{Comments: true})
+	if toks[3].Type != Comment || toks[3].Text != "/// doc" || toks[3].Line != 1 || toks[3].Column != 13 {
+		t.Errorf("comment token = %+v", toks[3])
+	}
+	if toks[4].Line != 2 || toks[4].Column != 2 || toks[4].Start != 23 {
+		t.Errorf("uint256 token = %+v", toks[4])
+	}
+}
+
+func TestTokenizeTriviaCoversSource(t *testing.T) {
+	content, err := os.ReadFile("../../testdata/test-flatten.sol")
+	if err != nil {
+		t.Skipf("Cannot read file: %v", err)
+	}
+	src := string(content)
+	var sb strings.Builder
+	end := 0
+	for _, tok := range Tokenize(src, &Options{Comments: true, Whitespace: true}) {
+		if tok.Start != end {
+			t.Fatalf("gap before %+v", tok)
+		}
+		if tok.Type == Illegal {
+			t.Errorf("illegal token %+v", tok)
+		}
+		sb.WriteString(tok.Text)
+		end = tok.End
+	}
+	if sb.String() != src {
+		t.Error("tokens with trivia do not reproduce the source")
+	}
+	for _, tok := range Tokenize(src, &Options{Whitespace: true}) {
+		if tok.Type == Comment {
+			t.Fatalf("unexpected comment %+v", tok)
+		}
+	}
+}
+
+func TestTokenKinds(t *testing.T) {
+	var got []Kind
+	for _, tok := range Tokenize("function f() external { x += 1; } // at", &Options{Comments: true}) {
+		got = append(got, tok.Kind)
+	}
+	want := []Kind{Function, NoKind, LParen, RParen, External, LBrace, NoKind, AddAssign, NoKind, Semicolon, RBrace, NoKind}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("kinds = %v, want %v", got, want)
+	}
+	if got := Tokenize("layout at", nil); got[0].Kind != Layout || got[1].Kind != At {
+		t.Errorf("contextual keyword kinds = %v %v", got[0].Kind, got[1].Kind)
+	}
+}
+
+func TestTokenTypeString(t *testing.T) {
+	if Punctuation.String() != "punctuation" || TokenType(99).String() != "unknown" {
+		t.Error("unexpected TokenType names")
+	}
+	if SarAssign.String() != ">>>=" || Contract.String() != "contract" || NoKind.String() != "" || Kind(999).String() != "unknown" {
+		t.Error("unexpected Kind names")
+	}
+	if !Comment.IsTrivia() || Keyword.IsTrivia() {
+		t.Error("unexpected IsTrivia")
+	}
+}
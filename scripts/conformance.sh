@@ -0,0 +1,59 @@
+#!/usr/bin/env bash
+#
+# Writes the golden files of the conformance suite (pkg/compat): the output of
+# the TypeScript @solidity-parser/parser, parse(src, { loc: true, range: true }),
+# for every .sol file under SRC, as SRC-relative .json files under OUT.
+#
+# Usage: scripts/conformance.sh [SRC] [OUT]
+#   SRC defaults to testdata/contracts, OUT to testdata/conformance.
+#   SOLIDITY_PARSER_VERSION selects the npm version (default: the pinned
+#   0.20.1, so the goldens only change on purpose); the version used is
+#   recorded in OUT/PARSER_VERSION.
+#
+# Requires node and npm.
+
+set -euo pipefail
+
+SRC="${1:-testdata/contracts}"
+OUT="${2:-testdata/conformance}"
+VERSION="${SOLIDITY_PARSER_VERSION:-0.20.1}"
+
+TMP="$(mktemp -d)"
+trap 'rm -rf "$TMP"' EXIT
+
+echo "Installing @solidity-parser/parser@$VERSION..."
+npm install --prefix "$TMP" --no-save --silent "@solidity-parser/parser@$VERSION"
+
+NODE_PATH="$TMP/node_modules" node - "$SRC" "$OUT" <<'JS'
+const fs = require('fs');
+const path = require('path');
+const parser = require('@solidity-parser/parser');
+
+const [src, out] = process.argv.slice(2);
+let written = 0, failed = 0;
+
+function walk(dir) {
+  for (const entry of fs.readdirSync(dir, { withFileTypes: true })) {
+    const file = path.join(dir, entry.name);
+    if (entry.isDirectory()) {
+      walk(file);
+    } else if (entry.name.endsWith('.sol')) {
+      const rel = path.relative(src, file).replace(/\.sol$/, '.json');
+      try {
+        const ast = parser.parse(fs.readFileSync(file, 'utf8'), { loc: true, range: true });
+        fs.mkdirSync(path.join(out, path.dirname(rel)), { recursive: true });
+        fs.writeFileSync(path.join(out, rel), JSON.stringify(ast, null, 2) + '\n');
+        written++;
+      } catch (e) {
+        console.error(`${file}: ${e.message}`);
+        failed++;
+      }
+    }
+  }
+}
+
+walk(src);
+fs.mkdirSync(out, { recursive: true });
+fs.writeFileSync(path.join(out, 'PARSER_VERSION'), require('@solidity-parser/parser/package.json').version + '\n');
+console.log(`Wrote ${written} golden files to ${out}` + (failed ? `; ${failed} failed to parse` : ''));
+JS